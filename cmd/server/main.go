@@ -1,11 +1,154 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/config"
 	"github.com/null-create/mcp-tls/pkg/server"
 )
 
-func main() {
-	router := server.NewRouter()
+// Runtime modes selectable with --mode
+const (
+	modeHTTP  = "http"
+	modeProxy = "proxy"
+)
+
+// options are the runtime choices parsed from the command line
+type options struct {
+	Mode     string
+	TLS      bool
+	CertFile string
+	KeyFile  string
+}
+
+// parseFlags parses the command line arguments into options, taking
+// defaults for anything not given from the loaded configs.
+func parseFlags(args []string, cfgs *config.Config) (*options, error) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+
+	defaultMode := modeHTTP
+	if cfgs.Proxy {
+		defaultMode = modeProxy
+	}
+
+	var opts options
+	fs.StringVar(&opts.Mode, "mode", defaultMode, "runtime to start: 'http' or 'proxy'")
+	useHTTP := fs.Bool("http", false, "run the HTTP API server (shorthand for --mode=http)")
+	fs.BoolVar(&opts.TLS, "tls", false, "serve the HTTP API over TLS")
+	fs.StringVar(&opts.CertFile, "cert", cfgs.TLSCert, "PEM certificate file used with --tls")
+	fs.StringVar(&opts.KeyFile, "key", cfgs.TLSKey, "PEM private key file used with --tls")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	if *useHTTP {
+		modeSet := false
+		fs.Visit(func(f *flag.Flag) {
+			modeSet = modeSet || f.Name == "mode"
+		})
+		if modeSet && opts.Mode != modeHTTP {
+			return nil, fmt.Errorf("--http conflicts with --mode=%s", opts.Mode)
+		}
+		opts.Mode = modeHTTP
+	}
+
+	switch opts.Mode {
+	case modeHTTP:
+		if opts.TLS && (opts.CertFile == "" || opts.KeyFile == "") {
+			return nil, errors.New("--tls requires both --cert and --key")
+		}
+	case modeProxy:
+		if opts.TLS {
+			return nil, errors.New("--tls is not supported in proxy mode")
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode '%s': expected '%s' or '%s'", opts.Mode, modeHTTP, modeProxy)
+	}
+
+	return &opts, nil
+}
+
+// runner starts the selected runtime. Tests swap in fakes to check which one is chosen.
+type runner struct {
+	http  func(opts *options)
+	proxy func()
+}
+
+var defaultRunner = runner{
+	http:  runHTTP,
+	proxy: server.Proxy,
+}
+
+// run starts the runtime selected by opts
+func run(opts *options, r runner) {
+	if opts.Mode == modeProxy {
+		r.proxy()
+		return
+	}
+	r.http(opts)
+}
+
+// runHTTP starts the HTTP API server, over TLS if requested
+func runHTTP(opts *options) {
+	// stops the router's background work once the server starts shutting down
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	router := server.NewRouter(ctx)
 	server := server.NewServer(router)
+	server.Svr.RegisterOnShutdown(cancel)
+	if opts.TLS {
+		server.RunTLS(opts.CertFile, opts.KeyFile)
+		return
+	}
 	server.Run()
 }
+
+// loadJWTSecret retrieves the secret tokens are signed with. Without one, tokens
+// can be forged by anyone, which is tolerated in dev but refused in prod.
+func loadJWTSecret(ctx context.Context, cfgs *config.Config) (string, error) {
+	secret, err := auth.RetrieveJWTSecret(ctx)
+	if err != nil {
+		return "", err
+	}
+	if secret == "" && cfgs.IsProd() {
+		return "", errors.New("a JWT secret is required in prod: set MCPTLS_JWT_SECRET or MCPTLS_JWT_SECRET_FILE")
+	}
+	return secret, nil
+}
+
+func main() {
+	cfgs := config.LoadConfigs()
+	opts, err := parseFlags(os.Args[1:], cfgs)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		log.Fatal(err)
+	}
+	// check the configuration as it takes effect, with flags overriding the environment
+	cfgs.Proxy = opts.Mode == modeProxy
+	cfgs.TLSCert, cfgs.TLSKey = opts.CertFile, opts.KeyFile
+	if err := cfgs.Validate(); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+
+	auth.SetLeeway(cfgs.JWTLeeway)
+	auth.SetIssuerAndAudience(cfgs.JWTIssuer, cfgs.JWTAudience)
+	secret, err := loadJWTSecret(context.Background(), cfgs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	auth.SetJWTSecret(secret)
+
+	run(opts, defaultRunner)
+}
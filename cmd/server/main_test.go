@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/config"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		cfgs      config.Config
+		expected  options
+		expectErr bool
+	}{
+		{name: "no flags defaults to http", args: nil, expected: options{Mode: modeHTTP}},
+		{name: "explicit http", args: []string{"--http"}, expected: options{Mode: modeHTTP}},
+		{name: "proxy mode", args: []string{"--mode=proxy"}, expected: options{Mode: modeProxy}},
+		{
+			name:     "tls with cert and key",
+			args:     []string{"--tls", "--cert", "server.crt", "--key", "server.key"},
+			expected: options{Mode: modeHTTP, TLS: true, CertFile: "server.crt", KeyFile: "server.key"},
+		},
+		{
+			name:     "tls files default from configs",
+			args:     []string{"--tls"},
+			cfgs:     config.Config{TLSCert: "env.crt", TLSKey: "env.key"},
+			expected: options{Mode: modeHTTP, TLS: true, CertFile: "env.crt", KeyFile: "env.key"},
+		},
+		{
+			name:     "flags override configs",
+			args:     []string{"--tls", "--cert", "flag.crt"},
+			cfgs:     config.Config{TLSCert: "env.crt", TLSKey: "env.key"},
+			expected: options{Mode: modeHTTP, TLS: true, CertFile: "flag.crt", KeyFile: "env.key"},
+		},
+		{name: "tls without key", args: []string{"--tls", "--cert", "server.crt"}, expectErr: true},
+		{name: "tls in proxy mode", args: []string{"--mode=proxy", "--tls", "--cert", "c", "--key", "k"}, expectErr: true},
+		{name: "proxy from configs", cfgs: config.Config{Proxy: true}, expected: options{Mode: modeProxy}},
+		{name: "http overrides proxy configs", args: []string{"--http"}, cfgs: config.Config{Proxy: true}, expected: options{Mode: modeHTTP}},
+		{name: "mode overrides proxy configs", args: []string{"--mode=http"}, cfgs: config.Config{Proxy: true}, expected: options{Mode: modeHTTP}},
+		{name: "http conflicts with proxy", args: []string{"--http", "--mode=proxy"}, expectErr: true},
+		{name: "unknown mode", args: []string{"--mode=grpc"}, expectErr: true},
+		{name: "unknown flag", args: []string{"--verbose"}, expectErr: true},
+		{name: "stray argument", args: []string{"proxy"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseFlags(tt.args, &tt.cfgs)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("parseFlags(%v) expected error, got options %+v", tt.args, opts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFlags(%v) unexpected error: %v", tt.args, err)
+			}
+			if *opts != tt.expected {
+				t.Errorf("parseFlags(%v) = %+v, want %+v", tt.args, *opts, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRun_SelectsRuntime(t *testing.T) {
+	tests := []struct {
+		name          string
+		proxy         bool
+		expectRuntime string
+	}{
+		{name: "proxy configured", proxy: true, expectRuntime: modeProxy},
+		{name: "proxy not configured", proxy: false, expectRuntime: modeHTTP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MCPTLS_PROXY", strconv.FormatBool(tt.proxy))
+
+			opts, err := parseFlags(nil, config.LoadConfigs())
+			if err != nil {
+				t.Fatalf("parseFlags() unexpected error: %v", err)
+			}
+
+			var started []string
+			run(opts, runner{
+				http:  func(*options) { started = append(started, modeHTTP) },
+				proxy: func() { started = append(started, modeProxy) },
+			})
+
+			if len(started) != 1 || started[0] != tt.expectRuntime {
+				t.Errorf("started runtimes = %v, want [%s]", started, tt.expectRuntime)
+			}
+		})
+	}
+}
+
+func TestLoadJWTSecret_RequiredInProd(t *testing.T) {
+	t.Setenv("MCPTLS_JWT_SECRET", "")
+	t.Setenv("MCPTLS_JWT_SECRET_FILE", "")
+
+	if _, err := loadJWTSecret(context.Background(), &config.Config{Env: config.EnvDev}); err != nil {
+		t.Errorf("Expected a missing secret to be tolerated in dev, got: %v", err)
+	}
+	if _, err := loadJWTSecret(context.Background(), &config.Config{Env: config.EnvProd}); err == nil {
+		t.Error("Expected a missing secret to be refused in prod")
+	}
+
+	t.Setenv("MCPTLS_JWT_SECRET", "s3cret")
+	if secret, err := loadJWTSecret(context.Background(), &config.Config{Env: config.EnvProd}); err != nil || secret != "s3cret" {
+		t.Errorf("loadJWTSecret() = %q, %v, want the configured secret", secret, err)
+	}
+}
@@ -0,0 +1,61 @@
+// Command signtool signs tool definition files offline, filling in the security
+// metadata the server verifies so they can be shipped to a registry:
+//
+//	signtool [--key-file key.b64] tool.json...
+//
+// The key file holds a base64 Ed25519 seed, the same format as MCPTLS_BUNDLE_KEY.
+// Without one, files get their checksum and schema fingerprints but no signature.
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// run signs the tool files named in args, reporting each one to out
+func run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("signtool", flag.ContinueOnError)
+	keyFile := fs.String("key-file", "", "file holding a base64 Ed25519 seed to sign with")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("no tool files given")
+	}
+
+	var key []byte
+	if *keyFile != "" {
+		encoded, err := os.ReadFile(*keyFile)
+		if err != nil {
+			return err
+		}
+		if key, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded))); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", *keyFile, err)
+		}
+	}
+
+	for _, path := range fs.Args() {
+		if err := mcp.SignToolFile(path, key); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Fprintf(out, "signed %s\n", path)
+	}
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	toolPath := filepath.Join(dir, "echo.json")
+	if err := os.WriteFile(toolPath, []byte(`{"name":"echo","description":"Echoes its input","inputSchema":{"type":"object"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "key.b64")
+	if err := os.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(privateKey.Seed())+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"--key-file", keyPath, toolPath}, &out); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if out.String() != "signed "+toolPath+"\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+
+	data, err := os.ReadFile(toolPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tool mcp.Tool
+	if err := json.Unmarshal(data, &tool); err != nil {
+		t.Fatal(err)
+	}
+	if err := mcp.VerifyPublisherSignature(tool, publicKey); err != nil {
+		t.Errorf("Signed file failed verification: %v", err)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	if err := run(nil, &bytes.Buffer{}); err == nil {
+		t.Error("Expected an error without tool files")
+	}
+	if err := run([]string{"--key-file", filepath.Join(t.TempDir(), "missing"), "tool.json"}, &bytes.Buffer{}); err == nil {
+		t.Error("Expected an error for a missing key file")
+	}
+}
@@ -0,0 +1,244 @@
+// Package audit keeps a tamper-evident log of security decisions. Each record carries
+// an HMAC of its contents and the hash of the one before it, so removing or altering
+// any record breaks the chain from that point on, which VerifyAuditChain detects.
+// Without the key, a tampered record can't be given a hash that verifies.
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// GenesisHash is the PrevHash of the first record in a chain
+var GenesisHash = hex.EncodeToString(make([]byte, sha256.Size))
+
+// Record is one entry in the audit log
+type Record struct {
+	Seq      uint64    `json:"seq"`
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"` // what happened, e.g. "tool.validate"
+	Tool     string    `json:"tool,omitempty"`
+	Actor    string    `json:"actor,omitempty"`
+	Outcome  string    `json:"outcome"`
+	Detail   string    `json:"detail,omitempty"`
+	PrevHash string    `json:"prevHash"`
+	Hash     string    `json:"hash"`
+}
+
+// ComputeHash returns the HMAC-SHA256 of the record's contents under key,
+// including PrevHash but not Hash
+func (r Record) ComputeHash(key []byte) string {
+	r.Hash = ""
+	// a struct of strings, integers and a time always marshals
+	data, _ := json.Marshal(r)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ChainError reports where an audit chain stops verifying
+type ChainError struct {
+	Index  int // position of the first record that fails
+	Reason string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit chain broken at record %d: %s", e.Index, e.Reason)
+}
+
+// VerifyAuditChain walks records in order, confirming each one's hash matches its
+// contents under key and links to the record before it. It returns a *ChainError for the first
+// record that doesn't. Dropping records from the end leaves a valid, shorter chain,
+// so compare the last hash against one kept elsewhere to detect truncation.
+func VerifyAuditChain(records []Record, key []byte) error {
+	return verifyFrom(GenesisHash, 0, records, key)
+}
+
+// VerifyAuditChainFrom verifies records that continue a chain from the record whose
+// hash is prevHash, such as those a Chain still holds after older ones were dropped.
+// prevHash should come from somewhere trusted, e.g. a head kept from earlier.
+func VerifyAuditChainFrom(prevHash string, records []Record, key []byte) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return verifyFrom(prevHash, records[0].Seq, records, key)
+}
+
+// verifyFrom verifies records as a chain starting at sequence number seq after prevHash
+func verifyFrom(prevHash string, seq uint64, records []Record, key []byte) error {
+	for i, record := range records {
+		if reason := checkRecord(record, prevHash, seq+uint64(i), key); reason != "" {
+			return &ChainError{Index: i, Reason: reason}
+		}
+		prevHash = record.Hash
+	}
+	return nil
+}
+
+// checkRecord returns why a record doesn't follow prevHash as record seq, or "" if it does
+func checkRecord(record Record, prevHash string, seq uint64, key []byte) string {
+	if record.Seq != seq {
+		return fmt.Sprintf("expected sequence %d, got %d", seq, record.Seq)
+	}
+	if record.PrevHash != prevHash {
+		return "does not link to the previous record"
+	}
+	if !hmac.Equal([]byte(record.ComputeHash(key)), []byte(record.Hash)) {
+		return "contents do not match its hash"
+	}
+	return ""
+}
+
+// DefaultMaxRecords is how many of the newest records a Chain keeps in memory by default
+const DefaultMaxRecords = 10000
+
+// Store persists records as a Chain appends them
+type Store interface {
+	Append(record Record) error
+}
+
+// ChainOption configures a Chain
+type ChainOption func(*Chain)
+
+// WithStore persists each record to store before the chain takes it
+func WithStore(store Store) ChainOption {
+	return func(c *Chain) { c.store = store }
+}
+
+// WithMaxRecords sets how many of the newest records are kept in memory
+func WithMaxRecords(n int) ChainOption {
+	return func(c *Chain) {
+		if n > 0 {
+			c.maxRecords = n
+		}
+	}
+}
+
+// Chain is an append-only, hash-linked audit log. It holds the newest records in
+// memory, dropping the oldest once it has maxRecords, while the chain itself carries
+// on from its head. Given a Store, every record is persisted as it is appended, and
+// can be verified from there with the same key.
+type Chain struct {
+	mu         sync.Mutex
+	key        []byte
+	store      Store // nil keeps records in memory only
+	maxRecords int
+	records    []Record // the newest records, oldest first
+	base       string   // PrevHash of records[0]: the hash of the last record dropped
+	next       uint64   // Seq of the next record
+	head       string   // hash of the newest record
+	verified   int      // how many of records Verify has checked
+}
+
+// NewChain returns an empty chain whose records are keyed with key
+func NewChain(key []byte, opts ...ChainOption) *Chain {
+	c := &Chain{key: key, maxRecords: DefaultMaxRecords, base: GenesisHash, head: GenesisHash}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Append links a record to the end of the chain, setting its sequence number,
+// time if unset, and hashes, and returns it as stored. If the chain has a store
+// and it fails to persist the record, the record is not added.
+func (c *Chain) Append(record Record) (Record, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	record.Seq = c.next
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+	record.PrevHash = c.head
+	record.Hash = record.ComputeHash(c.key)
+	if c.store != nil {
+		if err := c.store.Append(record); err != nil {
+			return Record{}, fmt.Errorf("failed to persist audit record %d: %w", record.Seq, err)
+		}
+	}
+	c.add(record)
+	return record, nil
+}
+
+// add makes record the head, dropping the oldest record held if the chain is full
+func (c *Chain) add(record Record) {
+	if len(c.records) >= c.maxRecords {
+		c.base = c.records[0].Hash
+		c.records = c.records[1:]
+		c.verified = max(c.verified-1, 0)
+	}
+	c.records = append(c.records, record)
+	c.next = record.Seq + 1
+	c.head = record.Hash
+}
+
+// load reads records as JSON values from r, verifying each continues the chain
+// before adding it, so the chain can resume where a stored log left off
+func (c *Chain) load(r io.Reader) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dec := json.NewDecoder(r)
+	for {
+		var record Record
+		if err := dec.Decode(&record); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read audit record %d: %w", c.next, err)
+		}
+		if reason := checkRecord(record, c.head, c.next, c.key); reason != "" {
+			return &ChainError{Index: int(c.next), Reason: reason}
+		}
+		c.add(record)
+	}
+	c.verified = len(c.records)
+	return nil
+}
+
+// Records returns a copy of the records held in memory, oldest first
+func (c *Chain) Records() []Record {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Record(nil), c.records...)
+}
+
+// Head returns the hash of the newest record, or GenesisHash for an empty chain.
+// Keeping it outside the log lets truncation be detected.
+func (c *Chain) Head() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.head
+}
+
+// Verify checks the records held in memory with the chain's key. Records already
+// verified aren't checked again, so each call only covers those appended since.
+func (c *Chain) Verify() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prevHash := c.base
+	if c.verified > 0 {
+		prevHash = c.records[c.verified-1].Hash
+	}
+	unverified := c.records[c.verified:]
+	if len(unverified) == 0 {
+		return nil
+	}
+	if err := verifyFrom(prevHash, unverified[0].Seq, unverified, c.key); err != nil {
+		var chainErr *ChainError
+		if errors.As(err, &chainErr) {
+			chainErr.Index += c.verified
+		}
+		return err
+	}
+	c.verified = len(c.records)
+	return nil
+}
@@ -0,0 +1,254 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestChain(t *testing.T) *Chain {
+	t.Helper()
+	chain := NewChain(testKey)
+	for _, tool := range []string{"weather", "search", "calendar", "email"} {
+		chain.Append(Record{Event: "tool.validate", Tool: tool, Actor: "alice", Outcome: "succeeded"})
+	}
+	return chain
+}
+
+func TestVerifyAuditChain_Valid(t *testing.T) {
+	chain := newTestChain(t)
+	if err := chain.Verify(); err != nil {
+		t.Fatalf("Expected a valid chain, got: %v", err)
+	}
+	if err := VerifyAuditChain(nil, testKey); err != nil {
+		t.Errorf("Expected an empty chain to verify, got: %v", err)
+	}
+
+	records := chain.Records()
+	if records[0].PrevHash != GenesisHash {
+		t.Errorf("Expected the first record to link to the genesis hash, got %s", records[0].PrevHash)
+	}
+	if chain.Head() != records[len(records)-1].Hash {
+		t.Errorf("Expected Head to be the newest record's hash")
+	}
+
+	// the chain survives a round trip through storage
+	data, err := json.Marshal(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored []Record
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAuditChain(stored, testKey); err != nil {
+		t.Errorf("Expected the stored chain to verify, got: %v", err)
+	}
+
+	// and only verifies with the key it was written with
+	var chainErr *ChainError
+	if err := VerifyAuditChain(stored, []byte("another key")); !errors.As(err, &chainErr) || chainErr.Index != 0 {
+		t.Errorf("Expected a break at record 0 under the wrong key, got: %v", err)
+	}
+}
+
+func TestVerifyAuditChain_DetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func([]Record) []Record
+		index  int
+	}{
+		{
+			name: "middle record removed",
+			tamper: func(records []Record) []Record {
+				return append(records[:2], records[3:]...)
+			},
+			index: 2,
+		},
+		{
+			name: "middle record altered",
+			tamper: func(records []Record) []Record {
+				records[1].Outcome = "failed"
+				return records
+			},
+			index: 1,
+		},
+		{
+			name: "middle record altered and rehashed without the key",
+			tamper: func(records []Record) []Record {
+				records[1].Outcome = "failed"
+				records[1].Hash = records[1].ComputeHash([]byte("guessed key"))
+				return records
+			},
+			index: 1,
+		},
+		{
+			name: "whole chain rewritten without the key",
+			tamper: func(records []Record) []Record {
+				forged := NewChain(nil)
+				for _, record := range records {
+					if record.Seq == 1 {
+						record.Outcome = "failed"
+					}
+					forged.Append(record)
+				}
+				return forged.Records()
+			},
+			index: 0,
+		},
+		{
+			name: "middle record altered and rehashed with the key",
+			tamper: func(records []Record) []Record {
+				records[1].Outcome = "failed"
+				records[1].Hash = records[1].ComputeHash(testKey)
+				return records
+			},
+			index: 2,
+		},
+		{
+			name: "records reordered",
+			tamper: func(records []Record) []Record {
+				records[1], records[2] = records[2], records[1]
+				return records
+			},
+			index: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := tt.tamper(newTestChain(t).Records())
+			err := VerifyAuditChain(records, testKey)
+			var chainErr *ChainError
+			if !errors.As(err, &chainErr) {
+				t.Fatalf("Expected a *ChainError, got: %v", err)
+			}
+			if chainErr.Index != tt.index {
+				t.Errorf("Expected the break at record %d, got %d: %v", tt.index, chainErr.Index, err)
+			}
+		})
+	}
+}
+
+func TestChain_DropsOldestRecords(t *testing.T) {
+	chain := NewChain(testKey, WithMaxRecords(3))
+	var appended []Record
+	for _, tool := range []string{"weather", "search", "calendar", "email", "files"} {
+		record, err := chain.Append(Record{Event: "tool.register", Tool: tool, Outcome: "succeeded"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		appended = append(appended, record)
+	}
+
+	records := chain.Records()
+	if len(records) != 3 || records[0].Seq != 2 {
+		t.Fatalf("Expected the newest 3 records from sequence 2, got %d from %d", len(records), records[0].Seq)
+	}
+	if chain.Head() != appended[4].Hash {
+		t.Errorf("Expected the head to survive dropping records")
+	}
+	if err := chain.Verify(); err != nil {
+		t.Errorf("Expected the remaining records to verify, got: %v", err)
+	}
+	// the remaining records continue from the last one dropped
+	if err := VerifyAuditChainFrom(appended[1].Hash, records, testKey); err != nil {
+		t.Errorf("Expected the remaining records to verify from the dropped head, got: %v", err)
+	}
+	if err := VerifyAuditChainFrom(appended[0].Hash, records, testKey); err == nil {
+		t.Error("Expected a gap after the given head to be detected")
+	}
+}
+
+func TestChain_VerifyIsIncremental(t *testing.T) {
+	chain := newTestChain(t)
+	if err := chain.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	// records already verified aren't walked again
+	chain.records[1].Outcome = "failed"
+	if err := chain.Verify(); err != nil {
+		t.Errorf("Expected verified records to be skipped, got: %v", err)
+	}
+
+	chain.Append(Record{Event: "tool.release", Tool: "weather", Outcome: "succeeded"})
+	chain.Append(Record{Event: "tool.release", Tool: "search", Outcome: "succeeded"})
+	chain.records[5].Outcome = "failed"
+	err := chain.Verify()
+	var chainErr *ChainError
+	if !errors.As(err, &chainErr) || chainErr.Index != 5 {
+		t.Errorf("Expected a break at record 5, got: %v", err)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Append(Record) error { return errors.New("disk full") }
+
+func TestChain_StoreFailure(t *testing.T) {
+	chain := NewChain(testKey, WithStore(failingStore{}))
+	if _, err := chain.Append(Record{Event: "tool.register", Outcome: "succeeded"}); err == nil {
+		t.Fatal("Expected the store's failure to be returned")
+	}
+	if len(chain.Records()) != 0 || chain.Head() != GenesisHash {
+		t.Error("Expected a record that wasn't persisted to be left out of the chain")
+	}
+}
+
+func TestOpenFileChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	chain, err := OpenFileChain(path, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range []string{"weather", "search", "calendar"} {
+		if _, err := chain.Append(Record{Event: "tool.register", Tool: tool, Outcome: "succeeded"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a restart resumes the chain from the file
+	resumed, err := OpenFileChain(path, testKey, WithMaxRecords(2))
+	if err != nil {
+		t.Fatalf("OpenFileChain() error = %v", err)
+	}
+	if resumed.Head() != chain.Head() || len(resumed.Records()) != 2 {
+		t.Fatalf("Expected the resumed chain to hold the newest 2 records under the same head")
+	}
+	record, err := resumed.Append(Record{Event: "tool.register", Tool: "email", Outcome: "succeeded"})
+	if err != nil || record.Seq != 3 {
+		t.Fatalf("Append() = %d, %v; want sequence 3", record.Seq, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored []Record
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var r Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		stored = append(stored, r)
+	}
+	if err := VerifyAuditChain(stored, testKey); err != nil || len(stored) != 4 {
+		t.Errorf("Expected the 4 stored records to verify, got %d: %v", len(stored), err)
+	}
+
+	var chainErr *ChainError
+	if _, err := OpenFileChain(path, []byte("another key")); !errors.As(err, &chainErr) || chainErr.Index != 0 {
+		t.Errorf("Expected a log written with another key to be refused, got: %v", err)
+	}
+	tampered := strings.Replace(string(data), `"tool":"search"`, `"tool":"files"`, 1)
+	if err := os.WriteFile(path, []byte(tampered), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileChain(path, testKey); !errors.As(err, &chainErr) || chainErr.Index != 1 {
+		t.Errorf("Expected the altered record to be found, got: %v", err)
+	}
+}
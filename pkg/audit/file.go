@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileStore persists records to a file, one JSON object per line
+type FileStore struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Append writes the record to the end of the file
+func (s *FileStore) Append(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file
+func (s *FileStore) Close() error {
+	return s.file.Close()
+}
+
+// OpenFileChain opens the audit log at path, creating it if needed, and returns a
+// chain that resumes from the records already in it and appends new ones to it.
+// The existing records are verified with key first, so the log must have been
+// written with the same key; one that doesn't verify is reported as a *ChainError.
+func OpenFileChain(path string, key []byte, opts ...ChainOption) (*Chain, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	store := &FileStore{file: file}
+	chain := NewChain(key, append(opts, WithStore(store))...)
+	if err := chain.load(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("audit log '%s': %w", path, err)
+	}
+	return chain, nil
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+)
+
+// Actions a CertPolicy grants
+const (
+	ActionRegister = "register"
+	ActionValidate = "validate"
+	ActionCall     = "call"
+)
+
+// ErrNoClientCert is returned when a CertPolicy is enforced on a request that
+// didn't present a verified client certificate
+var ErrNoClientCert = errors.New("client certificate required")
+
+// CertRole grants its actions on the tools matching any of its patterns,
+// which use path.Match syntax so "*" covers every tool.
+type CertRole struct {
+	Actions []string `json:"actions"`
+	Tools   []string `json:"tools"`
+}
+
+// CertPolicy maps client certificate identities to roles, giving certificate
+// based authorization independent of tokens. An identity is a certificate's
+// subject common name or any of its DNS, email or URI subject alternative names.
+type CertPolicy struct {
+	Roles      map[string]CertRole `json:"roles"`
+	Identities map[string][]string `json:"identities"` // identity -> role names
+}
+
+// LoadCertPolicy reads a CertPolicy from a JSON file, rejecting policies
+// that assign roles they don't define or use malformed tool patterns.
+func LoadCertPolicy(file string) (*CertPolicy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var policy CertPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid certificate policy '%s': %w", file, err)
+	}
+	for name, role := range policy.Roles {
+		for _, pattern := range role.Tools {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid certificate policy '%s': role '%s' tool pattern '%s': %w", file, name, pattern, err)
+			}
+		}
+	}
+	for identity, roles := range policy.Identities {
+		for _, role := range roles {
+			if _, ok := policy.Roles[role]; !ok {
+				return nil, fmt.Errorf("invalid certificate policy '%s': identity '%s' has undefined role '%s'", file, identity, role)
+			}
+		}
+	}
+	return &policy, nil
+}
+
+// CertIdentities returns the identities a certificate can be matched by:
+// its subject common name followed by its subject alternative names
+func CertIdentities(cert *x509.Certificate) []string {
+	var ids []string
+	if cert.Subject.CommonName != "" {
+		ids = append(ids, cert.Subject.CommonName)
+	}
+	ids = append(ids, cert.DNSNames...)
+	ids = append(ids, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		ids = append(ids, uri.String())
+	}
+	return ids
+}
+
+// Allows reports whether any identity of cert holds a role granting action on tool
+func (p *CertPolicy) Allows(cert *x509.Certificate, action, tool string) bool {
+	for _, identity := range CertIdentities(cert) {
+		for _, roleName := range p.Identities[identity] {
+			role := p.Roles[roleName]
+			if !slices.Contains(role.Actions, action) {
+				continue
+			}
+			for _, pattern := range role.Tools {
+				if ok, _ := path.Match(pattern, tool); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCertPolicy = `{
+	"roles": {
+		"publisher": {"actions": ["register", "validate"], "tools": ["*"]},
+		"weather-reader": {"actions": ["validate"], "tools": ["weather-*"]}
+	},
+	"identities": {
+		"ci.example.com": ["publisher"],
+		"spiffe://example.com/agent": ["weather-reader"]
+	}
+}`
+
+func writeCertPolicy(t *testing.T, policy string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(file, []byte(policy), 0o600); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	return file
+}
+
+func TestCertPolicy_Allows(t *testing.T) {
+	policy, err := LoadCertPolicy(writeCertPolicy(t, testCertPolicy))
+	if err != nil {
+		t.Fatalf("LoadCertPolicy failed: %v", err)
+	}
+
+	agentURI, _ := url.Parse("spiffe://example.com/agent")
+	publisher := &x509.Certificate{Subject: pkix.Name{CommonName: "ci.example.com"}}
+	agent := &x509.Certificate{Subject: pkix.Name{CommonName: "agent-7"}, URIs: []*url.URL{agentURI}}
+	stranger := &x509.Certificate{Subject: pkix.Name{CommonName: "stranger"}, DNSNames: []string{"stranger.example.com"}}
+
+	tests := []struct {
+		name   string
+		cert   *x509.Certificate
+		action string
+		tool   string
+		want   bool
+	}{
+		{name: "publisher registers", cert: publisher, action: ActionRegister, tool: "anything", want: true},
+		{name: "agent validates matching tool by SAN", cert: agent, action: ActionValidate, tool: "weather-today", want: true},
+		{name: "agent validates other tool", cert: agent, action: ActionValidate, tool: "delete-file"},
+		{name: "agent registers", cert: agent, action: ActionRegister, tool: "weather-today"},
+		{name: "unknown identity", cert: stranger, action: ActionValidate, tool: "weather-today"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Allows(tt.cert, tt.action, tt.tool); got != tt.want {
+				t.Errorf("Allows(%v, %s, %s) = %v, want %v", CertIdentities(tt.cert), tt.action, tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCertPolicy_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"malformed json":   `{"roles":`,
+		"undefined role":   `{"roles": {}, "identities": {"ci.example.com": ["publisher"]}}`,
+		"bad tool pattern": `{"roles": {"r": {"actions": ["validate"], "tools": ["["]}}}`,
+	}
+	for name, policy := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadCertPolicy(writeCertPolicy(t, policy)); err == nil || !strings.Contains(err.Error(), "invalid certificate policy") {
+				t.Errorf("LoadCertPolicy() error = %v, want an invalid policy error", err)
+			}
+		})
+	}
+}
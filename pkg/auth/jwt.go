@@ -3,35 +3,125 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/null-create/mcp-tls/pkg/config"
+	"github.com/null-create/mcp-tls/pkg/util"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
 var (
-	ErrNoAuthHeader error   = errors.New("authorization header not provided")
-	ErrInvalidToken error   = errors.New("invalid token")
-	ErrUnauthorized error   = errors.New("unauthorized")
-	jwtSecret       []byte  = []byte("")
+	ErrNoAuthHeader error  = errors.New("authorization header not provided")
+	ErrInvalidToken error  = errors.New("invalid token")
+	ErrUnauthorized error  = errors.New("unauthorized")
+	jwtSecret       []byte = []byte("")
+	secretProvider  SecretProvider
 	ContextUserKey  UserKey = "user"
+	clock           Clock   = realClock{}
+	leeway                  = config.DefaultJWTLeeway
+	issuer                  = DefaultIssuer
+	audience                = DefaultAudience
+)
+
+const (
+	// DefaultIssuer is the "iss" claim used when none is configured.
+	DefaultIssuer = "mcp-tls"
+	// DefaultAudience is the "aud" claim used when none is configured.
+	DefaultAudience = "mcp-tls"
 )
 
+// Clock provides the current time used when issuing and validating tokens.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// SetClock overrides the clock used for token issuance and validation.
+// Passing nil restores the real wall clock.
+func SetClock(c Clock) {
+	if c == nil {
+		c = realClock{}
+	}
+	clock = c
+}
+
 // Claims is a basic custom claims struct you can extend.
 type Claims struct {
 	Username string `json:"username"`
 	jwt.RegisteredClaims
 }
 
-func RetrieveJWTSecret() string {
+// SecretProvider supplies the JWT signing secret from an external secret store,
+// such as Vault or AWS Secrets Manager.
+type SecretProvider interface {
+	JWTSecret(ctx context.Context) (string, error)
+}
+
+// SetSecretProvider sets the provider RetrieveJWTSecret prefers over the secret
+// file and environment. Passing nil removes it.
+func SetSecretProvider(p SecretProvider) {
+	secretProvider = p
+}
+
+// RetrieveJWTSecret returns the secret tokens are signed with, from the first source
+// configured: the SecretProvider, the file named by MCPTLS_JWT_SECRET_FILE, or
+// MCPTLS_JWT_SECRET. A configured source that fails or is empty is an error rather
+// than a reason to fall back to the next one.
+func RetrieveJWTSecret(ctx context.Context) (string, error) {
+	if secretProvider != nil {
+		secret, err := secretProvider.JWTSecret(ctx)
+		if err != nil {
+			return "", fmt.Errorf("retrieving JWT secret from provider: %w", err)
+		}
+		if secret == "" {
+			return "", errors.New("JWT secret provider returned an empty secret")
+		}
+		return secret, nil
+	}
+	if path := os.Getenv("MCPTLS_JWT_SECRET_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading JWT secret file: %w", err)
+		}
+		// editors and secret mounts commonly leave a trailing newline
+		secret := strings.TrimSpace(string(data))
+		if secret == "" {
+			return "", fmt.Errorf("JWT secret file '%s' is empty", path)
+		}
+		return secret, nil
+	}
 	secret := os.Getenv("MCPTLS_JWT_SECRET")
 	if secret == "" {
 		log.Printf("WARNING MCPTLS_JWT_SECRET not set")
 	}
-	return secret
+	return secret, nil
+}
+
+// SetJWTSecret sets the secret tokens are signed and verified with.
+func SetJWTSecret(secret string) {
+	jwtSecret = []byte(secret)
+}
+
+// SetLeeway configures the clock skew tolerated when validating the
+// expiry, not-before, and issued-at claims of a token.
+func SetLeeway(d time.Duration) {
+	leeway = d
+}
+
+// SetIssuerAndAudience configures the "iss" and "aud" claims set on issued
+// tokens. Tokens carrying any other issuer or audience are rejected by ParseToken.
+func SetIssuerAndAudience(iss, aud string) {
+	issuer = iss
+	audience = aud
 }
 
 // ParseToken validates the JWT and returns the claims if valid.
@@ -42,7 +132,12 @@ func ParseToken(tokenString string) (*Claims, error) {
 			return nil, ErrInvalidToken
 		}
 		return jwtSecret, nil
-	})
+	},
+		jwt.WithTimeFunc(clock.Now),
+		jwt.WithLeeway(leeway),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -60,19 +155,23 @@ func Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, ErrNoAuthHeader.Error(), http.StatusUnauthorized)
+			util.WriteError(w, r, http.StatusUnauthorized, ErrNoAuthHeader.Error())
 			return
 		}
 
 		tokenString := extractBearerToken(authHeader)
 		if tokenString == "" {
-			http.Error(w, ErrInvalidToken.Error(), http.StatusUnauthorized)
+			util.WriteError(w, r, http.StatusUnauthorized, ErrInvalidToken.Error())
 			return
 		}
 
 		claims, err := ParseToken(tokenString)
 		if err != nil {
-			http.Error(w, ErrUnauthorized.Error(), http.StatusUnauthorized)
+			util.WriteError(w, r, http.StatusUnauthorized, ErrUnauthorized.Error())
+			return
+		}
+		if IsRevoked(tokenString) {
+			util.WriteError(w, r, http.StatusUnauthorized, ErrRevokedToken.Error())
 			return
 		}
 
@@ -82,6 +181,12 @@ func Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// TokenFromRequest returns the bearer token from the request's Authorization header,
+// or an empty string if there isn't one.
+func TokenFromRequest(r *http.Request) string {
+	return extractBearerToken(r.Header.Get("Authorization"))
+}
+
 // extractBearerToken gets the token string from "Authorization: Bearer <token>"
 func extractBearerToken(header string) string {
 	if strings.HasPrefix(header, "Bearer ") {
@@ -92,11 +197,14 @@ func extractBearerToken(header string) string {
 
 // CreateToken generates a JWT token with given username and expiry.
 func CreateToken(username string, expiry time.Duration) (string, error) {
+	now := clock.Now()
 	claims := &Claims{
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -114,7 +222,7 @@ func AuthContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		_, ok := FromContext(r.Context())
 		if !ok {
-			http.Error(w, ErrUnauthorized.Error(), http.StatusUnauthorized)
+			util.WriteError(w, r, http.StatusUnauthorized, ErrUnauthorized.Error())
 		}
 	})
 }
@@ -2,10 +2,17 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/null-create/mcp-tls/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 func TestCreateAndParseToken(t *testing.T) {
@@ -117,3 +124,253 @@ func TestFromContext(t *testing.T) {
 		t.Errorf("Expected username 'ctxuser', got %q", gotClaims.Username)
 	}
 }
+
+// fakeClock is a manually advanced Clock for deterministic expiry tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func useFakeClock(t *testing.T) *fakeClock {
+	t.Helper()
+	fc := &fakeClock{now: time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)}
+	SetClock(fc)
+	t.Cleanup(func() { SetClock(nil) })
+	return fc
+}
+
+func TestParseToken_ExpiredWithFakeClock(t *testing.T) {
+	fc := useFakeClock(t)
+
+	token, err := CreateToken("clockuser", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	fc.Advance(30 * time.Minute)
+	if _, err := ParseToken(token); err != nil {
+		t.Fatalf("Expected token to be valid before expiry, got %v", err)
+	}
+
+	fc.Advance(2 * time.Hour)
+	if _, err := ParseToken(token); err == nil {
+		t.Error("Expected error for expired token, got none")
+	}
+}
+
+func TestCreateToken_UsesClock(t *testing.T) {
+	fc := useFakeClock(t)
+
+	token, err := CreateToken("clockuser", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+
+	if !claims.IssuedAt.Time.Equal(fc.now) {
+		t.Errorf("Expected issued-at %v, got %v", fc.now, claims.IssuedAt.Time)
+	}
+	if !claims.ExpiresAt.Time.Equal(fc.now.Add(time.Minute)) {
+		t.Errorf("Expected expiry %v, got %v", fc.now.Add(time.Minute), claims.ExpiresAt.Time)
+	}
+}
+
+func TestParseToken_Leeway(t *testing.T) {
+	fc := useFakeClock(t)
+	SetLeeway(time.Minute)
+	t.Cleanup(func() { SetLeeway(config.DefaultJWTLeeway) })
+
+	token, err := CreateToken("skewuser", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	// just past expiry but within leeway
+	fc.Advance(time.Hour + 30*time.Second)
+	if _, err := ParseToken(token); err != nil {
+		t.Errorf("Expected token within leeway to validate, got %v", err)
+	}
+
+	// beyond expiry plus leeway
+	fc.Advance(time.Minute)
+	if _, err := ParseToken(token); err == nil {
+		t.Error("Expected error for token beyond leeway, got none")
+	}
+}
+
+func TestParseToken_NoLeeway(t *testing.T) {
+	fc := useFakeClock(t)
+	SetLeeway(0)
+	t.Cleanup(func() { SetLeeway(config.DefaultJWTLeeway) })
+
+	token, err := CreateToken("skewuser", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	fc.Advance(time.Hour + 30*time.Second)
+	if _, err := ParseToken(token); err == nil {
+		t.Error("Expected error for expired token without leeway, got none")
+	}
+}
+
+func TestParseToken_IssuerAndAudience(t *testing.T) {
+	signWith := func(t *testing.T, iss string, aud string) string {
+		t.Helper()
+		claims := &Claims{
+			Username: "claimsuser",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    iss,
+				Audience:  jwt.ClaimStrings{aud},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name      string
+		issuer    string
+		audience  string
+		expectErr bool
+	}{
+		{name: "matching issuer and audience", issuer: DefaultIssuer, audience: DefaultAudience, expectErr: false},
+		{name: "wrong issuer", issuer: "other-service", audience: DefaultAudience, expectErr: true},
+		{name: "wrong audience", issuer: DefaultIssuer, audience: "other-service", expectErr: true},
+		{name: "missing issuer", issuer: "", audience: DefaultAudience, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseToken(signWith(t, tt.issuer, tt.audience))
+			if tt.expectErr && err == nil {
+				t.Error("Expected error, got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetIssuerAndAudience(t *testing.T) {
+	SetIssuerAndAudience("service-a", "api-a")
+	t.Cleanup(func() { SetIssuerAndAudience(DefaultIssuer, DefaultAudience) })
+
+	token, err := CreateToken("claimsuser", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	if claims.Issuer != "service-a" {
+		t.Errorf("Expected issuer 'service-a', got %q", claims.Issuer)
+	}
+
+	// a different service must reject the token
+	SetIssuerAndAudience("service-b", "api-b")
+	if _, err := ParseToken(token); err == nil {
+		t.Error("Expected token minted for another service to be rejected")
+	}
+}
+
+type fakeSecretProvider struct {
+	secret string
+	err    error
+}
+
+func (p fakeSecretProvider) JWTSecret(ctx context.Context) (string, error) {
+	return p.secret, p.err
+}
+
+func writeSecretFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwt-secret")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRetrieveJWTSecret_Sources(t *testing.T) {
+	t.Cleanup(func() { SetSecretProvider(nil) })
+	t.Setenv("MCPTLS_JWT_SECRET", "from-env")
+
+	secret, err := RetrieveJWTSecret(context.Background())
+	if err != nil || secret != "from-env" {
+		t.Errorf("RetrieveJWTSecret() = %q, %v, want the env secret", secret, err)
+	}
+
+	// the file takes precedence over the env var, without its trailing newline
+	t.Setenv("MCPTLS_JWT_SECRET_FILE", writeSecretFile(t, "from-file\n"))
+	secret, err = RetrieveJWTSecret(context.Background())
+	if err != nil || secret != "from-file" {
+		t.Errorf("RetrieveJWTSecret() = %q, %v, want the file secret", secret, err)
+	}
+
+	// and a provider over both
+	SetSecretProvider(fakeSecretProvider{secret: "from-provider"})
+	secret, err = RetrieveJWTSecret(context.Background())
+	if err != nil || secret != "from-provider" {
+		t.Errorf("RetrieveJWTSecret() = %q, %v, want the provider secret", secret, err)
+	}
+}
+
+func TestRetrieveJWTSecret_FailingSourcesDontFallBack(t *testing.T) {
+	t.Cleanup(func() { SetSecretProvider(nil) })
+	t.Setenv("MCPTLS_JWT_SECRET", "from-env")
+
+	for name, path := range map[string]string{
+		"missing file": filepath.Join(t.TempDir(), "missing"),
+		"empty file":   writeSecretFile(t, "\n"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("MCPTLS_JWT_SECRET_FILE", path)
+			if secret, err := RetrieveJWTSecret(context.Background()); err == nil {
+				t.Errorf("Expected an error, got secret %q", secret)
+			}
+		})
+	}
+
+	unavailable := errors.New("vault sealed")
+	SetSecretProvider(fakeSecretProvider{err: unavailable})
+	if _, err := RetrieveJWTSecret(context.Background()); !errors.Is(err, unavailable) {
+		t.Errorf("Expected the provider's error, got %v", err)
+	}
+	SetSecretProvider(fakeSecretProvider{})
+	if secret, err := RetrieveJWTSecret(context.Background()); err == nil {
+		t.Errorf("Expected an error for an empty provider secret, got %q", secret)
+	}
+}
+
+func TestSetJWTSecret(t *testing.T) {
+	t.Cleanup(func() { SetJWTSecret("") })
+	SetJWTSecret("first-secret")
+	token, err := CreateToken("secretuser", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	if _, err := ParseToken(token); err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+
+	// tokens signed with another secret are rejected
+	SetJWTSecret("second-secret")
+	if _, err := ParseToken(token); err == nil {
+		t.Error("Expected token signed with a different secret to be rejected")
+	}
+}
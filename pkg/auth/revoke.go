@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRevokedToken is returned for tokens that were revoked before they expired.
+var ErrRevokedToken = errors.New("token has been revoked")
+
+// revocationList holds tokens revoked before their expiry, mapped to the time
+// after which ParseToken would reject them anyway and they can be forgotten.
+type revocationList struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+}
+
+var revoked = &revocationList{tokens: make(map[string]time.Time)}
+
+// Revoke adds a token to the revocation list so Middleware rejects it from now on.
+// Tokens that no longer parse are already unusable and are ignored.
+func Revoke(token string) {
+	claims, err := ParseToken(token)
+	if err != nil || claims.ExpiresAt == nil {
+		return
+	}
+
+	now := clock.Now()
+	revoked.mu.Lock()
+	defer revoked.mu.Unlock()
+	for tok, until := range revoked.tokens {
+		if now.After(until) {
+			delete(revoked.tokens, tok)
+		}
+	}
+	revoked.tokens[token] = claims.ExpiresAt.Add(leeway)
+}
+
+// IsRevoked reports whether the token has been revoked.
+func IsRevoked(token string) bool {
+	revoked.mu.RLock()
+	defer revoked.mu.RUnlock()
+	_, ok := revoked.tokens[token]
+	return ok
+}
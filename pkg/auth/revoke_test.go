@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRevoke_MiddlewareRejectsRevokedToken(t *testing.T) {
+	token, err := CreateToken("revokeuser", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request(); code != http.StatusOK {
+		t.Fatalf("Expected 200 before revoking, got %d", code)
+	}
+
+	Revoke(token)
+	if !IsRevoked(token) {
+		t.Fatal("Expected token to be revoked")
+	}
+	if code := request(); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 after revoking, got %d", code)
+	}
+}
+
+func TestRevoke_IgnoresInvalidToken(t *testing.T) {
+	Revoke("not.a.real.token")
+	if IsRevoked("not.a.real.token") {
+		t.Error("Expected invalid token not to be stored")
+	}
+}
+
+func TestRevoke_ForgetsExpiredTokens(t *testing.T) {
+	fc := useFakeClock(t)
+
+	expiring, err := CreateToken("expiring", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	Revoke(expiring)
+
+	// once past expiry and leeway the token can't be used anyway
+	fc.Advance(time.Minute + leeway + time.Second)
+	fresh, err := CreateToken("fresh", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	Revoke(fresh)
+
+	if IsRevoked(expiring) {
+		t.Error("Expected expired token to be dropped from the revocation list")
+	}
+	if !IsRevoked(fresh) {
+		t.Error("Expected fresh token to be revoked")
+	}
+}
+
+func TestTokenFromRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if tok := TokenFromRequest(req); tok != "" {
+		t.Errorf("Expected empty token, got %q", tok)
+	}
+	req.Header.Set("Authorization", "Bearer abc")
+	if tok := TokenFromRequest(req); tok != "abc" {
+		t.Errorf("Expected 'abc', got %q", tok)
+	}
+}
@@ -1,15 +1,29 @@
 package auth
 
 import (
+	"errors"
+	"sync"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/null-create/logger"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UserKey string // context key for the parsed claims
 
+var ErrUserExists error = errors.New("user already registered")
+
 type User struct {
-	name  string
-	token string
+	name         string
+	token        string
+	passwordHash []byte // bcrypt hash, the plaintext is never stored
+}
+
+// Credentials are the username and password supplied when logging in.
+type Credentials struct {
+	UserName string `json:"userName"`
+	Password string `json:"password"`
 }
 
 func (u *User) Name() string  { return u.name }
@@ -20,42 +34,168 @@ func (u *User) AddToken(tok string) {
 	}
 }
 
+// UsersManager stores registered users. It is safe for concurrent use.
 type UsersManager struct {
 	log   *logger.Logger
+	mu    sync.RWMutex
 	users map[string]*User
 }
 
-func NewUsersManager() UsersManager {
-	return UsersManager{
+func NewUsersManager() *UsersManager {
+	return &UsersManager{
 		log:   logger.NewLogger("USERS_MANAGER", uuid.NewString()),
 		users: make(map[string]*User),
 	}
 }
 
 func (u *UsersManager) HasUser(name string) bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
 	_, exists := u.users[name]
 	return exists
 }
 
-func (u *UsersManager) AddUser(name string) {
-	if !u.HasUser(name) {
-		u.users[name] = &User{name: name}
-		u.log.Info("user '%s' registered", name)
+// AddUser registers a new user, storing only a bcrypt hash of their password.
+// Returns ErrUserExists if the user is already registered; use UpdatePassword
+// to change an existing user's credential.
+func (u *UsersManager) AddUser(name, password string) error {
+	if u.HasUser(name) {
+		return ErrUserExists
+	}
+	// hash before taking the write lock, bcrypt is deliberately slow
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, exists := u.users[name]; exists {
+		return ErrUserExists // registered while we were hashing
+	}
+	u.users[name] = &User{name: name, passwordHash: hash}
+	u.log.Info("user '%s' registered", name)
+	return nil
+}
+
+// UpdatePassword replaces the stored credential of an existing user.
+func (u *UsersManager) UpdatePassword(userName, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	usr, exists := u.users[userName]
+	if !exists {
+		return ErrUnauthorized
+	}
+	usr.passwordHash = hash
+	u.log.Info("password updated for user '%s'", userName)
+	return nil
+}
+
+// VerifyPassword reports whether the password matches the stored credential for the user.
+// Users without a stored credential never verify.
+func (u *UsersManager) VerifyPassword(userName, password string) bool {
+	u.mu.RLock()
+	usr, exists := u.users[userName]
+	var hash []byte
+	if exists {
+		hash = usr.passwordHash
+	}
+	u.mu.RUnlock()
+
+	if len(hash) == 0 {
+		return false
 	}
+	return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
 }
 
 func (u *UsersManager) AddToken(userName, token string) error {
-	if !u.HasUser(userName) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	usr, exists := u.users[userName]
+	if !exists {
 		return ErrUnauthorized
 	}
-	u.users[userName].AddToken(token)
+	usr.AddToken(token)
 	return nil
 }
 
+// Token returns the user's current token, if one has been issued.
+func (u *UsersManager) Token(userName string) (string, bool) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	usr, exists := u.users[userName]
+	if !exists || usr.token == "" {
+		return "", false
+	}
+	return usr.token, true
+}
+
+// IssueToken enforces one active token per user. If the user's stored token is
+// still valid it is returned as is, otherwise a new token valid for expiry is
+// created and recorded against the user.
+func (u *UsersManager) IssueToken(userName string, expiry time.Duration) (string, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	usr, exists := u.users[userName]
+	if !exists {
+		return "", ErrUnauthorized
+	}
+
+	if usr.token != "" {
+		if _, err := ParseToken(usr.token); err == nil {
+			return usr.token, nil
+		}
+		usr.token = "" // expired or otherwise unusable
+	}
+
+	token, err := CreateToken(userName, expiry)
+	if err != nil {
+		return "", err
+	}
+	usr.AddToken(token)
+	u.log.Info("token issued for user '%s'", userName)
+	return token, nil
+}
+
+// RevokeToken revokes the user's current token so it can no longer be used,
+// and clears it so a new one can be issued.
+func (u *UsersManager) RevokeToken(userName string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	usr, exists := u.users[userName]
+	if !exists {
+		return ErrUnauthorized
+	}
+	if usr.token != "" {
+		Revoke(usr.token)
+	}
+	usr.token = ""
+	u.log.Info("token revoked for user '%s'", userName)
+	return nil
+}
+
+// GetUsers returns a snapshot of the registered users. The returned users are
+// copies, so later changes to the manager are not reflected in them.
 func (u *UsersManager) GetUsers() []*User {
-	var users []*User
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	users := make([]*User, 0, len(u.users))
 	for _, usr := range u.users {
-		users = append(users, usr)
+		snapshot := *usr
+		users = append(users, &snapshot)
 	}
 	return users
 }
+
+// hashPassword returns the bcrypt hash of a non-empty password.
+func hashPassword(password string) ([]byte, error) {
+	if password == "" {
+		return nil, errors.New("password cannot be empty")
+	}
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// keep the csv log files produced by the users manager out of the tree
+	logDir, err := os.MkdirTemp("", "mcp-tls-logs")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("LOG_DIR", logDir)
+
+	code := m.Run()
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
+func TestAddUser_StoresHash(t *testing.T) {
+	um := NewUsersManager()
+	if err := um.AddUser("alice", "hunter2"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	usr := um.users["alice"]
+	if len(usr.passwordHash) == 0 {
+		t.Fatal("Expected a stored password hash")
+	}
+	if bytes.Contains(usr.passwordHash, []byte("hunter2")) {
+		t.Error("Password stored in plaintext")
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	um := NewUsersManager()
+	if err := um.AddUser("alice", "hunter2"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	if !um.VerifyPassword("alice", "hunter2") {
+		t.Error("Expected correct password to verify")
+	}
+	if um.VerifyPassword("alice", "hunter3") {
+		t.Error("Expected wrong password to be rejected")
+	}
+	if um.VerifyPassword("bob", "hunter2") {
+		t.Error("Expected unknown user to be rejected")
+	}
+}
+
+func TestAddUser_DoesNotOverwrite(t *testing.T) {
+	um := NewUsersManager()
+	if err := um.AddUser("alice", "original"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	if err := um.AddUser("alice", "replacement"); err != ErrUserExists {
+		t.Errorf("Expected ErrUserExists, got %v", err)
+	}
+	if !um.VerifyPassword("alice", "original") {
+		t.Error("Original credential should be unchanged")
+	}
+
+	if err := um.UpdatePassword("alice", "replacement"); err != nil {
+		t.Fatalf("Failed to update password: %v", err)
+	}
+	if !um.VerifyPassword("alice", "replacement") || um.VerifyPassword("alice", "original") {
+		t.Error("Expected only the updated credential to verify")
+	}
+}
+
+func TestAddUser_EmptyPassword(t *testing.T) {
+	um := NewUsersManager()
+	if err := um.AddUser("alice", ""); err == nil {
+		t.Error("Expected error for empty password")
+	}
+	if um.HasUser("alice") {
+		t.Error("User should not be registered without a password")
+	}
+}
+
+func TestUsersManager_Concurrent(t *testing.T) {
+	// run with -race to check that the manager's locking covers every access
+	um := NewUsersManager()
+
+	const users = 8
+	var wg sync.WaitGroup
+	for i := range users {
+		name := fmt.Sprintf("user-%d", i)
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			if err := um.AddUser(name, "password"); err != nil {
+				t.Errorf("Failed to add user '%s': %v", name, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			// the user may not be registered yet
+			_ = um.AddToken(name, "token-"+name)
+			_ = um.HasUser(name)
+		}()
+		go func() {
+			defer wg.Done()
+			for _, usr := range um.GetUsers() {
+				_ = usr.Name()
+				_ = usr.Token()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range users {
+		name := fmt.Sprintf("user-%d", i)
+		if err := um.AddToken(name, "token-"+name); err != nil {
+			t.Errorf("Failed to add token for '%s': %v", name, err)
+		}
+	}
+
+	snapshot := um.GetUsers()
+	if len(snapshot) != users {
+		t.Fatalf("Expected %d users, got %d", users, len(snapshot))
+	}
+	for _, usr := range snapshot {
+		if usr.Token() != "token-"+usr.Name() {
+			t.Errorf("Expected token for '%s', got '%s'", usr.Name(), usr.Token())
+		}
+	}
+}
+
+func TestAddUser_ConcurrentSameName(t *testing.T) {
+	um := NewUsersManager()
+
+	const attempts = 4
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- um.AddUser("alice", "hunter2")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	registered := 0
+	for err := range errs {
+		switch err {
+		case nil:
+			registered++
+		case ErrUserExists:
+		default:
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if registered != 1 {
+		t.Errorf("Expected exactly one registration to succeed, got %d", registered)
+	}
+}
+
+func TestIssueToken_RecordsToken(t *testing.T) {
+	um := NewUsersManager()
+	if err := um.AddUser("alice", "hunter2"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	if _, ok := um.Token("alice"); ok {
+		t.Fatal("Expected no token before issuing")
+	}
+
+	token, err := um.IssueToken("alice", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	stored, ok := um.Token("alice")
+	if !ok || stored != token {
+		t.Errorf("Expected stored token %q, got %q", token, stored)
+	}
+
+	if _, err := um.IssueToken("bob", time.Hour); err != ErrUnauthorized {
+		t.Errorf("Expected ErrUnauthorized for unknown user, got %v", err)
+	}
+}
+
+func TestIssueToken_OneActiveTokenPerUser(t *testing.T) {
+	fc := useFakeClock(t)
+	um := NewUsersManager()
+	if err := um.AddUser("alice", "hunter2"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	first, err := um.IssueToken("alice", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	fc.Advance(time.Minute)
+	second, err := um.IssueToken("alice", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	if second != first {
+		t.Error("Expected the active token to be returned while it is still valid")
+	}
+
+	// once the active token expires a new one replaces it
+	fc.Advance(2 * time.Hour)
+	third, err := um.IssueToken("alice", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	if third == first {
+		t.Error("Expected a new token after the active one expired")
+	}
+	if stored, _ := um.Token("alice"); stored != third {
+		t.Errorf("Expected stored token to be replaced, got %q", stored)
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	fc := useFakeClock(t)
+	um := NewUsersManager()
+	if err := um.AddUser("alice", "hunter2"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+
+	first, err := um.IssueToken("alice", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	if err := um.RevokeToken("alice"); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if _, ok := um.Token("alice"); ok {
+		t.Error("Expected no token after revoking")
+	}
+
+	// tokens carry second precision timestamps, so move on to get a distinct token
+	fc.Advance(time.Second)
+	second, err := um.IssueToken("alice", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	if second == first {
+		t.Error("Expected a fresh token after revoking")
+	}
+
+	if err := um.RevokeToken("bob"); err != ErrUnauthorized {
+		t.Errorf("Expected ErrUnauthorized for unknown user, got %v", err)
+	}
+}
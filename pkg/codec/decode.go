@@ -0,0 +1,106 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrJSONTooDeep  = errors.New("json exceeds maximum nesting depth")
+	ErrJSONTooLarge = errors.New("json exceeds maximum size")
+)
+
+// DecodeLimits bounds the JSON accepted when decoding untrusted messages, so a huge
+// or deeply nested document is rejected before a recursive unmarshal can exhaust the stack.
+type DecodeLimits struct {
+	MaxDepth int // deepest nesting of objects and arrays
+	MaxSize  int // largest document, in bytes
+}
+
+// DefaultDecodeLimits are the limits used by ParseJSONRPCRequest and DecodeParams
+var DefaultDecodeLimits = DecodeLimits{MaxDepth: 64, MaxSize: 1 << 20}
+
+// CheckJSONLimits rejects documents that are too large or too deeply nested before they
+// reach a parser. It is a single pass over the bytes that only tracks string state and
+// bracket depth, so it is safe to run on untrusted input of any shape.
+func CheckJSONLimits(data []byte, maxDepth, maxSize int) error {
+	if len(data) > maxSize {
+		return fmt.Errorf("%w of %d bytes", ErrJSONTooLarge, maxSize)
+	}
+
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return fmt.Errorf("%w of %d", ErrJSONTooDeep, maxDepth)
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
+
+// Check applies the limits to a JSON document
+func (l DecodeLimits) Check(data []byte) error {
+	return CheckJSONLimits(data, l.MaxDepth, l.MaxSize)
+}
+
+// ParseRequest decodes a JSON-RPC request, checking its params against the limits.
+// A message over MaxSize is reported as INVALID_REQUEST without being decoded, so the
+// returned request is empty; a malformed message is reported as PARSE_ERROR, and params
+// over the limits as INVALID_PARAMS.
+func (l DecodeLimits) ParseRequest(data []byte) (JSONRPCRequest, *JSONRPCError) {
+	var req JSONRPCRequest
+	if len(data) > l.MaxSize {
+		return req, &JSONRPCError{Code: INVALID_REQUEST, Message: fmt.Sprintf("%v of %d bytes", ErrJSONTooLarge, l.MaxSize)}
+	}
+	// the envelope is decoded with params left raw, so nothing recurses into them yet
+	if err := json.Unmarshal(data, &req); err != nil {
+		return req, &JSONRPCError{Code: PARSE_ERROR, Message: "parse error: " + err.Error()}
+	}
+	if err := l.Check(req.Params); err != nil {
+		return req, &JSONRPCError{Code: INVALID_PARAMS, Message: "invalid params: " + err.Error()}
+	}
+	return req, nil
+}
+
+// DecodeParams unmarshals request params into v once they pass the limits,
+// reporting any failure as INVALID_PARAMS.
+func (l DecodeLimits) DecodeParams(params json.RawMessage, v any) *JSONRPCError {
+	if err := l.Check(params); err != nil {
+		return &JSONRPCError{Code: INVALID_PARAMS, Message: "invalid params: " + err.Error()}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &JSONRPCError{Code: INVALID_PARAMS, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+// ParseJSONRPCRequest decodes a JSON-RPC request using DefaultDecodeLimits
+func ParseJSONRPCRequest(data []byte) (JSONRPCRequest, *JSONRPCError) {
+	return DefaultDecodeLimits.ParseRequest(data)
+}
+
+// DecodeParams unmarshals request params into v using DefaultDecodeLimits
+func DecodeParams(params json.RawMessage, v any) *JSONRPCError {
+	return DefaultDecodeLimits.DecodeParams(params, v)
+}
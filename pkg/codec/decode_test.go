@@ -0,0 +1,102 @@
+package codec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func nestedParams(depth int) string {
+	return strings.Repeat(`{"a":`, depth) + `1` + strings.Repeat(`}`, depth)
+}
+
+func TestParseJSONRPCRequest(t *testing.T) {
+	maxDepth := DefaultDecodeLimits.MaxDepth
+	tests := []struct {
+		name     string
+		input    string
+		wantCode int // 0 for success
+	}{
+		{name: "valid request", input: `{"jsonrpc":"2.0","method":"tool.call","params":{"a":[1,2]},"id":1}`},
+		{name: "params at max depth", input: `{"jsonrpc":"2.0","method":"m","params":` + nestedParams(maxDepth) + `,"id":1}`},
+		{
+			name:     "over-nested params",
+			input:    `{"jsonrpc":"2.0","method":"m","params":` + nestedParams(maxDepth+1) + `,"id":1}`,
+			wantCode: INVALID_PARAMS,
+		},
+		{name: "malformed json", input: `{"jsonrpc":"2.0",`, wantCode: PARSE_ERROR},
+		{
+			name:     "oversized message",
+			input:    `{"jsonrpc":"2.0","method":"m","params":"` + strings.Repeat("a", DefaultDecodeLimits.MaxSize) + `","id":1}`,
+			wantCode: INVALID_REQUEST,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rpcErr := ParseJSONRPCRequest([]byte(tt.input))
+			switch {
+			case tt.wantCode == 0 && rpcErr != nil:
+				t.Errorf("ParseJSONRPCRequest() unexpected error: %d %s", rpcErr.Code, rpcErr.Message)
+			case tt.wantCode != 0 && rpcErr == nil:
+				t.Errorf("ParseJSONRPCRequest() = %+v, want error code %d", req, tt.wantCode)
+			case tt.wantCode != 0 && rpcErr.Code != tt.wantCode:
+				t.Errorf("ParseJSONRPCRequest() error code = %d, want %d", rpcErr.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestParseRequest_KeepsIDOnInvalidParams(t *testing.T) {
+	req, rpcErr := DefaultDecodeLimits.ParseRequest([]byte(`{"jsonrpc":"2.0","method":"m","params":` + nestedParams(100) + `,"id":7}`))
+	if rpcErr == nil || rpcErr.Code != INVALID_PARAMS {
+		t.Fatalf("ParseRequest() error = %v, want INVALID_PARAMS", rpcErr)
+	}
+	if req.ID == nil || *req.ID != 7 {
+		t.Errorf("ParseRequest() ID = %v, want 7", req.ID)
+	}
+}
+
+func TestDecodeParams_CustomLimits(t *testing.T) {
+	limits := DecodeLimits{MaxDepth: 2, MaxSize: 64}
+
+	var v map[string]any
+	if rpcErr := limits.DecodeParams([]byte(`{"a":{"b":1}}`), &v); rpcErr != nil {
+		t.Errorf("DecodeParams() at max depth unexpected error: %s", rpcErr.Message)
+	}
+	if rpcErr := limits.DecodeParams([]byte(`{"a":{"b":[1]}}`), &v); rpcErr == nil || rpcErr.Code != INVALID_PARAMS {
+		t.Errorf("DecodeParams() over max depth = %v, want INVALID_PARAMS", rpcErr)
+	}
+	if rpcErr := limits.DecodeParams([]byte(`[1,2]`), &v); rpcErr == nil || rpcErr.Code != INVALID_PARAMS {
+		t.Errorf("DecodeParams() with mismatched type = %v, want INVALID_PARAMS", rpcErr)
+	}
+}
+
+func TestCheckJSONLimits_Errors(t *testing.T) {
+	if err := CheckJSONLimits([]byte(`[[[]]]`), 2, 100); !errors.Is(err, ErrJSONTooDeep) {
+		t.Errorf("CheckJSONLimits() = %v, want ErrJSONTooDeep", err)
+	}
+	if err := CheckJSONLimits([]byte(`"abcdef"`), 2, 4); !errors.Is(err, ErrJSONTooLarge) {
+		t.Errorf("CheckJSONLimits() = %v, want ErrJSONTooLarge", err)
+	}
+	if err := CheckJSONLimits([]byte(`{"a":"[[[["}`), 2, 100); err != nil {
+		t.Errorf("CheckJSONLimits() counted brackets inside a string: %v", err)
+	}
+}
+
+func TestParseJSONRPCRequest_Notification(t *testing.T) {
+	tests := map[string]bool{
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`:        true,
+		`{"jsonrpc":"2.0","method":"ping","id":0}`:                      false,
+		`{"jsonrpc":"2.0","method":"ping","params":{"a":1},"id":12345}`: false,
+	}
+	for input, want := range tests {
+		req, rpcErr := ParseJSONRPCRequest([]byte(input))
+		if rpcErr != nil {
+			t.Fatalf("ParseJSONRPCRequest(%s) unexpected error: %s", input, rpcErr.Message)
+		}
+		if got := req.IsNotification(); got != want {
+			t.Errorf("IsNotification() for %s = %v, want %v", input, got, want)
+		}
+	}
+}
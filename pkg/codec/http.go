@@ -0,0 +1,45 @@
+package codec
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HTTPStatus maps a JSON-RPC error code to the HTTP status that best describes it.
+// Codes without a closer match, including the rest of the server error range, map to 500.
+func HTTPStatus(code int) int {
+	switch code {
+	case PARSE_ERROR, INVALID_REQUEST, INVALID_PARAMS:
+		return http.StatusBadRequest
+	case METHOD_NOT_FOUND:
+		return http.StatusNotFound
+	case RATE_LIMITED:
+		return http.StatusTooManyRequests
+	case TOOL_DENIED:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// WriteJSONRPCResponse writes resp as JSON. Responses are sent with 200 as JSON-RPC
+// over HTTP expects, unless mapStatus is set, in which case an error response
+// carries the HTTP status HTTPStatus maps its code to.
+func WriteJSONRPCResponse(w http.ResponseWriter, resp JSONRPCResponse, mapStatus bool) {
+	status := http.StatusOK
+	if mapStatus && resp.Error != nil {
+		status = HTTPStatus(resp.Error.Code)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// WriteJSONRPCError writes an error response to the request with the given id,
+// which is nil when the request's id couldn't be determined
+func WriteJSONRPCError(w http.ResponseWriter, id *int64, rpcErr *JSONRPCError, mapStatus bool) {
+	resp := NewJSONRPCResponse()
+	resp.ID = id
+	resp.Error = rpcErr
+	WriteJSONRPCResponse(w, resp, mapStatus)
+}
@@ -17,39 +17,51 @@ const (
 	INTERNAL_ERROR   = -32603
 )
 
+// Implementation-defined server errors, from the range JSON-RPC 2.0 reserves for them
+const (
+	RATE_LIMITED = -32001
+	TOOL_DENIED  = -32002
+)
+
 // Generic interface for JSON RPC Messages
 type JSONRPCMessage any
 
+// JSONRPCRequest is a JSON-RPC request, or a notification when ID is nil
 type JSONRPCRequest struct {
 	JSONRPC string          `json:"jsonrpc"`
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params"`
-	ID      int64           `json:"id"`
+	ID      *int64          `json:"id,omitempty"`
 }
 
-func (j *JSONRPCRequest) MarshalJSON() ([]byte, error) {
-	b, err := json.Marshal(j)
-	if err != nil {
-		return nil, err
-	}
-	return b, nil
-}
+// IsNotification reports whether the request omitted its id, meaning
+// the sender expects no response, not even an error.
+func (j JSONRPCRequest) IsNotification() bool { return j.ID == nil }
+
+// NewID returns a request ID for use in JSONRPCRequest and JSONRPCResponse
+func NewID(id int64) *int64 { return &id }
 
 type JSONRPCResponse struct {
 	JSONRPC string          `json:"jsonrpc"`
 	Result  json.RawMessage `json:"result,omitempty"`
 	Error   *JSONRPCError   `json:"error,omitempty"`
-	ID      int64           `json:"id"`
+	ID      *int64          `json:"id"` // null when the request's id couldn't be determined
 }
 
-func (j *JSONRPCResponse) MarshalJSON() ([]byte, error) {
-	b, err := json.Marshal(j.Result)
-	if err != nil {
-		return nil, err
+// MarshalJSON emits the full response object. Result is dropped when Error
+// is set, since JSON-RPC 2.0 forbids a response carrying both.
+func (j JSONRPCResponse) MarshalJSON() ([]byte, error) {
+	type response JSONRPCResponse // strips this method so json.Marshal doesn't recurse
+	resp := response(j)
+	if resp.Error != nil {
+		resp.Result = nil
 	}
-	return b, nil
+	return json.Marshal(resp)
 }
 
+// ToJSON returns the response serialized as sent on the wire
+func (j JSONRPCResponse) ToJSON() ([]byte, error) { return json.Marshal(j) }
+
 func NewJSONRPCResponse() JSONRPCResponse {
 	return JSONRPCResponse{
 		JSONRPC: JsonRPCVersion,
@@ -61,12 +73,9 @@ type JSONRCPNotification struct {
 	Notification
 }
 
-func (j *JSONRCPNotification) MarshalJSON() ([]byte, error) {
-	b, err := json.Marshal(j)
-	if err != nil {
-		return nil, err
-	}
-	return b, nil
+func (j JSONRCPNotification) MarshalJSON() ([]byte, error) {
+	type notification JSONRCPNotification // strips this method so json.Marshal doesn't recurse
+	return json.Marshal(notification(j))
 }
 
 type JSONRPCError struct {
@@ -78,19 +87,13 @@ type JSONRPCError struct {
 func (r *JSONRPCError) ErrCode() int { return r.Code }
 func (r *JSONRPCError) Msg() string  { return r.Message }
 
+// Notification has no marshaler of its own: one would be promoted into
+// JSONRCPNotification and drop its jsonrpc field.
 type Notification struct {
 	Method string             `json:"method"`
 	Params NotificationParams `json:"params,omitempty"` // Often null/omitted for simple notifications
 }
 
-func (n *Notification) MarshalJSON() ([]byte, error) {
-	b, err := json.Marshal(n)
-	if err != nil {
-		return nil, err
-	}
-	return b, nil
-}
-
 type NotificationParams struct {
 	Meta             map[string]any `json:"_meta,omitempty"`
 	AdditionalFields map[string]any `json:"-"`
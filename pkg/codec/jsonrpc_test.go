@@ -0,0 +1,205 @@
+package codec
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONRPCResponse_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		resp JSONRPCResponse
+		want string
+	}{
+		{
+			name: "result",
+			resp: JSONRPCResponse{JSONRPC: JsonRPCVersion, Result: json.RawMessage(`{"ok":true}`), ID: NewID(1)},
+			want: `{"jsonrpc":"2.0","result":{"ok":true},"id":1}`,
+		},
+		{
+			name: "error",
+			resp: JSONRPCResponse{JSONRPC: JsonRPCVersion, Error: &JSONRPCError{Code: METHOD_NOT_FOUND, Message: "method not found: x"}, ID: NewID(2)},
+			want: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: x"},"id":2}`,
+		},
+		{
+			name: "error drops result",
+			resp: JSONRPCResponse{JSONRPC: JsonRPCVersion, Result: json.RawMessage(`1`), Error: &JSONRPCError{Code: INTERNAL_ERROR, Message: "boom"}, ID: NewID(3)},
+			want: `{"jsonrpc":"2.0","error":{"code":-32603,"message":"boom"},"id":3}`,
+		},
+		{
+			name: "unknown id",
+			resp: JSONRPCResponse{JSONRPC: JsonRPCVersion, Error: &JSONRPCError{Code: PARSE_ERROR, Message: "parse error"}},
+			want: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"parse error"},"id":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, v := range []any{tt.resp, &tt.resp} {
+				got, err := json.Marshal(v)
+				if err != nil {
+					t.Fatalf("json.Marshal(%T) error: %v", v, err)
+				}
+				if string(got) != tt.want {
+					t.Errorf("json.Marshal(%T) = %s, want %s", v, got, tt.want)
+				}
+			}
+			got, err := tt.resp.ToJSON()
+			if err != nil {
+				t.Fatalf("ToJSON() error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteJSONRPCError(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       int
+		mapStatus  bool
+		wantStatus int
+	}{
+		{name: "unmapped", code: METHOD_NOT_FOUND, wantStatus: http.StatusOK},
+		{name: "parse error", code: PARSE_ERROR, mapStatus: true, wantStatus: http.StatusBadRequest},
+		{name: "invalid params", code: INVALID_PARAMS, mapStatus: true, wantStatus: http.StatusBadRequest},
+		{name: "method not found", code: METHOD_NOT_FOUND, mapStatus: true, wantStatus: http.StatusNotFound},
+		{name: "rate limited", code: RATE_LIMITED, mapStatus: true, wantStatus: http.StatusTooManyRequests},
+		{name: "tool denied", code: TOOL_DENIED, mapStatus: true, wantStatus: http.StatusForbidden},
+		{name: "internal error", code: INTERNAL_ERROR, mapStatus: true, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteJSONRPCError(rec, NewID(9), &JSONRPCError{Code: tt.code, Message: "failed"}, tt.mapStatus)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			var resp JSONRPCResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid response body %q: %v", rec.Body.String(), err)
+			}
+			if resp.Error == nil || resp.Error.Code != tt.code {
+				t.Errorf("error = %+v, want code %d", resp.Error, tt.code)
+			}
+			if resp.ID == nil || *resp.ID != 9 {
+				t.Errorf("id = %v, want 9", resp.ID)
+			}
+		})
+	}
+}
+
+func TestWriteJSONRPCResponse_ResultIsOK(t *testing.T) {
+	rec := httptest.NewRecorder()
+	resp := NewJSONRPCResponse()
+	resp.ID = NewID(1)
+	resp.Result = json.RawMessage(`"done"`)
+	WriteJSONRPCResponse(rec, resp, true)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if want := `{"jsonrpc":"2.0","result":"done","id":1}` + "\n"; rec.Body.String() != want {
+		t.Errorf("body = %s, want %s", rec.Body.String(), want)
+	}
+}
+
+func TestJSONRPCResponse_MarshalJSONFields(t *testing.T) {
+	result := NewJSONRPCResponse()
+	result.ID = NewID(1)
+	result.Result = json.RawMessage(`{"tools":[]}`)
+
+	failure := NewJSONRPCResponse()
+	failure.ID = NewID(2)
+	failure.Error = &JSONRPCError{Code: INVALID_PARAMS, Message: "bad params", Data: map[string]any{"field": "name"}}
+
+	tests := []struct {
+		name      string
+		resp      *JSONRPCResponse
+		wantKey   string
+		unwantKey string
+	}{
+		{name: "result", resp: &result, wantKey: "result", unwantKey: "error"},
+		{name: "error", resp: &failure, wantKey: "error", unwantKey: "result"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.resp)
+			if err != nil {
+				t.Fatalf("json.Marshal() error: %v", err)
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatalf("json.Marshal() = %s, not an object: %v", data, err)
+			}
+			for _, key := range []string{"jsonrpc", "id", tt.wantKey} {
+				if _, ok := fields[key]; !ok {
+					t.Errorf("json.Marshal() = %s, missing %q", data, key)
+				}
+			}
+			if _, ok := fields[tt.unwantKey]; ok {
+				t.Errorf("json.Marshal() = %s, unexpected %q", data, tt.unwantKey)
+			}
+
+			var decoded JSONRPCResponse
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("json.Unmarshal() error: %v", err)
+			}
+			if decoded.ID == nil || *decoded.ID != *tt.resp.ID {
+				t.Errorf("round-tripped id = %v, want %d", decoded.ID, *tt.resp.ID)
+			}
+			if (decoded.Error == nil) != (tt.resp.Error == nil) {
+				t.Errorf("round-tripped error = %+v, want %+v", decoded.Error, tt.resp.Error)
+			}
+		})
+	}
+}
+
+func TestJSONRPCRequest_Marshal(t *testing.T) {
+	req := &JSONRPCRequest{JSONRPC: JsonRPCVersion, Method: "tools/call", Params: json.RawMessage(`{"name":"echo"}`), ID: NewID(4)}
+
+	got, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json.Marshal() error: %v", err)
+	}
+	if want := `{"jsonrpc":"2.0","method":"tools/call","params":{"name":"echo"},"id":4}`; string(got) != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
+
+func TestJSONRCPNotification_Marshal(t *testing.T) {
+	n := JSONRCPNotification{
+		JSONRPC: JsonRPCVersion,
+		Notification: Notification{
+			Method: "notifications/progress",
+			Params: NotificationParams{
+				Meta:             map[string]any{"progressToken": "abc"},
+				AdditionalFields: map[string]any{"progress": 50},
+			},
+		},
+	}
+
+	for _, v := range []any{n, &n} {
+		got, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("json.Marshal(%T) error: %v", v, err)
+		}
+		if !json.Valid(got) {
+			t.Fatalf("json.Marshal(%T) = %s, not valid JSON", v, got)
+		}
+		want := `{"jsonrpc":"2.0","method":"notifications/progress","params":{"_meta":{"progressToken":"abc"},"progress":50}}`
+		if string(got) != want {
+			t.Errorf("json.Marshal(%T) = %s, want %s", v, got, want)
+		}
+	}
+}
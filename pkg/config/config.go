@@ -0,0 +1,348 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Deployment environments selected with MCPTLS_ENV
+const (
+	EnvDev  = "dev"
+	EnvProd = "prod" // malformed settings and a missing JWT secret are fatal, and tool security checks default on
+
+	DefaultEnv = EnvDev
+)
+
+// Default values used when the corresponding environment variable is unset
+const (
+	DefaultJWTLeeway   = 60 * time.Second
+	DefaultJWTIssuer   = "mcp-tls"
+	DefaultJWTAudience = "mcp-tls"
+
+	DefaultShutdownGrace = 10 * time.Second
+
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultIdleTimeout       = 30 * time.Second
+	DefaultHTTP2MaxStreams   = 250
+
+	DefaultToolCallTimeout = 30 * time.Second
+	// DefaultValidateTimeout stays under the server's 30s write timeout, so the 503 still reaches the client
+	DefaultValidateTimeout = 20 * time.Second
+
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	DefaultJSONMaxDepth = 64
+
+	DefaultLogLevel        = "info"
+	DefaultMaxLoggedOutput = 1024
+
+	DefaultMaxBodySize       = 1 << 20
+	DefaultMaxImportBodySize = 32 << 20
+
+	DefaultValidationErrorPolicy    = "fail-closed"
+	DefaultValidationErrorVerbosity = "full"
+
+	DefaultNumberCanonicalization = "exact"
+)
+
+// Config holds the runtime configuration shared across the MCP-TLS server components.
+type Config struct {
+	Env string // deployment environment, EnvDev or EnvProd
+
+	JWTLeeway   time.Duration // tolerated clock skew when validating token time claims
+	JWTIssuer   string        // "iss" claim set on issued tokens and required on incoming ones
+	JWTAudience string        // "aud" claim set on issued tokens and required on incoming ones
+	TLSCert     string        // path to the PEM certificate served when TLS is enabled
+	TLSKey      string        // path to the PEM private key for TLSCert
+	TLSClientCA string        // path to the PEM CA bundle client certificates are verified against
+	Proxy       bool          // start the validating JSON-RPC proxy instead of the HTTP server
+
+	ShutdownGrace time.Duration // time allowed for in-flight requests to drain before forcing shutdown
+
+	ReadHeaderTimeout time.Duration // time allowed to read a request's headers, bounding slow clients
+	IdleTimeout       time.Duration // how long an idle keep-alive connection is held open
+	KeepAlive         bool          // reuse connections across requests
+	HTTP2             bool          // offer HTTP/2 to TLS clients
+	HTTP2MaxStreams   int           // concurrent requests allowed on one HTTP/2 connection
+
+	ToolAllowlist []string // if set, the only tools the proxy forwards calls to
+	ToolDenylist  []string // tools the proxy never forwards calls to, even if allowlisted
+
+	JSONMaxDepth int // deepest nesting accepted in JSON-RPC params
+
+	SensitiveFields []string // tool input and output properties whose values are redacted from logs
+	LogLevel        string   // "debug", "info" or "warn": rejected tool input and output is only logged in full at "debug"
+	MaxLoggedOutput int      // bytes of a rejected tool input or output kept in errors and logs
+
+	StrictJSON bool // reject unknown fields in tool registration and validation bodies
+
+	MaxBodySize       int // largest request body accepted by the HTTP API, in bytes
+	MaxImportBodySize int // largest signed tool bundle accepted by the import endpoint, in bytes
+
+	ValidationErrorPolicy    string // "fail-closed" or "fail-open": whether the proxy blocks calls whose validation errored
+	ValidationErrorVerbosity string // "full" or "summary": how much of a schema violation report errors carry
+
+	DescriptionDenylist   []string // phrases, e.g. known prompt injections, that tool descriptions may not contain
+	NormalizeDescriptions bool     // NFKC normalize tool descriptions before matching them against the denylist
+
+	TrustedSources []string // patterns of the tool sources trusted at lookup; empty trusts all
+
+	PublisherKeys []string // base64 Ed25519 public keys tools must be signed by; empty requires no publisher signature
+
+	RequiredAnnotations []string // annotations, e.g. "title" or "readOnlyHint", tools must declare to be registered
+
+	ValidateChecksums   bool // re-verify a tool's checksum and fingerprints whenever it's looked up; on by default in prod
+	RejectUnsignedTools bool // refuse tools without a checksum and signature; on by default in prod
+
+	AdminUsers       []string // users allowed to use the admin endpoints; their names can't be registered through the API
+	AdminCredentials []string // "name:password" pairs creating the admin users' accounts at startup
+
+	RPCErrorStatus bool // send JSON-RPC errors with the HTTP status matching their code rather than 200
+
+	CertPolicyFile string // path to the JSON policy mapping client certificates to roles; unset disables it
+
+	IntegrityScanInterval time.Duration // how often registered tools are re-verified in the background; 0 disables it
+
+	ToolCallTimeout time.Duration // how long a tools/call may run, unless the tool declares its own x-timeout-ms
+
+	ValidateTimeout time.Duration // how long a /api/validate request may run before it's answered with 503; 0 disables it
+
+	NumberCanonicalization string // "exact" or "float64": how numbers are written when computing checksums and fingerprints
+
+	DeprecationCutoff time.Time // when deprecated tools stop being served; zero keeps serving them with a warning
+
+	RedisAddr      string        // Redis server keeping idempotent responses; unset keeps them in memory
+	IdempotencyTTL time.Duration // how long responses to requests with an Idempotency-Key are replayed
+
+	AuditLogFile string // file the audit chain is appended to and resumed from; unset keeps it in memory only
+
+	invalid []error // malformed settings replaced with their defaults, fatal in prod
+}
+
+// LoadConfigs reads the server configuration from the environment,
+// falling back to defaults for anything unset or malformed.
+func LoadConfigs() *Config {
+	var env envReader
+	deployment := env.stringFromEnv("MCPTLS_ENV", DefaultEnv)
+	prod := deployment == EnvProd
+	cfgs := &Config{
+		Env: deployment,
+
+		JWTLeeway:   env.durationFromEnv("MCPTLS_JWT_LEEWAY", DefaultJWTLeeway),
+		JWTIssuer:   env.stringFromEnv("MCPTLS_JWT_ISSUER", DefaultJWTIssuer),
+		JWTAudience: env.stringFromEnv("MCPTLS_JWT_AUDIENCE", DefaultJWTAudience),
+		TLSCert:     os.Getenv("MCPTLS_TLS_CERT"),
+		TLSKey:      os.Getenv("MCPTLS_TLS_KEY"),
+		TLSClientCA: os.Getenv("MCPTLS_TLS_CLIENT_CA"),
+		Proxy:       env.boolFromEnv("MCPTLS_PROXY", false),
+
+		ShutdownGrace: env.durationFromEnv("MCPTLS_SHUTDOWN_GRACE", DefaultShutdownGrace),
+
+		ReadHeaderTimeout: env.durationFromEnv("MCPTLS_READ_HEADER_TIMEOUT", DefaultReadHeaderTimeout),
+		IdleTimeout:       env.durationFromEnv("MCPTLS_IDLE_TIMEOUT", DefaultIdleTimeout),
+		KeepAlive:         env.boolFromEnv("MCPTLS_KEEP_ALIVE", true),
+		HTTP2:             env.boolFromEnv("MCPTLS_HTTP2", true),
+		HTTP2MaxStreams:   env.intFromEnv("MCPTLS_HTTP2_MAX_STREAMS", DefaultHTTP2MaxStreams),
+
+		ToolAllowlist: env.listFromEnv("MCPTLS_TOOL_ALLOWLIST"),
+		ToolDenylist:  env.listFromEnv("MCPTLS_TOOL_DENYLIST"),
+
+		JSONMaxDepth: env.intFromEnv("MCPTLS_JSON_MAX_DEPTH", DefaultJSONMaxDepth),
+
+		SensitiveFields: env.listFromEnv("MCPTLS_SENSITIVE_FIELDS"),
+		LogLevel:        env.stringFromEnv("MCPTLS_LOG_LEVEL", DefaultLogLevel),
+		MaxLoggedOutput: env.intFromEnv("MCPTLS_MAX_LOGGED_OUTPUT", DefaultMaxLoggedOutput),
+
+		StrictJSON: env.boolFromEnv("MCPTLS_STRICT_JSON", false),
+
+		MaxBodySize:       env.intFromEnv("MCPTLS_MAX_BODY_SIZE", DefaultMaxBodySize),
+		MaxImportBodySize: env.intFromEnv("MCPTLS_MAX_IMPORT_BODY_SIZE", DefaultMaxImportBodySize),
+
+		ValidationErrorPolicy:    env.stringFromEnv("MCPTLS_VALIDATION_ERROR_POLICY", DefaultValidationErrorPolicy),
+		ValidationErrorVerbosity: env.stringFromEnv("MCPTLS_VALIDATION_ERROR_VERBOSITY", DefaultValidationErrorVerbosity),
+
+		DescriptionDenylist:   env.listFromEnv("MCPTLS_DESCRIPTION_DENYLIST"),
+		NormalizeDescriptions: env.boolFromEnv("MCPTLS_NORMALIZE_DESCRIPTIONS", false),
+
+		TrustedSources: env.listFromEnv("MCPTLS_TRUSTED_SOURCES"),
+
+		PublisherKeys: env.listFromEnv("MCPTLS_PUBLISHER_KEYS"),
+
+		RequiredAnnotations: env.listFromEnv("MCPTLS_REQUIRED_ANNOTATIONS"),
+
+		ValidateChecksums:   env.boolFromEnv("MCPTLS_VALIDATE_CHECKSUMS", prod),
+		RejectUnsignedTools: env.boolFromEnv("MCPTLS_REJECT_UNSIGNED_TOOLS", prod),
+
+		AdminUsers:       env.listFromEnv("MCPTLS_ADMIN_USERS"),
+		AdminCredentials: env.listFromEnv("MCPTLS_ADMIN_CREDENTIALS"),
+
+		RPCErrorStatus: env.boolFromEnv("MCPTLS_RPC_ERROR_STATUS", false),
+
+		CertPolicyFile: os.Getenv("MCPTLS_CERT_POLICY"),
+
+		IntegrityScanInterval: env.durationFromEnv("MCPTLS_INTEGRITY_SCAN_INTERVAL", 0),
+
+		ToolCallTimeout: env.durationFromEnv("MCPTLS_TOOL_CALL_TIMEOUT", DefaultToolCallTimeout),
+
+		ValidateTimeout: env.durationFromEnv("MCPTLS_VALIDATE_TIMEOUT", DefaultValidateTimeout),
+
+		NumberCanonicalization: env.stringFromEnv("MCPTLS_CANONICAL_NUMBERS", DefaultNumberCanonicalization),
+
+		DeprecationCutoff: env.timeFromEnv("MCPTLS_DEPRECATION_CUTOFF"),
+
+		RedisAddr:      os.Getenv("MCPTLS_REDIS_ADDR"),
+		IdempotencyTTL: env.durationFromEnv("MCPTLS_IDEMPOTENCY_TTL", DefaultIdempotencyTTL),
+
+		AuditLogFile: os.Getenv("MCPTLS_AUDIT_LOG"),
+	}
+	cfgs.invalid = env.invalid
+	return cfgs
+}
+
+// IsProd reports whether the server is deployed as EnvProd
+func (c *Config) IsProd() bool {
+	return c.Env == EnvProd
+}
+
+// Validate checks that the configuration is consistent across fields, such as a TLS
+// certificate configured without its key, and that the files it names exist. Unlike
+// a malformed value, which falls back to its default, these would leave a feature
+// silently off or the server failing at its first request, so the server refuses to
+// start. In prod, malformed values are refused too. Every problem found is reported,
+// each naming its environment variable.
+func (c *Config) Validate() error {
+	var errs []error
+	switch c.Env {
+	case "", EnvDev:
+	case EnvProd:
+		errs = append(errs, c.invalid...)
+	default:
+		// a typo shouldn't quietly drop a prod deployment's safeguards
+		errs = append(errs, fmt.Errorf("invalid MCPTLS_ENV value '%s': expected '%s' or '%s'", c.Env, EnvDev, EnvProd))
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		errs = append(errs, errors.New("MCPTLS_TLS_CERT and MCPTLS_TLS_KEY must be set together"))
+	}
+	if c.TLSClientCA != "" && (c.TLSCert == "" || c.TLSKey == "") {
+		errs = append(errs, errors.New("MCPTLS_TLS_CLIENT_CA requires MCPTLS_TLS_CERT and MCPTLS_TLS_KEY, as client certificates are only requested over TLS"))
+	}
+	if c.CertPolicyFile != "" && c.TLSClientCA == "" {
+		errs = append(errs, errors.New("MCPTLS_CERT_POLICY requires MCPTLS_TLS_CLIENT_CA, or no client presents a certificate for it to authorize"))
+	}
+	if c.Proxy && (c.TLSClientCA != "" || c.CertPolicyFile != "") {
+		errs = append(errs, errors.New("MCPTLS_TLS_CLIENT_CA and MCPTLS_CERT_POLICY are not supported in proxy mode, which doesn't serve TLS"))
+	}
+	for _, file := range []struct{ key, path string }{
+		{"MCPTLS_TLS_CERT", c.TLSCert},
+		{"MCPTLS_TLS_KEY", c.TLSKey},
+		{"MCPTLS_TLS_CLIENT_CA", c.TLSClientCA},
+		{"MCPTLS_CERT_POLICY", c.CertPolicyFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// envReader reads settings from the environment, noting the malformed
+// values it replaced with their defaults
+type envReader struct {
+	invalid []error
+}
+
+// warn logs a malformed value and what was done instead, and notes it for Validate
+func (e *envReader) warn(key, value, fallback string) {
+	err := fmt.Errorf("invalid %s value '%s'", key, value)
+	log.Printf("WARNING %v, %s", err, fallback)
+	e.invalid = append(e.invalid, err)
+}
+
+// stringFromEnv returns the named environment variable, or the fallback if it is unset.
+func (e *envReader) stringFromEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// listFromEnv splits the named environment variable on commas,
+// trimming whitespace and dropping empty entries.
+func (e *envReader) listFromEnv(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// boolFromEnv parses a boolean (e.g. "true", "1") from the named
+// environment variable, returning the fallback if it is unset or invalid.
+func (e *envReader) boolFromEnv(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.warn(key, value, fmt.Sprintf("using default %v", fallback))
+		return fallback
+	}
+	return b
+}
+
+// intFromEnv parses a positive integer from the named environment
+// variable, returning the fallback if it is unset or invalid.
+func (e *envReader) intFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		e.warn(key, value, fmt.Sprintf("using default %v", fallback))
+		return fallback
+	}
+	return n
+}
+
+// durationFromEnv parses a time.Duration (e.g. "30s") from the named
+// environment variable, returning the fallback if it is unset or invalid.
+func (e *envReader) durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		e.warn(key, value, fmt.Sprintf("using default %v", fallback))
+		return fallback
+	}
+	return d
+}
+
+// timeFromEnv parses an RFC 3339 time or a date (e.g. "2026-01-31", midnight UTC) from
+// the named environment variable, returning the zero time if it is unset or invalid.
+func (e *envReader) timeFromEnv(key string) time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	e.warn(key, value, "ignoring it")
+	return time.Time{}
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigs_JWTLeeway(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "unset uses default", value: "", expected: DefaultJWTLeeway},
+		{name: "valid duration", value: "15s", expected: 15 * time.Second},
+		{name: "zero disables leeway", value: "0s", expected: 0},
+		{name: "malformed uses default", value: "soon", expected: DefaultJWTLeeway},
+		{name: "negative uses default", value: "-5s", expected: DefaultJWTLeeway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MCPTLS_JWT_LEEWAY", tt.value)
+
+			cfg := LoadConfigs()
+			if cfg.JWTLeeway != tt.expected {
+				t.Errorf("JWTLeeway = %v, want %v", cfg.JWTLeeway, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfigs_Proxy(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected bool
+	}{
+		{name: "unset uses default", value: "", expected: false},
+		{name: "true", value: "true", expected: true},
+		{name: "numeric true", value: "1", expected: true},
+		{name: "false", value: "false", expected: false},
+		{name: "malformed uses default", value: "yes please", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MCPTLS_PROXY", tt.value)
+
+			cfg := LoadConfigs()
+			if cfg.Proxy != tt.expected {
+				t.Errorf("Proxy = %v, want %v", cfg.Proxy, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfigs_ToolLists(t *testing.T) {
+	t.Setenv("MCPTLS_TOOL_ALLOWLIST", "read-file, list-dir,,")
+	t.Setenv("MCPTLS_TOOL_DENYLIST", "")
+
+	cfg := LoadConfigs()
+	if !slices.Equal(cfg.ToolAllowlist, []string{"read-file", "list-dir"}) {
+		t.Errorf("ToolAllowlist = %v, want [read-file list-dir]", cfg.ToolAllowlist)
+	}
+	if cfg.ToolDenylist != nil {
+		t.Errorf("ToolDenylist = %v, want empty", cfg.ToolDenylist)
+	}
+}
+
+func TestLoadConfigs_JSONMaxDepth(t *testing.T) {
+	tests := map[string]int{
+		"":    DefaultJSONMaxDepth,
+		"32":  32,
+		"0":   DefaultJSONMaxDepth,
+		"-3":  DefaultJSONMaxDepth,
+		"abc": DefaultJSONMaxDepth,
+	}
+	for value, want := range tests {
+		t.Setenv("MCPTLS_JSON_MAX_DEPTH", value)
+		if got := LoadConfigs().JSONMaxDepth; got != want {
+			t.Errorf("JSONMaxDepth with %q = %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestLoadConfigs_SensitiveFields(t *testing.T) {
+	t.Setenv("MCPTLS_SENSITIVE_FIELDS", "password, apiKey")
+
+	cfg := LoadConfigs()
+	if !slices.Equal(cfg.SensitiveFields, []string{"password", "apiKey"}) {
+		t.Errorf("SensitiveFields = %v, want [password apiKey]", cfg.SensitiveFields)
+	}
+}
+
+func TestLoadConfigs_StrictJSON(t *testing.T) {
+	t.Setenv("MCPTLS_STRICT_JSON", "")
+	if LoadConfigs().StrictJSON {
+		t.Error("StrictJSON should be off by default")
+	}
+	t.Setenv("MCPTLS_STRICT_JSON", "true")
+	if !LoadConfigs().StrictJSON {
+		t.Error("StrictJSON = false, want true")
+	}
+}
+
+func TestLoadConfigs_MaxBodySize(t *testing.T) {
+	t.Setenv("MCPTLS_MAX_BODY_SIZE", "")
+	t.Setenv("MCPTLS_MAX_IMPORT_BODY_SIZE", "")
+	cfg := LoadConfigs()
+	if cfg.MaxBodySize != DefaultMaxBodySize || cfg.MaxImportBodySize != DefaultMaxImportBodySize {
+		t.Errorf("got limits %d/%d, want defaults", cfg.MaxBodySize, cfg.MaxImportBodySize)
+	}
+
+	t.Setenv("MCPTLS_MAX_BODY_SIZE", "2048")
+	t.Setenv("MCPTLS_MAX_IMPORT_BODY_SIZE", "-1")
+	cfg = LoadConfigs()
+	if cfg.MaxBodySize != 2048 {
+		t.Errorf("MaxBodySize = %d, want 2048", cfg.MaxBodySize)
+	}
+	if cfg.MaxImportBodySize != DefaultMaxImportBodySize {
+		t.Errorf("MaxImportBodySize = %d, want default for invalid value", cfg.MaxImportBodySize)
+	}
+}
+
+func TestLoadConfigs_ValidationErrorPolicy(t *testing.T) {
+	t.Setenv("MCPTLS_VALIDATION_ERROR_POLICY", "")
+	if got := LoadConfigs().ValidationErrorPolicy; got != DefaultValidationErrorPolicy {
+		t.Errorf("ValidationErrorPolicy = %q, want %q", got, DefaultValidationErrorPolicy)
+	}
+	t.Setenv("MCPTLS_VALIDATION_ERROR_POLICY", "fail-open")
+	if got := LoadConfigs().ValidationErrorPolicy; got != "fail-open" {
+		t.Errorf("ValidationErrorPolicy = %q, want fail-open", got)
+	}
+}
+
+func TestLoadConfigs_PublisherKeys(t *testing.T) {
+	t.Setenv("MCPTLS_PUBLISHER_KEYS", "a2V5LW9uZQ==, a2V5LXR3bw==")
+	got := LoadConfigs().PublisherKeys
+	if len(got) != 2 || got[0] != "a2V5LW9uZQ==" || got[1] != "a2V5LXR3bw==" {
+		t.Errorf("PublisherKeys = %q, want [a2V5LW9uZQ== a2V5LXR3bw==]", got)
+	}
+}
+
+func TestLoadConfigs_TrustedSources(t *testing.T) {
+	t.Setenv("MCPTLS_TRUSTED_SOURCES", "internal, /etc/mcp-tls/tools/*.json")
+	got := LoadConfigs().TrustedSources
+	if len(got) != 2 || got[0] != "internal" || got[1] != "/etc/mcp-tls/tools/*.json" {
+		t.Errorf("TrustedSources = %q, want [internal /etc/mcp-tls/tools/*.json]", got)
+	}
+}
+
+func TestLoadConfigs_AdminUsers(t *testing.T) {
+	t.Setenv("MCPTLS_ADMIN_USERS", "")
+	if got := LoadConfigs().AdminUsers; len(got) != 0 {
+		t.Errorf("AdminUsers = %q, want none by default", got)
+	}
+	t.Setenv("MCPTLS_ADMIN_USERS", "root,ops")
+	if got := LoadConfigs().AdminUsers; len(got) != 2 || got[0] != "root" || got[1] != "ops" {
+		t.Errorf("AdminUsers = %q, want [root ops]", got)
+	}
+}
+
+func TestLoadConfigs_DeprecationCutoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Time
+	}{
+		{name: "unset never blocks", value: "", expected: time.Time{}},
+		{name: "date", value: "2026-01-31", expected: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "timestamp", value: "2026-01-31T12:00:00Z", expected: time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{name: "malformed is ignored", value: "next spring", expected: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MCPTLS_DEPRECATION_CUTOFF", tt.value)
+			if got := LoadConfigs().DeprecationCutoff; !got.Equal(tt.expected) {
+				t.Errorf("DeprecationCutoff = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	dir := t.TempDir()
+	file := func(name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("placeholder"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	cert, key, ca, policy := file("cert.pem"), file("key.pem"), file("ca.pem"), file("policy.json")
+	missing := filepath.Join(dir, "missing.pem")
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string // a substring of the error, or "" for a valid config
+	}{
+		{name: "defaults", cfg: Config{}},
+		{name: "TLS with client certificates and a policy", cfg: Config{TLSCert: cert, TLSKey: key, TLSClientCA: ca, CertPolicyFile: policy}},
+		{name: "proxy", cfg: Config{Proxy: true}},
+		{name: "cert without key", cfg: Config{TLSCert: cert}, wantErr: "MCPTLS_TLS_CERT and MCPTLS_TLS_KEY must be set together"},
+		{name: "key without cert", cfg: Config{TLSKey: key}, wantErr: "MCPTLS_TLS_CERT and MCPTLS_TLS_KEY must be set together"},
+		{name: "client CA without TLS", cfg: Config{TLSClientCA: ca}, wantErr: "MCPTLS_TLS_CLIENT_CA requires MCPTLS_TLS_CERT and MCPTLS_TLS_KEY"},
+		{name: "cert policy without client CA", cfg: Config{TLSCert: cert, TLSKey: key, CertPolicyFile: policy}, wantErr: "MCPTLS_CERT_POLICY requires MCPTLS_TLS_CLIENT_CA"},
+		{name: "client certificates in proxy mode", cfg: Config{Proxy: true, TLSCert: cert, TLSKey: key, TLSClientCA: ca}, wantErr: "not supported in proxy mode"},
+		{name: "missing certificate file", cfg: Config{TLSCert: missing, TLSKey: key}, wantErr: "MCPTLS_TLS_CERT: "},
+		{name: "missing policy file", cfg: Config{TLSCert: cert, TLSKey: key, TLSClientCA: ca, CertPolicyFile: missing}, wantErr: "MCPTLS_CERT_POLICY: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateReportsEveryProblem(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	err := (&Config{TLSCert: missing, CertPolicyFile: missing}).Validate()
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, want := range []string{
+		"MCPTLS_TLS_CERT and MCPTLS_TLS_KEY must be set together",
+		"MCPTLS_CERT_POLICY requires MCPTLS_TLS_CLIENT_CA",
+		"MCPTLS_TLS_CERT: ",
+		"MCPTLS_CERT_POLICY: ",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q among the problems, got: %v", want, err)
+		}
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the missing files' errors to be wrapped, got: %v", err)
+	}
+}
+
+func TestLoadConfigs_EnvSecurityDefaults(t *testing.T) {
+	tests := []struct {
+		name              string
+		env               string
+		validateChecksums string
+		rejectUnsigned    string
+		expected          [2]bool
+	}{
+		{name: "dev by default", expected: [2]bool{false, false}},
+		{name: "prod turns checks on", env: EnvProd, expected: [2]bool{true, true}},
+		{name: "prod defaults can be overridden", env: EnvProd, rejectUnsigned: "false", expected: [2]bool{true, false}},
+		{name: "dev can opt in", env: EnvDev, validateChecksums: "true", expected: [2]bool{true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MCPTLS_ENV", tt.env)
+			t.Setenv("MCPTLS_VALIDATE_CHECKSUMS", tt.validateChecksums)
+			t.Setenv("MCPTLS_REJECT_UNSIGNED_TOOLS", tt.rejectUnsigned)
+
+			cfg := LoadConfigs()
+			if got := [2]bool{cfg.ValidateChecksums, cfg.RejectUnsignedTools}; got != tt.expected {
+				t.Errorf("ValidateChecksums, RejectUnsignedTools = %v, want %v", got, tt.expected)
+			}
+			if cfg.IsProd() != (tt.env == EnvProd) {
+				t.Errorf("IsProd() = %v for MCPTLS_ENV=%q", cfg.IsProd(), tt.env)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateMalformedValuesByEnv(t *testing.T) {
+	t.Setenv("MCPTLS_JWT_LEEWAY", "soon")
+
+	t.Setenv("MCPTLS_ENV", EnvDev)
+	if err := LoadConfigs().Validate(); err != nil {
+		t.Errorf("Expected malformed values to fall back to defaults in dev, got: %v", err)
+	}
+
+	t.Setenv("MCPTLS_ENV", EnvProd)
+	cfg := LoadConfigs()
+	if cfg.JWTLeeway != DefaultJWTLeeway {
+		t.Errorf("JWTLeeway = %v, want the default", cfg.JWTLeeway)
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "invalid MCPTLS_JWT_LEEWAY value 'soon'") {
+		t.Errorf("Validate() = %v, want malformed values refused in prod", err)
+	}
+}
+
+func TestConfig_ValidateUnknownEnv(t *testing.T) {
+	t.Setenv("MCPTLS_ENV", "production")
+	err := LoadConfigs().Validate()
+	if err == nil || !strings.Contains(err.Error(), "invalid MCPTLS_ENV value 'production'") {
+		t.Errorf("Validate() = %v, want the unknown environment refused", err)
+	}
+}
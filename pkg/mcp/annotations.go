@@ -0,0 +1,175 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMissingAnnotations is returned by RegisterTool for a tool that doesn't declare
+// every annotation set with SetRequiredAnnotations
+var ErrMissingAnnotations = errors.New("is missing required annotations")
+
+// Annotation names a ToolAnnotation field by its JSON key
+type Annotation string
+
+const (
+	AnnotationTitle       Annotation = "title"
+	AnnotationReadOnly    Annotation = "readOnlyHint"
+	AnnotationDestructive Annotation = "destructiveHint"
+	AnnotationIdempotent  Annotation = "idempotentHint"
+	AnnotationOpenWorld   Annotation = "openWorldHint"
+)
+
+// annotationHints flags the hints of a ToolAnnotation that were set explicitly
+type annotationHints uint8
+
+const (
+	hintReadOnly annotationHints = 1 << iota
+	hintDestructive
+	hintIdempotent
+	hintOpenWorld
+)
+
+// hintFlags maps each hint annotation to its flag
+var hintFlags = map[Annotation]annotationHints{
+	AnnotationReadOnly:    hintReadOnly,
+	AnnotationDestructive: hintDestructive,
+	AnnotationIdempotent:  hintIdempotent,
+	AnnotationOpenWorld:   hintOpenWorld,
+}
+
+// ParseRequiredAnnotations parses annotation names, e.g. from configuration. Unknown
+// names are left out of the result and reported in the error.
+func ParseRequiredAnnotations(names []string) ([]Annotation, error) {
+	var (
+		required []Annotation
+		unknown  []string
+	)
+	for _, name := range names {
+		annotation := Annotation(name)
+		if _, hint := hintFlags[annotation]; !hint && annotation != AnnotationTitle {
+			unknown = append(unknown, name)
+			continue
+		}
+		required = append(required, annotation)
+	}
+	if len(unknown) > 0 {
+		return required, fmt.Errorf("unknown annotations '%s'", strings.Join(unknown, "', '"))
+	}
+	return required, nil
+}
+
+// Declares reports whether the annotation is set: a title that isn't empty, or a
+// hint that is true or was given explicitly, in JSON or with SetHint. A hint left
+// out is at its default, which says nothing about how the tool behaves.
+func (a ToolAnnotation) Declares(name Annotation) bool {
+	switch name {
+	case AnnotationTitle:
+		return a.Title != ""
+	case AnnotationReadOnly:
+		return a.ReadOnlyHint || a.declared&hintReadOnly != 0
+	case AnnotationDestructive:
+		return a.DestructiveHint || a.declared&hintDestructive != 0
+	case AnnotationIdempotent:
+		return a.IdempotentHint || a.declared&hintIdempotent != 0
+	case AnnotationOpenWorld:
+		return a.OpenWorldHint || a.declared&hintOpenWorld != 0
+	}
+	return false
+}
+
+// SetHint sets a hint explicitly, so that it counts as declared even when false
+func (a *ToolAnnotation) SetHint(name Annotation, value bool) error {
+	flag, ok := hintFlags[name]
+	if !ok {
+		return fmt.Errorf("'%s' is not a hint annotation", name)
+	}
+	switch name {
+	case AnnotationReadOnly:
+		a.ReadOnlyHint = value
+	case AnnotationDestructive:
+		a.DestructiveHint = value
+	case AnnotationIdempotent:
+		a.IdempotentHint = value
+	case AnnotationOpenWorld:
+		a.OpenWorldHint = value
+	}
+	a.declared |= flag
+	return nil
+}
+
+// annotationJSON is the wire form of ToolAnnotation, with hints left out
+// distinguishable from hints given as false
+type annotationJSON struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
+}
+
+// UnmarshalJSON records which hints were given, so Declares can tell an
+// explicit false from one left out
+func (a *ToolAnnotation) UnmarshalJSON(data []byte) error {
+	var wire annotationJSON
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*a = ToolAnnotation{Title: wire.Title}
+	for name, hint := range map[Annotation]*bool{
+		AnnotationReadOnly:    wire.ReadOnlyHint,
+		AnnotationDestructive: wire.DestructiveHint,
+		AnnotationIdempotent:  wire.IdempotentHint,
+		AnnotationOpenWorld:   wire.OpenWorldHint,
+	} {
+		if hint != nil {
+			_ = a.SetHint(name, *hint)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON writes hints that are true or were given explicitly, so a
+// declared false survives being stored and reloaded
+func (a ToolAnnotation) MarshalJSON() ([]byte, error) {
+	hint := func(name Annotation, value bool) *bool {
+		if !a.Declares(name) {
+			return nil
+		}
+		return &value
+	}
+	return json.Marshal(annotationJSON{
+		Title:           a.Title,
+		ReadOnlyHint:    hint(AnnotationReadOnly, a.ReadOnlyHint),
+		DestructiveHint: hint(AnnotationDestructive, a.DestructiveHint),
+		IdempotentHint:  hint(AnnotationIdempotent, a.IdempotentHint),
+		OpenWorldHint:   hint(AnnotationOpenWorld, a.OpenWorldHint),
+	})
+}
+
+// SetRequiredAnnotations makes RegisterTool reject tools that don't declare every
+// one of the given annotations. Nil requires none.
+func (tr *ToolRegistry) SetRequiredAnnotations(required []Annotation) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.requiredAnnotations = required
+}
+
+// checkAnnotations rejects a tool missing any of the required annotations
+func (tr *ToolRegistry) checkAnnotations(tool Tool) error {
+	tr.mu.RLock()
+	required := tr.requiredAnnotations
+	tr.mu.RUnlock()
+	var missing []string
+	for _, name := range required {
+		if !tool.Annotations.Declares(name) {
+			missing = append(missing, string(name))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("tool '%s' %w: %s", tool.Name, ErrMissingAnnotations, strings.Join(missing, ", "))
+	}
+	return nil
+}
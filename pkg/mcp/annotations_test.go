@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseRequiredAnnotations(t *testing.T) {
+	required, err := ParseRequiredAnnotations([]string{"title", "readOnlyHint", "destructiveHint"})
+	if err != nil {
+		t.Fatalf("ParseRequiredAnnotations() error = %v", err)
+	}
+	if len(required) != 3 || required[0] != AnnotationTitle || required[2] != AnnotationDestructive {
+		t.Errorf("ParseRequiredAnnotations() = %v", required)
+	}
+
+	required, err = ParseRequiredAnnotations([]string{"title", "safeHint"})
+	if err == nil || !strings.Contains(err.Error(), "safeHint") {
+		t.Errorf("Expected an error naming the unknown annotation, got: %v", err)
+	}
+	if len(required) != 1 || required[0] != AnnotationTitle {
+		t.Errorf("Expected the known annotations to be kept, got: %v", required)
+	}
+}
+
+func TestToolAnnotationDeclares(t *testing.T) {
+	var annotations ToolAnnotation
+	if err := json.Unmarshal([]byte(`{"title":"Search","readOnlyHint":false,"openWorldHint":true}`), &annotations); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[Annotation]bool{
+		AnnotationTitle:       true,
+		AnnotationReadOnly:    true, // given as false
+		AnnotationDestructive: false,
+		AnnotationIdempotent:  false,
+		AnnotationOpenWorld:   true,
+	} {
+		if got := annotations.Declares(name); got != want {
+			t.Errorf("Declares(%s) = %v, want %v", name, got, want)
+		}
+	}
+
+	// an explicit false survives a round trip
+	data, err := json.Marshal(annotations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"readOnlyHint":false`) || strings.Contains(string(data), "destructiveHint") {
+		t.Errorf("Marshal() = %s, want only the declared hints", data)
+	}
+	var reloaded ToolAnnotation
+	if err := json.Unmarshal(data, &reloaded); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded != annotations {
+		t.Errorf("round trip = %+v, want %+v", reloaded, annotations)
+	}
+
+	var set ToolAnnotation
+	if err := set.SetHint(AnnotationDestructive, false); err != nil || !set.Declares(AnnotationDestructive) {
+		t.Errorf("SetHint() = %v, Declares() = %v; want the hint declared", err, set.Declares(AnnotationDestructive))
+	}
+	if err := set.SetHint(AnnotationTitle, true); err == nil {
+		t.Error("Expected SetHint to refuse a non-hint annotation")
+	}
+}
+
+func TestRegisterTool_RequiredAnnotations(t *testing.T) {
+	tool := func(annotations string) Tool {
+		var tool Tool
+		data := `{"name":"delete-file","description":"Deletes a file","inputSchema":{"type":"object"},"annotations":` + annotations + `}`
+		if err := json.Unmarshal([]byte(data), &tool); err != nil {
+			t.Fatal(err)
+		}
+		return tool
+	}
+
+	tests := []struct {
+		name        string
+		annotations string
+		missing     string
+	}{
+		{name: "all declared", annotations: `{"title":"Delete file","readOnlyHint":false,"destructiveHint":true}`},
+		{name: "missing title", annotations: `{"readOnlyHint":false,"destructiveHint":true}`, missing: "title"},
+		{name: "empty title", annotations: `{"title":"","readOnlyHint":false,"destructiveHint":true}`, missing: "title"},
+		{name: "hint left at default", annotations: `{"title":"Delete file","destructiveHint":true}`, missing: "readOnlyHint"},
+		{name: "no annotations", annotations: `{}`, missing: "title, readOnlyHint, destructiveHint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewToolRegistry(true)
+			registry.SetRequiredAnnotations([]Annotation{AnnotationTitle, AnnotationReadOnly, AnnotationDestructive})
+
+			err := registry.RegisterTool(tool(tt.annotations))
+			if tt.missing == "" {
+				if err != nil {
+					t.Fatalf("RegisterTool() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrMissingAnnotations) || !strings.HasSuffix(err.Error(), ": "+tt.missing) {
+				t.Fatalf("Expected the missing annotations %s to be reported, got: %v", tt.missing, err)
+			}
+			if _, err := registry.GetTool("delete-file"); err == nil {
+				t.Error("Expected the tool not to be registered")
+			}
+		})
+	}
+
+	// nothing is required by default
+	if err := NewToolRegistry(true).RegisterTool(tool(`{}`)); err != nil {
+		t.Errorf("RegisterTool() without required annotations error = %v", err)
+	}
+}
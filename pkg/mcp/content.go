@@ -0,0 +1,37 @@
+package mcp
+
+// Content types, as used in the type field of each Content
+const (
+	ContentTypeText  = "text"
+	ContentTypeImage = "image"
+)
+
+// Content is a single piece of content in a tool result
+type Content interface {
+	ContentType() string
+}
+
+// TextContent is plain text content
+type TextContent struct {
+	Type string `json:"type"` // always "text"
+	Text string `json:"text"`
+}
+
+func NewTextContent(text string) TextContent {
+	return TextContent{Type: ContentTypeText, Text: text}
+}
+
+func (TextContent) ContentType() string { return ContentTypeText }
+
+// ImageContent is an image, carried inline as base64-encoded data
+type ImageContent struct {
+	Type     string `json:"type"` // always "image"
+	Data     string `json:"data"` // base64-encoded image data
+	MIMEType string `json:"mimeType"`
+}
+
+func NewImageContent(data, mimeType string) ImageContent {
+	return ImageContent{Type: ContentTypeImage, Data: data, MIMEType: mimeType}
+}
+
+func (ImageContent) ContentType() string { return ContentTypeImage }
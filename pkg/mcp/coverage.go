@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"encoding/json"
+)
+
+// ToolSchemaCoverage reports which of a tool's schemas are present, and whether
+// a present schema is permissive: one that accepts any object, such as a bare
+// {"type":"object"}, so the tool is effectively unvalidated despite declaring it.
+type ToolSchemaCoverage struct {
+	Name             string `json:"name"`
+	HasInputSchema   bool   `json:"hasInputSchema"`
+	HasOutputSchema  bool   `json:"hasOutputSchema"`
+	InputPermissive  bool   `json:"inputPermissive,omitempty"`
+	OutputPermissive bool   `json:"outputPermissive,omitempty"`
+}
+
+// Validated reports whether both of the tool's schemas are present and constrain something
+func (c ToolSchemaCoverage) Validated() bool {
+	return c.HasInputSchema && !c.InputPermissive && c.HasOutputSchema && !c.OutputPermissive
+}
+
+// SchemaCoverageReport summarizes how much of the registry's tool traffic can be validated
+type SchemaCoverageReport struct {
+	Tools []ToolSchemaCoverage `json:"tools"`
+	// Share of the registered tools' input and output schemas that are present and
+	// not permissive, from 0 to 100. 0 when no tools are registered.
+	Percent float64 `json:"percent"`
+}
+
+// schemaAnnotations are keywords that describe a schema without constraining it
+var schemaAnnotations = map[string]bool{
+	"$schema":     true,
+	"$id":         true,
+	"$comment":    true,
+	"title":       true,
+	"description": true,
+	"examples":    true,
+	"default":     true,
+}
+
+// isPermissiveSchema reports whether a present schema accepts any object: one with
+// nothing but annotations besides "type":"object" and empty properties, like {} or
+// {"type":"object"}.
+// Schemas that fail to parse are left to the validators to report.
+func isPermissiveSchema(schema json.RawMessage) bool {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(schema, &keywords); err != nil {
+		return false
+	}
+	for keyword, value := range keywords {
+		if schemaAnnotations[keyword] {
+			continue
+		}
+		if keyword == "type" && string(value) == `"object"` {
+			continue
+		}
+		if keyword == "properties" {
+			var properties map[string]json.RawMessage
+			if json.Unmarshal(value, &properties) == nil && len(properties) == 0 {
+				continue
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// SchemaCoverage reports the schema coverage of every registered tool, in name order
+func (tr *ToolRegistry) SchemaCoverage() SchemaCoverageReport {
+	tools := tr.ListTools().Tools
+	report := SchemaCoverageReport{Tools: make([]ToolSchemaCoverage, 0, len(tools))}
+	covered := 0
+	for _, tool := range tools {
+		coverage := ToolSchemaCoverage{
+			Name:            tool.Name,
+			HasInputSchema:  hasSchema(tool.InputSchema),
+			HasOutputSchema: hasSchema(tool.OutputSchema),
+		}
+		coverage.InputPermissive = coverage.HasInputSchema && isPermissiveSchema(tool.InputSchema)
+		coverage.OutputPermissive = coverage.HasOutputSchema && isPermissiveSchema(tool.OutputSchema)
+
+		if coverage.HasInputSchema && !coverage.InputPermissive {
+			covered++
+		}
+		if coverage.HasOutputSchema && !coverage.OutputPermissive {
+			covered++
+		}
+		report.Tools = append(report.Tools, coverage)
+	}
+	if len(tools) > 0 {
+		report.Percent = float64(covered) * 100 / float64(2*len(tools))
+	}
+	return report
+}
@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSchemaCoverage(t *testing.T) {
+	registry := NewToolRegistry(true)
+	tools := []Tool{
+		{
+			Name:         "full",
+			Description:  "Declares both schemas",
+			InputSchema:  json.RawMessage(`{"type":"object","properties":{"q":{"type":"string"}},"required":["q"]}`),
+			OutputSchema: json.RawMessage(`{"type":"object","properties":{"answer":{"type":"string"}}}`),
+		},
+		{
+			Name:        "partial",
+			Description: "Declares only an input schema",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"q":{"type":"string"}}}`),
+		},
+		{
+			Name:         "permissive",
+			Description:  "Declares schemas that accept any object",
+			InputSchema:  json.RawMessage(`{"type":"object"}`),
+			OutputSchema: json.RawMessage(`{"title":"anything","type":"object"}`),
+		},
+	}
+	for _, tool := range tools {
+		if err := registry.RegisterTool(tool); err != nil {
+			t.Fatalf("Failed to register tool '%s': %v", tool.Name, err)
+		}
+	}
+	// RegisterTool needs an input schema to fingerprint, so add this one directly
+	none := Tool{Name: "none", Description: "Declares no schemas"}
+	registry.tools[none.Name] = none
+	tools = append(tools, none)
+
+	report := registry.SchemaCoverage()
+	if len(report.Tools) != len(tools) {
+		t.Fatalf("Expected %d tools in the report, got %d", len(tools), len(report.Tools))
+	}
+	got := make(map[string]ToolSchemaCoverage, len(report.Tools))
+	for _, coverage := range report.Tools {
+		got[coverage.Name] = coverage
+	}
+
+	expected := map[string]ToolSchemaCoverage{
+		"full":       {Name: "full", HasInputSchema: true, HasOutputSchema: true},
+		"partial":    {Name: "partial", HasInputSchema: true},
+		"permissive": {Name: "permissive", HasInputSchema: true, HasOutputSchema: true, InputPermissive: true, OutputPermissive: true},
+		"none":       {Name: "none"},
+	}
+	for name, want := range expected {
+		if got[name] != want {
+			t.Errorf("Coverage of '%s' = %+v, want %+v", name, got[name], want)
+		}
+	}
+	if !got["full"].Validated() {
+		t.Error("Expected the fully described tool to count as validated")
+	}
+	for _, name := range []string{"partial", "permissive", "none"} {
+		if got[name].Validated() {
+			t.Errorf("Expected '%s' not to count as validated", name)
+		}
+	}
+
+	// 3 of 8 schemas validate anything: both of full's and partial's input
+	if report.Percent != 37.5 {
+		t.Errorf("Expected 37.5%% coverage, got %v", report.Percent)
+	}
+}
+
+func TestSchemaCoverage_Empty(t *testing.T) {
+	report := NewToolRegistry(true).SchemaCoverage()
+	if len(report.Tools) != 0 || report.Percent != 0 {
+		t.Errorf("Expected an empty report, got %+v", report)
+	}
+}
+
+func TestIsPermissiveSchema(t *testing.T) {
+	tests := []struct {
+		schema     string
+		permissive bool
+	}{
+		{`{}`, true},
+		{`{"type":"object"}`, true},
+		{`{"type":"object","description":"args"}`, true},
+		{`{"type":"object","properties":{}}`, true},
+		{`{"type":"object","required":["q"]}`, false},
+		{`{"type":"object","additionalProperties":false}`, false},
+		{`{"type":"string"}`, false},
+		{`{"$ref":"#/definitions/args"}`, false},
+	}
+	for _, tt := range tests {
+		if got := isPermissiveSchema(json.RawMessage(tt.schema)); got != tt.permissive {
+			t.Errorf("isPermissiveSchema(%s) = %v, want %v", tt.schema, got, tt.permissive)
+		}
+	}
+}
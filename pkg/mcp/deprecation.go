@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrToolDeprecated is returned by GetTool for a deprecated tool once the deprecation cutoff has passed
+var ErrToolDeprecated = errors.New("deprecated and blocked")
+
+// DeprecationWarning describes a deprecated tool for callers still using it, or
+// returns "" if the tool isn't deprecated
+func (t Tool) DeprecationWarning() string {
+	if !t.Deprecated {
+		return ""
+	}
+	if t.DeprecationMessage == "" {
+		return fmt.Sprintf("tool '%s' is deprecated", t.Name)
+	}
+	return fmt.Sprintf("tool '%s' is deprecated: %s", t.Name, t.DeprecationMessage)
+}
+
+// SetDeprecationCutoff makes GetTool reject deprecated tools from cutoff on. Until
+// then, and always with a zero cutoff, deprecated tools are returned with a warning
+// logged on each use.
+func (tr *ToolRegistry) SetDeprecationCutoff(cutoff time.Time) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.deprecationCutoff = cutoff
+}
+
+// checkDeprecation warns about the use of a deprecated tool, or rejects it past the cutoff
+func (tr *ToolRegistry) checkDeprecation(tool Tool) error {
+	if !tool.Deprecated {
+		return nil
+	}
+	tr.mu.RLock()
+	cutoff := tr.deprecationCutoff
+	tr.mu.RUnlock()
+	if !cutoff.IsZero() && !time.Now().Before(cutoff) {
+		err := fmt.Errorf("tool '%s' %w since %s", tool.Name, ErrToolDeprecated, cutoff.Format(time.RFC3339))
+		if tool.DeprecationMessage != "" {
+			err = fmt.Errorf("%w: %s", err, tool.DeprecationMessage)
+		}
+		return err
+	}
+	log.Printf("WARNING %s", tool.DeprecationWarning())
+	return nil
+}
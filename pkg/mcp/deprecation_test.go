@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func deprecatedTool() Tool {
+	return Tool{
+		Name:               "forecast-v1",
+		Description:        "Looks up the forecast for a city",
+		InputSchema:        json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+		Deprecated:         true,
+		DeprecationMessage: "use forecast-v2",
+	}
+}
+
+// captureLog redirects the standard logger for the rest of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	prev := log.Writer()
+	log.SetOutput(buf)
+	t.Cleanup(func() { log.SetOutput(prev) })
+	return buf
+}
+
+func TestGetTool_DeprecatedWarns(t *testing.T) {
+	logged := captureLog(t)
+	registry := NewToolRegistry(true)
+	registry.SetSecurityOptions(true, true)
+	if err := registry.RegisterTool(deprecatedTool()); err != nil {
+		t.Fatal(err)
+	}
+	// a cutoff still to come only warns
+	registry.SetDeprecationCutoff(time.Now().Add(time.Hour))
+
+	tool, err := registry.GetTool("forecast-v1")
+	if err != nil {
+		t.Fatalf("Expected the deprecated tool to still be served, got: %v", err)
+	}
+	want := "tool 'forecast-v1' is deprecated: use forecast-v2"
+	if tool.DeprecationWarning() != want {
+		t.Errorf("DeprecationWarning() = %q, want %q", tool.DeprecationWarning(), want)
+	}
+	if !strings.Contains(logged.String(), "WARNING "+want) {
+		t.Errorf("Expected the use to be logged, got: %s", logged)
+	}
+
+	if warning := (Tool{Name: "current"}).DeprecationWarning(); warning != "" {
+		t.Errorf("Expected no warning for a current tool, got %q", warning)
+	}
+}
+
+func TestGetTool_DeprecationCutoff(t *testing.T) {
+	captureLog(t)
+	registry := NewToolRegistry(true)
+	if err := registry.RegisterTool(deprecatedTool()); err != nil {
+		t.Fatal(err)
+	}
+	current := Tool{Name: "forecast-v2", InputSchema: json.RawMessage(`{"type":"object"}`)}
+	if err := registry.RegisterTool(current); err != nil {
+		t.Fatal(err)
+	}
+	registry.SetDeprecationCutoff(time.Now().Add(-time.Hour))
+
+	_, err := registry.GetTool("forecast-v1")
+	if !errors.Is(err, ErrToolDeprecated) {
+		t.Fatalf("Expected ErrToolDeprecated past the cutoff, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "use forecast-v2") {
+		t.Errorf("Expected the error to carry the deprecation message, got: %v", err)
+	}
+	if _, err := registry.GetTool("forecast-v2"); err != nil {
+		t.Errorf("Expected tools that aren't deprecated to be unaffected, got: %v", err)
+	}
+
+	// clearing the cutoff serves it again
+	registry.SetDeprecationCutoff(time.Time{})
+	if _, err := registry.GetTool("forecast-v1"); err != nil {
+		t.Errorf("Expected the tool without a cutoff, got: %v", err)
+	}
+}
+
+func TestChecksum_CoversDeprecation(t *testing.T) {
+	tool := deprecatedTool()
+	if err := SecureTool(&tool); err != nil {
+		t.Fatal(err)
+	}
+	// quietly undeprecating a signed tool breaks its checksum
+	tool.Deprecated = false
+	if err := verifyToolMetadata(tool); err == nil {
+		t.Error("Expected the checksum to cover Deprecated")
+	}
+	tool.Deprecated = true
+	tool.DeprecationMessage = "keep using this"
+	if err := verifyToolMetadata(tool); err == nil {
+		t.Error("Expected the checksum to cover DeprecationMessage")
+	}
+
+	// and tools that were never deprecated keep their existing checksums
+	data, _ := json.Marshal(Tool{Name: "plain", InputSchema: json.RawMessage(`{"type":"object"}`)})
+	if strings.Contains(string(data), "deprecat") {
+		t.Errorf("Expected the deprecation fields to be omitted from checksummed JSON when unset: %s", data)
+	}
+}
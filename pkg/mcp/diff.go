@@ -0,0 +1,210 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ToolSetDiff lists the names of the tools that differ between two tool sets
+type ToolSetDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// IsEmpty reports whether the two tool sets were identical
+func (d ToolSetDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffToolSets compares two tool sets by tool name. A tool is changed if any part of
+// its definition differs; formatting differences in its schemas are ignored.
+// The names in each list are sorted.
+func DiffToolSets(from, to ToolSet) ToolSetDiff {
+	before := make(map[string]Tool, len(from.Tools))
+	for _, tool := range from.Tools {
+		before[tool.Name] = tool
+	}
+
+	var diff ToolSetDiff
+	after := make(map[string]struct{}, len(to.Tools))
+	for _, tool := range to.Tools {
+		after[tool.Name] = struct{}{}
+		old, exists := before[tool.Name]
+		switch {
+		case !exists:
+			diff.Added = append(diff.Added, tool.Name)
+		case !sameTool(old, tool):
+			diff.Changed = append(diff.Changed, tool.Name)
+		}
+	}
+	for name := range before {
+		if _, exists := after[name]; !exists {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// sameTool compares the canonical JSON of two tool definitions
+func sameTool(a, b Tool) bool {
+	canonicalA, errA := canonicalTool(a)
+	canonicalB, errB := canonicalTool(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return bytes.Equal(canonicalA, canonicalB)
+}
+
+func canonicalTool(tool Tool) (json.RawMessage, error) {
+	data, err := json.Marshal(tool)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalizeJson(data)
+}
+
+// SchemaDiff describes how the properties of a JSON schema changed between two
+// versions. Properties of nested objects are named by their path, e.g.
+// "address.city", with "[]" standing for the items of an array, e.g. "tags[]".
+// Each list is sorted.
+type SchemaDiff struct {
+	AddedProperties   []string     `json:"addedProperties,omitempty"`
+	RemovedProperties []string     `json:"removedProperties,omitempty"`
+	ChangedTypes      []TypeChange `json:"changedTypes,omitempty"`
+	AddedRequired     []string     `json:"addedRequired,omitempty"`
+	RemovedRequired   []string     `json:"removedRequired,omitempty"`
+}
+
+// TypeChange is a property whose declared type changed. Union types are written
+// as their sorted members joined by "|", and an undeclared type as "".
+type TypeChange struct {
+	Property string `json:"property"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// IsEmpty reports whether the two schemas declared the same properties, types, and required lists
+func (d SchemaDiff) IsEmpty() bool {
+	return len(d.AddedProperties) == 0 && len(d.RemovedProperties) == 0 && len(d.ChangedTypes) == 0 &&
+		len(d.AddedRequired) == 0 && len(d.RemovedRequired) == 0
+}
+
+// schemaNode is the part of a JSON schema DiffSchemas compares
+type schemaNode struct {
+	Type       json.RawMessage        `json:"type"`
+	Properties map[string]*schemaNode `json:"properties"`
+	Items      *schemaNode            `json:"items"`
+	Required   []string               `json:"required"`
+}
+
+// DiffSchemas compares two JSON schemas property by property, reporting the properties
+// added and removed, those whose type changed, and the changes to required lists.
+// Keywords it doesn't cover, like enums and bounds, are not compared.
+func DiffSchemas(from, to json.RawMessage) (SchemaDiff, error) {
+	before, err := parseSchemaNode(from)
+	if err != nil {
+		return SchemaDiff{}, fmt.Errorf("invalid 'from' schema: %w", err)
+	}
+	after, err := parseSchemaNode(to)
+	if err != nil {
+		return SchemaDiff{}, fmt.Errorf("invalid 'to' schema: %w", err)
+	}
+
+	var diff SchemaDiff
+	diff.compare("", before, after)
+	sort.Strings(diff.AddedProperties)
+	sort.Strings(diff.RemovedProperties)
+	sort.Strings(diff.AddedRequired)
+	sort.Strings(diff.RemovedRequired)
+	sort.Slice(diff.ChangedTypes, func(i, j int) bool {
+		return diff.ChangedTypes[i].Property < diff.ChangedTypes[j].Property
+	})
+	return diff, nil
+}
+
+// UnmarshalJSON decodes object subschemas, leaving the node empty for boolean
+// subschemas and tuple-form items, which aren't compared
+func (n *schemaNode) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return nil
+	}
+	type plain schemaNode
+	return json.Unmarshal(data, (*plain)(n))
+}
+
+func parseSchemaNode(data json.RawMessage) (*schemaNode, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return nil, errors.New("schema must be a JSON object")
+	}
+	var node schemaNode
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// compare records the differences between two versions of the schema at path
+func (d *SchemaDiff) compare(path string, before, after *schemaNode) {
+	if from, to := before.typeName(), after.typeName(); path != "" && from != to {
+		d.ChangedTypes = append(d.ChangedTypes, TypeChange{Property: path, From: from, To: to})
+	}
+
+	for _, name := range before.Required {
+		if !slices.Contains(after.Required, name) {
+			d.RemovedRequired = append(d.RemovedRequired, joinSchemaPath(path, name))
+		}
+	}
+	for _, name := range after.Required {
+		if !slices.Contains(before.Required, name) {
+			d.AddedRequired = append(d.AddedRequired, joinSchemaPath(path, name))
+		}
+	}
+
+	for name, property := range before.Properties {
+		if _, exists := after.Properties[name]; !exists {
+			d.RemovedProperties = append(d.RemovedProperties, joinSchemaPath(path, name))
+		} else if property != nil && after.Properties[name] != nil {
+			d.compare(joinSchemaPath(path, name), property, after.Properties[name])
+		}
+	}
+	for name := range after.Properties {
+		if _, exists := before.Properties[name]; !exists {
+			d.AddedProperties = append(d.AddedProperties, joinSchemaPath(path, name))
+		}
+	}
+
+	if before.Items != nil && after.Items != nil {
+		d.compare(path+"[]", before.Items, after.Items)
+	}
+}
+
+// typeName writes the schema's type as a string, sorting the members of a union
+func (n *schemaNode) typeName() string {
+	var single string
+	if err := json.Unmarshal(n.Type, &single); err == nil {
+		return single
+	}
+	var union []string
+	if err := json.Unmarshal(n.Type, &union); err == nil {
+		sort.Strings(union)
+		return strings.Join(union, "|")
+	}
+	return ""
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
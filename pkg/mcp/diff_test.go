@@ -0,0 +1,124 @@
+package mcp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffToolSets(t *testing.T) {
+	tool := func(name, description, schema string) Tool {
+		return Tool{Name: name, Description: description, InputSchema: json.RawMessage(schema)}
+	}
+
+	from := ToolSet{Tools: []Tool{
+		tool("kept", "unchanged", `{"type":"object"}`),
+		tool("reformatted", "same schema", `{"type":"object","required":["a"]}`),
+		tool("described", "old description", `{"type":"object"}`),
+		tool("removed", "going away", `{"type":"object"}`),
+	}}
+	to := ToolSet{Tools: []Tool{
+		tool("new", "brand new", `{"type":"object"}`),
+		tool("kept", "unchanged", `{"type":"object"}`),
+		tool("reformatted", "same schema", `{ "required": ["a"], "type": "object" }`),
+		tool("described", "new description", `{"type":"object"}`),
+	}}
+
+	diff := DiffToolSets(from, to)
+	expected := ToolSetDiff{
+		Added:   []string{"new"},
+		Removed: []string{"removed"},
+		Changed: []string{"described"},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("DiffToolSets() = %+v, want %+v", diff, expected)
+	}
+	if diff.IsEmpty() {
+		t.Error("Expected diff not to be empty")
+	}
+
+	if diff := DiffToolSets(from, from); !diff.IsEmpty() {
+		t.Errorf("Expected no differences comparing a set with itself, got %+v", diff)
+	}
+}
+
+func TestDiffSchemas(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		expected SchemaDiff
+	}{
+		{
+			name: "unchanged apart from formatting",
+			from: `{"type":"object","properties":{"q":{"type":"string"}},"required":["q"]}`,
+			to:   `{ "required": ["q"], "properties": { "q": { "type": "string" } }, "type": "object" }`,
+		},
+		{
+			name:     "added and removed properties",
+			from:     `{"type":"object","properties":{"city":{"type":"string"},"country":{"type":"string"}}}`,
+			to:       `{"type":"object","properties":{"city":{"type":"string"},"units":{"type":"string"},"days":{"type":"integer"}}}`,
+			expected: SchemaDiff{AddedProperties: []string{"days", "units"}, RemovedProperties: []string{"country"}},
+		},
+		{
+			name: "changed types",
+			from: `{"type":"object","properties":{"days":{"type":"string"},"note":{"type":["string","null"]},"any":{}}}`,
+			to:   `{"type":"object","properties":{"days":{"type":"integer"},"note":{"type":["null","string"]},"any":{"type":"boolean"}}}`,
+			expected: SchemaDiff{ChangedTypes: []TypeChange{
+				{Property: "any", From: "", To: "boolean"},
+				{Property: "days", From: "string", To: "integer"},
+			}},
+		},
+		{
+			name:     "changed required list",
+			from:     `{"type":"object","properties":{"a":{},"b":{}},"required":["a"]}`,
+			to:       `{"type":"object","properties":{"a":{},"b":{}},"required":["b"]}`,
+			expected: SchemaDiff{AddedRequired: []string{"b"}, RemovedRequired: []string{"a"}},
+		},
+		{
+			name: "nested objects and array items",
+			from: `{"type":"object","properties":{
+				"address":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]},
+				"tags":{"type":"array","items":{"type":"string"}}}}`,
+			to: `{"type":"object","properties":{
+				"address":{"type":"object","properties":{"city":{"type":"string"},"zip":{"type":"string"}},"required":["city","zip"]},
+				"tags":{"type":"array","items":{"type":"integer"}}}}`,
+			expected: SchemaDiff{
+				AddedProperties: []string{"address.zip"},
+				ChangedTypes:    []TypeChange{{Property: "tags[]", From: "string", To: "integer"}},
+				AddedRequired:   []string{"address.zip"},
+			},
+		},
+		{
+			name:     "boolean subschemas are skipped",
+			from:     `{"type":"object","properties":{"a":true,"b":{"type":"array","items":[{"type":"string"}]}}}`,
+			to:       `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"array","items":[{"type":"integer"}]}}}`,
+			expected: SchemaDiff{ChangedTypes: []TypeChange{{Property: "a", From: "", To: "string"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := DiffSchemas(json.RawMessage(tt.from), json.RawMessage(tt.to))
+			if err != nil {
+				t.Fatalf("DiffSchemas() error = %v", err)
+			}
+			if !reflect.DeepEqual(diff, tt.expected) {
+				t.Errorf("DiffSchemas() = %+v, want %+v", diff, tt.expected)
+			}
+			if diff.IsEmpty() != reflect.DeepEqual(tt.expected, SchemaDiff{}) {
+				t.Errorf("IsEmpty() = %v for %+v", diff.IsEmpty(), diff)
+			}
+		})
+	}
+}
+
+func TestDiffSchemas_InvalidSchemas(t *testing.T) {
+	valid := json.RawMessage(`{"type":"object"}`)
+	for _, schema := range []string{``, `[1]`, `true`, `{"type":`} {
+		if _, err := DiffSchemas(json.RawMessage(schema), valid); err == nil {
+			t.Errorf("Expected an error for 'from' schema %q", schema)
+		}
+		if _, err := DiffSchemas(valid, json.RawMessage(schema)); err == nil {
+			t.Errorf("Expected an error for 'to' schema %q", schema)
+		}
+	}
+}
@@ -4,9 +4,83 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sync/atomic"
 )
 
+// NumberCanonicalization selects how numbers are written when a schema or tool
+// definition is canonicalized for its fingerprint and checksum
+type NumberCanonicalization string
+
+const (
+	// NumbersExact keeps every number as written, so large integers such as 64-bit
+	// IDs keep their precision. This is the default.
+	NumbersExact NumberCanonicalization = "exact"
+	// NumbersFloat64 rounds every number through float64, as canonicalization did
+	// before NumbersExact. Checksums and fingerprints stored then only verify in
+	// this mode when the definition has numbers float64 writes differently, e.g.
+	// 1.0, 1e3 or integers above 2^53; run Recompute to move them to NumbersExact.
+	NumbersFloat64 NumberCanonicalization = "float64"
+)
+
+// ParseNumberCanonicalization parses "exact" or "float64". An empty string means NumbersExact.
+func ParseNumberCanonicalization(s string) (NumberCanonicalization, error) {
+	switch NumberCanonicalization(s) {
+	case "", NumbersExact:
+		return NumbersExact, nil
+	case NumbersFloat64:
+		return NumbersFloat64, nil
+	}
+	return NumbersExact, fmt.Errorf("unknown number canonicalization '%s'", s)
+}
+
+var float64Numbers atomic.Bool
+
+// SetNumberCanonicalization sets how numbers are canonicalized for checksums and fingerprints
+func SetNumberCanonicalization(mode NumberCanonicalization) {
+	float64Numbers.Store(mode == NumbersFloat64)
+}
+
+// decodeForCanonicalization decodes a JSON document for re-marshalling in canonical
+// form, keeping numbers as json.Number unless NumbersFloat64 is set
+func decodeForCanonicalization(data []byte) (any, error) {
+	var obj any
+	if float64Numbers.Load() {
+		err := json.Unmarshal(data, &obj)
+		return obj, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+	// match json.Unmarshal, which rejects anything after the document
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid character after top-level value")
+	}
+	return obj, nil
+}
+
+// CanonicalJSON converts a JSON document to the canonical form checksums and
+// fingerprints are computed over: object keys sorted, insignificant whitespace removed
+func CanonicalJSON(data json.RawMessage) (json.RawMessage, error) {
+	return canonicalizeJson(data)
+}
+
+// ToolChecksum computes the checksum stored in a tool's security metadata, covering
+// its name, description, input schema, annotations and deprecation
+func ToolChecksum(tool Tool) (string, error) {
+	return generateToolChecksum(tool)
+}
+
+// SchemaFingerprint computes the fingerprint stored in a tool's security metadata for a schema
+func SchemaFingerprint(schema json.RawMessage) (string, error) {
+	return generateSchemaFingerprint(schema)
+}
+
 func CanonicalizeAndHash(tool Tool) (string, error) {
 	// Use canonical serialization (deterministic field order)
 	buf := &bytes.Buffer{}
@@ -0,0 +1,158 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// LoadFromDir registers every *.json tool definition in dir, recording each tool's file
+// path as its SecurityMetadata.Source. Tools carrying security
+// metadata are verified against it. Tools without a checksum or fingerprint are only
+// accepted, with their metadata generated, when unsigned tools are allowed (the
+// development setup); otherwise they are rejected like any unsigned tool.
+//
+// Files that can't be read, parsed or verified are skipped with a logged warning
+// rather than aborting the load. Calling LoadFromDir again for the same directory
+// replaces the tools it loaded before, dropping those whose files are gone. A tool
+// whose file is still there but no longer loads, e.g. because it's being rewritten,
+// keeps its previous definition until the file loads again.
+func (tr *ToolRegistry) LoadFromDir(dir string) error {
+	_, err := tr.loadDir(dir)
+	return err
+}
+
+// loadDir does the work of LoadFromDir, reporting whether the directory's tools changed
+func (tr *ToolRegistry) loadDir(dir string) (bool, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return false, err
+	}
+	if !info.IsDir() {
+		return false, fmt.Errorf("'%s' is not a directory", dir)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return false, err
+	}
+
+	files := make(map[string]Tool, len(paths))
+	for _, path := range paths {
+		tool, err := tr.readToolFile(path)
+		if err != nil {
+			log.Printf("WARNING skipping tool file '%s': %v", path, err)
+			continue
+		}
+		tool.SecurityMetadata.Source = path
+		files[path] = tool
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	previous := tr.dirTools[dir]
+	byPath := make(map[string]Tool, len(previous))
+	for name, path := range previous {
+		if tool, ok := tr.tools[name]; ok {
+			byPath[path] = tool
+		}
+	}
+	loaded := make(map[string]Tool, len(files))
+	names := make(map[string]string, len(files))
+	for _, path := range paths {
+		tool, ok := files[path]
+		if !ok {
+			if tool, ok = byPath[path]; !ok {
+				continue
+			}
+			log.Printf("WARNING keeping the previous definition of tool '%s' from '%s'", tool.Name, path)
+		}
+		if _, dup := loaded[tool.Name]; dup {
+			log.Printf("WARNING skipping tool file '%s': tool '%s' is already defined in this directory", path, tool.Name)
+			continue
+		}
+		if _, exists := tr.tools[tool.Name]; exists {
+			if _, ours := previous[tool.Name]; !ours {
+				log.Printf("WARNING skipping tool file '%s': tool '%s' is already registered", path, tool.Name)
+				continue
+			}
+		}
+		loaded[tool.Name] = tool
+		names[tool.Name] = path
+	}
+
+	changed := len(loaded) != len(previous)
+	for name := range previous {
+		if _, ok := loaded[name]; !ok {
+			delete(tr.tools, name)
+			changed = true
+		}
+	}
+	for name, tool := range loaded {
+		if old, ok := tr.tools[name]; !ok || !sameTool(old, tool) {
+			changed = true
+		}
+		tr.tools[name] = tool
+	}
+	tr.dirTools[dir] = names
+	return changed, nil
+}
+
+// readToolFile parses and verifies a single tool definition file
+func (tr *ToolRegistry) readToolFile(path string) (Tool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Tool{}, err
+	}
+
+	var tool Tool
+	if err := json.Unmarshal(data, &tool); err != nil {
+		return Tool{}, fmt.Errorf("invalid tool definition: %w", err)
+	}
+	return tr.checkLoadedTool(tool)
+}
+
+// checkLoadedTool checks a tool definition loaded from a file or a tool repo before it
+// joins the registry. It must declare the required annotations, as registered tools do.
+// With security enabled, signed tools must verify, and unsigned ones are rejected or
+// have their security metadata generated, by rejectUnsignedTools.
+func (tr *ToolRegistry) checkLoadedTool(tool Tool) (Tool, error) {
+	if tool.Name == "" {
+		return Tool{}, errors.New("tool definition has no name")
+	}
+	if err := validateToolDefinition(tool); err != nil {
+		return Tool{}, err
+	}
+	if err := tr.checkAnnotations(tool); err != nil {
+		return Tool{}, err
+	}
+	if err := tr.checkPublisher(tool); err != nil {
+		return Tool{}, err
+	}
+
+	if !tr.securityEnabled {
+		return tool, nil
+	}
+
+	tr.mu.RLock()
+	rejectUnsigned := tr.rejectUnsignedTools
+	tr.mu.RUnlock()
+	if tool.SecurityMetadata.Checksum == "" || tool.SecurityMetadata.Signature == "" {
+		if rejectUnsigned {
+			return Tool{}, errors.New("unsigned tool rejected")
+		}
+		if err := SecureTool(&tool); err != nil {
+			return Tool{}, fmt.Errorf("failed to generate security metadata: %w", err)
+		}
+		return tool, nil
+	}
+
+	if err := verifyToolMetadata(tool); err != nil {
+		return Tool{}, err
+	}
+	return tool, nil
+}
@@ -0,0 +1,226 @@
+package mcp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeToolFile writes a tool definition into dir as <name>.json
+func writeToolFile(t *testing.T, dir, name string, tool any) {
+	t.Helper()
+	data, err := json.Marshal(tool)
+	if err != nil {
+		t.Fatalf("Failed to marshal tool: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".json"), data, 0o644); err != nil {
+		t.Fatalf("Failed to write tool file: %v", err)
+	}
+}
+
+func signedTool(t *testing.T, name string) Tool {
+	t.Helper()
+	tool := Tool{
+		Name:        name,
+		Description: "A tool loaded from disk",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"}}}`),
+	}
+	if err := SecureTool(&tool); err != nil {
+		t.Fatalf("Failed to secure tool: %v", err)
+	}
+	return tool
+}
+
+func TestLoadFromDir(t *testing.T) {
+	dir := t.TempDir()
+	writeToolFile(t, dir, "signed", signedTool(t, "signed-tool"))
+	writeToolFile(t, dir, "unsigned", Tool{
+		Name:        "unsigned-tool",
+		Description: "A tool without security metadata",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+	})
+	tampered := signedTool(t, "tampered-tool")
+	tampered.Description = "Changed after signing"
+	writeToolFile(t, dir, "tampered", tampered)
+	if err := os.WriteFile(filepath.Join(dir, "malformed.json"), []byte(`{"name": "broken",`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a tool"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Development Generates Missing Metadata", func(t *testing.T) {
+		registry := NewToolRegistry(true)
+		registry.SetSecurityOptions(true, false)
+		if err := registry.LoadFromDir(dir); err != nil {
+			t.Fatalf("LoadFromDir failed: %v", err)
+		}
+
+		if _, err := registry.GetTool("signed-tool"); err != nil {
+			t.Errorf("Expected signed tool to load: %v", err)
+		}
+		unsigned, err := registry.GetTool("unsigned-tool")
+		if err != nil {
+			t.Fatalf("Expected unsigned tool to load in development: %v", err)
+		}
+		if unsigned.SecurityMetadata.Checksum == "" || unsigned.SecurityMetadata.Signature == "" {
+			t.Error("Expected security metadata to be generated")
+		}
+		if _, err := registry.GetTool("tampered-tool"); err == nil {
+			t.Error("Expected tampered tool to be skipped")
+		}
+		if _, err := registry.GetTool("broken"); err == nil {
+			t.Error("Expected malformed tool to be skipped")
+		}
+		if n := len(registry.ListTools().Tools); n != 2 {
+			t.Errorf("Expected 2 tools loaded, got %d", n)
+		}
+	})
+
+	t.Run("Unsigned Tools Rejected", func(t *testing.T) {
+		registry := NewToolRegistry(true)
+		registry.SetSecurityOptions(true, true)
+		if err := registry.LoadFromDir(dir); err != nil {
+			t.Fatalf("LoadFromDir failed: %v", err)
+		}
+
+		if _, err := registry.GetTool("signed-tool"); err != nil {
+			t.Errorf("Expected signed tool to load: %v", err)
+		}
+		if _, err := registry.GetTool("unsigned-tool"); err == nil {
+			t.Error("Expected unsigned tool to be rejected")
+		}
+	})
+
+	t.Run("Missing Directory", func(t *testing.T) {
+		registry := NewToolRegistry(true)
+		if err := registry.LoadFromDir(filepath.Join(dir, "missing")); err == nil {
+			t.Error("Expected error for missing directory")
+		}
+	})
+}
+
+func TestLoadFromDirRequiredAnnotations(t *testing.T) {
+	captureLog(t)
+	dir := t.TempDir()
+	annotated := Tool{
+		Name:        "annotated-tool",
+		Description: "A tool loaded from disk",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+		Annotations: ToolAnnotation{Title: "Annotated tool"},
+	}
+	if err := SecureTool(&annotated); err != nil {
+		t.Fatal(err)
+	}
+	writeToolFile(t, dir, "annotated", annotated)
+	writeToolFile(t, dir, "bare", signedTool(t, "bare-tool"))
+
+	registry := NewToolRegistry(true)
+	registry.SetSecurityOptions(true, true)
+	registry.SetRequiredAnnotations([]Annotation{AnnotationTitle})
+	if err := registry.LoadFromDir(dir); err != nil {
+		t.Fatalf("LoadFromDir failed: %v", err)
+	}
+
+	if _, err := registry.GetTool("annotated-tool"); err != nil {
+		t.Errorf("Expected the annotated tool to load: %v", err)
+	}
+	if _, err := registry.GetTool("bare-tool"); err == nil {
+		t.Error("Expected the tool missing its title to be skipped")
+	}
+}
+
+func TestLoadFromDirReload(t *testing.T) {
+	dir := t.TempDir()
+	writeToolFile(t, dir, "first", signedTool(t, "first-tool"))
+	writeToolFile(t, dir, "second", signedTool(t, "second-tool"))
+
+	registry := NewToolRegistry(true)
+	registry.SetSecurityOptions(true, true)
+	if err := registry.RegisterTool(signedTool(t, "api-tool")); err != nil {
+		t.Fatalf("Failed to register tool: %v", err)
+	}
+	if err := registry.LoadFromDir(dir); err != nil {
+		t.Fatalf("LoadFromDir failed: %v", err)
+	}
+
+	// remove one file, change another, and add a file clashing with an API registered tool
+	if err := os.Remove(filepath.Join(dir, "second.json")); err != nil {
+		t.Fatal(err)
+	}
+	changed := signedTool(t, "first-tool")
+	changed.Description = "Updated description"
+	if err := SecureTool(&changed); err != nil {
+		t.Fatal(err)
+	}
+	writeToolFile(t, dir, "first", changed)
+	writeToolFile(t, dir, "api", signedTool(t, "api-tool"))
+
+	if err := registry.LoadFromDir(dir); err != nil {
+		t.Fatalf("LoadFromDir failed: %v", err)
+	}
+
+	first, err := registry.GetTool("first-tool")
+	if err != nil {
+		t.Fatalf("Expected first tool to remain: %v", err)
+	}
+	if first.Description != "Updated description" {
+		t.Errorf("Expected changed definition to replace the old one, got %q", first.Description)
+	}
+	if _, err := registry.GetTool("second-tool"); err == nil {
+		t.Error("Expected tool with a deleted file to be removed")
+	}
+	if _, err := registry.GetTool("api-tool"); err != nil {
+		t.Errorf("Expected API registered tool to be kept: %v", err)
+	}
+
+	// the API registered tool is not owned by the directory, so removing the file keeps it
+	if err := os.Remove(filepath.Join(dir, "api.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.LoadFromDir(dir); err != nil {
+		t.Fatalf("LoadFromDir failed: %v", err)
+	}
+	if _, err := registry.GetTool("api-tool"); err != nil {
+		t.Errorf("Expected API registered tool to be kept: %v", err)
+	}
+}
+
+func TestLoadFromDirKeepsToolWhileFileIsUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	writeToolFile(t, dir, "first", signedTool(t, "first-tool"))
+
+	registry := NewToolRegistry(true)
+	registry.SetSecurityOptions(true, true)
+	if changed, err := registry.loadDir(dir); err != nil || !changed {
+		t.Fatalf("loadDir() = %v, %v; want the first load to change the tools", changed, err)
+	}
+	if changed, err := registry.loadDir(dir); err != nil || changed {
+		t.Errorf("loadDir() = %v, %v; want reloading unchanged files to change nothing", changed, err)
+	}
+
+	// a file caught mid-write doesn't parse
+	if err := os.WriteFile(filepath.Join(dir, "first.json"), []byte(`{"name": "first-to`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := registry.loadDir(dir); err != nil || changed {
+		t.Errorf("loadDir() = %v, %v; want the previous definition kept", changed, err)
+	}
+	if _, err := registry.GetTool("first-tool"); err != nil {
+		t.Errorf("Expected the tool to be kept while its file doesn't parse: %v", err)
+	}
+
+	updated := signedTool(t, "first-tool")
+	updated.Description = "Updated description"
+	if err := SecureTool(&updated); err != nil {
+		t.Fatal(err)
+	}
+	writeToolFile(t, dir, "first", updated)
+	if changed, err := registry.loadDir(dir); err != nil || !changed {
+		t.Errorf("loadDir() = %v, %v; want the finished write to change the tool", changed, err)
+	}
+	if tool, err := registry.GetTool("first-tool"); err != nil || tool.Description != "Updated description" {
+		t.Errorf("Expected the rewritten definition to be loaded, got %q: %v", tool.Description, err)
+	}
+}
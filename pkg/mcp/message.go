@@ -0,0 +1,32 @@
+package mcp
+
+import "time"
+
+// Role identifies the sender of a Message
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// Message is a single entry in a conversation. Assistant messages may request tool
+// calls, and tool results carry the name of the tool that produced them along with
+// their provenance.
+type Message struct {
+	Role      Role                `json:"role"`
+	Content   string              `json:"content"`
+	ToolCalls []ToolCall          `json:"toolCalls,omitempty"` // calls requested by an assistant message
+	ToolName  string              `json:"toolName,omitempty"`
+	Metadata  *ToolResultMetadata `json:"metadata,omitempty"`
+}
+
+// ToolResultMetadata records how a tool result was produced, so consumers
+// can tell whether it was executed and validated successfully.
+type ToolResultMetadata struct {
+	ExecutionStatus  ExecutionStatus `json:"executionStatus"`
+	Duration         time.Duration   `json:"duration"`                   // execution time, in nanoseconds
+	OutputChecksum   string          `json:"outputChecksum"`             // SHA-256 of the raw output
+	ValidationStatus string          `json:"validationStatus,omitempty"` // output validation outcome, unset if the tool failed to run
+}
@@ -1,8 +1,39 @@
 package mcp
 
-// Version represents the MCP-TLS protocol version
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnsupportedVersion is returned when a client requests a protocol version the server does not support
+var ErrUnsupportedVersion = errors.New("unsupported protocol version")
+
+// Version represents the latest MCP-TLS protocol version
 const Version = "2025-03-26"
 
+// SupportedVersions lists every protocol version the server can speak, newest first
+var SupportedVersions = []string{Version, "2024-11-05"}
+
+// NegotiateVersion selects the protocol version to use with a client requesting the given one.
+// The requested version is used as-is if supported, otherwise the newest supported
+// version that is older than the request is chosen, since a client is assumed to
+// understand every version up to the one it asked for. Requests that are malformed
+// or older than every supported version are rejected with ErrUnsupportedVersion.
+func NegotiateVersion(requested string) (string, error) {
+	if _, err := time.Parse(time.DateOnly, requested); err != nil {
+		return "", fmt.Errorf("%w: malformed version '%s'", ErrUnsupportedVersion, requested)
+	}
+	for _, version := range SupportedVersions {
+		// versions are ISO dates so lexical order is chronological order
+		if version <= requested {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedVersion, requested)
+}
+
 // Implementation describes the name and version of an MCP implementation.
 type Implementation struct {
 	Name    string `json:"name"`
@@ -84,3 +115,32 @@ type InitializeResult struct {
 	// For example, this information MAY be added to the system prompt.
 	Instructions string `json:"instructions,omitempty"`
 }
+
+// ListToolsParams represents parameters for the tools/list method
+type ListToolsParams struct {
+	// An opaque token from a previous page's NextCursor; empty for the first page.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ListToolsResult is sent in response to a tools/list request
+type ListToolsResult struct {
+	Result
+	Tools []Tool `json:"tools"`
+	// Set when more tools remain, to be passed as the cursor of the next request.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// CallToolParams represents parameters for the tools/call method
+type CallToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// CallToolResult is sent in response to a tools/call request. Failures of the
+// tool itself are reported here with IsError set, rather than as a JSON-RPC
+// error, so the model can see them and retry.
+type CallToolResult struct {
+	Result
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
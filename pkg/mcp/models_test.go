@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		expected  string
+		expectErr bool
+	}{
+		{name: "exact match latest", requested: Version, expected: Version},
+		{name: "exact match older", requested: "2024-11-05", expected: "2024-11-05"},
+		{name: "newer client downgrades to latest", requested: "2026-01-01", expected: Version},
+		{name: "between versions downgrades", requested: "2025-01-15", expected: "2024-11-05"},
+		{name: "older than all supported", requested: "2024-01-01", expectErr: true},
+		{name: "malformed version", requested: "2025-3-25", expectErr: true},
+		{name: "empty version", requested: "", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := NegotiateVersion(tt.requested)
+			if tt.expectErr {
+				if !errors.Is(err, ErrUnsupportedVersion) {
+					t.Errorf("Expected ErrUnsupportedVersion, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if version != tt.expected {
+				t.Errorf("Expected version %s, got %s", tt.expected, version)
+			}
+		})
+	}
+}
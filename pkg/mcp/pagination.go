@@ -0,0 +1,42 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+)
+
+// ErrInvalidCursor is returned for a tools/list cursor the server didn't issue
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// DefaultPageSize is the number of tools returned per tools/list page
+const DefaultPageSize = 100
+
+// ListToolsPage returns up to pageSize registered tools, in name order, following
+// the one the cursor points at. NextCursor is set when more tools remain.
+// Tools added or removed between pages don't shift the pages that follow,
+// as the cursor records the last name returned rather than an offset.
+func (tr *ToolRegistry) ListToolsPage(cursor string, pageSize int) (ListToolsResult, error) {
+	var after string
+	if cursor != "" {
+		name, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || len(name) == 0 {
+			return ListToolsResult{}, ErrInvalidCursor
+		}
+		after = string(name)
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	tools := tr.ListTools().Tools
+	start := sort.Search(len(tools), func(i int) bool { return tools[i].Name > after })
+	tools = tools[start:]
+
+	result := ListToolsResult{Tools: tools}
+	if len(tools) > pageSize {
+		result.Tools = tools[:pageSize]
+		result.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(tools[pageSize-1].Name))
+	}
+	return result, nil
+}
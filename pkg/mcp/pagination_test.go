@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestListToolsPage(t *testing.T) {
+	registry := NewToolRegistry(true)
+	for i := range 5 {
+		if err := registry.RegisterTool(repoTool(fmt.Sprintf("tool-%d", i), "a paged tool")); err != nil {
+			t.Fatalf("RegisterTool failed: %v", err)
+		}
+	}
+
+	var names []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("Expected 3 pages, still paging with cursor %q", cursor)
+		}
+		page, err := registry.ListToolsPage(cursor, 2)
+		if err != nil {
+			t.Fatalf("ListToolsPage(%q) failed: %v", cursor, err)
+		}
+		for _, tool := range page.Tools {
+			names = append(names, tool.Name)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+
+		if pages == 0 {
+			// removing a tool already returned must not shift the pages that follow
+			registry.mu.Lock()
+			delete(registry.tools, "tool-0")
+			registry.mu.Unlock()
+		}
+	}
+
+	if fmt.Sprint(names) != "[tool-0 tool-1 tool-2 tool-3 tool-4]" {
+		t.Errorf("Paged through %v, want every tool once in order", names)
+	}
+
+	if _, err := registry.ListToolsPage("%%%", 2); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for a malformed cursor, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package mcp
+
+// DefaultToolVersion is the SecurityMetadata.Version PrepareToolForRegistration records
+// unless WithToolVersion sets another
+const DefaultToolVersion = "1.0.0"
+
+// PrepareOption configures the security metadata PrepareToolForRegistration generates
+type PrepareOption func(*SecurityMetadata)
+
+// WithToolVersion records the version of the tool definition being registered
+func WithToolVersion(version string) PrepareOption {
+	return func(m *SecurityMetadata) { m.Version = version }
+}
+
+// WithPublicKeyID records the identifier of the key the tool's publisher signs with
+func WithPublicKeyID(id string) PrepareOption {
+	return func(m *SecurityMetadata) { m.PublicKeyID = id }
+}
+
+// PrepareToolForRegistration replaces a tool's SecurityMetadata with the metadata the
+// registration API expects: the checksum and schema fingerprints the server verifies,
+// SourceUserProvided as the source it records, and a version. The same tool and
+// options always produce the same metadata. Tools whose output schema doesn't compile
+// are rejected here rather than by the server.
+func PrepareToolForRegistration(tool *Tool, opts ...PrepareOption) error {
+	if err := validateToolDefinition(*tool); err != nil {
+		return err
+	}
+	if err := SecureTool(tool); err != nil {
+		return err
+	}
+
+	tool.SecurityMetadata.Source = SourceUserProvided
+	tool.SecurityMetadata.Version = DefaultToolVersion
+	for _, opt := range opts {
+		opt(&tool.SecurityMetadata)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPrepareToolForRegistration(t *testing.T) {
+	tool := Tool{
+		Name:         "prepared-tool",
+		Description:  "A tool prepared by a client",
+		InputSchema:  json.RawMessage(`{"type":"object","properties":{"q":{"type":"string"}}}`),
+		OutputSchema: json.RawMessage(`{"type":"object"}`),
+		SecurityMetadata: SecurityMetadata{
+			Source:   "trusted-registry", // a client's claim, which preparing replaces
+			Checksum: "stale",
+		},
+	}
+
+	if err := PrepareToolForRegistration(&tool, WithToolVersion("2.1.0"), WithPublicKeyID("key-7")); err != nil {
+		t.Fatalf("PrepareToolForRegistration failed: %v", err)
+	}
+	metadata := tool.SecurityMetadata
+	if err := verifyToolMetadata(tool); err != nil {
+		t.Errorf("Prepared tool fails verification: %v", err)
+	}
+	if metadata.OutputSignature == "" {
+		t.Error("Expected an output schema fingerprint")
+	}
+	if metadata.Source != SourceUserProvided {
+		t.Errorf("Source = %q, want %q", metadata.Source, SourceUserProvided)
+	}
+	if metadata.Version != "2.1.0" || metadata.PublicKeyID != "key-7" {
+		t.Errorf("Options not applied: version %q, key %q", metadata.Version, metadata.PublicKeyID)
+	}
+
+	if err := PrepareToolForRegistration(&tool, WithToolVersion("2.1.0"), WithPublicKeyID("key-7")); err != nil {
+		t.Fatalf("PrepareToolForRegistration failed: %v", err)
+	}
+	if tool.SecurityMetadata != metadata {
+		t.Errorf("Preparing again changed the metadata: %+v, was %+v", tool.SecurityMetadata, metadata)
+	}
+
+	t.Run("Defaults version", func(t *testing.T) {
+		plain := Tool{Name: "plain", InputSchema: json.RawMessage(`{"type":"object"}`)}
+		if err := PrepareToolForRegistration(&plain); err != nil {
+			t.Fatalf("PrepareToolForRegistration failed: %v", err)
+		}
+		if plain.SecurityMetadata.Version != DefaultToolVersion {
+			t.Errorf("Version = %q, want %q", plain.SecurityMetadata.Version, DefaultToolVersion)
+		}
+	})
+
+	t.Run("Rejects invalid output schema", func(t *testing.T) {
+		broken := Tool{Name: "broken", OutputSchema: json.RawMessage(`{"type":"not-a-type"}`)}
+		if err := PrepareToolForRegistration(&broken); err == nil {
+			t.Error("Expected an error for an output schema that doesn't compile")
+		}
+	})
+}
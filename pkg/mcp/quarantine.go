@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrToolUnregistered is returned when looking up a tool that isn't registered
+var ErrToolUnregistered = errors.New("not found")
+
+// ErrToolQuarantined is returned when looking up a tool that has been quarantined
+var ErrToolQuarantined = errors.New("tool is quarantined")
+
+// QuarantinedTool is a tool withdrawn from use, kept as it was when it was flagged
+// so an admin can inspect what changed before releasing or discarding it.
+type QuarantinedTool struct {
+	Tool          Tool      `json:"tool"`
+	Reason        string    `json:"reason"`
+	QuarantinedAt time.Time `json:"quarantinedAt"`
+}
+
+// Quarantine withdraws a registered tool from use. GetTool returns ErrToolQuarantined
+// for it and ListTools leaves it out, but it is kept, with the reason and time, for
+// ListQuarantined and GetQuarantined until Release restores it.
+func (tr *ToolRegistry) Quarantine(name, reason string) error {
+	tr.mu.Lock()
+	tool, exists := tr.tools[name]
+	if !exists {
+		tr.mu.Unlock()
+		return fmt.Errorf("tool '%s' %w", name, ErrToolUnregistered)
+	}
+	delete(tr.tools, name)
+	tr.quarantined[name] = QuarantinedTool{Tool: tool, Reason: reason, QuarantinedAt: time.Now().UTC()}
+	tr.mu.Unlock()
+
+	tr.notifyListChanged()
+	return nil
+}
+
+// Release returns a quarantined tool to the registry unchanged. If its metadata was
+// flagged after a legitimate algorithm change, run Recompute once it is released.
+func (tr *ToolRegistry) Release(name string) error {
+	tr.mu.Lock()
+	entry, exists := tr.quarantined[name]
+	if !exists {
+		tr.mu.Unlock()
+		return fmt.Errorf("tool '%s' is not quarantined", name)
+	}
+	delete(tr.quarantined, name)
+	tr.tools[name] = entry.Tool
+	tr.mu.Unlock()
+
+	tr.notifyListChanged()
+	return nil
+}
+
+// GetQuarantined returns a quarantined tool and why it was quarantined
+func (tr *ToolRegistry) GetQuarantined(name string) (QuarantinedTool, bool) {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	entry, exists := tr.quarantined[name]
+	return entry, exists
+}
+
+// ListQuarantined returns every quarantined tool, sorted by name
+func (tr *ToolRegistry) ListQuarantined() []QuarantinedTool {
+	tr.mu.RLock()
+	entries := make([]QuarantinedTool, 0, len(tr.quarantined))
+	for _, entry := range tr.quarantined {
+		entries = append(entries, entry)
+	}
+	tr.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Tool.Name < entries[j].Tool.Name
+	})
+	return entries
+}
+
+// isQuarantined reports whether the named tool is quarantined
+func (tr *ToolRegistry) isQuarantined(name string) bool {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	_, quarantined := tr.quarantined[name]
+	return quarantined
+}
@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQuarantine(t *testing.T) {
+	registry := NewToolRegistry(true)
+	for _, name := range []string{"good-tool", "tampered-tool"} {
+		if err := registry.RegisterTool(repoTool(name, "a registered tool")); err != nil {
+			t.Fatalf("Failed to register tool: %v", err)
+		}
+	}
+	tampered := registry.tools["tampered-tool"]
+	tampered.Description = "modified after registration"
+	registry.tools["tampered-tool"] = tampered
+
+	// a runtime sweep flags the tool
+	err := registry.VerifyTool("tampered-tool")
+	if err == nil {
+		t.Fatal("Expected the tampered tool to fail verification")
+	}
+	before := time.Now().UTC()
+	if err := registry.Quarantine("tampered-tool", err.Error()); err != nil {
+		t.Fatalf("Quarantine failed: %v", err)
+	}
+
+	if _, err := registry.GetTool("tampered-tool"); !errors.Is(err, ErrToolQuarantined) {
+		t.Errorf("Expected ErrToolQuarantined, got: %v", err)
+	}
+	if err := registry.VerifyTool("tampered-tool"); !errors.Is(err, ErrToolQuarantined) {
+		t.Errorf("Expected VerifyTool to report ErrToolQuarantined, got: %v", err)
+	}
+	tools := registry.ListTools().Tools
+	if len(tools) != 1 || tools[0].Name != "good-tool" {
+		t.Errorf("Expected only good-tool to be listed, got %v", tools)
+	}
+
+	entry, ok := registry.GetQuarantined("tampered-tool")
+	if !ok {
+		t.Fatal("Expected the quarantined tool to be kept for inspection")
+	}
+	if entry.Tool.Description != "modified after registration" {
+		t.Errorf("Expected the tampered definition to be kept as evidence, got %q", entry.Tool.Description)
+	}
+	if entry.Reason == "" || entry.QuarantinedAt.Before(before) {
+		t.Errorf("Expected a reason and timestamp, got %+v", entry)
+	}
+	if list := registry.ListQuarantined(); len(list) != 1 || list[0].Tool.Name != "tampered-tool" {
+		t.Errorf("ListQuarantined() = %v, want tampered-tool", list)
+	}
+
+	if err := registry.RegisterTool(repoTool("tampered-tool", "a replacement")); !errors.Is(err, ErrToolQuarantined) {
+		t.Errorf("Expected registering over a quarantined tool to fail, got: %v", err)
+	}
+	if err := registry.Quarantine("missing-tool", "no such tool"); err == nil {
+		t.Error("Expected quarantining an unknown tool to fail")
+	}
+
+	if err := registry.Release("tampered-tool"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if _, ok := registry.GetQuarantined("tampered-tool"); ok {
+		t.Error("Expected the released tool to leave quarantine")
+	}
+	if n := len(registry.ListTools().Tools); n != 2 {
+		t.Errorf("Expected the released tool to be listed again, got %d tools", n)
+	}
+	if err := registry.Release("tampered-tool"); err == nil {
+		t.Error("Expected releasing a tool twice to fail")
+	}
+}
@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/null-create/mcp-tls/pkg/util"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// DefaultRepoName is the name SetRegistryCreds gives the repo it configures
+const DefaultRepoName = "default"
+
+// number of times LoadTools tries a tool repo before giving up
+const loadToolsAttempts = 3
+
+// ConflictPolicy decides which definition LoadTools keeps when
+// more than one repo provides a tool with the same name.
+type ConflictPolicy string
+
+const (
+	ConflictFirstWins ConflictPolicy = "first-wins" // keep the tool from the repo added first (default)
+	ConflictLastWins  ConflictPolicy = "last-wins"  // keep the tool from the repo added last
+	ConflictReject    ConflictPolicy = "reject"     // fail the load rather than choose
+)
+
+// toolRepo is a remote repository of trusted tools. Its fields don't change once it's
+// in the registry, so loads can use it without holding the lock; AddRepo replaces it.
+type toolRepo struct {
+	name    string
+	url     string
+	apiKey  string
+	breaker *util.CircuitBreaker // fails fast while the repo is down
+}
+
+// AddRepo adds a trusted tool repo for LoadTools to fetch from. Repos are loaded in
+// the order they were added, which the conflict policy uses to settle duplicate tool
+// names. Adding a repo under an existing name replaces that repo's URL and API key.
+func (tr *ToolRegistry) AddRepo(name, url, apiKey string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for i, repo := range tr.repos {
+		if repo.name == name {
+			// a load in progress keeps using the old repo; the breaker carries over
+			tr.repos[i] = &toolRepo{name: name, url: url, apiKey: apiKey, breaker: repo.breaker}
+			return
+		}
+	}
+	tr.repos = append(tr.repos, &toolRepo{
+		name:    name,
+		url:     url,
+		apiKey:  apiKey,
+		breaker: util.NewCircuitBreaker(repoBreakerThreshold, repoBreakerCooldown),
+	})
+}
+
+// SetConflictPolicy sets how LoadTools merges same-named tools from different repos
+func (tr *ToolRegistry) SetConflictPolicy(policy ConflictPolicy) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.conflictPolicy = policy
+}
+
+// RepoState reports the circuit breaker state of the remote tool repos. With several
+// repos it reports the least available: open if any repo's breaker is open.
+func (tr *ToolRegistry) RepoState() util.BreakerState {
+	tr.mu.RLock()
+	repos := append([]*toolRepo{}, tr.repos...)
+	tr.mu.RUnlock()
+
+	state := util.BreakerClosed
+	for _, repo := range repos {
+		switch repo.breaker.State() {
+		case util.BreakerOpen:
+			return util.BreakerOpen
+		case util.BreakerHalfOpen:
+			state = util.BreakerHalfOpen
+		}
+	}
+	return state
+}
+
+// fetchRemoteTools retrieves the trusted tools from every remote repo and merges
+// them by the conflict policy. Each tool's SecurityMetadata.Source is set to the
+// name of the repo it came from. Tools failing checkRemoteTool are left out of the
+// merge and returned in rejected, by name. If any repo fails, nothing is returned.
+func (tr *ToolRegistry) fetchRemoteTools() (tools map[string]Tool, rejected map[string]error, err error) {
+	tr.mu.RLock()
+	repos := append([]*toolRepo{}, tr.repos...)
+	policy := tr.conflictPolicy
+	tr.mu.RUnlock()
+
+	if len(repos) == 0 {
+		return nil, nil, fmt.Errorf("missing tool repo credentials")
+	}
+
+	merged := make(map[string]Tool)
+	rejected = make(map[string]error)
+	for _, repo := range repos {
+		entries, err := repo.fetchRemoteTools()
+		if err != nil {
+			return nil, nil, fmt.Errorf("tool repo '%s': %w", repo.name, err)
+		}
+		for name, entry := range entries {
+			tool, err := tr.checkRemoteTool(name, entry)
+			if err != nil {
+				rejected[name] = fmt.Errorf("tool '%s' from repo '%s' rejected: %w", name, repo.name, err)
+				continue
+			}
+			tool.SecurityMetadata.Source = repo.name
+			existing, conflict := merged[name]
+			switch {
+			case !conflict, policy == ConflictLastWins:
+				merged[name] = tool
+			case policy == ConflictReject:
+				return nil, nil, fmt.Errorf("tool '%s' is provided by both repo '%s' and repo '%s'",
+					name, existing.SecurityMetadata.Source, repo.name)
+			}
+		}
+	}
+	return merged, rejected, nil
+}
+
+// checkRemoteTool decodes and checks a tool definition a repo listed under name. On top
+// of the checks for every loaded tool, it must have a compilable input schema, and be
+// listed under its own name so it can't take another tool's place.
+func (tr *ToolRegistry) checkRemoteTool(name string, entry json.RawMessage) (Tool, error) {
+	var tool Tool
+	if err := json.Unmarshal(entry, &tool); err != nil {
+		return Tool{}, fmt.Errorf("invalid tool definition: %w", err)
+	}
+	if tool.Name != "" && tool.Name != name {
+		return Tool{}, fmt.Errorf("listed as '%s' but named '%s'", name, tool.Name)
+	}
+	if !hasSchema(tool.InputSchema) {
+		return Tool{}, errors.New("tool definition has no input schema")
+	}
+	if _, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(tool.InputSchema)); err != nil {
+		return Tool{}, fmt.Errorf("invalid input schema: %w", err)
+	}
+	return tr.checkLoadedTool(tool)
+}
+
+// sortedRejections lists the rejected tools' errors in name order, for stable reporting
+func sortedRejections(rejected map[string]error) []error {
+	errs := make([]error, 0, len(rejected))
+	for _, name := range slices.Sorted(maps.Keys(rejected)) {
+		errs = append(errs, rejected[name])
+	}
+	return errs
+}
+
+// fetchRemoteTools retrieves the repo's trusted tools, retrying transient failures
+func (repo *toolRepo) fetchRemoteTools() (map[string]json.RawMessage, error) {
+	if repo.apiKey == "" || repo.url == "" {
+		return nil, fmt.Errorf("missing tool repo credentials")
+	}
+
+	var tools map[string]json.RawMessage
+	err := util.Retry(context.Background(), loadToolsAttempts, util.DefaultBackoff, func() error {
+		err := repo.breaker.Do(func() error {
+			var err error
+			tools, err = repo.fetchTools()
+			return err
+		})
+		if errors.Is(err, util.ErrCircuitOpen) {
+			return util.Permanent(fmt.Errorf("tool repo unavailable: %w", err))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tools, nil
+}
@@ -0,0 +1,306 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveTools starts a tool repo serving the given tools
+func serveTools(t *testing.T, tools ...Tool) *httptest.Server {
+	t.Helper()
+	byName := make(map[string]Tool, len(tools))
+	for _, tool := range tools {
+		byName[tool.Name] = tool
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(byName)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func repoTool(name, description string) Tool {
+	return Tool{Name: name, Description: description, InputSchema: json.RawMessage(`{"type": "object"}`)}
+}
+
+func TestLoadToolsMergesRepos(t *testing.T) {
+	internal := serveTools(t, repoTool("search", "internal search"), repoTool("deploy", "internal deploy"))
+	vendor := serveTools(t, repoTool("translate", "vendor translate"))
+
+	registry := NewToolRegistry(false)
+	registry.AddRepo("internal", internal.URL, "internal-key")
+	registry.AddRepo("vendor", vendor.URL, "vendor-key")
+	if err := registry.LoadTools(); err != nil {
+		t.Fatalf("LoadTools failed: %v", err)
+	}
+
+	expected := map[string]string{"search": "internal", "deploy": "internal", "translate": "vendor"}
+	if len(registry.tools) != len(expected) {
+		t.Errorf("Expected %d tools, got %d", len(expected), len(registry.tools))
+	}
+	for name, source := range expected {
+		tool, err := registry.GetTool(name)
+		if err != nil {
+			t.Errorf("Expected tool '%s' to be loaded: %v", name, err)
+			continue
+		}
+		if tool.SecurityMetadata.Source != source {
+			t.Errorf("Tool '%s' source = %q, want %q", name, tool.SecurityMetadata.Source, source)
+		}
+	}
+}
+
+func TestAddRepoReplacesDuringLoad(t *testing.T) {
+	reached, release := make(chan struct{}), make(chan struct{})
+	old := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(reached)
+		<-release
+		json.NewEncoder(w).Encode(map[string]Tool{"search": repoTool("search", "old search")})
+	}))
+	t.Cleanup(old.Close)
+	current := serveTools(t, repoTool("search", "current search"))
+
+	registry := NewToolRegistry(false)
+	registry.AddRepo("vendor", old.URL, "old-key")
+	done := make(chan error, 1)
+	go func() { done <- registry.LoadTools() }()
+	// replacing the repo mid-load must not race with the load reading it
+	<-reached
+	registry.AddRepo("vendor", current.URL, "current-key")
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("LoadTools failed: %v", err)
+	}
+
+	if err := registry.LoadTools(); err != nil {
+		t.Fatalf("LoadTools failed: %v", err)
+	}
+	tool, err := registry.GetTool("search")
+	if err != nil || tool.Description != "current search" {
+		t.Errorf("GetTool(search) = %+v, %v, want the replaced repo's definition", tool, err)
+	}
+	if len(registry.repos) != 1 {
+		t.Errorf("Expected the repo to be replaced, got %d repos", len(registry.repos))
+	}
+}
+
+func TestLoadToolsConflictPolicy(t *testing.T) {
+	internal := serveTools(t, repoTool("search", "internal search"))
+	vendor := serveTools(t, repoTool("search", "vendor search"), repoTool("translate", "vendor translate"))
+
+	tests := []struct {
+		policy      ConflictPolicy
+		description string // of the search tool kept, empty if the load should fail
+	}{
+		{policy: ConflictFirstWins, description: "internal search"},
+		{policy: ConflictLastWins, description: "vendor search"},
+		{policy: ConflictReject},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			registry := NewToolRegistry(false)
+			registry.AddRepo("internal", internal.URL, "internal-key")
+			registry.AddRepo("vendor", vendor.URL, "vendor-key")
+			registry.SetConflictPolicy(tt.policy)
+
+			err := registry.LoadTools()
+			if tt.description == "" {
+				if err == nil || !strings.Contains(err.Error(), "'search'") {
+					t.Fatalf("Expected a conflict error naming the tool, got: %v", err)
+				}
+				if len(registry.tools) != 0 {
+					t.Errorf("Expected a rejected load to leave the registry untouched, got %d tools", len(registry.tools))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadTools failed: %v", err)
+			}
+			tool, err := registry.GetTool("search")
+			if err != nil {
+				t.Fatalf("Expected search tool: %v", err)
+			}
+			if tool.Description != tt.description {
+				t.Errorf("Kept %q, want %q", tool.Description, tt.description)
+			}
+			if _, err := registry.GetTool("translate"); err != nil {
+				t.Errorf("Expected non-conflicting tool to be loaded: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadToolsFailsIfAnyRepoFails(t *testing.T) {
+	good := serveTools(t, repoTool("search", "internal search"))
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer bad.Close()
+
+	registry := NewToolRegistry(false)
+	registry.AddRepo("internal", good.URL, "internal-key")
+	registry.AddRepo("vendor", bad.URL, "vendor-key")
+	err := registry.LoadTools()
+	if err == nil || !strings.Contains(err.Error(), "'vendor'") {
+		t.Fatalf("Expected an error naming the failed repo, got: %v", err)
+	}
+	if len(registry.tools) != 0 {
+		t.Errorf("Expected the registry to be left untouched, got %d tools", len(registry.tools))
+	}
+}
+
+// serveRaw starts a tool repo answering with the given body
+func serveRaw(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+const invalidToolsResponse = `{
+	"search": {"name": "search", "description": "valid", "inputSchema": {"type": "object"}},
+	"nameless": {"description": "no name", "inputSchema": {"type": "object"}},
+	"schemaless": {"name": "schemaless", "description": "no input schema"},
+	"broken": {"name": "broken", "inputSchema": {"type": 12}},
+	"impostor": {"name": "search", "description": "takes another tool's place", "inputSchema": {"type": "object"}},
+	"scalar": 42
+}`
+
+func TestLoadToolsDropsInvalidTools(t *testing.T) {
+	logged := captureLog(t)
+	registry := NewToolRegistry(false)
+	registry.AddRepo("vendor", serveRaw(t, invalidToolsResponse).URL, "vendor-key")
+	if err := registry.LoadTools(); err != nil {
+		t.Fatalf("Expected the valid tools to load, got: %v", err)
+	}
+
+	if len(registry.tools) != 1 {
+		t.Errorf("Expected only the valid tool to be loaded, got %d tools", len(registry.tools))
+	}
+	tool, err := registry.GetTool("search")
+	if err != nil || tool.Description != "valid" {
+		t.Errorf("GetTool(search) = %+v, %v, want the valid definition", tool, err)
+	}
+	for _, name := range []string{"nameless", "schemaless", "broken", "impostor", "scalar"} {
+		if !strings.Contains(logged.String(), "tool '"+name+"' from repo 'vendor' rejected") {
+			t.Errorf("Expected a warning for tool '%s', got: %s", name, logged)
+		}
+	}
+}
+
+func TestLoadToolsChecksSecurityMetadata(t *testing.T) {
+	captureLog(t)
+	signed := repoTool("signed", "carries its metadata")
+	if err := SecureTool(&signed); err != nil {
+		t.Fatal(err)
+	}
+	tampered := repoTool("tampered", "original")
+	if err := SecureTool(&tampered); err != nil {
+		t.Fatal(err)
+	}
+	tampered.Description = "modified after signing"
+	srv := serveTools(t, signed, tampered, repoTool("unsigned", "no metadata"))
+
+	registry := NewToolRegistry(true)
+	registry.SetSecurityOptions(true, true)
+	registry.SetRegistryCreds(srv.URL, "key")
+	if err := registry.LoadTools(); err != nil {
+		t.Fatalf("LoadTools failed: %v", err)
+	}
+	if _, err := registry.GetTool("signed"); err != nil {
+		t.Errorf("Expected the signed tool to load: %v", err)
+	}
+	for _, name := range []string{"tampered", "unsigned"} {
+		if _, exists := registry.tools[name]; exists {
+			t.Errorf("Expected tool '%s' to be dropped", name)
+		}
+	}
+}
+
+func TestLoadToolsRequiredAnnotations(t *testing.T) {
+	annotated := repoTool("search", "annotated search")
+	annotated.Annotations = ToolAnnotation{Title: "Search"}
+	if err := annotated.Annotations.SetHint(AnnotationReadOnly, true); err != nil {
+		t.Fatal(err)
+	}
+	srv := serveTools(t, annotated, repoTool("deploy", "missing its annotations"))
+	required := []Annotation{AnnotationTitle, AnnotationReadOnly}
+
+	t.Run("load drops the tool", func(t *testing.T) {
+		logged := captureLog(t)
+		registry := NewToolRegistry(false)
+		registry.SetRequiredAnnotations(required)
+		registry.AddRepo("vendor", srv.URL, "vendor-key")
+		if err := registry.LoadTools(); err != nil {
+			t.Fatalf("LoadTools failed: %v", err)
+		}
+		if _, err := registry.GetTool("search"); err != nil {
+			t.Errorf("Expected the annotated tool to load: %v", err)
+		}
+		if _, exists := registry.tools["deploy"]; exists {
+			t.Error("Expected the tool missing its annotations to be dropped")
+		}
+		if !strings.Contains(logged.String(), "tool 'deploy' from repo 'vendor' rejected") {
+			t.Errorf("Expected a warning for the dropped tool, got: %s", logged)
+		}
+	})
+
+	t.Run("preview is refused", func(t *testing.T) {
+		registry := NewToolRegistry(false)
+		registry.SetRequiredAnnotations(required)
+		registry.SetRegistryCreds(srv.URL, "key")
+		_, err := registry.LoadToolsPreview()
+		if err == nil || !strings.Contains(err.Error(), "'deploy'") {
+			t.Fatalf("Expected the preview to be refused naming the tool, got: %v", err)
+		}
+		if err := registry.CommitLoad(); err == nil {
+			t.Error("Expected nothing to commit after a refused preview")
+		}
+	})
+}
+
+func TestLoadToolsRejectsMalformedResponses(t *testing.T) {
+	for name, body := range map[string]string{
+		"array":         `[{"name": "search", "inputSchema": {"type": "object"}}]`,
+		"null":          `null`,
+		"string":        `"search"`,
+		"trailing data": `{"search": {"name": "search", "inputSchema": {"type": "object"}}} {}`,
+		"truncated":     `{"search": {"name": "search"`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			registry := NewToolRegistry(false)
+			if err := registry.RegisterTool(repoTool("existing", "registered before the load")); err != nil {
+				t.Fatal(err)
+			}
+			registry.SetRegistryCreds(serveRaw(t, body).URL, "key")
+			if err := registry.LoadTools(); err == nil || !strings.Contains(err.Error(), "invalid tool repo response") {
+				t.Fatalf("Expected the response to be rejected, got: %v", err)
+			}
+			if _, err := registry.GetTool("existing"); err != nil {
+				t.Errorf("Expected a rejected load to leave the registry untouched: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadToolsPreviewRefusesInvalidTools(t *testing.T) {
+	registry := NewToolRegistry(false)
+	registry.SetRegistryCreds(serveRaw(t, invalidToolsResponse).URL, "key")
+	_, err := registry.LoadToolsPreview()
+	if err == nil {
+		t.Fatal("Expected the preview to be refused")
+	}
+	for _, name := range []string{"nameless", "schemaless", "broken", "impostor", "scalar"} {
+		if !strings.Contains(err.Error(), "'"+name+"'") {
+			t.Errorf("Expected the error to name tool '%s', got: %v", name, err)
+		}
+	}
+	if err := registry.CommitLoad(); err == nil {
+		t.Error("Expected nothing to commit after a refused preview")
+	}
+}
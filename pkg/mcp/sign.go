@@ -0,0 +1,183 @@
+package mcp
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidSigningKey is returned for a key that is neither an Ed25519 seed nor private key
+var ErrInvalidSigningKey = errors.New("invalid signing key")
+
+// ErrPublisherSignature is returned when a tool's publisher signature is missing or doesn't verify
+var ErrPublisherSignature = errors.New("publisher signature verification failed")
+
+// SignToolFile signs a tool definition file in place for shipping to a registry. It
+// replaces the file's SecurityMetadata with what PrepareToolForRegistration computes,
+// the same checksum and schema fingerprints the server verifies. If key is given, as
+// an Ed25519 seed or private key, the metadata also names the key in PublicKeyID and
+// carries an Ed25519 signature over it, which VerifyPublisherSignature checks.
+func SignToolFile(path string, key []byte) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var tool Tool
+	if err := json.Unmarshal(data, &tool); err != nil {
+		return fmt.Errorf("invalid tool definition: %w", err)
+	}
+	if tool.Name == "" {
+		return errors.New("tool definition has no name")
+	}
+
+	var opts []PrepareOption
+	var privateKey ed25519.PrivateKey
+	if len(key) > 0 {
+		if privateKey, err = signingKey(key); err != nil {
+			return err
+		}
+		opts = append(opts, WithPublicKeyID(KeyID(privateKey.Public().(ed25519.PublicKey))))
+	}
+	if err := PrepareToolForRegistration(&tool, opts...); err != nil {
+		return err
+	}
+	if privateKey != nil {
+		signature := ed25519.Sign(privateKey, publisherSignedBytes(tool.SecurityMetadata))
+		tool.SecurityMetadata.PublisherSignature = base64.StdEncoding.EncodeToString(signature)
+	}
+
+	signed, err := json.MarshalIndent(tool, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(signed, '\n'))
+}
+
+// VerifyPublisherSignature checks a tool's publisher signature against publicKey,
+// and that the checksum and fingerprints it covers still match the definition.
+func VerifyPublisherSignature(tool Tool, publicKey ed25519.PublicKey) error {
+	if err := verifyToolMetadata(tool); err != nil {
+		return fmt.Errorf("tool '%s': %w: %v", tool.Name, ErrPublisherSignature, err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(tool.SecurityMetadata.PublisherSignature)
+	if err != nil || len(signature) == 0 {
+		return fmt.Errorf("tool '%s': %w: no valid signature", tool.Name, ErrPublisherSignature)
+	}
+	if !ed25519.Verify(publicKey, publisherSignedBytes(tool.SecurityMetadata), signature) {
+		return fmt.Errorf("tool '%s': %w", tool.Name, ErrPublisherSignature)
+	}
+	return nil
+}
+
+// ParsePublisherKeys decodes base64 Ed25519 public keys. Keys that don't decode are
+// left out and named in the returned error.
+func ParsePublisherKeys(encoded []string) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	var invalid []string
+	for _, e := range encoded {
+		key, err := base64.StdEncoding.DecodeString(e)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			invalid = append(invalid, e)
+			continue
+		}
+		keys = append(keys, ed25519.PublicKey(key))
+	}
+	if len(invalid) > 0 {
+		return keys, fmt.Errorf("invalid publisher keys, expected base64 %d byte Ed25519 public keys: %s",
+			ed25519.PublicKeySize, strings.Join(invalid, ", "))
+	}
+	return keys, nil
+}
+
+// SetPublisherKeys makes RegisterTool, ImportToolSet, and loading from tool directories
+// and repos accept only tools signed by one of keys, as found by the tool's PublicKeyID.
+// Nil accepts tools without a publisher signature.
+func (tr *ToolRegistry) SetPublisherKeys(keys []ed25519.PublicKey) {
+	var byID map[string]ed25519.PublicKey
+	if len(keys) > 0 {
+		byID = make(map[string]ed25519.PublicKey, len(keys))
+		for _, key := range keys {
+			byID[KeyID(key)] = key
+		}
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.publisherKeys = byID
+}
+
+// checkPublisher rejects a tool not signed by one of the publisher keys, if any are set
+func (tr *ToolRegistry) checkPublisher(tool Tool) error {
+	tr.mu.RLock()
+	keys := tr.publisherKeys
+	tr.mu.RUnlock()
+	if keys == nil {
+		return nil
+	}
+	key, ok := keys[tool.SecurityMetadata.PublicKeyID]
+	if !ok {
+		return fmt.Errorf("tool '%s': %w: not signed by a trusted publisher key", tool.Name, ErrPublisherSignature)
+	}
+	return VerifyPublisherSignature(tool, key)
+}
+
+// KeyID derives the identifier recorded in PublicKeyID from an Ed25519 public key
+func KeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// publisherSignedBytes is what a publisher signature covers: the metadata other than
+// the signature itself and the source, which the registry sets to where it got the
+// tool from. The checksum and fingerprints in it bind the tool definition, annotations included.
+func publisherSignedBytes(metadata SecurityMetadata) []byte {
+	metadata.PublisherSignature = ""
+	metadata.Source = ""
+	// a struct of strings always marshals
+	data, _ := json.Marshal(metadata)
+	return data
+}
+
+// signingKey accepts an Ed25519 seed or full private key
+func signingKey(key []byte) (ed25519.PrivateKey, error) {
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	}
+	return nil, fmt.Errorf("%w: expected a %d byte seed or %d byte private key, got %d bytes",
+		ErrInvalidSigningKey, ed25519.SeedSize, ed25519.PrivateKeySize, len(key))
+}
+
+// writeFileAtomic replaces path with data, keeping its permissions, so readers such as
+// WatchDir never see a partly written file
+func writeFileAtomic(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
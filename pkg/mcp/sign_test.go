@@ -0,0 +1,263 @@
+package mcp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// unsignedToolFile writes a tool definition with no security metadata and returns its path
+func unsignedToolFile(t *testing.T, dir string) string {
+	t.Helper()
+	writeToolFile(t, dir, "weather", Tool{
+		Name:         "weather",
+		Description:  "Looks up the forecast for a city",
+		InputSchema:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`),
+		OutputSchema: json.RawMessage(`{"type":"object","properties":{"forecast":{"type":"string"}}}`),
+	})
+	return filepath.Join(dir, "weather.json")
+}
+
+func readToolFileForTest(t *testing.T, path string) Tool {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read tool file: %v", err)
+	}
+	var tool Tool
+	if err := json.Unmarshal(data, &tool); err != nil {
+		t.Fatalf("Signed file is not a valid tool: %v", err)
+	}
+	return tool
+}
+
+func TestSignToolFile_PassesServerVerification(t *testing.T) {
+	dir := t.TempDir()
+	path := unsignedToolFile(t, dir)
+	if err := SignToolFile(path, nil); err != nil {
+		t.Fatalf("SignToolFile failed: %v", err)
+	}
+	signed := readToolFileForTest(t, path)
+
+	// the metadata matches what the server computes for the same tool
+	expected := signed
+	if err := PrepareToolForRegistration(&expected); err != nil {
+		t.Fatal(err)
+	}
+	if signed.SecurityMetadata != expected.SecurityMetadata {
+		t.Errorf("Signed metadata = %+v, want %+v", signed.SecurityMetadata, expected.SecurityMetadata)
+	}
+
+	// a registry that rejects unsigned tools loads and verifies it
+	registry := NewToolRegistry(true)
+	registry.SetSecurityOptions(true, true)
+	if err := registry.LoadFromDir(dir); err != nil {
+		t.Fatalf("LoadFromDir rejected the signed file: %v", err)
+	}
+	if err := registry.VerifyTool("weather"); err != nil {
+		t.Errorf("VerifyTool failed for the signed tool: %v", err)
+	}
+	if err := verifyToolMetadata(signed); err != nil {
+		t.Errorf("verifyToolMetadata failed for the signed tool: %v", err)
+	}
+}
+
+func TestSignToolFile_Ed25519(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := unsignedToolFile(t, dir)
+	if err := SignToolFile(path, privateKey.Seed()); err != nil {
+		t.Fatalf("SignToolFile failed: %v", err)
+	}
+	signed := readToolFileForTest(t, path)
+
+	if signed.SecurityMetadata.PublicKeyID != KeyID(publicKey) {
+		t.Errorf("PublicKeyID = %q, want %q", signed.SecurityMetadata.PublicKeyID, KeyID(publicKey))
+	}
+	if err := VerifyPublisherSignature(signed, publicKey); err != nil {
+		t.Errorf("VerifyPublisherSignature failed: %v", err)
+	}
+	if err := verifyToolMetadata(signed); err != nil {
+		t.Errorf("verifyToolMetadata failed for the signed tool: %v", err)
+	}
+
+	otherKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	if err := VerifyPublisherSignature(signed, otherKey); !errors.Is(err, ErrPublisherSignature) {
+		t.Errorf("Expected ErrPublisherSignature for the wrong key, got: %v", err)
+	}
+
+	// signing the same file again with the same key changes nothing
+	before, _ := os.ReadFile(path)
+	if err := SignToolFile(path, privateKey); err != nil {
+		t.Fatalf("Re-signing with the full private key failed: %v", err)
+	}
+	after, _ := os.ReadFile(path)
+	if string(before) != string(after) {
+		t.Errorf("Re-signing changed the file:\n%s\n%s", before, after)
+	}
+
+	tampered := signed
+	tampered.SecurityMetadata.Version = "2.0.0"
+	if err := VerifyPublisherSignature(tampered, publicKey); !errors.Is(err, ErrPublisherSignature) {
+		t.Errorf("Expected ErrPublisherSignature for altered metadata, got: %v", err)
+	}
+}
+
+func TestVerifyPublisherSignature_CoversAnnotations(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writeToolFile(t, dir, "delete-file", json.RawMessage(`{
+		"name": "delete-file",
+		"description": "Deletes a file",
+		"inputSchema": {"type":"object","properties":{"path":{"type":"string"}}},
+		"annotations": {"title": "Delete file", "readOnlyHint": false, "destructiveHint": true}
+	}`))
+	path := filepath.Join(dir, "delete-file.json")
+	if err := SignToolFile(path, privateKey.Seed()); err != nil {
+		t.Fatal(err)
+	}
+	signed := readToolFileForTest(t, path)
+	if err := VerifyPublisherSignature(signed, publicKey); err != nil {
+		t.Fatalf("VerifyPublisherSignature failed: %v", err)
+	}
+
+	for name, edit := range map[string]func(*ToolAnnotation){
+		"marked read-only":      func(a *ToolAnnotation) { _ = a.SetHint(AnnotationReadOnly, true) },
+		"destructive dropped":   func(a *ToolAnnotation) { *a = ToolAnnotation{Title: a.Title} },
+		"declared hint removed": func(a *ToolAnnotation) { *a = ToolAnnotation{Title: a.Title, DestructiveHint: true} },
+		"retitled":              func(a *ToolAnnotation) { a.Title = "Tidy up" },
+	} {
+		edited := signed
+		edit(&edited.Annotations)
+		if err := VerifyPublisherSignature(edited, publicKey); !errors.Is(err, ErrPublisherSignature) {
+			t.Errorf("%s: expected ErrPublisherSignature, got: %v", name, err)
+		}
+	}
+}
+
+func TestSignToolFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := unsignedToolFile(t, dir)
+	original, _ := os.ReadFile(path)
+
+	if err := SignToolFile(path, []byte("short")); !errors.Is(err, ErrInvalidSigningKey) {
+		t.Errorf("Expected ErrInvalidSigningKey, got: %v", err)
+	}
+	if current, _ := os.ReadFile(path); string(current) != string(original) {
+		t.Error("A failed signing modified the file")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"name":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SignToolFile(filepath.Join(dir, "broken.json"), nil); err == nil {
+		t.Error("Expected an error signing an invalid tool file")
+	}
+	if err := SignToolFile(filepath.Join(dir, "missing.json"), nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist, got: %v", err)
+	}
+}
+
+func TestPublisherKeys_Enforced(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// signs a copy of the weather tool with key, or only prepares its metadata without one
+	sign := func(key ed25519.PrivateKey) Tool {
+		dir := t.TempDir()
+		path := unsignedToolFile(t, dir)
+		var seed []byte
+		if key != nil {
+			seed = key.Seed()
+		}
+		if err := SignToolFile(path, seed); err != nil {
+			t.Fatal(err)
+		}
+		return readToolFileForTest(t, path)
+	}
+	newRegistry := func() *ToolRegistry {
+		registry := NewToolRegistry(true)
+		registry.SetPublisherKeys([]ed25519.PublicKey{publicKey})
+		return registry
+	}
+
+	tests := []struct {
+		name string
+		tool Tool
+		ok   bool
+	}{
+		{name: "trusted publisher", tool: sign(privateKey), ok: true},
+		{name: "unknown publisher", tool: sign(otherKey)},
+		{name: "no publisher signature", tool: sign(nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := func(op string, err error) {
+				t.Helper()
+				if tt.ok && err != nil {
+					t.Errorf("%s() error = %v", op, err)
+				}
+				if !tt.ok && !errors.Is(err, ErrPublisherSignature) {
+					t.Errorf("Expected %s() to fail with ErrPublisherSignature, got: %v", op, err)
+				}
+			}
+
+			// the source the registry assigns isn't covered by the signature
+			registered := tt.tool
+			registered.SecurityMetadata.Source = SourceUserProvided
+			check("RegisterTool", newRegistry().RegisterTool(registered))
+			check("ImportToolSet", newRegistry().ImportToolSet(ToolSet{
+				SchemaFingerprintAlgo: SchemaFingerprintAlgo,
+				ChecksumAlgo:          ChecksumAlgo,
+				Tools:                 []Tool{tt.tool},
+			}))
+
+			dir := t.TempDir()
+			writeToolFile(t, dir, tt.tool.Name, tt.tool)
+			registry := newRegistry()
+			if err := registry.LoadFromDir(dir); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := registry.GetTool(tt.tool.Name); (err == nil) != tt.ok {
+				t.Errorf("GetTool() after LoadFromDir error = %v, want loaded = %v", err, tt.ok)
+			}
+		})
+	}
+
+	// no keys, no publisher signature required
+	if err := NewToolRegistry(true).RegisterTool(sign(nil)); err != nil {
+		t.Errorf("RegisterTool() without publisher keys error = %v", err)
+	}
+}
+
+func TestParsePublisherKeys(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(publicKey)
+	keys, err := ParsePublisherKeys([]string{encoded, "c2hvcnQ=", "not base64!"})
+	if err == nil || !strings.Contains(err.Error(), "c2hvcnQ=") || !strings.Contains(err.Error(), "not base64!") {
+		t.Errorf("Expected an error naming the invalid keys, got: %v", err)
+	}
+	if len(keys) != 1 || !keys[0].Equal(publicKey) {
+		t.Errorf("Expected the valid key to be kept, got: %v", keys)
+	}
+}
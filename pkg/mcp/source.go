@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"log"
+	"path"
+)
+
+// SourceUserProvided is the SecurityMetadata.Source recorded for tools registered
+// directly, e.g. through the registration API, rather than loaded from a tool repo
+// (which records the repo's name) or a directory (which records the file's path).
+const SourceUserProvided = "user-provided"
+
+// SetTrustedSources restricts GetTool to tools whose SecurityMetadata.Source matches
+// one of the given patterns, rejecting the rest. Patterns use path.Match syntax, so
+// "/etc/mcp-tls/tools/*.json" trusts every tool loaded from that directory. With no
+// patterns, tools from every source are trusted.
+func (tr *ToolRegistry) SetTrustedSources(patterns []string) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			log.Printf("WARNING invalid trusted source pattern '%s': %v", pattern, err)
+		}
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.trustedSources = append([]string(nil), patterns...)
+}
+
+// trustsSource reports whether tools from source pass the trusted sources policy
+func (tr *ToolRegistry) trustsSource(source string) bool {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	if len(tr.trustedSources) == 0 {
+		return true
+	}
+	for _, pattern := range tr.trustedSources {
+		if ok, _ := path.Match(pattern, source); ok {
+			return true
+		}
+	}
+	return false
+}
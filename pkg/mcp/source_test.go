@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceRecorded(t *testing.T) {
+	dir := t.TempDir()
+	writeToolFile(t, dir, "disk", signedTool(t, "disk-tool"))
+	repo := serveTools(t, repoTool("repo-tool", "a tool from a repo"))
+
+	registry := NewToolRegistry(true)
+	registry.AddRepo("internal", repo.URL, "key")
+	if err := registry.LoadTools(); err != nil {
+		t.Fatalf("LoadTools failed: %v", err)
+	}
+	if err := registry.LoadFromDir(dir); err != nil {
+		t.Fatalf("LoadFromDir failed: %v", err)
+	}
+	if err := registry.RegisterTool(repoTool("api-tool", "a tool registered directly")); err != nil {
+		t.Fatalf("RegisterTool failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"repo-tool": "internal",
+		"disk-tool": filepath.Join(dir, "disk.json"),
+		"api-tool":  SourceUserProvided,
+	}
+	for name, source := range expected {
+		if got := registry.tools[name].SecurityMetadata.Source; got != source {
+			t.Errorf("Tool '%s' source = %q, want %q", name, got, source)
+		}
+	}
+
+	t.Run("ListTools filters by source", func(t *testing.T) {
+		if n := len(registry.ListTools().Tools); n != 3 {
+			t.Errorf("Expected 3 tools unfiltered, got %d", n)
+		}
+		tools := registry.ListTools(SourceUserProvided).Tools
+		if len(tools) != 1 || tools[0].Name != "api-tool" {
+			t.Errorf("ListTools(%q) = %v, want only api-tool", SourceUserProvided, tools)
+		}
+		if n := len(registry.ListTools("internal", SourceUserProvided).Tools); n != 2 {
+			t.Errorf("Expected 2 tools from two sources, got %d", n)
+		}
+		if n := len(registry.ListTools("unknown").Tools); n != 0 {
+			t.Errorf("Expected no tools from an unknown source, got %d", n)
+		}
+	})
+}
+
+func TestTrustedSourcesPolicy(t *testing.T) {
+	dir := t.TempDir()
+	writeToolFile(t, dir, "disk", signedTool(t, "disk-tool"))
+
+	registry := NewToolRegistry(true)
+	if err := registry.LoadFromDir(dir); err != nil {
+		t.Fatalf("LoadFromDir failed: %v", err)
+	}
+	if err := registry.RegisterTool(repoTool("api-tool", "a tool registered directly")); err != nil {
+		t.Fatalf("RegisterTool failed: %v", err)
+	}
+
+	if _, err := registry.GetTool("api-tool"); err != nil {
+		t.Fatalf("Expected every source to be trusted by default, got: %v", err)
+	}
+
+	registry.SetTrustedSources([]string{filepath.Join(dir, "*.json")})
+	if _, err := registry.GetTool("disk-tool"); err != nil {
+		t.Errorf("Expected tool from a trusted directory to be returned, got: %v", err)
+	}
+	_, err := registry.GetTool("api-tool")
+	if err == nil || !strings.Contains(err.Error(), "untrusted source") {
+		t.Errorf("Expected user-provided tool to be rejected, got: %v", err)
+	}
+
+	registry.SetTrustedSources(nil)
+	if _, err := registry.GetTool("api-tool"); err != nil {
+		t.Errorf("Expected clearing the policy to trust every source again, got: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TimeoutExtension is the input schema keyword a tool uses to declare how long,
+// in milliseconds, a call may run. Declaring it in the schema rather than the
+// annotations puts it under the schema fingerprint, so it can't be raised unnoticed.
+const TimeoutExtension = "x-timeout-ms"
+
+// MaxToolTimeout is the longest call timeout a tool may declare
+const MaxToolTimeout = 10 * time.Minute
+
+// declaredTimeout reads the x-timeout-ms keyword from a tool's input schema.
+// ok is false when the tool doesn't declare one.
+func declaredTimeout(tool Tool) (timeout time.Duration, ok bool, err error) {
+	if !hasSchema(tool.InputSchema) {
+		return 0, false, nil
+	}
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(tool.InputSchema, &keywords); err != nil {
+		// not an object; the schema validators report this
+		return 0, false, nil
+	}
+	raw, ok := keywords[TimeoutExtension]
+	if !ok {
+		return 0, false, nil
+	}
+
+	var ms int64
+	if err := json.Unmarshal(raw, &ms); err != nil {
+		return 0, true, fmt.Errorf("%s must be a whole number of milliseconds, got %s", TimeoutExtension, raw)
+	}
+	timeout = time.Duration(ms) * time.Millisecond
+	if ms <= 0 || timeout > MaxToolTimeout {
+		return 0, true, fmt.Errorf("%s must be between 1 and %d, got %d", TimeoutExtension, MaxToolTimeout.Milliseconds(), ms)
+	}
+	return timeout, true, nil
+}
+
+// CallTimeout returns how long a call to the tool may run: the timeout it declares
+// with x-timeout-ms, or fallback if it declares none. Registration rejects tools
+// declaring an invalid timeout, so fallback is also used for any that slip through.
+func (t Tool) CallTimeout(fallback time.Duration) time.Duration {
+	if timeout, ok, err := declaredTimeout(t); ok && err == nil {
+		return timeout
+	}
+	return fallback
+}
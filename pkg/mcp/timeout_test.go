@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallTimeout(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   time.Duration
+	}{
+		{name: "declared", schema: `{"type":"object","x-timeout-ms":1500}`, want: 1500 * time.Millisecond},
+		{name: "undeclared", schema: `{"type":"object"}`, want: 30 * time.Second},
+		{name: "no schema", schema: ``, want: 30 * time.Second},
+		{name: "invalid", schema: `{"type":"object","x-timeout-ms":-1}`, want: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := Tool{Name: "timed-tool", InputSchema: json.RawMessage(tt.schema)}
+			if got := tool.CallTimeout(30 * time.Second); got != tt.want {
+				t.Errorf("CallTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterTool_RejectsInvalidTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout string
+	}{
+		{name: "zero", timeout: `0`},
+		{name: "negative", timeout: `-100`},
+		{name: "over the maximum", timeout: `600001`},
+		{name: "fractional", timeout: `1.5`},
+		{name: "string", timeout: `"5000"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewToolRegistry(true)
+			err := registry.RegisterTool(Tool{
+				Name:        "timed-tool",
+				Description: "Declares a timeout",
+				InputSchema: json.RawMessage(`{"type":"object","x-timeout-ms":` + tt.timeout + `}`),
+			})
+			var verr ToolVerificationError
+			if !errors.As(err, &verr) || verr.Code != ErrInvalidToolDefinition {
+				t.Fatalf("Expected an invalid tool definition error, got: %v", err)
+			}
+			if !strings.Contains(err.Error(), TimeoutExtension) {
+				t.Errorf("Expected the error to name %s, got: %v", TimeoutExtension, err)
+			}
+		})
+	}
+
+	registry := NewToolRegistry(true)
+	if err := registry.RegisterTool(Tool{
+		Name:        "timed-tool",
+		Description: "Declares the longest allowed timeout",
+		InputSchema: json.RawMessage(`{"type":"object","x-timeout-ms":600000}`),
+	}); err != nil {
+		t.Errorf("Expected the maximum timeout to be accepted, got: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// toolCallType is the only kind of tool call and tool definition in the wire format
+const toolCallType = "function"
+
+// ToolCall is a request to run a tool. It is sent in the OpenAI wire format:
+//
+//	{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"location\":\"Paris\"}"}}
+//
+// where the arguments are a JSON-encoded string. When decoding, arguments given as a
+// JSON object are accepted too, as is the MCP tools/call form {"name": ..., "arguments": {...}}.
+type ToolCall struct {
+	ID           string
+	FunctionName string
+	Arguments    json.RawMessage // JSON object of arguments
+}
+
+// toolCallWire is the OpenAI encoding of a ToolCall
+type toolCallWire struct {
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type"`
+	Function toolCallFunction `json:"function"`
+}
+
+type toolCallFunction struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+func (c ToolCall) MarshalJSON() ([]byte, error) {
+	args := c.Arguments
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	// arguments travel as a string holding the JSON object
+	encodedArgs, err := json.Marshal(string(args))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(toolCallWire{
+		ID:       c.ID,
+		Type:     toolCallType,
+		Function: toolCallFunction{Name: c.FunctionName, Arguments: encodedArgs},
+	})
+}
+
+func (c *ToolCall) UnmarshalJSON(data []byte) error {
+	var wire struct {
+		toolCallWire
+		Name      string          `json:"name"`      // MCP tools/call form
+		Arguments json.RawMessage `json:"arguments"` // MCP tools/call form
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+
+	name, args := wire.Function.Name, wire.Function.Arguments
+	if name == "" {
+		name, args = wire.Name, wire.Arguments
+	}
+	if name == "" {
+		return errors.New("tool call is missing the function name")
+	}
+	if wire.Type != "" && wire.Type != toolCallType {
+		return fmt.Errorf("unsupported tool call type '%s'", wire.Type)
+	}
+
+	args, err := decodeToolCallArguments(args)
+	if err != nil {
+		return fmt.Errorf("tool call '%s': %w", name, err)
+	}
+	*c = ToolCall{ID: wire.ID, FunctionName: name, Arguments: args}
+	return nil
+}
+
+// decodeToolCallArguments unwraps arguments sent as a JSON-encoded string, returning the object
+func decodeToolCallArguments(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return json.RawMessage("{}"), nil
+	}
+	if raw[0] == '"' {
+		var encoded string
+		if err := json.Unmarshal(raw, &encoded); err != nil {
+			return nil, err
+		}
+		raw = json.RawMessage(encoded)
+	}
+	if !json.Valid(raw) {
+		return nil, errors.New("arguments are not valid JSON")
+	}
+	return raw, nil
+}
+
+// ToolDefinition advertises a tool to a model in the OpenAI wire format:
+//
+//	{"type": "function", "function": {"name": ..., "description": ..., "parameters": {...}}}
+type ToolDefinition struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition describes a tool's name, purpose, and JSON Schema parameters
+type FunctionDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// NewToolDefinition builds the definition advertising the given tool
+func NewToolDefinition(tool Tool) ToolDefinition {
+	return ToolDefinition{
+		Type: toolCallType,
+		Function: FunctionDefinition{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  tool.InputSchema,
+		},
+	}
+}
@@ -0,0 +1,126 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestToolCall_UnmarshalOpenAI(t *testing.T) {
+	// as returned in an OpenAI chat completion's message.tool_calls
+	data := []byte(`{
+		"id": "call_abc123",
+		"type": "function",
+		"function": {
+			"name": "get_weather",
+			"arguments": "{\"location\":\"Paris\",\"unit\":\"celsius\"}"
+		}
+	}`)
+
+	var call ToolCall
+	if err := json.Unmarshal(data, &call); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if call.ID != "call_abc123" || call.FunctionName != "get_weather" {
+		t.Errorf("ToolCall = %+v, want id call_abc123 calling get_weather", call)
+	}
+	if string(call.Arguments) != `{"location":"Paris","unit":"celsius"}` {
+		t.Errorf("Arguments = %s, want the decoded argument object", call.Arguments)
+	}
+}
+
+func TestToolCall_UnmarshalAlternateForms(t *testing.T) {
+	tests := map[string]string{
+		"arguments as object": `{"type":"function","function":{"name":"get_weather","arguments":{"location":"Paris"}}}`,
+		"mcp tools/call":      `{"name":"get_weather","arguments":{"location":"Paris"}}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var call ToolCall
+			if err := json.Unmarshal([]byte(data), &call); err != nil {
+				t.Fatalf("Unmarshal() error: %v", err)
+			}
+			if call.FunctionName != "get_weather" || string(call.Arguments) != `{"location":"Paris"}` {
+				t.Errorf("ToolCall = %+v, want get_weather with location Paris", call)
+			}
+		})
+	}
+}
+
+func TestToolCall_UnmarshalErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing name":        `{"type":"function","function":{"arguments":"{}"}}`,
+		"unsupported type":    `{"type":"retrieval","function":{"name":"get_weather"}}`,
+		"malformed arguments": `{"type":"function","function":{"name":"get_weather","arguments":"{not json"}}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var call ToolCall
+			if err := json.Unmarshal([]byte(data), &call); err == nil {
+				t.Errorf("Unmarshal() = %+v, want error", call)
+			}
+		})
+	}
+}
+
+func TestToolCall_RoundTrip(t *testing.T) {
+	original := ToolCall{
+		ID:           "call_1",
+		FunctionName: "get_weather",
+		Arguments:    json.RawMessage(`{"location":"Paris"}`),
+	}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	want := `{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"location\":\"Paris\"}"}}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var decoded ToolCall
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if decoded.ID != original.ID || decoded.FunctionName != original.FunctionName ||
+		!bytes.Equal(decoded.Arguments, original.Arguments) {
+		t.Errorf("round trip = %+v, want %+v", decoded, original)
+	}
+}
+
+func TestMessage_RoundTripToolCalls(t *testing.T) {
+	msg := Message{
+		Role:      RoleAssistant,
+		ToolCalls: []ToolCall{{ID: "call_1", FunctionName: "get_weather", Arguments: json.RawMessage(`{}`)}},
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if decoded.Role != RoleAssistant || len(decoded.ToolCalls) != 1 || decoded.ToolCalls[0].FunctionName != "get_weather" {
+		t.Errorf("round trip = %+v, want an assistant message calling get_weather", decoded)
+	}
+}
+
+func TestNewToolDefinition(t *testing.T) {
+	tool := Tool{
+		Name:        "get_weather",
+		Description: "Gets the weather",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+	}
+
+	data, err := json.Marshal(NewToolDefinition(tool))
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	want := `{"type":"function","function":{"name":"get_weather","description":"Gets the weather","parameters":{"type":"object"}}}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+}
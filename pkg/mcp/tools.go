@@ -1,30 +1,43 @@
 package mcp
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"slices"
 	"sort"
+	"sync"
 	"time"
+
+	"github.com/null-create/mcp-tls/pkg/util"
+
+	"github.com/xeipuuv/gojsonschema"
 )
 
 // SecurityMetadata contains information used to verify the trust and integrity of components.
 type SecurityMetadata struct {
-	Source      string `json:"source,omitempty"`        // Origin of the data (e.g., "trusted-registry", "user-provided", "api-endpoint-v2")
-	Signature   string `json:"signature,omitempty"`     // Cryptographic signature to verify authenticity/integrity (e.g., JWT, HMAC-SHA256)
-	PublicKeyID string `json:"public_key_id,omitempty"` // Identifier for the key needed to verify the signature
-	Version     string `json:"version,omitempty"`       // Version identifier for the tool description or other signed component
-	Checksum    string `json:"checksum,omitempty"`      // Hash of the component itself (e.g., hash of the ToolDescription structure)
+	Source          string `json:"source,omitempty"`           // Origin of the data (e.g., "trusted-registry", "user-provided", "api-endpoint-v2")
+	Signature       string `json:"signature,omitempty"`        // Cryptographic signature to verify authenticity/integrity (e.g., JWT, HMAC-SHA256)
+	OutputSignature string `json:"output_signature,omitempty"` // Fingerprint of the tool's output schema, if it declares one
+	PublicKeyID     string `json:"public_key_id,omitempty"`    // Identifier for the key needed to verify the signature
+	Version         string `json:"version,omitempty"`          // Version identifier for the tool description or other signed component
+	Checksum        string `json:"checksum,omitempty"`         // Hash of the component itself (e.g., hash of the ToolDescription structure)
+
+	PublisherSignature string `json:"publisher_signature,omitempty"` // Ed25519 signature by the key PublicKeyID names, see SignToolFile
 }
 
 func (s *SecurityMetadata) IsEmpty() bool {
 	return s.Source == "" && s.Signature == "" &&
-		s.PublicKeyID == "" && s.Version == "" &&
-		s.Checksum == ""
+		s.OutputSignature == "" && s.PublicKeyID == "" &&
+		s.Version == "" && s.Checksum == "" && s.PublisherSignature == ""
 }
 
 // ToolOption is a function that configures a Tool.
@@ -48,8 +61,17 @@ type Tool struct {
 	OutputSchema     json.RawMessage  `json:"outputSchema"`
 	Annotations      ToolAnnotation   `json:"annotations"`
 	SecurityMetadata SecurityMetadata `json:"secMetaData"`
+
+	Deprecated         bool   `json:"deprecated,omitempty"`         // still usable, but callers are warned; see SetDeprecationCutoff
+	DeprecationMessage string `json:"deprecationMessage,omitempty"` // what to use instead, shown in the warning
 }
 
+// Hash algorithm identifiers advertised in a ToolSet
+const (
+	SchemaFingerprintAlgo = "SHA-256"
+	ChecksumAlgo          = "SHA-256"
+)
+
 // ToolSet represents a collection of tools with security information
 type ToolSet struct {
 	Tools                 []Tool `json:"tools"`
@@ -69,6 +91,8 @@ type ToolAnnotation struct {
 	IdempotentHint bool `json:"idempotentHint,omitempty"`
 	// If true, tool interacts with external entities
 	OpenWorldHint bool `json:"openWorldHint,omitempty"`
+
+	declared annotationHints // hints set explicitly, even to false; see Declares
 }
 
 // NewTool creates a new Tool with the given name and options.
@@ -124,41 +148,71 @@ type ToolValidationResult struct {
 	Checksum string `json:"checksum,omitempty"`
 	Valid    bool   `json:"valid"`
 	Error    string `json:"error,omitempty"`
+	Warning  string `json:"warning,omitempty"` // e.g. that the tool is deprecated
 }
 
 // ToolRegistry maintains the set of trusted tools and schemas
 // used for validation
 type ToolRegistry struct {
-	toolRepo            string // URL to exteral repository of trusted tools
-	apiKey              string // API key to trust tool repo
+	repos               []*toolRepo    // external repositories of trusted tools, in load order
+	conflictPolicy      ConflictPolicy // how LoadTools merges same-named tools from different repos
+	mu                  sync.RWMutex
 	tools               map[string]Tool
 	securityEnabled     bool
 	validateChecksums   bool
 	rejectUnsignedTools bool
+	dirTools            map[string]map[string]string // by directory and tool name, the file each tool was loaded from
+	listeners           []func()                     // called when the tool list changes
+	trustedSources      []string                     // patterns of the sources GetTool accepts tools from; empty trusts all
+	deprecationCutoff   time.Time                    // when set, GetTool rejects deprecated tools from then on
+	quarantined         map[string]QuarantinedTool   // tools withdrawn from use, kept for inspection
+	pending             map[string]Tool              // remote tools fetched by LoadToolsPreview awaiting CommitLoad
+	requiredAnnotations []Annotation                 // annotations RegisterTool requires tools to declare
+	publisherKeys       map[string]ed25519.PublicKey // by KeyID, the publishers tools must be signed by; nil requires none
 }
 
+// Circuit breaker settings for each remote tool repo
+const (
+	repoBreakerThreshold = 5
+	repoBreakerCooldown  = 30 * time.Second
+)
+
 // NewToolRegistry creates a new tool registry
 func NewToolRegistry(securityEnabled bool) *ToolRegistry {
 	return &ToolRegistry{
 		tools:           make(map[string]Tool),
 		securityEnabled: securityEnabled,
+		conflictPolicy:  ConflictFirstWins,
+		dirTools:        make(map[string]map[string]string),
+		quarantined:     make(map[string]QuarantinedTool),
 	}
 }
 
-// Configure the remote tool repo credentials
+// Configure the remote tool repo credentials. This sets the repo named DefaultRepoName;
+// use AddRepo to load from several repos.
 func (tr *ToolRegistry) SetRegistryCreds(url, apiKey string) {
-	tr.toolRepo = url
-	tr.apiKey = apiKey
+	tr.AddRepo(DefaultRepoName, url, apiKey)
 }
 
 // SetSecurityOptions configures the security options for the tool registry
 func (tr *ToolRegistry) SetSecurityOptions(validateChecksums, rejectUnsignedTools bool) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
 	tr.validateChecksums = validateChecksums
 	tr.rejectUnsignedTools = rejectUnsignedTools
 }
 
 // RegisterTool adds a tool to the registry with security checks
 func (tr *ToolRegistry) RegisterTool(tool Tool) error {
+	if err := validateToolDefinition(tool); err != nil {
+		return err
+	}
+	if err := tr.checkAnnotations(tool); err != nil {
+		return err
+	}
+	if err := tr.checkPublisher(tool); err != nil {
+		return err
+	}
 	if tr.securityEnabled {
 		if tool.SecurityMetadata.Checksum == "" {
 			checksum, err := generateToolChecksum(tool)
@@ -175,6 +229,23 @@ func (tr *ToolRegistry) RegisterTool(tool Tool) error {
 			}
 			tool.SecurityMetadata.Signature = fingerprint
 		}
+
+		if tool.SecurityMetadata.OutputSignature == "" {
+			outputFingerprint, err := generateOutputFingerprint(tool.OutputSchema)
+			if err != nil {
+				return err
+			}
+			tool.SecurityMetadata.OutputSignature = outputFingerprint
+		}
+	}
+	if tool.SecurityMetadata.Source == "" {
+		tool.SecurityMetadata.Source = SourceUserProvided
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if _, quarantined := tr.quarantined[tool.Name]; quarantined {
+		// a replacement would bury the quarantined copy, so it has to be released first
+		return fmt.Errorf("tool '%s': %w", tool.Name, ErrToolQuarantined)
 	}
 	if _, ok := tr.tools[tool.Name]; !ok {
 		tr.tools[tool.Name] = tool
@@ -184,44 +255,104 @@ func (tr *ToolRegistry) RegisterTool(tool Tool) error {
 
 // GetTool retrieves a tool from the registry with security validation
 func (tr *ToolRegistry) GetTool(name string) (Tool, error) {
+	if tr.isQuarantined(name) {
+		return Tool{}, fmt.Errorf("tool '%s': %w", name, ErrToolQuarantined)
+	}
+	tr.mu.RLock()
 	tool, exists := tr.tools[name]
+	validateChecksums, rejectUnsigned := tr.validateChecksums, tr.rejectUnsignedTools
+	tr.mu.RUnlock()
 	if !exists {
-		return Tool{}, fmt.Errorf("tool '%s' not found", name)
+		return Tool{}, fmt.Errorf("tool '%s' %w", name, ErrToolUnregistered)
 	}
 
-	if tr.securityEnabled && tr.validateChecksums {
-		expectedChecksum, err := generateToolChecksum(tool)
-		if err != nil {
-			return Tool{}, fmt.Errorf("failed to generate expected checksum: %v", err)
+	if tr.securityEnabled && validateChecksums {
+		if err := verifyToolMetadata(tool); err != nil {
+			return Tool{}, err
 		}
+	}
 
-		if expectedChecksum != tool.SecurityMetadata.Checksum {
-			return Tool{}, errors.New("tool checksum validation failed")
-		}
+	if tr.securityEnabled && rejectUnsigned && (tool.SecurityMetadata.Checksum == "" || tool.SecurityMetadata.Signature == "") {
+		return Tool{}, errors.New("unsigned tool rejected")
+	}
+
+	if !tr.trustsSource(tool.SecurityMetadata.Source) {
+		return Tool{}, fmt.Errorf("tool '%s' from untrusted source '%s' rejected", name, tool.SecurityMetadata.Source)
+	}
 
-		expectedSignature, err := generateSchemaFingerprint(tool.InputSchema)
+	if err := tr.checkDeprecation(tool); err != nil {
+		return Tool{}, err
+	}
+
+	return tool, nil
+}
+
+// VerifyTool re-checks a registered tool's checksum and schema fingerprints against its
+// definition. Unlike GetTool this always runs the checks when security is enabled,
+// regardless of the validateChecksums option.
+func (tr *ToolRegistry) VerifyTool(name string) error {
+	if tr.isQuarantined(name) {
+		return fmt.Errorf("tool '%s': %w", name, ErrToolQuarantined)
+	}
+	tr.mu.RLock()
+	tool, exists := tr.tools[name]
+	tr.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("tool '%s' %w", name, ErrToolUnregistered)
+	}
+	if !tr.securityEnabled {
+		return nil
+	}
+	return verifyToolMetadata(tool)
+}
+
+// Recompute regenerates the checksum and schema fingerprints of every registered tool
+// from its current definition and reports how many tools' metadata changed. It is the
+// migration step after a checksum or fingerprint algorithm change, when the stored
+// metadata no longer matches what verification computes. If any tool fails to hash,
+// no tool is updated.
+func (tr *ToolRegistry) Recompute() (int, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	updated := make(map[string]Tool)
+	for name, tool := range tr.tools {
+		metadata := tool.SecurityMetadata
+		checksum, err := generateToolChecksum(tool)
 		if err != nil {
-			return Tool{}, fmt.Errorf("failed to generate expected signature: %v", err)
+			return 0, fmt.Errorf("tool '%s': failed to generate checksum: %w", name, err)
 		}
+		metadata.Checksum = checksum
 
-		if expectedSignature != tool.SecurityMetadata.Signature {
-			return Tool{}, errors.New("schema fingerprint validation failed")
+		if metadata.Signature, err = generateSchemaFingerprint(tool.InputSchema); err != nil {
+			return 0, fmt.Errorf("tool '%s': failed to generate schema fingerprint: %w", name, err)
+		}
+		if metadata.OutputSignature, err = generateOutputFingerprint(tool.OutputSchema); err != nil {
+			return 0, fmt.Errorf("tool '%s': failed to generate output schema fingerprint: %w", name, err)
 		}
-	}
 
-	if tr.securityEnabled && tr.rejectUnsignedTools && (tool.SecurityMetadata.Checksum == "" || tool.SecurityMetadata.Signature == "") {
-		return Tool{}, errors.New("unsigned tool rejected")
+		if metadata != tool.SecurityMetadata {
+			tool.SecurityMetadata = metadata
+			updated[name] = tool
+		}
 	}
-
-	return tool, nil
+	for name, tool := range updated {
+		tr.tools[name] = tool
+	}
+	return len(updated), nil
 }
 
-// ListTools returns all registered tools
-func (tr *ToolRegistry) ListTools() ToolSet {
+// ListTools returns all registered tools, or if any sources are given,
+// only the tools whose SecurityMetadata.Source matches one of them
+func (tr *ToolRegistry) ListTools(sources ...string) ToolSet {
+	tr.mu.RLock()
 	tools := make([]Tool, 0, len(tr.tools))
 	for _, tool := range tr.tools {
-		tools = append(tools, tool)
+		if len(sources) == 0 || slices.Contains(sources, tool.SecurityMetadata.Source) {
+			tools = append(tools, tool)
+		}
 	}
+	tr.mu.RUnlock()
 
 	// Sort tools by name for consistent ordering
 	sort.Slice(tools, func(i, j int) bool {
@@ -231,49 +362,153 @@ func (tr *ToolRegistry) ListTools() ToolSet {
 	return ToolSet{
 		Tools:                 tools,
 		SecurityEnabled:       tr.securityEnabled,
-		SchemaFingerprintAlgo: "SHA-256",
-		ChecksumAlgo:          "SHA-256",
+		SchemaFingerprintAlgo: SchemaFingerprintAlgo,
+		ChecksumAlgo:          ChecksumAlgo,
 	}
 }
 
-// LoadTools retrieves all trusted tool schema definitions
-// into the internal map. These definitions are not exported anywhere
-// since the validator is intended to be stateless.
-func (tr *ToolRegistry) LoadTools() error {
-	if tr.apiKey == "" || tr.toolRepo == "" {
-		return fmt.Errorf("missing tool repo credentials")
+// ImportToolSet registers every tool of an exported ToolSet after verifying
+// that each tool's checksum and schema fingerprint match its definition.
+// Nothing is imported if any tool fails verification.
+func (tr *ToolRegistry) ImportToolSet(set ToolSet) error {
+	if set.SchemaFingerprintAlgo != SchemaFingerprintAlgo || set.ChecksumAlgo != ChecksumAlgo {
+		return fmt.Errorf(
+			"unsupported toolset algorithms: fingerprint=%s checksum=%s",
+			set.SchemaFingerprintAlgo, set.ChecksumAlgo,
+		)
 	}
 
-	// API call to get list of trusted tool schemas
-	client := http.Client{Timeout: time.Second * 3}
+	for _, tool := range set.Tools {
+		if err := verifyToolMetadata(tool); err != nil {
+			return fmt.Errorf("tool '%s' failed verification: %w", tool.Name, err)
+		}
+		// checked up front so a bad tool doesn't leave the set half imported
+		if err := validateToolDefinition(tool); err != nil {
+			return err
+		}
+		if err := tr.checkAnnotations(tool); err != nil {
+			return err
+		}
+		if err := tr.checkPublisher(tool); err != nil {
+			return err
+		}
+	}
+	for _, tool := range set.Tools {
+		if err := tr.RegisterTool(tool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	req, err := http.NewRequest(http.MethodGet, tr.toolRepo, nil)
+// LoadTools retrieves all trusted tool schema definitions from every configured
+// repo into the internal map, merging them according to the conflict policy.
+// Tools that fail their checks are left out with a warning.
+// These definitions are not exported anywhere since the validator is intended to be stateless.
+func (tr *ToolRegistry) LoadTools() error {
+	tools, rejected, err := tr.fetchRemoteTools()
 	if err != nil {
 		return err
 	}
-	resp, err := client.Do(req)
+	for _, err := range sortedRejections(rejected) {
+		log.Printf("WARNING %v", err)
+	}
+	tr.replaceTools(tools)
+	return nil
+}
+
+// LoadToolsPreview fetches and verifies the remote tool set like LoadTools, but instead of
+// replacing the registry it returns what would change. The fetched set is held until
+// CommitLoad applies it, or another preview replaces it.
+func (tr *ToolRegistry) LoadToolsPreview() (ToolSetDiff, error) {
+	tools, rejected, err := tr.fetchRemoteTools()
 	if err != nil {
-		return err
+		return ToolSetDiff{}, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("received non-200 status: %d", resp.StatusCode)
+	// unlike LoadTools, which drops them, a set under review with bad tools is refused
+	if len(rejected) > 0 {
+		return ToolSetDiff{}, errors.Join(sortedRejections(rejected)...)
 	}
 
-	// parse results into mcp.Tool objects and add to internal map
-	var tools map[string]Tool
-	if err = json.NewDecoder(resp.Body).Decode(&tools); err != nil {
-		return err
+	incoming := make([]Tool, 0, len(tools))
+	for _, tool := range tools {
+		incoming = append(incoming, tool)
 	}
+	diff := DiffToolSets(tr.ListTools(), ToolSet{Tools: incoming})
 
-	tr.tools = tools
+	tr.mu.Lock()
+	tr.pending = tools
+	tr.mu.Unlock()
+	return diff, nil
+}
+
+// CommitLoad replaces the registry with the tool set fetched by the last LoadToolsPreview.
+func (tr *ToolRegistry) CommitLoad() error {
+	tr.mu.Lock()
+	tools := tr.pending
+	tr.pending = nil
+	tr.mu.Unlock()
 
+	if tools == nil {
+		return errors.New("no previewed tool set to commit")
+	}
+	tr.replaceTools(tools)
 	return nil
 }
 
-// canonicalizeJson converts a JSON object to a canonical form for consistent hashing
+// replaceTools swaps in a new set of tools, forgetting any directory loads
+func (tr *ToolRegistry) replaceTools(tools map[string]Tool) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.tools = tools
+	tr.dirTools = make(map[string]map[string]string)
+}
+
+// fetchTools makes a single request for the repo's trusted tools. Errors that
+// another attempt cannot fix are marked permanent.
+func (repo *toolRepo) fetchTools() (map[string]json.RawMessage, error) {
+	client := http.Client{Timeout: time.Second * 3}
+
+	req, err := http.NewRequest(http.MethodGet, repo.url, nil)
+	if err != nil {
+		return nil, util.Permanent(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("received non-200 status: %d", resp.StatusCode)
+		if resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
+			return nil, util.Permanent(err)
+		}
+		return nil, err
+	}
+
+	// the response must be a single JSON object of tool definitions keyed by name;
+	// the definitions themselves are checked one by one by the registry
+	var entries map[string]json.RawMessage
+	dec := json.NewDecoder(resp.Body)
+	if err = dec.Decode(&entries); err != nil {
+		return nil, util.Permanent(fmt.Errorf("invalid tool repo response: %w", err))
+	}
+	if entries == nil {
+		return nil, util.Permanent(errors.New("invalid tool repo response: expected an object of tools, got null"))
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, util.Permanent(errors.New("invalid tool repo response: unexpected data after the tools"))
+	}
+	return entries, nil
+}
+
+// canonicalizeJson converts a JSON object to a canonical form for consistent hashing.
+// Numbers are kept exactly as written unless SetNumberCanonicalization selects
+// NumbersFloat64, so integers beyond float64's 53 bits of precision hash correctly.
 func canonicalizeJson(data json.RawMessage) (json.RawMessage, error) {
-	var obj any
-	if err := json.Unmarshal(data, &obj); err != nil {
+	obj, err := decodeForCanonicalization(data)
+	if err != nil {
 		return nil, err
 	}
 
@@ -297,12 +532,54 @@ func generateSchemaFingerprint(schema json.RawMessage) (string, error) {
 	return hex.EncodeToString(hash[:]), nil
 }
 
+// hasSchema reports whether a raw schema is present, treating an explicit
+// JSON null the same as an absent schema.
+func hasSchema(schema json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(schema)
+	return len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null"))
+}
+
+// validateToolDefinition checks that a tool's output schema, if it declares one, is a
+// valid JSON Schema, so a broken schema is reported when the tool is registered rather
+// than when its output is first validated. A declared call timeout must be in range.
+func validateToolDefinition(tool Tool) error {
+	if _, _, err := declaredTimeout(tool); err != nil {
+		return ToolVerificationError{
+			Message: fmt.Sprintf("tool '%s' has an invalid timeout: %v", tool.Name, err),
+			Code:    ErrInvalidToolDefinition,
+		}
+	}
+	if !hasSchema(tool.OutputSchema) {
+		return nil
+	}
+	if _, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(tool.OutputSchema)); err != nil {
+		return ToolVerificationError{
+			Message: fmt.Sprintf("tool '%s' has an invalid output schema: %v", tool.Name, err),
+			Code:    ErrInvalidToolDefinition,
+		}
+	}
+	return nil
+}
+
+// generateOutputFingerprint creates a fingerprint of an optional output schema.
+// Tools without an output schema have an empty fingerprint.
+func generateOutputFingerprint(schema json.RawMessage) (string, error) {
+	if !hasSchema(schema) {
+		return "", nil
+	}
+	return generateSchemaFingerprint(schema)
+}
+
 // generateToolChecksum creates a checksum of the entire tool definition using SHA-256
 func generateToolChecksum(tool Tool) (string, error) {
 	toolCopy := Tool{
-		Name:        tool.Name,
-		Description: tool.Description,
-		InputSchema: tool.InputSchema,
+		Name:               tool.Name,
+		Description:        tool.Description,
+		InputSchema:        tool.InputSchema,
+		Deprecated:         tool.Deprecated,
+		DeprecationMessage: tool.DeprecationMessage,
+		// the hints pick the tool's rate limit and are checked against required annotations
+		Annotations: tool.Annotations,
 	}
 
 	data, err := json.Marshal(toolCopy)
@@ -320,6 +597,35 @@ func generateToolChecksum(tool Tool) (string, error) {
 	return hex.EncodeToString(hash[:]), nil
 }
 
+// verifyToolMetadata recomputes a tool's checksum and schema fingerprint
+// and compares them against its security metadata.
+func verifyToolMetadata(tool Tool) error {
+	expectedChecksum, err := generateToolChecksum(tool)
+	if err != nil {
+		return fmt.Errorf("failed to generate expected checksum: %v", err)
+	}
+	if expectedChecksum != tool.SecurityMetadata.Checksum {
+		return errors.New("tool checksum validation failed")
+	}
+
+	expectedSignature, err := generateSchemaFingerprint(tool.InputSchema)
+	if err != nil {
+		return fmt.Errorf("failed to generate expected signature: %v", err)
+	}
+	if expectedSignature != tool.SecurityMetadata.Signature {
+		return errors.New("schema fingerprint validation failed")
+	}
+
+	expectedOutputSignature, err := generateOutputFingerprint(tool.OutputSchema)
+	if err != nil {
+		return fmt.Errorf("failed to generate expected output signature: %v", err)
+	}
+	if expectedOutputSignature != tool.SecurityMetadata.OutputSignature {
+		return errors.New("output schema fingerprint validation failed")
+	}
+	return nil
+}
+
 // ToolVerificationError represents an error during tool verification
 type ToolVerificationError struct {
 	Message string
@@ -367,21 +673,19 @@ func NewToolManager(name, version string, securityEnabled bool) *ToolManager {
 	}
 }
 
-// HandleInitialize processes an initialize request
-func (s *ToolManager) HandleInitialize(params InitializeParams) InitializeResult {
-	// Configure security settings based on client capabilities
-	if params.Capabilities.Tools != nil && params.Capabilities.Tools.Security != nil {
-		s.toolRegistry.SetSecurityOptions(
-			params.Capabilities.Tools.Security.ChecksumValidation,
-			params.Capabilities.Tools.Security.SchemaFingerprint,
-		)
+// HandleInitialize processes an initialize request, negotiating the protocol version with the
+// client. The security capabilities a client declares leave the registry's options as configured.
+func (s *ToolManager) HandleInitialize(params InitializeParams) (InitializeResult, error) {
+	version, err := NegotiateVersion(params.ProtocolVersion)
+	if err != nil {
+		return InitializeResult{}, err
 	}
 
 	return InitializeResult{
-		ProtocolVersion: Version,
+		ProtocolVersion: version,
 		Capabilities:    s.capabilities,
 		ServerInfo:      s.serverInfo,
-	}
+	}, nil
 }
 
 // RegisterTool adds a tool to the server's registry
@@ -389,14 +693,90 @@ func (t *ToolManager) RegisterTool(tool Tool) error {
 	return t.toolRegistry.RegisterTool(tool)
 }
 
+// SetRequiredAnnotations sets the annotations the server's registry requires registered tools to declare
+func (t *ToolManager) SetRequiredAnnotations(required []Annotation) {
+	t.toolRegistry.SetRequiredAnnotations(required)
+}
+
 // GetTool retrieves a tool from the server's registry
 func (t *ToolManager) GetTool(name string) (Tool, error) {
 	return t.toolRegistry.GetTool(name)
 }
 
-// ListTools returns all tools registered with the server
-func (t *ToolManager) ListTools() ToolSet {
-	return t.toolRegistry.ListTools()
+// VerifyTool re-checks the integrity of a tool in the server's registry
+func (t *ToolManager) VerifyTool(name string) error {
+	return t.toolRegistry.VerifyTool(name)
+}
+
+// Recompute regenerates the security metadata of every tool in the server's registry
+func (t *ToolManager) Recompute() (int, error) {
+	return t.toolRegistry.Recompute()
+}
+
+// AddRepo adds a trusted tool repo for LoadTools to fetch from
+func (t *ToolManager) AddRepo(name, url, apiKey string) {
+	t.toolRegistry.AddRepo(name, url, apiKey)
+}
+
+// SetConflictPolicy sets how LoadTools merges same-named tools from different repos
+func (t *ToolManager) SetConflictPolicy(policy ConflictPolicy) {
+	t.toolRegistry.SetConflictPolicy(policy)
+}
+
+// ListTools returns the tools registered with the server, optionally only those from the given sources
+func (t *ToolManager) ListTools(sources ...string) ToolSet {
+	return t.toolRegistry.ListTools(sources...)
+}
+
+// ListToolsPage returns a page of the tools in the server's registry for tools/list
+func (t *ToolManager) ListToolsPage(cursor string, pageSize int) (ListToolsResult, error) {
+	return t.toolRegistry.ListToolsPage(cursor, pageSize)
+}
+
+// SchemaCoverage reports which tools in the server's registry lack effective input or output schemas
+func (t *ToolManager) SchemaCoverage() SchemaCoverageReport {
+	return t.toolRegistry.SchemaCoverage()
+}
+
+// Quarantine withdraws a tool in the server's registry from use, keeping it for inspection
+func (t *ToolManager) Quarantine(name, reason string) error {
+	return t.toolRegistry.Quarantine(name, reason)
+}
+
+// Release returns a quarantined tool to the server's registry
+func (t *ToolManager) Release(name string) error {
+	return t.toolRegistry.Release(name)
+}
+
+// GetQuarantined returns a quarantined tool and why it was quarantined
+func (t *ToolManager) GetQuarantined(name string) (QuarantinedTool, bool) {
+	return t.toolRegistry.GetQuarantined(name)
+}
+
+// ListQuarantined returns every quarantined tool
+func (t *ToolManager) ListQuarantined() []QuarantinedTool {
+	return t.toolRegistry.ListQuarantined()
+}
+
+// SetTrustedSources restricts GetTool to tools from sources matching the given patterns
+func (t *ToolManager) SetTrustedSources(patterns []string) {
+	t.toolRegistry.SetTrustedSources(patterns)
+}
+
+// SetPublisherKeys requires the server's registry to only accept tools signed by one of the given publisher keys
+func (t *ToolManager) SetPublisherKeys(keys []ed25519.PublicKey) {
+	t.toolRegistry.SetPublisherKeys(keys)
+}
+
+// SetDeprecationCutoff rejects deprecated tools in the server's registry from the given time on
+func (t *ToolManager) SetDeprecationCutoff(cutoff time.Time) {
+	t.toolRegistry.SetDeprecationCutoff(cutoff)
+}
+
+// SetSecurityOptions sets whether the server's registry re-verifies tool checksums on
+// lookup and rejects unsigned tools
+func (t *ToolManager) SetSecurityOptions(validateChecksums, rejectUnsignedTools bool) {
+	t.toolRegistry.SetSecurityOptions(validateChecksums, rejectUnsignedTools)
 }
 
 // LoadTools retrieves all trusted tools from an external API
@@ -404,6 +784,41 @@ func (t *ToolManager) LoadTools() error {
 	return t.toolRegistry.LoadTools()
 }
 
+// ToolRepoState reports whether calls to the remote tool repo are currently allowed
+func (t *ToolManager) ToolRepoState() util.BreakerState {
+	return t.toolRegistry.RepoState()
+}
+
+// LoadToolsPreview reports how LoadTools would change the registry without applying it
+func (t *ToolManager) LoadToolsPreview() (ToolSetDiff, error) {
+	return t.toolRegistry.LoadToolsPreview()
+}
+
+// CommitLoad applies the tool set fetched by the last LoadToolsPreview
+func (t *ToolManager) CommitLoad() error {
+	return t.toolRegistry.CommitLoad()
+}
+
+// LoadFromDir registers the tool definition files in a local directory
+func (t *ToolManager) LoadFromDir(dir string) error {
+	return t.toolRegistry.LoadFromDir(dir)
+}
+
+// WatchDir keeps the registry in sync with a local directory of tool definition files
+func (t *ToolManager) WatchDir(ctx context.Context, dir string) error {
+	return t.toolRegistry.WatchDir(ctx, dir)
+}
+
+// OnListChanged registers a callback run whenever the registered tools change
+func (t *ToolManager) OnListChanged(fn func()) {
+	t.toolRegistry.OnListChanged(fn)
+}
+
+// ImportToolSet verifies and registers all tools from an exported ToolSet
+func (t *ToolManager) ImportToolSet(set ToolSet) error {
+	return t.toolRegistry.ImportToolSet(set)
+}
+
 // GetTools returns all tools available from the internal tool registry
 func (t *ToolManager) GetTools() []Tool {
 	return t.toolRegistry.ListTools().Tools
@@ -419,6 +834,16 @@ func (t *ToolManager) SchemaFingerprint(tool *Tool) error {
 	return nil
 }
 
+// OutputSchemaFingerprint generates a hash for a given tools output schema
+func (t *ToolManager) OutputSchemaFingerprint(tool *Tool) error {
+	fingerPrint, err := generateOutputFingerprint(tool.OutputSchema)
+	if err != nil {
+		return err
+	}
+	tool.SecurityMetadata.OutputSignature = fingerPrint
+	return nil
+}
+
 // ToolChecksum creates a checksum of the entire tool definition using SHA-256
 func (t *ToolManager) ToolChecksum(tool *Tool) error {
 	checkSum, err := generateToolChecksum(*tool)
@@ -437,6 +862,12 @@ func SecureTool(tool *Tool) error {
 		return err
 	}
 
+	// Generate fingerprint from output schema, if any
+	outputFingerprint, err := generateOutputFingerprint(tool.OutputSchema)
+	if err != nil {
+		return err
+	}
+
 	// Generate checksum from parameters schema
 	checksum, err := generateToolChecksum(*tool)
 	if err != nil {
@@ -445,8 +876,9 @@ func SecureTool(tool *Tool) error {
 
 	// Set security metadata
 	tool.SecurityMetadata = SecurityMetadata{
-		Signature: fingerprint,
-		Checksum:  checksum,
+		Signature:       fingerprint,
+		OutputSignature: outputFingerprint,
+		Checksum:        checksum,
 	}
 
 	return nil
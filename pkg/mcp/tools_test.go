@@ -2,7 +2,14 @@ package mcp
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/util"
 )
 
 func TestToolRegistry(t *testing.T) {
@@ -158,6 +165,52 @@ func TestCanonicalJson(t *testing.T) {
 	}
 }
 
+func TestCanonicalJson_LargeIntegers(t *testing.T) {
+	schema := json.RawMessage(`{"type": "integer", "const": 9007199254740993}`)
+	neighbour := json.RawMessage(`{"type": "integer", "const": 9007199254740992}`)
+
+	canonical, err := canonicalizeJson(schema)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize JSON: %v", err)
+	}
+	if !strings.Contains(string(canonical), "9007199254740993") {
+		t.Errorf("Canonical form lost integer precision: %s", canonical)
+	}
+	fingerprint, _ := generateSchemaFingerprint(schema)
+	again, _ := generateSchemaFingerprint(schema)
+	other, _ := generateSchemaFingerprint(neighbour)
+	if fingerprint != again {
+		t.Errorf("Fingerprint is not stable: %s vs %s", fingerprint, again)
+	}
+	if fingerprint == other {
+		t.Error("Integers differing beyond float64 precision share a fingerprint")
+	}
+
+	if _, err := canonicalizeJson(json.RawMessage(`{"a": 1} {"b": 2}`)); err == nil {
+		t.Error("Expected an error for data after the document")
+	}
+
+	// the legacy mode rounds through float64, as earlier checksums were computed
+	SetNumberCanonicalization(NumbersFloat64)
+	t.Cleanup(func() { SetNumberCanonicalization(NumbersExact) })
+	legacy, _ := generateSchemaFingerprint(schema)
+	legacyOther, _ := generateSchemaFingerprint(neighbour)
+	if legacy != legacyOther {
+		t.Error("Expected float64 canonicalization to round both integers to the same value")
+	}
+}
+
+func TestParseNumberCanonicalization(t *testing.T) {
+	for input, want := range map[string]NumberCanonicalization{"": NumbersExact, "exact": NumbersExact, "float64": NumbersFloat64} {
+		if got, err := ParseNumberCanonicalization(input); got != want || err != nil {
+			t.Errorf("ParseNumberCanonicalization(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+	if got, err := ParseNumberCanonicalization("decimal"); got != NumbersExact || err == nil {
+		t.Errorf("Expected an error and the default for an unknown mode, got %v, %v", got, err)
+	}
+}
+
 func TestSchemaModification(t *testing.T) {
 	// Create a tool registry with security enabled
 	registry := NewToolRegistry(true)
@@ -268,7 +321,10 @@ func TestToolManagerInitialization(t *testing.T) {
 	}
 
 	// Initialize the server
-	result := manager.HandleInitialize(params)
+	result, err := manager.HandleInitialize(params)
+	if err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
 
 	// Verify the result
 	if result.ProtocolVersion != Version {
@@ -312,3 +368,417 @@ func TestUnsignedToolRejection(t *testing.T) {
 		t.Error("Expected unsigned tool to be rejected, but it was accepted")
 	}
 }
+
+func TestOutputSchemaTampering(t *testing.T) {
+	registry := NewToolRegistry(true)
+	registry.SetSecurityOptions(true, true)
+
+	tool := Tool{
+		Name:         "output-tool",
+		Description:  "A tool with an output schema",
+		InputSchema:  json.RawMessage(`{"type": "object"}`),
+		OutputSchema: json.RawMessage(`{"type": "object", "properties": {"result": {"type": "string"}}}`),
+	}
+
+	if err := registry.RegisterTool(tool); err != nil {
+		t.Fatalf("Failed to register tool: %v", err)
+	}
+
+	registeredTool, err := registry.GetTool("output-tool")
+	if err != nil {
+		t.Fatalf("Failed to get tool: %v", err)
+	}
+
+	if registeredTool.SecurityMetadata.OutputSignature == "" {
+		t.Fatal("Output schema signature was not generated")
+	}
+
+	// Swap the output schema but keep the original metadata
+	tamperedTool := registeredTool
+	tamperedTool.OutputSchema = json.RawMessage(`{"type": "object", "properties": {"result": {"type": "number"}}}`)
+	registry.tools["output-tool"] = tamperedTool
+
+	_, err = registry.GetTool("output-tool")
+	if err == nil {
+		t.Error("Expected output schema modification to be detected, but it was not")
+	}
+}
+
+func TestOutputSignatureOmittedWithoutOutputSchema(t *testing.T) {
+	registry := NewToolRegistry(true)
+	registry.SetSecurityOptions(true, true)
+
+	tool := Tool{
+		Name:         "no-output-tool",
+		Description:  "A tool without an output schema",
+		InputSchema:  json.RawMessage(`{"type": "object"}`),
+		OutputSchema: json.RawMessage(`null`),
+	}
+
+	if err := registry.RegisterTool(tool); err != nil {
+		t.Fatalf("Failed to register tool: %v", err)
+	}
+
+	registeredTool, err := registry.GetTool("no-output-tool")
+	if err != nil {
+		t.Fatalf("Failed to get tool: %v", err)
+	}
+
+	if registeredTool.SecurityMetadata.OutputSignature != "" {
+		t.Errorf("Expected empty output signature, got %s", registeredTool.SecurityMetadata.OutputSignature)
+	}
+}
+
+func TestToolManagerInitializationKeepsSecurityOptions(t *testing.T) {
+	manager := NewToolManager("TestServer", "1.0.0", true)
+	manager.toolRegistry.SetSecurityOptions(true, true)
+
+	// a client declaring no security capabilities can't switch them off
+	_, err := manager.HandleInitialize(InitializeParams{
+		ProtocolVersion: Version,
+		Capabilities: ServerToolCapabilities{
+			Tools: &ToolCapabilities{Security: &SecurityCapabilities{}},
+		},
+		ClientInfo: Implementation{Name: "TestClient", Version: "1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	if !manager.toolRegistry.validateChecksums || !manager.toolRegistry.rejectUnsignedTools {
+		t.Error("Expected the configured security options to be kept")
+	}
+}
+
+func TestToolManagerInitializationVersionMismatch(t *testing.T) {
+	manager := NewToolManager("TestServer", "1.0.0", true)
+
+	_, err := manager.HandleInitialize(InitializeParams{
+		ProtocolVersion: "1999-01-01",
+		ClientInfo:      Implementation{Name: "TestClient", Version: "1.0.0"},
+	})
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+}
+
+func TestToolManagerInitializationDowngrade(t *testing.T) {
+	manager := NewToolManager("TestServer", "1.0.0", true)
+
+	result, err := manager.HandleInitialize(InitializeParams{
+		ProtocolVersion: "2025-01-15",
+		ClientInfo:      Implementation{Name: "TestClient", Version: "1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	if result.ProtocolVersion != "2024-11-05" {
+		t.Errorf("Expected negotiated version 2024-11-05, got %s", result.ProtocolVersion)
+	}
+}
+
+func TestVerifyToolIgnoresChecksumOption(t *testing.T) {
+	// checksum validation is off for lookups, but an explicit verify still runs it
+	registry := NewToolRegistry(true)
+
+	tool := Tool{
+		Name:        "drifted-tool",
+		Description: "A tool whose description drifted",
+		InputSchema: json.RawMessage(`{"type": "object"}`),
+	}
+	if err := registry.RegisterTool(tool); err != nil {
+		t.Fatalf("Failed to register tool: %v", err)
+	}
+	if err := registry.VerifyTool("drifted-tool"); err != nil {
+		t.Errorf("Expected untouched tool to verify, got: %v", err)
+	}
+
+	drifted := registry.tools["drifted-tool"]
+	drifted.Description = "Now does something else"
+	registry.tools["drifted-tool"] = drifted
+
+	if _, err := registry.GetTool("drifted-tool"); err != nil {
+		t.Errorf("Expected GetTool to skip checksum validation, got: %v", err)
+	}
+	if err := registry.VerifyTool("drifted-tool"); err == nil {
+		t.Error("Expected VerifyTool to detect the drifted description")
+	}
+	if err := registry.VerifyTool("missing-tool"); err == nil {
+		t.Error("Expected error verifying an unknown tool")
+	}
+}
+
+func TestLoadToolsRetriesTransientFailures(t *testing.T) {
+	tools := map[string]Tool{
+		"remote-tool": {Name: "remote-tool", InputSchema: json.RawMessage(`{"type": "object"}`)},
+	}
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(tools)
+	}))
+	defer srv.Close()
+
+	registry := NewToolRegistry(true)
+	registry.SetRegistryCreds(srv.URL, "key")
+	if err := registry.LoadTools(); err != nil {
+		t.Fatalf("Expected LoadTools to succeed after retries, got: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 requests, got %d", calls)
+	}
+	if _, exists := registry.tools["remote-tool"]; !exists {
+		t.Error("Expected remote tool to be loaded")
+	}
+}
+
+func TestLoadToolsDoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	registry := NewToolRegistry(true)
+	registry.SetRegistryCreds(srv.URL, "key")
+	if err := registry.LoadTools(); err == nil {
+		t.Fatal("Expected LoadTools to fail")
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 request, got %d", calls)
+	}
+}
+
+func TestLoadToolsCircuitBreaker(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	registry := NewToolRegistry(true)
+	registry.SetRegistryCreds(srv.URL, "key")
+
+	// each LoadTools makes up to loadToolsAttempts requests until the breaker opens
+	for registry.RepoState() != util.BreakerOpen {
+		if err := registry.LoadTools(); err == nil {
+			t.Fatal("Expected LoadTools to fail")
+		}
+		if calls > repoBreakerThreshold {
+			t.Fatalf("Expected breaker to open after %d failures, made %d requests", repoBreakerThreshold, calls)
+		}
+	}
+	if calls != repoBreakerThreshold {
+		t.Errorf("Expected %d requests before opening, got %d", repoBreakerThreshold, calls)
+	}
+
+	err := registry.LoadTools()
+	if !errors.Is(err, util.ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got: %v", err)
+	}
+	if calls != repoBreakerThreshold {
+		t.Errorf("Expected open breaker to fail fast, but the repo was called again")
+	}
+}
+
+func TestLoadToolsPreview(t *testing.T) {
+	secured := func(name, description string) Tool {
+		tool := Tool{Name: name, Description: description, InputSchema: json.RawMessage(`{"type": "object"}`)}
+		if err := SecureTool(&tool); err != nil {
+			t.Fatalf("Failed to secure tool: %v", err)
+		}
+		// as if previously loaded from the repo, which stamps its name on each tool
+		tool.SecurityMetadata.Source = DefaultRepoName
+		return tool
+	}
+
+	remote := map[string]Tool{
+		"kept":    secured("kept", "unchanged"),
+		"changed": secured("changed", "new description"),
+		"added":   secured("added", "only on the remote"),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(remote)
+	}))
+	defer srv.Close()
+
+	registry := NewToolRegistry(true)
+	registry.SetRegistryCreds(srv.URL, "key")
+	for _, tool := range []Tool{secured("kept", "unchanged"), secured("changed", "old description"), secured("removed", "only local")} {
+		if err := registry.RegisterTool(tool); err != nil {
+			t.Fatalf("Failed to register tool: %v", err)
+		}
+	}
+
+	if err := registry.CommitLoad(); err == nil {
+		t.Error("Expected CommitLoad without a preview to fail")
+	}
+
+	diff, err := registry.LoadToolsPreview()
+	if err != nil {
+		t.Fatalf("LoadToolsPreview failed: %v", err)
+	}
+	expected := ToolSetDiff{Added: []string{"added"}, Removed: []string{"removed"}, Changed: []string{"changed"}}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("LoadToolsPreview() = %+v, want %+v", diff, expected)
+	}
+
+	// previewing leaves the registry untouched
+	if _, err := registry.GetTool("removed"); err != nil {
+		t.Errorf("Expected preview not to remove tools: %v", err)
+	}
+	if _, err := registry.GetTool("added"); err == nil {
+		t.Error("Expected preview not to add tools")
+	}
+
+	if err := registry.CommitLoad(); err != nil {
+		t.Fatalf("CommitLoad failed: %v", err)
+	}
+	if _, err := registry.GetTool("added"); err != nil {
+		t.Errorf("Expected commit to add tools: %v", err)
+	}
+	if _, err := registry.GetTool("removed"); err == nil {
+		t.Error("Expected commit to remove tools")
+	}
+	if tool, _ := registry.GetTool("changed"); tool.Description != "new description" {
+		t.Errorf("Expected commit to apply changes, got description %q", tool.Description)
+	}
+
+	if err := registry.CommitLoad(); err == nil {
+		t.Error("Expected a preview to be committed only once")
+	}
+}
+
+func TestLoadToolsPreviewRejectsTamperedTools(t *testing.T) {
+	tampered := Tool{Name: "tampered", Description: "original", InputSchema: json.RawMessage(`{"type": "object"}`)}
+	if err := SecureTool(&tampered); err != nil {
+		t.Fatal(err)
+	}
+	tampered.Description = "modified after signing"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]Tool{"tampered": tampered})
+	}))
+	defer srv.Close()
+
+	registry := NewToolRegistry(true)
+	registry.SetRegistryCreds(srv.URL, "key")
+	if _, err := registry.LoadToolsPreview(); err == nil {
+		t.Fatal("Expected preview of a tampered tool set to fail")
+	}
+	if err := registry.CommitLoad(); err == nil {
+		t.Error("Expected nothing to commit after a failed preview")
+	}
+}
+
+func TestRegisterToolRejectsInvalidOutputSchema(t *testing.T) {
+	for _, securityEnabled := range []bool{true, false} {
+		registry := NewToolRegistry(securityEnabled)
+
+		tool := Tool{
+			Name:         "bad-output-tool",
+			Description:  "A tool with a broken output schema",
+			InputSchema:  json.RawMessage(`{"type": "object"}`),
+			OutputSchema: json.RawMessage(`{"type": "invalid-type"}`),
+		}
+
+		err := registry.RegisterTool(tool)
+		var verr ToolVerificationError
+		if !errors.As(err, &verr) || verr.Code != ErrInvalidToolDefinition {
+			t.Fatalf("RegisterTool() error = %v, want code %d", err, ErrInvalidToolDefinition)
+		}
+		if _, err := registry.GetTool("bad-output-tool"); err == nil {
+			t.Error("Tool with an invalid output schema was registered")
+		}
+	}
+}
+
+func TestImportToolSetRejectsInvalidOutputSchema(t *testing.T) {
+	source := NewToolRegistry(true)
+	good := Tool{Name: "good-tool", InputSchema: json.RawMessage(`{"type": "object"}`)}
+	if err := source.RegisterTool(good); err != nil {
+		t.Fatalf("Failed to register tool: %v", err)
+	}
+	set := source.ListTools()
+
+	// a signed tool whose output schema is broken, as a hand-edited export might carry
+	bad := Tool{
+		Name:         "bad-output-tool",
+		InputSchema:  json.RawMessage(`{"type": "object"}`),
+		OutputSchema: json.RawMessage(`{"type": "invalid-type"}`),
+	}
+	if err := SecureTool(&bad); err != nil {
+		t.Fatalf("SecureTool() error: %v", err)
+	}
+	set.Tools = append(set.Tools, bad)
+
+	target := NewToolRegistry(true)
+	if err := target.ImportToolSet(set); err == nil {
+		t.Fatal("ImportToolSet() accepted a tool with an invalid output schema")
+	}
+	if _, err := target.GetTool("good-tool"); err == nil {
+		t.Error("ImportToolSet() partially imported a rejected set")
+	}
+}
+
+func TestRecompute(t *testing.T) {
+	registry := NewToolRegistry(true)
+	registry.SetSecurityOptions(true, true)
+
+	for _, name := range []string{"migrated-tool", "current-tool"} {
+		tool := Tool{
+			Name:         name,
+			Description:  "A tool hashed before an algorithm change",
+			InputSchema:  json.RawMessage(`{"type": "object"}`),
+			OutputSchema: json.RawMessage(`{"type": "string"}`),
+		}
+		if err := registry.RegisterTool(tool); err != nil {
+			t.Fatalf("Failed to register tool: %v", err)
+		}
+	}
+
+	// simulate metadata stored under a previous algorithm
+	stale := registry.tools["migrated-tool"]
+	stale.SecurityMetadata.Checksum = "md5:0123456789abcdef"
+	stale.SecurityMetadata.Signature = "md5:fedcba9876543210"
+	stale.SecurityMetadata.OutputSignature = "md5:0000000000000000"
+	stale.SecurityMetadata.Source = "trusted-registry"
+	registry.tools["migrated-tool"] = stale
+
+	if _, err := registry.GetTool("migrated-tool"); err == nil {
+		t.Fatal("Expected GetTool to reject metadata from the old algorithm")
+	}
+
+	changed, err := registry.Recompute()
+	if err != nil {
+		t.Fatalf("Recompute failed: %v", err)
+	}
+	if changed != 1 {
+		t.Errorf("Expected 1 tool to change, got %d", changed)
+	}
+
+	tool, err := registry.GetTool("migrated-tool")
+	if err != nil {
+		t.Fatalf("Expected recomputed tool to pass verification, got: %v", err)
+	}
+	if tool.SecurityMetadata.Source != "trusted-registry" {
+		t.Errorf("Expected unrelated metadata to be kept, got source %q", tool.SecurityMetadata.Source)
+	}
+	if err := registry.VerifyTool("migrated-tool"); err != nil {
+		t.Errorf("Expected VerifyTool to pass after Recompute, got: %v", err)
+	}
+
+	changed, err = registry.Recompute()
+	if err != nil {
+		t.Fatalf("Recompute failed: %v", err)
+	}
+	if changed != 0 {
+		t.Errorf("Expected a second Recompute to change nothing, got %d", changed)
+	}
+}
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// MethodToolsListChanged is the notification sent to clients when the set of available tools changes.
+const MethodToolsListChanged = "notifications/tools/list_changed"
+
+// watchDebounce is how long WatchDir waits for a burst of file events to settle before reloading
+const watchDebounce = 100 * time.Millisecond
+
+// OnListChanged registers fn to be called whenever a reload changes the registered tools.
+func (tr *ToolRegistry) OnListChanged(fn func()) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.listeners = append(tr.listeners, fn)
+}
+
+// notifyListChanged calls every OnListChanged listener
+func (tr *ToolRegistry) notifyListChanged() {
+	tr.mu.RLock()
+	listeners := append([]func(){}, tr.listeners...)
+	tr.mu.RUnlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// WatchDir loads the tool definitions in dir and keeps the registry in sync with it,
+// re-running LoadFromDir whenever a tool file is created, modified, renamed or deleted
+// and notifying OnListChanged listeners if that changed the directory's tools. Bursts of events are debounced
+// into a single reload. WatchDir blocks until ctx is cancelled.
+func (tr *ToolRegistry) WatchDir(ctx context.Context, dir string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// watch before the initial load so no change in between is missed
+	if err := watcher.Add(dir); err != nil {
+		return err
+	}
+	if err := tr.LoadFromDir(dir); err != nil {
+		return err
+	}
+
+	reload := time.NewTimer(watchDebounce)
+	reload.Stop()
+	defer reload.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Ext(event.Name) != ".json" || event.Op == fsnotify.Chmod {
+				continue
+			}
+			reload.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("WARNING error watching tool directory '%s': %v", dir, err)
+		case <-reload.C:
+			changed, err := tr.loadDir(dir)
+			if err != nil {
+				log.Printf("WARNING failed to reload tool directory '%s': %v", dir, err)
+				continue
+			}
+			if changed {
+				tr.notifyListChanged()
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or the timeout passes
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return cond()
+}
+
+func TestWatchDir(t *testing.T) {
+	dir := t.TempDir()
+	writeToolFile(t, dir, "existing", signedTool(t, "existing-tool"))
+
+	registry := NewToolRegistry(true)
+	registry.SetSecurityOptions(true, true)
+	changed := make(chan struct{}, 10)
+	registry.OnListChanged(func() { changed <- struct{}{} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- registry.WatchDir(ctx, dir) }()
+
+	// the watcher is running once the initial load is visible
+	if !waitFor(t, 2*time.Second, func() bool {
+		_, err := registry.GetTool("existing-tool")
+		return err == nil
+	}) {
+		t.Fatal("Expected initial load of existing tool")
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		writeToolFile(t, dir, "new", signedTool(t, "new-tool"))
+		if !waitFor(t, 2*time.Second, func() bool {
+			_, err := registry.GetTool("new-tool")
+			return err == nil
+		}) {
+			t.Fatal("Expected new tool file to be loaded")
+		}
+		select {
+		case <-changed:
+		case <-time.After(2 * time.Second):
+			t.Error("Expected a list changed notification")
+		}
+	})
+
+	t.Run("Modify", func(t *testing.T) {
+		modified := signedTool(t, "new-tool")
+		modified.Description = "Modified on disk"
+		if err := SecureTool(&modified); err != nil {
+			t.Fatal(err)
+		}
+		writeToolFile(t, dir, "new", modified)
+		if !waitFor(t, 2*time.Second, func() bool {
+			tool, err := registry.GetTool("new-tool")
+			return err == nil && tool.Description == "Modified on disk"
+		}) {
+			t.Fatal("Expected modified tool file to be reloaded")
+		}
+		select {
+		case <-changed:
+		case <-time.After(2 * time.Second):
+			t.Error("Expected a list changed notification")
+		}
+	})
+
+	t.Run("Unchanged", func(t *testing.T) {
+		// rewriting a file with the same definition, or a write caught halfway,
+		// doesn't change the tools
+		tool, err := registry.GetTool("new-tool")
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeToolFile(t, dir, "new", tool)
+		if err := os.WriteFile(filepath.Join(dir, "existing.json"), []byte(`{"name": `), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-changed:
+			t.Error("Expected no list changed notification")
+		case <-time.After(10 * watchDebounce):
+		}
+		if _, err := registry.GetTool("existing-tool"); err != nil {
+			t.Errorf("Expected a tool whose file doesn't parse to be kept: %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := os.Remove(filepath.Join(dir, "existing.json")); err != nil {
+			t.Fatal(err)
+		}
+		if !waitFor(t, 2*time.Second, func() bool {
+			_, err := registry.GetTool("existing-tool")
+			return err != nil
+		}) {
+			t.Fatal("Expected deleted tool file to be unloaded")
+		}
+	})
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected clean stop, got: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WatchDir did not stop after the context was cancelled")
+	}
+}
+
+func TestWatchDirMissingDirectory(t *testing.T) {
+	registry := NewToolRegistry(true)
+	if err := registry.WatchDir(context.Background(), filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error watching a missing directory")
+	}
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounterVec(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "Test counter.", "direction", "result")
+
+	c.With("in", "ok").Inc()
+	c.With("in", "ok").Add(2)
+	c.With("out", `bad "quote"`).Inc()
+
+	if got := c.With("in", "ok").Value(); got != 3 {
+		t.Errorf("counter value = %d, want 3", got)
+	}
+
+	var out strings.Builder
+	if err := r.Write(&out); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	want := `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{direction="in",result="ok"} 3
+test_total{direction="out",result="bad \"quote\""} 1
+`
+	if out.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestCounterVec_WrongLabelCount(t *testing.T) {
+	c := NewRegistry().NewCounterVec("test_total", "Test counter.", "direction")
+	defer func() {
+		if recover() == nil {
+			t.Error("With() with the wrong number of label values should panic")
+		}
+	}()
+	c.With("in", "extra")
+}
+
+func TestHistogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("test_seconds", "Test histogram.", []float64{1, 0.1})
+
+	for _, v := range []float64{0.05, 0.1, 0.5, 2} {
+		h.Observe(v)
+	}
+	if h.Count() != 4 {
+		t.Errorf("Count() = %d, want 4", h.Count())
+	}
+
+	var out strings.Builder
+	if err := r.Write(&out); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	want := `# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 2
+test_seconds_bucket{le="1"} 3
+test_seconds_bucket{le="+Inf"} 4
+test_seconds_sum 2.65
+test_seconds_count 4
+`
+	if out.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestRegistry_DuplicateName(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "Test counter.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate metric name should panic")
+		}
+	}()
+	r.NewHistogram("test_total", "Test histogram.", DefBuckets)
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "Test counter.").With().Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "test_total 1\n") {
+		t.Errorf("body missing counter:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/null-create/mcp-tls/pkg/config"
+)
+
+// ToolAccessList decides which tools the proxy forwards calls to. A denied tool is
+// always blocked; otherwise, if the allowlist is non-empty only tools on it pass.
+// The lists can be swapped at runtime and it is safe for concurrent use.
+type ToolAccessList struct {
+	mu    sync.RWMutex
+	allow map[string]struct{}
+	deny  map[string]struct{}
+}
+
+func NewToolAccessList(allow, deny []string) *ToolAccessList {
+	a := &ToolAccessList{}
+	a.SetLists(allow, deny)
+	return a
+}
+
+// SetLists replaces both lists
+func (a *ToolAccessList) SetLists(allow, deny []string) {
+	allowSet, denySet := toSet(allow), toSet(deny)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.allow, a.deny = allowSet, denySet
+}
+
+// Reload re-reads both lists from the environment configuration
+func (a *ToolAccessList) Reload() {
+	cfgs := config.LoadConfigs()
+	a.SetLists(cfgs.ToolAllowlist, cfgs.ToolDenylist)
+}
+
+// Allowed reports whether calls to the named tool may be forwarded
+func (a *ToolAccessList) Allowed(toolName string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if _, denied := a.deny[toolName]; denied {
+		return false
+	}
+	if len(a.allow) == 0 {
+		return true
+	}
+	_, allowed := a.allow[toolName]
+	return allowed
+}
+
+func toSet(items []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		set[item] = struct{}{}
+	}
+	return set
+}
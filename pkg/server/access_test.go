@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/codec"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolAccessList_Allowed(t *testing.T) {
+	open := NewToolAccessList(nil, nil)
+	assert.True(t, open.Allowed("anything"), "empty lists should allow every tool")
+
+	a := NewToolAccessList([]string{"read-file", "delete-file"}, []string{"delete-file"})
+	assert.True(t, a.Allowed("read-file"))
+	assert.False(t, a.Allowed("delete-file"), "denylist should take precedence over allowlist")
+	assert.False(t, a.Allowed("write-file"), "tools missing from a non-empty allowlist should be blocked")
+
+	a.SetLists(nil, []string{"read-file"})
+	assert.False(t, a.Allowed("read-file"))
+	assert.True(t, a.Allowed("write-file"))
+}
+
+func TestToolAccessList_Reload(t *testing.T) {
+	a := NewToolAccessList(nil, nil)
+
+	t.Setenv("MCPTLS_TOOL_ALLOWLIST", "")
+	t.Setenv("MCPTLS_TOOL_DENYLIST", "delete-file")
+	a.Reload()
+
+	assert.False(t, a.Allowed("delete-file"))
+	assert.True(t, a.Allowed("read-file"))
+}
+
+func TestValidateAndForward_ToolAccess(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	h.toolAccess = NewToolAccessList(nil, []string{"delete-file"})
+
+	call := func(name string) []byte {
+		return toolCallRequest(t, h, mcp.Tool{
+			Name:        name,
+			Description: "Operates on a file",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"}}}`),
+		}, `{"path":"/tmp/x"}`, 1)
+	}
+
+	t.Run("denied tool is blocked", func(t *testing.T) {
+		forward, out, err := h.validateAndForward(call("delete-file"))
+		require.NoError(t, err)
+		assert.Empty(t, forward, "rejected calls should not reach the server")
+		_, rpcErr, data := proxyErrorResponse(t, out)
+		assert.Equal(t, codec.TOOL_DENIED, rpcErr.Code)
+		assert.Equal(t, "delete-file", data.Tool)
+	})
+
+	t.Run("allowed tool passes", func(t *testing.T) {
+		req := call("read-file")
+		out, reply, err := h.validateAndForward(req)
+		require.NoError(t, err)
+		assert.Empty(t, reply)
+		assert.Equal(t, req, out)
+	})
+}
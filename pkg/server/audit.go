@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/null-create/mcp-tls/pkg/audit"
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/util"
+)
+
+// Audit events recorded by the server
+const (
+	auditRegister   = "tool.register"
+	auditImport     = "tool.import"
+	auditCall       = "tool.call"
+	auditQuarantine = "tool.quarantine"
+	auditRelease    = "tool.release"
+)
+
+// Audit outcomes
+const (
+	auditSucceeded = "succeeded"
+	auditFailed    = "failed"
+	auditDenied    = "denied"
+)
+
+// recordAudit appends a security decision to the audit chain, attributed to the
+// user authenticated on r, if any
+func (h *Handlers) recordAudit(r *http.Request, event, tool, outcome, detail string) {
+	if h.auditLog == nil {
+		return
+	}
+	record := audit.Record{Event: event, Tool: tool, Outcome: outcome, Detail: detail}
+	if r != nil {
+		if claims, ok := auth.FromContext(r.Context()); ok {
+			record.Actor = claims.Username
+		}
+	}
+	if _, err := h.auditLog.Append(record); err != nil {
+		h.log.Error("failed to record audit event %s: %v", event, err)
+	}
+}
+
+// AuditLog is the audit chain along with its head, which can be kept elsewhere to detect truncation
+type AuditLog struct {
+	Head    string         `json:"head"`
+	Records []audit.Record `json:"records"`
+}
+
+// Returns the audit chain, after checking it still verifies
+func (h *Handlers) AuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	if err := h.auditLog.Verify(); err != nil {
+		h.errorMsg(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	util.WriteJSON(w, AuditLog{Head: h.auditLog.Head(), Records: h.auditLog.Records()})
+}
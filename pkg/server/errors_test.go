@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_ErrorEnvelope(t *testing.T) {
+	t.Setenv("MCPTLS_MAX_BODY_SIZE", "1024")
+	router := NewRouter(t.Context())
+	creds := auth.Credentials{UserName: "eve", Password: "hunter2"}
+	token := loginToken(t, router, creds)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		contentType string
+		token       string
+		status      int
+	}{
+		{name: "missing auth header", method: http.MethodGet, path: "/api/tools/list", status: http.StatusUnauthorized},
+		{name: "invalid token", method: http.MethodGet, path: "/api/tools/list", token: "not-a-jwt", status: http.StatusUnauthorized},
+		{name: "wrong content type", method: http.MethodPost, path: "/api/users/login", body: "userName=eve", contentType: "text/plain", status: http.StatusUnsupportedMediaType},
+		{name: "body too large", method: http.MethodPost, path: "/api/users/login", body: oversizedJSON(4096), status: http.StatusRequestEntityTooLarge},
+		{name: "malformed login", method: http.MethodPost, path: "/api/users/login", body: `{"userName":`, status: http.StatusBadRequest},
+		{name: "missing username", method: http.MethodPost, path: "/api/users/login", body: `{"password":"pw"}`, status: http.StatusBadRequest},
+		{name: "bad credentials", method: http.MethodPost, path: "/api/users/login", body: `{"userName":"eve","password":"wrong"}`, status: http.StatusUnauthorized},
+		{name: "existing user", method: http.MethodPost, path: "/api/users/new", body: `{"userName":"eve","password":"hunter2"}`, status: http.StatusConflict},
+		{name: "basic auth missing", method: http.MethodGet, path: "/api/users/auth", status: http.StatusUnauthorized},
+		{name: "invalid tool JSON", method: http.MethodPost, path: "/api/validate/tool", body: `{"name":`, token: token, status: http.StatusBadRequest},
+		{name: "invalid tools array", method: http.MethodPost, path: "/api/validate/tools", body: `{}`, token: token, status: http.StatusBadRequest},
+		{name: "unsigned registration", method: http.MethodPost, path: "/api/tools/register", body: `{"name":"t","inputSchema":{"type":"object"}}`, token: token, status: http.StatusBadRequest},
+		{name: "bad import bundle", method: http.MethodPost, path: "/api/tools/import", body: `{}`, token: token, status: http.StatusBadRequest},
+		{name: "not an admin", method: http.MethodGet, path: "/api/admin/quarantine", token: token, status: http.StatusForbidden},
+		{name: "unknown route", method: http.MethodGet, path: "/api/nope", status: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodDelete, path: "/health", status: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				contentType := tt.contentType
+				if contentType == "" {
+					contentType = "application/json"
+				}
+				req.Header.Set("Content-Type", contentType)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var envelope util.ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &envelope), rec.Body.String())
+			assert.NotEmpty(t, envelope.Error)
+			assert.Equal(t, tt.status, envelope.Code)
+			assert.NotEmpty(t, envelope.RequestID)
+		})
+	}
+}
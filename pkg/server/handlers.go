@@ -1,62 +1,214 @@
 package server
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/null-create/mcp-tls/pkg/audit"
 	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/codec"
+	"github.com/null-create/mcp-tls/pkg/config"
 	"github.com/null-create/mcp-tls/pkg/mcp"
+	"github.com/null-create/mcp-tls/pkg/tls"
 	"github.com/null-create/mcp-tls/pkg/util"
 	"github.com/null-create/mcp-tls/pkg/validate"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/null-create/logger"
 )
 
 type Handlers struct {
 	log          *logger.Logger
-	usersManager auth.UsersManager
+	usersManager *auth.UsersManager
 	toolManager  *mcp.ToolManager
+	bundleKey    ed25519.PrivateKey
+
+	attestationKey []byte // when set, the proxy signs the tool calls it validated with it
+
+	validationCache *validate.ValidationCache
+	validators      *validate.ValidatorRegistry
+	rateLimiter     *ToolRateLimiter
+	toolAccess      *ToolAccessList
+	decodeLimits    codec.DecodeLimits
+	strictJSON      bool // reject unknown fields in tool bodies
+	errorPolicy     validate.ErrorPolicy
+	rpcErrorStatus  bool // send JSON-RPC errors with a matching HTTP status instead of 200
+	executor        ToolExecutor
+	toolCallTimeout time.Duration    // how long a tools/call may run when the tool declares no x-timeout-ms
+	certPolicy      *auth.CertPolicy // when set, client certificates must be authorized for tool actions
+	adminUsers      []string         // names reserved for the admin accounts provisioned from configuration
+	auditLog        *audit.Chain     // security decisions, HMAC'd with the audit key
 }
 
 func NewHandler() Handlers {
+	bundleKey, err := tls.RetrieveBundleKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	attestationKey, err := tls.RetrieveAttestationKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	auditKey, err := tls.RetrieveAuditKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cache := validate.NewValidationCache(validate.DefaultCacheSize)
+	cfgs := config.LoadConfigs()
+	validate.SetSensitiveFields(cfgs.SensitiveFields)
+	logLevel, err := validate.ParseLogLevel(cfgs.LogLevel)
+	if err != nil {
+		log.Printf("WARNING %v, using %s", err, logLevel)
+	}
+	validate.SetLogLevel(logLevel)
+	validate.SetMaxLoggedOutput(cfgs.MaxLoggedOutput)
+	validate.SetDescriptionDenylist(cfgs.DescriptionDenylist)
+	validate.SetDescriptionNormalization(cfgs.NormalizeDescriptions)
+	toolManager := mcp.NewToolManager("mcp-tls-tool-manager", "1.0.0", true)
+	toolManager.SetTrustedSources(cfgs.TrustedSources)
+	publisherKeys, err := mcp.ParsePublisherKeys(cfgs.PublisherKeys)
+	if err != nil {
+		log.Printf("WARNING %v, ignoring them", err)
+	}
+	toolManager.SetPublisherKeys(publisherKeys)
+	toolManager.SetSecurityOptions(cfgs.ValidateChecksums, cfgs.RejectUnsignedTools)
+	requiredAnnotations, err := mcp.ParseRequiredAnnotations(cfgs.RequiredAnnotations)
+	if err != nil {
+		log.Printf("WARNING %v, ignoring them", err)
+	}
+	toolManager.SetRequiredAnnotations(requiredAnnotations)
+	errorPolicy, err := validate.ParseErrorPolicy(cfgs.ValidationErrorPolicy)
+	if err != nil {
+		log.Printf("WARNING %v, using %s", err, errorPolicy)
+	}
+	verbosity, err := validate.ParseErrorVerbosity(cfgs.ValidationErrorVerbosity)
+	if err != nil {
+		log.Printf("WARNING %v, using %s", err, verbosity)
+	}
+	validate.SetErrorVerbosity(verbosity)
+	numbers, err := mcp.ParseNumberCanonicalization(cfgs.NumberCanonicalization)
+	if err != nil {
+		log.Printf("WARNING %v, using %s", err, numbers)
+	}
+	mcp.SetNumberCanonicalization(numbers)
+	toolManager.SetDeprecationCutoff(cfgs.DeprecationCutoff)
+	var certPolicy *auth.CertPolicy
+	if cfgs.CertPolicyFile != "" {
+		if certPolicy, err = auth.LoadCertPolicy(cfgs.CertPolicyFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	auditLog := audit.NewChain(auditKey)
+	if cfgs.AuditLogFile != "" {
+		if auditLog, err = audit.OpenFileChain(cfgs.AuditLogFile, auditKey); err != nil {
+			log.Fatalf("%v; MCPTLS_AUDIT_KEY must be the key the log was written with", err)
+		}
+	}
+	usersManager := auth.NewUsersManager()
+	provisionAdmins(usersManager, cfgs.AdminUsers, cfgs.AdminCredentials)
 	return Handlers{
 		log:          logger.NewLogger("API", uuid.NewString()),
-		usersManager: auth.NewUsersManager(),
-		toolManager:  mcp.NewToolManager("mcp-tls-tool-manager", "1.0.0", true),
+		usersManager: usersManager,
+		toolManager:  toolManager,
+		bundleKey:    bundleKey,
+
+		attestationKey: attestationKey,
+
+		validationCache: cache,
+		validators:      validate.NewValidatorRegistry(validate.SchemaValidator{Cache: cache}),
+		rateLimiter:     NewToolRateLimiter(DefaultToolRateLimits()),
+		toolAccess:      NewToolAccessList(cfgs.ToolAllowlist, cfgs.ToolDenylist),
+		decodeLimits: codec.DecodeLimits{
+			MaxDepth: cfgs.JSONMaxDepth,
+			MaxSize:  codec.DefaultDecodeLimits.MaxSize,
+		},
+		strictJSON:      cfgs.StrictJSON,
+		errorPolicy:     errorPolicy,
+		rpcErrorStatus:  cfgs.RPCErrorStatus,
+		toolCallTimeout: cfgs.ToolCallTimeout,
+		certPolicy:      certPolicy,
+		adminUsers:      cfgs.AdminUsers,
+		auditLog:        auditLog,
 	}
 }
 
-func (h *Handlers) errorMsg(w http.ResponseWriter, err error, statusCode int) {
+// provisionAdmins creates the accounts of the admin users from their "name:password"
+// credentials. Credentials for anyone who isn't an admin are ignored.
+func provisionAdmins(users *auth.UsersManager, admins, credentials []string) {
+	for _, cred := range credentials {
+		name, password, ok := strings.Cut(cred, ":")
+		if !ok || name == "" || password == "" {
+			// the entry may be a bare password, so it isn't logged
+			log.Printf("WARNING ignoring malformed admin credentials, expected name:password")
+			continue
+		}
+		if !slices.Contains(admins, name) {
+			log.Printf("WARNING ignoring credentials for '%s', who is not an admin user", name)
+			continue
+		}
+		if err := users.AddUser(name, password); err != nil {
+			log.Printf("WARNING failed to create admin user '%s': %v", name, err)
+		}
+	}
+}
+
+func (h *Handlers) errorMsg(w http.ResponseWriter, r *http.Request, err error, statusCode int) {
 	h.log.Error("%v", err)
-	http.Error(w, err.Error(), statusCode)
+	util.WriteError(w, r, statusCode, err.Error())
+}
+
+// authorizeCert checks the request's verified client certificate against the
+// certificate policy, if one is configured, for performing action on tool
+func (h *Handlers) authorizeCert(r *http.Request, action, tool string) error {
+	if h.certPolicy == nil {
+		return nil
+	}
+	if r == nil || r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return auth.ErrNoClientCert
+	}
+	cert := r.TLS.PeerCertificates[0]
+	if !h.certPolicy.Allows(cert, action, tool) {
+		return fmt.Errorf("client certificate '%s' is not authorized to %s tool '%s'", cert.Subject.CommonName, action, tool)
+	}
+	return nil
 }
 
 func (h *Handlers) HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	type HealthResponse struct {
-		Status string `json:"status"`
+		Status   string `json:"status"`
+		ToolRepo string `json:"toolRepo"` // circuit breaker state of the remote tool repo
 	}
 
 	err := json.NewEncoder(w).Encode(HealthResponse{
-		Status: "ok",
+		Status:   "ok",
+		ToolRepo: string(h.toolManager.ToolRepoState()),
 	})
 	if err != nil {
-		h.errorMsg(w, err, http.StatusInternalServerError)
+		h.errorMsg(w, r, err, http.StatusInternalServerError)
 	}
 }
 
 func (h *Handlers) LoadToolsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		h.errorMsg(w, errors.New("method not allowed"), http.StatusBadRequest)
+		h.errorMsg(w, r, errors.New("method not allowed"), http.StatusBadRequest)
 		return
 	}
 
 	if err := h.toolManager.LoadTools(); err != nil {
-		h.errorMsg(w, err, http.StatusInternalServerError)
+		h.errorMsg(w, r, err, http.StatusInternalServerError)
 	}
 
 	// send confirmation response
@@ -65,22 +217,32 @@ func (h *Handlers) LoadToolsHandler(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handlers) ValidateToolHandler(w http.ResponseWriter, r *http.Request) {
 	var tool mcp.Tool
-	if err := json.NewDecoder(r.Body).Decode(&tool); err != nil {
-		util.WriteError(w, http.StatusBadRequest, "Invalid tool JSON: "+err.Error())
+	if err := util.DecodeJSON(r.Body, &tool, h.strictJSON); err != nil {
+		util.WriteError(w, r, bodyStatus(err), "Invalid tool JSON: "+err.Error())
+		return
+	}
+	if err := h.authorizeCert(r, auth.ActionValidate, tool.Name); err != nil {
+		h.errorMsg(w, r, err, http.StatusForbidden)
 		return
 	}
 
-	result := h.validate(&tool)
+	result := h.validate(r.Context(), &tool)
 
 	util.WriteJSON(w, result)
 }
 
 func (h *Handlers) ValidateToolsHandler(w http.ResponseWriter, r *http.Request) {
 	var tools []mcp.Tool
-	if err := json.NewDecoder(r.Body).Decode(&tools); err != nil {
-		util.WriteError(w, http.StatusBadRequest, "Invalid JSON array: "+err.Error())
+	if err := util.DecodeJSON(r.Body, &tools, h.strictJSON); err != nil {
+		util.WriteError(w, r, bodyStatus(err), "Invalid JSON array: "+err.Error())
 		return
 	}
+	for _, tool := range tools {
+		if err := h.authorizeCert(r, auth.ActionValidate, tool.Name); err != nil {
+			h.errorMsg(w, r, err, http.StatusForbidden)
+			return
+		}
+	}
 
 	var (
 		wg      sync.WaitGroup
@@ -93,7 +255,7 @@ func (h *Handlers) ValidateToolsHandler(w http.ResponseWriter, r *http.Request)
 		go func() {
 			defer wg.Done()
 
-			result := h.validate(&tool)
+			result := h.validate(r.Context(), &tool)
 
 			mu.Lock()
 			results = append(results, result)
@@ -106,7 +268,57 @@ func (h *Handlers) ValidateToolsHandler(w http.ResponseWriter, r *http.Request)
 	util.WriteJSON(w, results)
 }
 
-func (h *Handlers) validate(tool *mcp.Tool) mcp.ToolValidationResult {
+// toolCallPreviewRequest names a registered tool and the arguments a call to it would carry
+type toolCallPreviewRequest struct {
+	ToolName  string          `json:"toolName"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// toolCallPreview reports whether a tool call would pass validation
+type toolCallPreview struct {
+	ToolName string                    `json:"toolName"`
+	Status   validate.ValidationStatus `json:"status"`
+	Valid    bool                      `json:"valid"`
+	Errors   []validate.FieldError     `json:"errors,omitempty"`
+}
+
+// Checks a tool call's arguments against the registered tool without executing or
+// forwarding it, so clients can find out whether a call would be accepted first
+func (h *Handlers) ValidateToolCallHandler(w http.ResponseWriter, r *http.Request) {
+	var req toolCallPreviewRequest
+	if err := util.DecodeJSON(r.Body, &req, h.strictJSON); err != nil {
+		util.WriteError(w, r, bodyStatus(err), "Invalid tool call JSON: "+err.Error())
+		return
+	}
+	if req.ToolName == "" {
+		util.WriteError(w, r, http.StatusBadRequest, "toolName is required")
+		return
+	}
+	if err := h.authorizeCert(r, auth.ActionValidate, req.ToolName); err != nil {
+		h.errorMsg(w, r, err, http.StatusForbidden)
+		return
+	}
+
+	tool, status, err := validate.ValidateToolCall(req.ToolName, req.Arguments, h.toolManager)
+	if tool == nil {
+		h.errorMsg(w, r, err, http.StatusNotFound)
+		return
+	}
+
+	preview := toolCallPreview{ToolName: req.ToolName, Status: status, Valid: err == nil && status == validate.StatusSucceeded}
+	switch {
+	case status == validate.StatusError:
+		// internal failures aren't the client's to fix, so don't leak their details
+		h.log.Error("previewing call to tool '%s': %v", req.ToolName, err)
+	case err != nil:
+		preview.Errors = fieldErrors(err)
+	}
+	util.WriteJSON(w, preview)
+}
+
+// validate checks a submitted tool against the registered one, giving up on the
+// input check once ctx is done
+func (h *Handlers) validate(ctx context.Context, tool *mcp.Tool) mcp.ToolValidationResult {
 	origTool, err := h.toolManager.GetTool(tool.Name)
 	if err != nil {
 		h.log.Error("%v", err)
@@ -117,8 +329,10 @@ func (h *Handlers) validate(tool *mcp.Tool) mcp.ToolValidationResult {
 		}
 	}
 
-	if tool.SecurityMetadata.Signature != origTool.SecurityMetadata.Signature ||
-		tool.SecurityMetadata.Checksum != origTool.SecurityMetadata.Checksum {
+	// compare in constant time, as the submitted values are attacker controlled
+	if !util.SecureEqual(tool.SecurityMetadata.Signature, origTool.SecurityMetadata.Signature) ||
+		!util.SecureEqual(tool.SecurityMetadata.OutputSignature, origTool.SecurityMetadata.OutputSignature) ||
+		!util.SecureEqual(tool.SecurityMetadata.Checksum, origTool.SecurityMetadata.Checksum) {
 		h.log.Error("signature or checksum mismatch")
 		return mcp.ToolValidationResult{
 			Name:  tool.Name,
@@ -139,7 +353,7 @@ func (h *Handlers) validate(tool *mcp.Tool) mcp.ToolValidationResult {
 	}
 
 	// validate tool schema
-	status, err := validate.ValidateToolInputSchema(tool, tool.Arguments)
+	status, err := validate.ValidateInputContext(ctx, h.validators.For(tool), tool, tool.Arguments)
 	if err != nil {
 		h.log.Error("tool input validation failed: %v", err)
 		return mcp.ToolValidationResult{
@@ -162,32 +376,265 @@ func (h *Handlers) validate(tool *mcp.Tool) mcp.ToolValidationResult {
 		Name:     tool.Name,
 		Valid:    true,
 		Checksum: tool.SecurityMetadata.Checksum,
+		Warning:  origTool.DeprecationWarning(),
 	}
 }
 
-// Lists tools known to the server
+// Lists tools known to the server, optionally only those from the sources given
+// as source query parameters. The response carries an ETag so clients
+// polling for changes can send If-None-Match and get a 304 when nothing changed.
 func (h *Handlers) ListToolsHandler(w http.ResponseWriter, r *http.Request) {
+	tools := h.toolManager.ListTools(r.URL.Query()["source"]...).Tools
+	body, err := json.Marshal(tools)
+	if err != nil {
+		h.errorMsg(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	etag := toolsETag(body)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
+
+// toolsETag derives a strong ETag from the serialized tool list. GetTools returns
+// tools sorted by name, so the same registry contents always produce the same tag.
+func toolsETag(body []byte) string {
+	hash := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, or the tag
+// Gzip gives it when compressing, using the weak comparison RFC 9110 specifies for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag || candidate == gzipETag(etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Re-checks the integrity of every registered tool and reports the result for each.
+// Tools failing the check are quarantined.
+func (h *Handlers) VerifyToolsHandler(w http.ResponseWriter, r *http.Request) {
 	tools := h.toolManager.GetTools()
-	if err := json.NewEncoder(w).Encode(tools); err != nil {
-		h.errorMsg(w, err, http.StatusInternalServerError)
+	results := make([]mcp.ToolValidationResult, 0, len(tools))
+	for _, tool := range tools {
+		results = append(results, h.verifyTool(tool))
+	}
+	util.WriteJSON(w, results)
+}
+
+// Reports which registered tools lack input or output schemas, or declare ones that
+// accept anything, along with the share of schemas that actually validate traffic
+func (h *Handlers) SchemaCoverageHandler(w http.ResponseWriter, r *http.Request) {
+	util.WriteJSON(w, h.toolManager.SchemaCoverage())
+}
+
+// schemaDiffRequest carries the two versions of a schema to compare
+type schemaDiffRequest struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// Compares two versions of a tool schema, listing the properties added and removed,
+// changed types, and changes to required lists, for reviewing tool updates
+func (h *Handlers) SchemaDiffHandler(w http.ResponseWriter, r *http.Request) {
+	var req schemaDiffRequest
+	if err := util.DecodeJSON(r.Body, &req, h.strictJSON); err != nil {
+		util.WriteError(w, r, bodyStatus(err), "Invalid schema diff JSON: "+err.Error())
+		return
+	}
+	diff, err := mcp.DiffSchemas(req.From, req.To)
+	if err != nil {
+		h.errorMsg(w, r, err, http.StatusBadRequest)
+		return
+	}
+	util.WriteJSON(w, diff)
+}
+
+func (h *Handlers) verifyTool(tool mcp.Tool) mcp.ToolValidationResult {
+	if err := h.checkIntegrity(tool.Name); err != nil {
+		return mcp.ToolValidationResult{
+			Name:  tool.Name,
+			Valid: false,
+			Error: err.Error(),
+		}
+	}
+
+	if err := validate.ValidateToolDescription(tool.Description); err != nil {
+		h.log.Error("tool '%s' description validation failed: %v", tool.Name, err)
+		return mcp.ToolValidationResult{
+			Name:  tool.Name,
+			Valid: false,
+			Error: err.Error(),
+		}
 	}
+
+	return mcp.ToolValidationResult{
+		Name:     tool.Name,
+		Valid:    true,
+		Checksum: tool.SecurityMetadata.Checksum,
+	}
+}
+
+// Exports the full registry as a signed ToolSet bundle
+func (h *Handlers) ExportToolsHandler(w http.ResponseWriter, r *http.Request) {
+	bundle, err := tls.SignBundle(h.toolManager.ListTools(), h.bundleKey)
+	if err != nil {
+		h.errorMsg(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(bundle); err != nil {
+		h.log.Error("failed to write export bundle: %v", err)
+	}
+}
+
+// Lists the quarantined tools along with why and when each was quarantined
+func (h *Handlers) ListQuarantinedHandler(w http.ResponseWriter, r *http.Request) {
+	util.WriteJSON(w, h.toolManager.ListQuarantined())
+}
+
+// Returns a single quarantined tool for inspection
+func (h *Handlers) GetQuarantinedHandler(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	entry, ok := h.toolManager.GetQuarantined(name)
+	if !ok {
+		h.errorMsg(w, r, fmt.Errorf("tool '%s' is not quarantined", name), http.StatusNotFound)
+		return
+	}
+	util.WriteJSON(w, entry)
+}
+
+// Returns a quarantined tool to the registry
+func (h *Handlers) ReleaseQuarantinedHandler(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := h.toolManager.Release(name); err != nil {
+		h.errorMsg(w, r, err, http.StatusNotFound)
+		return
+	}
+	h.log.Info("tool '%s' released from quarantine", name)
+	h.recordAudit(r, auditRelease, name, auditSucceeded, "")
+
+	type Response struct {
+		Msg string `json:"message"`
+	}
+
+	util.WriteJSON(w, Response{Msg: fmt.Sprintf("tool '%s' released", name)})
+}
+
+// caches returns the caches operators can inspect and flush
+func (h *Handlers) caches() []validate.StatsCache {
+	var caches []validate.StatsCache
+	if h.validationCache != nil {
+		caches = append(caches, h.validationCache)
+	}
+	return caches
+}
+
+// Reports hits, misses, and size for each cache
+func (h *Handlers) CacheStatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats := []validate.CacheStats{}
+	for _, cache := range h.caches() {
+		stats = append(stats, cache.Stats())
+	}
+	util.WriteJSON(w, stats)
+}
+
+// Empties every cache, e.g. after a registry reload
+func (h *Handlers) CacheFlushHandler(w http.ResponseWriter, r *http.Request) {
+	caches := h.caches()
+	for _, cache := range caches {
+		cache.Flush()
+	}
+	h.log.Info("%d caches flushed", len(caches))
+
+	type Response struct {
+		Msg string `json:"message"`
+	}
+
+	util.WriteJSON(w, Response{Msg: fmt.Sprintf("%d caches flushed", len(caches))})
+}
+
+// Verifies a signed ToolSet bundle and imports its tools into the registry
+func (h *Handlers) ImportToolsHandler(w http.ResponseWriter, r *http.Request) {
+	bundle, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.errorMsg(w, r, err, bodyStatus(err))
+		return
+	}
+
+	var toolSet mcp.ToolSet
+	pubKey := h.bundleKey.Public().(ed25519.PublicKey)
+	if err := tls.VerifyBundle(bundle, pubKey, &toolSet); err != nil {
+		h.errorMsg(w, r, err, http.StatusBadRequest)
+		return
+	}
+	for _, tool := range toolSet.Tools {
+		if err := h.authorizeCert(r, auth.ActionRegister, tool.Name); err != nil {
+			h.recordAudit(r, auditImport, tool.Name, auditDenied, err.Error())
+			h.errorMsg(w, r, err, http.StatusForbidden)
+			return
+		}
+	}
+	if err := h.toolManager.ImportToolSet(toolSet); err != nil {
+		h.recordAudit(r, auditImport, "", auditFailed, err.Error())
+		h.errorMsg(w, r, err, http.StatusBadRequest)
+		return
+	}
+	for _, tool := range toolSet.Tools {
+		h.recordAudit(r, auditImport, tool.Name, auditSucceeded, "")
+	}
+
+	type Response struct {
+		Msg string `json:"message"`
+	}
+
+	json.NewEncoder(w).Encode(Response{
+		Msg: fmt.Sprintf("%d tools imported", len(toolSet.Tools)),
+	})
 }
 
 // Handles tool registration
 func (h *Handlers) ToolRegistrationHandler(w http.ResponseWriter, r *http.Request) {
 	var tool mcp.Tool
-	if err := json.NewDecoder(r.Body).Decode(&tool); err != nil {
-		h.errorMsg(w, err, http.StatusInternalServerError)
+	if err := util.DecodeJSON(r.Body, &tool, h.strictJSON); err != nil {
+		h.errorMsg(w, r, fmt.Errorf("invalid tool JSON: %w", err), bodyStatus(err))
 		return
 	}
 	if tool.SecurityMetadata.IsEmpty() {
-		h.errorMsg(w, errors.New("no security metadata found"), http.StatusBadRequest)
+		h.errorMsg(w, r, errors.New("no security metadata found"), http.StatusBadRequest)
 		return
 	}
+	if err := h.authorizeCert(r, auth.ActionRegister, tool.Name); err != nil {
+		h.recordAudit(r, auditRegister, tool.Name, auditDenied, err.Error())
+		h.errorMsg(w, r, err, http.StatusForbidden)
+		return
+	}
+	// callers can't vouch for where a tool came from, so don't let them claim a trusted source
+	tool.SecurityMetadata.Source = mcp.SourceUserProvided
+	for _, warning := range validate.CheckDescriptionSchemaConsistency(&tool) {
+		h.log.Warn("tool '%s' description and schema disagree: %s", tool.Name, warning)
+	}
 	if err := h.toolManager.RegisterTool(tool); err != nil {
-		h.errorMsg(w, err, http.StatusInternalServerError)
+		h.recordAudit(r, auditRegister, tool.Name, auditFailed, err.Error())
+		status := http.StatusInternalServerError
+		if errors.Is(err, mcp.ErrMissingAnnotations) || errors.Is(err, mcp.ErrPublisherSignature) {
+			status = http.StatusBadRequest
+		}
+		h.errorMsg(w, r, err, status)
 		return
 	}
+	h.recordAudit(r, auditRegister, tool.Name, auditSucceeded, "")
 
 	type Response struct {
 		Msg string `json:"message"`
@@ -198,23 +645,45 @@ func (h *Handlers) ToolRegistrationHandler(w http.ResponseWriter, r *http.Reques
 	})
 }
 
-// Gives a temporary token to the requestor to be able to register and valdiate tools
+// Gives a temporary token to the requestor to be able to register and valdiate tools.
+// The requestor must supply their credentials using HTTP basic authentication.
 // Tokens last an hour by default
 func (h *Handlers) TokenRequestHandler(w http.ResponseWriter, r *http.Request) {
-	userName := r.URL.Query().Get("userName")
-	if userName == "" {
-		h.errorMsg(w, errors.New("missing username"), http.StatusBadRequest)
+	userName, password, ok := r.BasicAuth()
+	if !ok || userName == "" {
+		h.errorMsg(w, r, errors.New("missing credentials"), http.StatusUnauthorized)
 		return
 	}
 
-	if !h.usersManager.HasUser(userName) {
-		h.errorMsg(w, errors.New("register before requesting token"), http.StatusBadRequest)
+	h.issueToken(w, r, auth.Credentials{UserName: userName, Password: password})
+}
+
+// Verifies a user's credentials and issues a token on success
+func (h *Handlers) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var creds auth.Credentials
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		h.errorMsg(w, r, err, bodyStatus(err))
 		return
 	}
+	if creds.UserName == "" {
+		h.errorMsg(w, r, errors.New("missing username"), http.StatusBadRequest)
+		return
+	}
+
+	h.issueToken(w, r, creds)
+}
 
-	token, err := auth.CreateToken(userName, time.Hour)
+// issueToken returns the user's active token once their credentials are verified,
+// creating and recording a new one if they don't have a valid token already
+func (h *Handlers) issueToken(w http.ResponseWriter, r *http.Request, creds auth.Credentials) {
+	if !h.usersManager.VerifyPassword(creds.UserName, creds.Password) {
+		h.errorMsg(w, r, errors.New("invalid credentials"), http.StatusUnauthorized)
+		return
+	}
+
+	token, err := h.usersManager.IssueToken(creds.UserName, time.Hour)
 	if err != nil {
-		h.errorMsg(w, err, http.StatusInternalServerError)
+		h.errorMsg(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -224,29 +693,74 @@ func (h *Handlers) TokenRequestHandler(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(Token{Tok: token})
 	if err != nil {
-		h.errorMsg(w, err, http.StatusInternalServerError)
+		h.errorMsg(w, r, err, http.StatusInternalServerError)
+	}
+}
+
+// Revokes the caller's current token so it can't be used again
+func (h *Handlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		h.errorMsg(w, r, auth.ErrUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	auth.Revoke(auth.TokenFromRequest(r))
+	if err := h.usersManager.RevokeToken(claims.Username); err != nil {
+		h.errorMsg(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	type LogoutResponse struct {
+		Message string `json:"message"`
+	}
+
+	err := json.NewEncoder(w).Encode(LogoutResponse{
+		Message: fmt.Sprintf("'%s' logged out", claims.Username),
+	})
+	if err != nil {
+		h.errorMsg(w, r, err, http.StatusInternalServerError)
 	}
 }
 
 // Adds a new user to the session so they can be granted a token
 func (h *Handlers) RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
-	userName := r.URL.Query().Get("userName")
-	if userName == "" {
-		h.errorMsg(w, errors.New("missing username"), http.StatusBadRequest)
+	var creds auth.Credentials
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		h.errorMsg(w, r, err, bodyStatus(err))
+		return
+	}
+	if creds.UserName == "" {
+		h.errorMsg(w, r, errors.New("missing username"), http.StatusBadRequest)
+		return
+	}
+	if creds.Password == "" {
+		h.errorMsg(w, r, errors.New("missing password"), http.StatusBadRequest)
+		return
+	}
+	// admin accounts come from configuration, so nobody can claim an admin name by registering first
+	if slices.Contains(h.adminUsers, creds.UserName) {
+		h.errorMsg(w, r, fmt.Errorf("username '%s' is reserved", creds.UserName), http.StatusForbidden)
 		return
 	}
 
-	// will be a no-op if the user is already registered
-	h.usersManager.AddUser(userName)
+	if err := h.usersManager.AddUser(creds.UserName, creds.Password); err != nil {
+		if errors.Is(err, auth.ErrUserExists) {
+			h.errorMsg(w, r, err, http.StatusConflict)
+			return
+		}
+		h.errorMsg(w, r, err, http.StatusInternalServerError)
+		return
+	}
 
 	type RegisterResponse struct {
 		Message string `json:"message"`
 	}
 
 	err := json.NewEncoder(w).Encode(RegisterResponse{
-		Message: fmt.Sprintf("'%s' registered", userName),
+		Message: fmt.Sprintf("'%s' registered", creds.UserName),
 	})
 	if err != nil {
-		h.errorMsg(w, err, http.StatusInternalServerError)
+		h.errorMsg(w, r, err, http.StatusInternalServerError)
 	}
 }
@@ -0,0 +1,711 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/codec"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+	"github.com/null-create/mcp-tls/pkg/tls"
+	"github.com/null-create/mcp-tls/pkg/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// keep the csv log files produced by the handlers' loggers out of the tree
+	logDir, err := os.MkdirTemp("", "mcp-tls-logs")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("LOG_DIR", logDir)
+
+	code := m.Run()
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
+// newTestHandler creates a handler using the shared bundle key seed.
+func newTestHandler(t *testing.T, seed []byte) *Handlers {
+	t.Helper()
+	t.Setenv("MCPTLS_BUNDLE_KEY", base64.StdEncoding.EncodeToString(seed))
+	h := NewHandler()
+	return &h
+}
+
+func mustGenerateSeed(t *testing.T) []byte {
+	t.Helper()
+	seed := make([]byte, ed25519.SeedSize)
+	_, err := rand.Read(seed)
+	require.NoError(t, err)
+	return seed
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	seed := mustGenerateSeed(t)
+	source := newTestHandler(t, seed)
+	target := newTestHandler(t, seed)
+
+	tool := mcp.Tool{
+		Name:        "export-tool",
+		Description: "A tool to be exported",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"a":{"type":"string"}}}`),
+	}
+	require.NoError(t, source.toolManager.RegisterTool(tool))
+
+	exportRec := httptest.NewRecorder()
+	source.ExportToolsHandler(exportRec, httptest.NewRequest(http.MethodGet, "/api/tools/export", nil))
+	require.Equal(t, http.StatusOK, exportRec.Code)
+	bundle := exportRec.Body.Bytes()
+
+	importRec := httptest.NewRecorder()
+	target.ImportToolsHandler(importRec, httptest.NewRequest(http.MethodPost, "/api/tools/import", bytes.NewReader(bundle)))
+	require.Equal(t, http.StatusOK, importRec.Code, importRec.Body.String())
+
+	imported, err := target.toolManager.GetTool("export-tool")
+	require.NoError(t, err)
+	original, err := source.toolManager.GetTool("export-tool")
+	require.NoError(t, err)
+	assert.Equal(t, original.SecurityMetadata, imported.SecurityMetadata)
+
+	t.Run("Fail Tampered Bundle", func(t *testing.T) {
+		var signed tls.SignedBundle
+		require.NoError(t, json.Unmarshal(bundle, &signed))
+
+		var toolSet mcp.ToolSet
+		require.NoError(t, json.Unmarshal(signed.Payload, &toolSet))
+		toolSet.Tools[0].Description = "Tampered description"
+		signed.Payload, err = json.Marshal(toolSet)
+		require.NoError(t, err)
+		tampered, err := json.Marshal(signed)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		newTestHandler(t, seed).ImportToolsHandler(rec, httptest.NewRequest(http.MethodPost, "/api/tools/import", bytes.NewReader(tampered)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Fail Foreign Signing Key", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		other := newTestHandler(t, mustGenerateSeed(t))
+		other.ImportToolsHandler(rec, httptest.NewRequest(http.MethodPost, "/api/tools/import", bytes.NewReader(bundle)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		_, err := other.toolManager.GetTool("export-tool")
+		assert.Error(t, err)
+	})
+}
+
+// registerTestUser registers a user with the given password through the handler.
+func registerTestUser(t *testing.T, h *Handlers, userName, password string) {
+	t.Helper()
+	body, err := json.Marshal(auth.Credentials{UserName: userName, Password: password})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h.RegisterUserHandler(rec, httptest.NewRequest(http.MethodPost, "/api/users/new", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestLoginHandler(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	registerTestUser(t, h, "alice", "correct horse battery staple")
+
+	login := func(creds auth.Credentials) *httptest.ResponseRecorder {
+		body, err := json.Marshal(creds)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		h.LoginHandler(rec, httptest.NewRequest(http.MethodPost, "/api/users/login", bytes.NewReader(body)))
+		return rec
+	}
+
+	t.Run("Correct Credentials Issue Token", func(t *testing.T) {
+		rec := login(auth.Credentials{UserName: "alice", Password: "correct horse battery staple"})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp struct {
+			Token string `json:"token"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		claims, err := auth.ParseToken(resp.Token)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", claims.Username)
+
+		stored, ok := h.usersManager.Token("alice")
+		require.True(t, ok, "issued token should be recorded against the user")
+		assert.Equal(t, resp.Token, stored)
+
+		// logging in again while the token is active returns the same token
+		again := login(auth.Credentials{UserName: "alice", Password: "correct horse battery staple"})
+		require.Equal(t, http.StatusOK, again.Code)
+		var againResp struct {
+			Token string `json:"token"`
+		}
+		require.NoError(t, json.Unmarshal(again.Body.Bytes(), &againResp))
+		assert.Equal(t, resp.Token, againResp.Token)
+	})
+
+	t.Run("Wrong Password Unauthorized", func(t *testing.T) {
+		rec := login(auth.Credentials{UserName: "alice", Password: "wrong"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Unknown User Unauthorized", func(t *testing.T) {
+		rec := login(auth.Credentials{UserName: "mallory", Password: "anything"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestTokenRequestHandler_RequiresCredentials(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	registerTestUser(t, h, "bob", "s3cret")
+
+	t.Run("Missing Credentials", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.TokenRequestHandler(rec, httptest.NewRequest(http.MethodGet, "/api/users/auth?userName=bob", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Wrong Password", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/auth", nil)
+		req.SetBasicAuth("bob", "guess")
+		rec := httptest.NewRecorder()
+		h.TokenRequestHandler(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Correct Credentials", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/auth", nil)
+		req.SetBasicAuth("bob", "s3cret")
+		rec := httptest.NewRecorder()
+		h.TokenRequestHandler(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	})
+}
+
+func TestRegisterUserHandler_ExistingUser(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	registerTestUser(t, h, "carol", "first")
+
+	body, err := json.Marshal(auth.Credentials{UserName: "carol", Password: "second"})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h.RegisterUserHandler(rec, httptest.NewRequest(http.MethodPost, "/api/users/new", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	assert.True(t, h.usersManager.VerifyPassword("carol", "first"))
+}
+
+func TestRegisterUserHandler_AdminNames(t *testing.T) {
+	t.Setenv("MCPTLS_ADMIN_USERS", "root,ops")
+	t.Setenv("MCPTLS_ADMIN_CREDENTIALS", "root:correct horse,guest:battery staple")
+	h := newTestHandler(t, mustGenerateSeed(t))
+
+	// admins come from configuration, never from whoever registers their name first
+	for _, name := range []string{"root", "ops"} {
+		body, err := json.Marshal(auth.Credentials{UserName: name, Password: "hijacked"})
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		h.RegisterUserHandler(rec, httptest.NewRequest(http.MethodPost, "/api/users/new", bytes.NewReader(body)))
+		assert.Equal(t, http.StatusForbidden, rec.Code, name)
+		assert.False(t, h.usersManager.VerifyPassword(name, "hijacked"), name)
+	}
+
+	assert.True(t, h.usersManager.VerifyPassword("root", "correct horse"), "configured admins should be provisioned")
+	assert.False(t, h.usersManager.HasUser("ops"), "admins without credentials have no account")
+	assert.False(t, h.usersManager.HasUser("guest"), "credentials for non-admins should be ignored")
+	registerTestUser(t, h, "guest", "battery staple")
+}
+
+// failingValidator rejects every input and output.
+type failingValidator struct{}
+
+func (failingValidator) ValidateInput(tool *mcp.Tool, inputArguments []byte) (validate.ValidationStatus, error) {
+	return validate.StatusFailed, errors.New("rejected by failing validator")
+}
+
+func (failingValidator) ValidateOutput(tool *mcp.Tool, rawResult string) (validate.ValidationStatus, error) {
+	return validate.StatusFailed, errors.New("rejected by failing validator")
+}
+
+func TestValidate_UsesToolValidator(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+
+	tool := mcp.Tool{
+		Name:        "custom-tool",
+		Description: "A tool with a custom validator",
+		Arguments:   json.RawMessage(`{}`),
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+	}
+	require.NoError(t, h.toolManager.RegisterTool(tool))
+	registered, err := h.toolManager.GetTool("custom-tool")
+	require.NoError(t, err)
+	tool.SecurityMetadata = registered.SecurityMetadata
+
+	result := h.validate(context.Background(), &tool)
+	require.True(t, result.Valid, result.Error)
+
+	h.validators.Register("custom-tool", failingValidator{})
+	result = h.validate(context.Background(), &tool)
+	assert.False(t, result.Valid)
+	assert.Contains(t, result.Error, "rejected by failing validator")
+}
+
+func TestValidate_DeprecatedTool(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	tool := mcp.Tool{
+		Name:               "old-tool",
+		Description:        "A tool on its way out",
+		Arguments:          json.RawMessage(`{}`),
+		InputSchema:        json.RawMessage(`{"type":"object"}`),
+		Deprecated:         true,
+		DeprecationMessage: "use new-tool",
+	}
+	require.NoError(t, h.toolManager.RegisterTool(tool))
+	registered, err := h.toolManager.GetTool("old-tool")
+	require.NoError(t, err)
+	tool.SecurityMetadata = registered.SecurityMetadata
+
+	result := h.validate(context.Background(), &tool)
+	require.True(t, result.Valid, result.Error)
+	assert.Equal(t, "tool 'old-tool' is deprecated: use new-tool", result.Warning)
+
+	h.toolManager.SetDeprecationCutoff(time.Now().Add(-time.Minute))
+	result = h.validate(context.Background(), &tool)
+	assert.False(t, result.Valid)
+	assert.Contains(t, result.Error, "deprecated and blocked")
+}
+
+func TestValidateToolHandler_SecurityMetadataMismatch(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+		Name:        "signed-tool",
+		Description: "A tool checked against its registered metadata",
+		Arguments:   json.RawMessage(`{}`),
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+	}))
+	registered, err := h.toolManager.GetTool("signed-tool")
+	require.NoError(t, err)
+
+	// flipLast changes the final character so the value keeps its length
+	flipLast := func(s string) string {
+		if strings.HasSuffix(s, "0") {
+			return s[:len(s)-1] + "1"
+		}
+		return s[:len(s)-1] + "0"
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(md *mcp.SecurityMetadata)
+		valid  bool
+	}{
+		{name: "matching", tamper: func(md *mcp.SecurityMetadata) {}, valid: true},
+		{name: "checksum same length", tamper: func(md *mcp.SecurityMetadata) { md.Checksum = flipLast(md.Checksum) }},
+		{name: "checksum truncated", tamper: func(md *mcp.SecurityMetadata) { md.Checksum = md.Checksum[:8] }},
+		{name: "checksum missing", tamper: func(md *mcp.SecurityMetadata) { md.Checksum = "" }},
+		{name: "signature same length", tamper: func(md *mcp.SecurityMetadata) { md.Signature = flipLast(md.Signature) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := registered
+			tt.tamper(&tool.SecurityMetadata)
+			body, err := json.Marshal(tool)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			h.ValidateToolHandler(rec, httptest.NewRequest(http.MethodPost, "/api/validate/tool", bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var result mcp.ToolValidationResult
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+			assert.Equal(t, tt.valid, result.Valid, result.Error)
+			if !tt.valid {
+				assert.Equal(t, "signature or checksum mismatch", result.Error)
+			}
+		})
+	}
+}
+
+func TestListToolsHandler_ETag(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+		Name:        "list-tool",
+		Description: "A tool to be listed",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+	}))
+
+	list := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/list", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		h.ListToolsHandler(rec, req)
+		return rec
+	}
+
+	first := list("")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	var tools []mcp.Tool
+	require.NoError(t, json.Unmarshal(first.Body.Bytes(), &tools))
+	assert.Len(t, tools, 1)
+
+	t.Run("Unchanged Returns 304", func(t *testing.T) {
+		for _, header := range []string{etag, `"stale", ` + etag, "W/" + etag, "*"} {
+			rec := list(header)
+			assert.Equal(t, http.StatusNotModified, rec.Code, header)
+			assert.Empty(t, rec.Body.Bytes(), header)
+			assert.Equal(t, etag, rec.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("Stale ETag Returns List", func(t *testing.T) {
+		rec := list(`"stale"`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, first.Body.String(), rec.Body.String())
+	})
+
+	t.Run("Registry Change Invalidates ETag", func(t *testing.T) {
+		require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+			Name:        "another-tool",
+			Description: "A newly registered tool",
+			InputSchema: json.RawMessage(`{"type":"object"}`),
+		}))
+
+		rec := list(etag)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+	})
+}
+
+func TestVerifyToolsHandler(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+		Name:        "good-tool",
+		Description: "An untouched tool",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+	}))
+	// a registry entry whose checksum no longer matches its definition
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+		Name:             "tampered-tool",
+		Description:      "A tool that was modified after signing",
+		InputSchema:      json.RawMessage(`{"type":"object"}`),
+		SecurityMetadata: mcp.SecurityMetadata{Checksum: "0000"},
+	}))
+
+	rec := httptest.NewRecorder()
+	h.VerifyToolsHandler(rec, httptest.NewRequest(http.MethodPost, "/api/tools/verify", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var results []mcp.ToolValidationResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "good-tool", results[0].Name)
+	assert.True(t, results[0].Valid)
+	assert.NotEmpty(t, results[0].Checksum)
+
+	assert.Equal(t, "tampered-tool", results[1].Name)
+	assert.False(t, results[1].Valid)
+	assert.Contains(t, results[1].Error, "checksum")
+}
+
+func TestSchemaCoverageHandler(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+		Name:         "described-tool",
+		Description:  "A tool with both schemas",
+		InputSchema:  json.RawMessage(`{"type":"object","properties":{"q":{"type":"string"}}}`),
+		OutputSchema: json.RawMessage(`{"type":"object","properties":{"answer":{"type":"string"}}}`),
+	}))
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+		Name:        "open-tool",
+		Description: "A tool that accepts any arguments",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+	}))
+
+	rec := httptest.NewRecorder()
+	h.SchemaCoverageHandler(rec, httptest.NewRequest(http.MethodGet, "/api/tools/coverage", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var report mcp.SchemaCoverageReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, []mcp.ToolSchemaCoverage{
+		{Name: "described-tool", HasInputSchema: true, HasOutputSchema: true},
+		{Name: "open-tool", HasInputSchema: true, InputPermissive: true},
+	}, report.Tools)
+	assert.Equal(t, 50.0, report.Percent)
+}
+
+func TestStrictJSON_RejectsUnknownFields(t *testing.T) {
+	body := `{"name":"typo-tool","description":"A tool","inputShema":{"type":"object"}}`
+
+	tests := []struct {
+		name    string
+		handler func(h *Handlers) http.HandlerFunc
+		body    string
+	}{
+		{name: "validate tool", handler: func(h *Handlers) http.HandlerFunc { return h.ValidateToolHandler }, body: body},
+		{name: "validate tools", handler: func(h *Handlers) http.HandlerFunc { return h.ValidateToolsHandler }, body: "[" + body + "]"},
+		{name: "register tool", handler: func(h *Handlers) http.HandlerFunc { return h.ToolRegistrationHandler }, body: body},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, mustGenerateSeed(t))
+			h.strictJSON = true
+
+			rec := httptest.NewRecorder()
+			tt.handler(h)(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), "inputShema", "error should name the unknown field")
+		})
+	}
+
+	t.Run("lenient by default", func(t *testing.T) {
+		h := newTestHandler(t, mustGenerateSeed(t))
+
+		rec := httptest.NewRecorder()
+		h.ValidateToolHandler(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "inputShema")
+	})
+}
+
+func TestToolRegistrationHandler_RecordsUserProvidedSource(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	tool := mcp.Tool{
+		Name:        "claimed-tool",
+		Description: "A tool claiming to come from a trusted repo",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+	}
+	require.NoError(t, mcp.SecureTool(&tool))
+	tool.SecurityMetadata.Source = "trusted-registry"
+	body, err := json.Marshal(tool)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h.ToolRegistrationHandler(rec, httptest.NewRequest(http.MethodPost, "/api/tools/register", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	list := func(query string) []mcp.Tool {
+		rec := httptest.NewRecorder()
+		h.ListToolsHandler(rec, httptest.NewRequest(http.MethodGet, "/api/tools/list"+query, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var tools []mcp.Tool
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tools))
+		return tools
+	}
+
+	tools := list("?source=" + mcp.SourceUserProvided)
+	require.Len(t, tools, 1)
+	assert.Equal(t, "claimed-tool", tools[0].Name)
+	assert.Empty(t, list("?source=trusted-registry"))
+}
+
+func TestToolRegistrationHandler_RequiredAnnotations(t *testing.T) {
+	t.Setenv("MCPTLS_REQUIRED_ANNOTATIONS", "title,destructiveHint")
+	h := newTestHandler(t, mustGenerateSeed(t))
+	register := func(tool mcp.Tool) *httptest.ResponseRecorder {
+		require.NoError(t, mcp.SecureTool(&tool))
+		body, err := json.Marshal(tool)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		h.ToolRegistrationHandler(rec, httptest.NewRequest(http.MethodPost, "/api/tools/register", bytes.NewReader(body)))
+		return rec
+	}
+
+	rec := register(mcp.Tool{
+		Name:        "unlabelled-tool",
+		Description: "A tool leaving its hints at their defaults",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+		Annotations: mcp.ToolAnnotation{Title: "Unlabelled"},
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "destructiveHint")
+	_, err := h.toolManager.GetTool("unlabelled-tool")
+	assert.Error(t, err)
+
+	labelled := mcp.Tool{
+		Name:        "labelled-tool",
+		Description: "A tool stating it's not destructive",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+		Annotations: mcp.ToolAnnotation{Title: "Labelled"},
+	}
+	require.NoError(t, labelled.Annotations.SetHint(mcp.AnnotationDestructive, false))
+	rec = register(labelled)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestToolRegistrationHandler_AcceptsPreparedToolUnmodified(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	tool := mcp.Tool{
+		Name:         "prepared-tool",
+		Description:  "A tool whose metadata the client generated",
+		InputSchema:  json.RawMessage(`{"type":"object","properties":{"q":{"type":"string"}}}`),
+		OutputSchema: json.RawMessage(`{"type":"object"}`),
+	}
+	require.NoError(t, mcp.PrepareToolForRegistration(&tool, mcp.WithToolVersion("1.2.0")))
+	body, err := json.Marshal(tool)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h.ToolRegistrationHandler(rec, httptest.NewRequest(http.MethodPost, "/api/tools/register", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	registered, err := h.toolManager.GetTool("prepared-tool")
+	require.NoError(t, err)
+	assert.Equal(t, tool.SecurityMetadata, registered.SecurityMetadata)
+	require.NoError(t, h.toolManager.VerifyTool("prepared-tool"))
+
+	tool.Arguments = json.RawMessage(`{"q":"weather"}`)
+	result := h.validate(context.Background(), &tool)
+	assert.True(t, result.Valid, result.Error)
+}
+
+func TestCertPolicy_AuthorizesToolActions(t *testing.T) {
+	policy := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(policy, []byte(`{
+		"roles": {
+			"publisher": {"actions": ["register", "validate"], "tools": ["*"]},
+			"reader": {"actions": ["validate"], "tools": ["cert-*"]}
+		},
+		"identities": {"ci.example.com": ["publisher"], "agent.example.com": ["reader"]}
+	}`), 0o600))
+	t.Setenv("MCPTLS_CERT_POLICY", policy)
+	h := newTestHandler(t, mustGenerateSeed(t))
+
+	tool := mcp.Tool{Name: "cert-tool", Description: "A tool behind the cert policy", InputSchema: json.RawMessage(`{"type":"object"}`)}
+	require.NoError(t, mcp.PrepareToolForRegistration(&tool))
+	body, err := json.Marshal(tool)
+	require.NoError(t, err)
+
+	request := func(path, commonName string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		if commonName != "" {
+			req.TLS = &cryptotls.ConnectionState{PeerCertificates: []*x509.Certificate{
+				{Subject: pkix.Name{CommonName: "client"}, DNSNames: []string{commonName}},
+			}}
+		}
+		return req
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		path       string
+		identity   string
+		wantStatus int
+	}{
+		{name: "reader cannot register", handler: h.ToolRegistrationHandler, path: "/api/tools/register", identity: "agent.example.com", wantStatus: http.StatusForbidden},
+		{name: "no certificate", handler: h.ToolRegistrationHandler, path: "/api/tools/register", wantStatus: http.StatusForbidden},
+		{name: "publisher registers", handler: h.ToolRegistrationHandler, path: "/api/tools/register", identity: "ci.example.com", wantStatus: http.StatusOK},
+		{name: "reader validates", handler: h.ValidateToolHandler, path: "/api/validate/tool", identity: "agent.example.com", wantStatus: http.StatusOK},
+		{name: "unknown certificate", handler: h.ValidateToolHandler, path: "/api/validate/tool", identity: "stranger.example.com", wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, request(tt.path, tt.identity))
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+
+	_, err = h.toolManager.GetTool("cert-tool")
+	assert.NoError(t, err, "the authorized registration should have gone through")
+}
+
+func TestCertPolicy_AuthorizesImportAndCall(t *testing.T) {
+	policy := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(policy, []byte(`{
+		"roles": {
+			"publisher": {"actions": ["register"], "tools": ["*"]},
+			"caller": {"actions": ["call"], "tools": ["cert-*"]}
+		},
+		"identities": {"ci.example.com": ["publisher"], "agent.example.com": ["caller"]}
+	}`), 0o600))
+	t.Setenv("MCPTLS_CERT_POLICY", policy)
+	seed := mustGenerateSeed(t)
+
+	withCert := func(req *http.Request, identity string) *http.Request {
+		if identity != "" {
+			req.TLS = &cryptotls.ConnectionState{PeerCertificates: []*x509.Certificate{
+				{Subject: pkix.Name{CommonName: "client"}, DNSNames: []string{identity}},
+			}}
+		}
+		return req
+	}
+
+	source := newTestHandler(t, seed)
+	tool := mcp.Tool{Name: "cert-tool", Description: "A tool behind the cert policy", InputSchema: json.RawMessage(`{"type":"object"}`)}
+	require.NoError(t, source.toolManager.RegisterTool(tool))
+	exportRec := httptest.NewRecorder()
+	source.ExportToolsHandler(exportRec, httptest.NewRequest(http.MethodGet, "/api/tools/export", nil))
+	require.Equal(t, http.StatusOK, exportRec.Code)
+	bundle := exportRec.Body.Bytes()
+
+	t.Run("import", func(t *testing.T) {
+		for identity, want := range map[string]int{
+			"":                  http.StatusForbidden,
+			"agent.example.com": http.StatusForbidden,
+			"ci.example.com":    http.StatusOK,
+		} {
+			target := newTestHandler(t, seed)
+			rec := httptest.NewRecorder()
+			target.ImportToolsHandler(rec, withCert(httptest.NewRequest(http.MethodPost, "/api/tools/import", bytes.NewReader(bundle)), identity))
+			assert.Equal(t, want, rec.Code, "identity %q: %s", identity, rec.Body.String())
+			_, err := target.toolManager.GetTool("cert-tool")
+			assert.Equal(t, want == http.StatusOK, err == nil, "identity %q imported the tool", identity)
+		}
+	})
+
+	t.Run("tools/call", func(t *testing.T) {
+		source.SetToolExecutor(echoExecutor{})
+		body, err := json.Marshal(codec.JSONRPCRequest{
+			JSONRPC: codec.JsonRPCVersion,
+			Method:  "tools/call",
+			Params:  json.RawMessage(`{"name":"cert-tool","arguments":{}}`),
+			ID:      codec.NewID(1),
+		})
+		require.NoError(t, err)
+
+		for identity, allowed := range map[string]bool{
+			"":                  false,
+			"ci.example.com":    false,
+			"agent.example.com": true,
+		} {
+			rec := httptest.NewRecorder()
+			source.RPCHandler(rec, withCert(httptest.NewRequest(http.MethodPost, "/api/rpc", bytes.NewReader(body)), identity))
+			var resp codec.JSONRPCResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			if allowed {
+				assert.Nil(t, resp.Error, "identity %q", identity)
+				continue
+			}
+			require.NotNil(t, resp.Error, "identity %q should be refused", identity)
+			assert.Equal(t, codec.TOOL_DENIED, resp.Error.Code)
+		}
+	})
+}
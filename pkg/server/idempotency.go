@@ -0,0 +1,272 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/util"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// IdempotencyKeyHeader names the request header carrying a client's idempotency key
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLen bounds the keys clients may send
+	maxIdempotencyKeyLen = 255
+	// idempotencyPendingTTL bounds how long a key stays claimed by a request that never
+	// finished, e.g. because the server restarted, before retries may process it again
+	idempotencyPendingTTL = time.Minute
+
+	idempotencyKeyPrefix = "mcptls:idempotency:"
+)
+
+// IdempotencyStore holds the results of requests made with an idempotency key.
+// It is a plain expiring key-value store, so Redis can back it across replicas.
+type IdempotencyStore interface {
+	// SetNX stores value under key for ttl unless key already exists, reporting whether it did
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// Get returns the value stored under key, or false if there is none or it expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl, replacing any existing value
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key
+	Delete(ctx context.Context, key string) error
+}
+
+// idempotencyRecord is the stored state of a request made with an idempotency key.
+// A pending record claims the key while the first request is still being processed.
+type idempotencyRecord struct {
+	RequestHash string `json:"requestHash"`
+	Pending     bool   `json:"pending,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Idempotent makes a route safe to retry. The first request carrying an Idempotency-Key
+// header is processed and its response kept in store for ttl; requests repeating the key
+// within that window get the kept response back, marked with Idempotent-Replayed, without
+// being processed again. Keys are scoped to the caller identified by auth.Middleware.
+// Reusing a key for a different request body is rejected with 422, and retrying while the
+// first request is still in flight with 409. Server errors aren't kept, so they can be retried.
+func Idempotent(store IdempotencyStore, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				util.WriteError(w, r, http.StatusBadRequest, "Idempotency-Key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				util.WriteError(w, r, bodyStatus(err), err.Error())
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			hash := sha256.Sum256(body)
+			requestHash := hex.EncodeToString(hash[:])
+
+			username := ""
+			if claims, ok := auth.FromContext(r.Context()); ok {
+				username = claims.Username
+			}
+			storeKey := idempotencyKeyPrefix + username + ":" + key
+
+			ctx := r.Context()
+			pending, _ := json.Marshal(idempotencyRecord{RequestHash: requestHash, Pending: true})
+			// a kept response can expire between a failed claim and reading it back, so try twice
+			for attempt := 0; attempt < 2; attempt++ {
+				claimed, err := store.SetNX(ctx, storeKey, pending, idempotencyPendingTTL)
+				if err != nil {
+					log.Printf("ERROR idempotency store unavailable: %v", err)
+					util.WriteError(w, r, http.StatusServiceUnavailable, "idempotency store unavailable")
+					return
+				}
+				if claimed {
+					processIdempotent(w, r, next, store, storeKey, requestHash, ttl)
+					return
+				}
+
+				data, found, err := store.Get(ctx, storeKey)
+				if err != nil {
+					log.Printf("ERROR idempotency store unavailable: %v", err)
+					util.WriteError(w, r, http.StatusServiceUnavailable, "idempotency store unavailable")
+					return
+				}
+				if !found {
+					continue
+				}
+				var record idempotencyRecord
+				if err := json.Unmarshal(data, &record); err != nil {
+					util.WriteError(w, r, http.StatusInternalServerError, "corrupt idempotency record")
+					return
+				}
+				switch {
+				case record.RequestHash != requestHash:
+					util.WriteError(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+				case record.Pending:
+					util.WriteError(w, r, http.StatusConflict, "a request with this Idempotency-Key is still being processed")
+				default:
+					if record.ContentType != "" {
+						w.Header().Set("Content-Type", record.ContentType)
+					}
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(record.Status)
+					w.Write(record.Body)
+				}
+				return
+			}
+			util.WriteError(w, r, http.StatusConflict, "a request with this Idempotency-Key is still being processed")
+		})
+	}
+}
+
+// processIdempotent runs a request whose idempotency key it has claimed, keeping the
+// response for later retries, or releasing the key if the request failed on the server.
+func processIdempotent(w http.ResponseWriter, r *http.Request, next http.Handler, store IdempotencyStore, storeKey, requestHash string, ttl time.Duration) {
+	rec := &recordingResponseWriter{StatusRecorder: util.NewStatusRecorder(w)}
+	next.ServeHTTP(rec, r)
+	status := rec.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	// the client may have given up, but the outcome must still be recorded
+	ctx := context.WithoutCancel(r.Context())
+	if status >= http.StatusInternalServerError {
+		if err := store.Delete(ctx, storeKey); err != nil {
+			log.Printf("ERROR failed to release idempotency key: %v", err)
+		}
+		return
+	}
+	data, _ := json.Marshal(idempotencyRecord{
+		RequestHash: requestHash,
+		Status:      status,
+		ContentType: rec.Header().Get("Content-Type"),
+		Body:        rec.body.Bytes(),
+	})
+	if err := store.Set(ctx, storeKey, data, ttl); err != nil {
+		log.Printf("ERROR failed to record idempotent response: %v", err)
+	}
+}
+
+// recordingResponseWriter passes a response through while keeping a copy of it
+type recordingResponseWriter struct {
+	*util.StatusRecorder
+	body bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) Write(p []byte) (int, error) {
+	rw.body.Write(p)
+	return rw.StatusRecorder.Write(p)
+}
+
+// ---- Stores
+
+// RedisIdempotencyStore keeps idempotent responses in Redis, shared by every replica
+type RedisIdempotencyStore struct {
+	client *redis.Client
+}
+
+func NewRedisIdempotencyStore(client *redis.Client) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client}
+}
+
+func (s *RedisIdempotencyStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (s *RedisIdempotencyStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (s *RedisIdempotencyStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *RedisIdempotencyStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+// MemoryIdempotencyStore keeps idempotent responses in process memory. It suits a
+// single server; retries reaching another replica won't see its responses.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryIdempotencyStore) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// new keys are the only growth, so expired ones are swept here
+	for k, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, k)
+		}
+	}
+	if _, exists := s.entries[key]; exists {
+		return false, nil
+	}
+	s.entries[key] = memoryEntry{value: value, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryIdempotencyStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, exists := s.entries[key]
+	if !exists || time.Now().After(entry.expires) {
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryEntry{value: value, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
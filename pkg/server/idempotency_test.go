@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idempotentRequest builds a POST from the given user, with an idempotency key if one is given
+func idempotentRequest(user, key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/tools/register", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return req.WithContext(context.WithValue(req.Context(), auth.ContextUserKey, &auth.Claims{Username: user}))
+}
+
+func TestIdempotent_RetriedRegistrationReturnsCachedResult(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	var registrations atomic.Int32
+	handler := Idempotent(NewMemoryIdempotencyStore(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registrations.Add(1)
+		h.ToolRegistrationHandler(w, r)
+	}))
+
+	tool := mcp.Tool{
+		Name:        "retried-tool",
+		Description: "A tool registered over a flaky network",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"q":{"type":"string"}}}`),
+	}
+	require.NoError(t, mcp.SecureTool(&tool))
+	data, err := json.Marshal(tool)
+	require.NoError(t, err)
+	body := string(data)
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest("alice", "register-1", body))
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+	require.Equal(t, int32(1), registrations.Load())
+	_, err = h.toolManager.GetTool("retried-tool")
+	require.NoError(t, err)
+
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, idempotentRequest("alice", "register-1", body))
+	require.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, int32(1), registrations.Load(), "the retry registered the tool again")
+
+	// a new key is a new request
+	other := httptest.NewRecorder()
+	handler.ServeHTTP(other, idempotentRequest("alice", "register-2", body))
+	require.Equal(t, http.StatusOK, other.Code)
+	assert.Empty(t, other.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, int32(2), registrations.Load())
+}
+
+func TestIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int32{"call": n})
+	})
+
+	t.Run("Without Key", func(t *testing.T) {
+		calls.Store(0)
+		handler := Idempotent(NewMemoryIdempotencyStore(), time.Hour)(next)
+		for i := 0; i < 2; i++ {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, idempotentRequest("alice", "", `{}`))
+			assert.Equal(t, http.StatusCreated, rec.Code)
+		}
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Replays Status And Body", func(t *testing.T) {
+		calls.Store(0)
+		handler := Idempotent(NewMemoryIdempotencyStore(), time.Hour)(next)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("alice", "k", `{}`))
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("alice", "k", `{}`))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"call":1}`, rec.Body.String())
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Expired Key", func(t *testing.T) {
+		calls.Store(0)
+		handler := Idempotent(NewMemoryIdempotencyStore(), 20*time.Millisecond)(next)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("alice", "k", `{}`))
+		time.Sleep(40 * time.Millisecond)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("alice", "k", `{}`))
+		assert.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+		assert.JSONEq(t, `{"call":2}`, rec.Body.String())
+	})
+
+	t.Run("Keys Scoped To User", func(t *testing.T) {
+		calls.Store(0)
+		handler := Idempotent(NewMemoryIdempotencyStore(), time.Hour)(next)
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("alice", "k", `{}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("bob", "k", `{}`))
+		assert.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Different Body", func(t *testing.T) {
+		calls.Store(0)
+		handler := Idempotent(NewMemoryIdempotencyStore(), time.Hour)(next)
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("alice", "k", `{"a":1}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("alice", "k", `{"a":2}`))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("In Flight", func(t *testing.T) {
+		calls.Store(0)
+		store := NewMemoryIdempotencyStore()
+		handler := Idempotent(store, time.Hour)(next)
+		// the first request has claimed the key but not finished
+		hash := sha256.Sum256([]byte(`{}`))
+		pending, err := json.Marshal(idempotencyRecord{RequestHash: hex.EncodeToString(hash[:]), Pending: true})
+		require.NoError(t, err)
+		require.NoError(t, store.Set(context.Background(), idempotencyKeyPrefix+"alice:k", pending, time.Hour))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("alice", "k", `{}`))
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Zero(t, calls.Load())
+	})
+
+	t.Run("Server Errors Not Kept", func(t *testing.T) {
+		calls.Store(0)
+		handler := Idempotent(NewMemoryIdempotencyStore(), time.Hour)(next)
+		failing := idempotentRequest("alice", "k", `{}`)
+		failing.URL.RawQuery = "fail=1"
+		handler.ServeHTTP(httptest.NewRecorder(), failing)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("alice", "k", `{}`))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Key Too Long", func(t *testing.T) {
+		handler := Idempotent(NewMemoryIdempotencyStore(), time.Hour)(next)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("alice", strings.Repeat("k", maxIdempotencyKeyLen+1), `{}`))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
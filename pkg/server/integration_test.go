@@ -0,0 +1,112 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/codec"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// call sends a request to the test server and decodes a JSON response into out, if given.
+func call(t *testing.T, ts *httptest.Server, method, path string, body any, token string, out any) int {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, ts.URL+path, bytes.NewReader(data))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+func TestIntegration_RegisterThenValidate(t *testing.T) {
+	t.Setenv("MCPTLS_BUNDLE_KEY", "")
+	ts, err := NewTestServer()
+	require.NoError(t, err)
+	defer ts.Close()
+
+	creds := auth.Credentials{UserName: "frank", Password: "this mission is too important"}
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodPost, "/api/users/new", creds, "", nil))
+	var login tokenResponse
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodPost, "/api/users/login", creds, "", &login))
+	require.NotEmpty(t, login.Token)
+
+	// handshake
+	params, err := json.Marshal(mcp.InitializeParams{
+		ProtocolVersion: mcp.Version,
+		ClientInfo:      mcp.Implementation{Name: "integration-client", Version: "1.0.0"},
+	})
+	require.NoError(t, err)
+	var initResp codec.JSONRPCResponse
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodPost, "/api/rpc",
+		codec.JSONRPCRequest{JSONRPC: codec.JsonRPCVersion, Method: "initialize", Params: params, ID: codec.NewID(1)},
+		login.Token, &initResp))
+	require.Nil(t, initResp.Error)
+
+	// register
+	tool := mcp.Tool{
+		Name:        "greet",
+		Description: "Greets someone by name",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}`),
+	}
+	require.NoError(t, mcp.SecureTool(&tool))
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodPost, "/api/tools/register", tool, login.Token, nil))
+
+	var listed []mcp.Tool
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/tools/list", nil, login.Token, &listed))
+	require.Len(t, listed, 1)
+	registered := listed[0]
+	require.NotEmpty(t, registered.SecurityMetadata.Checksum)
+
+	// validate
+	t.Run("Valid Call", func(t *testing.T) {
+		toolCall := registered
+		toolCall.Arguments = json.RawMessage(`{"name":"Dave"}`)
+		var result mcp.ToolValidationResult
+		require.Equal(t, http.StatusOK, call(t, ts, http.MethodPost, "/api/validate/tool", toolCall, login.Token, &result))
+		assert.True(t, result.Valid, result.Error)
+	})
+
+	t.Run("Invalid Arguments", func(t *testing.T) {
+		toolCall := registered
+		toolCall.Arguments = json.RawMessage(`{"name":42}`)
+		var result mcp.ToolValidationResult
+		require.Equal(t, http.StatusOK, call(t, ts, http.MethodPost, "/api/validate/tool", toolCall, login.Token, &result))
+		assert.False(t, result.Valid)
+	})
+
+	t.Run("Tampered Tool", func(t *testing.T) {
+		toolCall := registered
+		toolCall.SecurityMetadata.Checksum = "0000"
+		toolCall.Arguments = json.RawMessage(`{"name":"Dave"}`)
+		var result mcp.ToolValidationResult
+		require.Equal(t, http.StatusOK, call(t, ts, http.MethodPost, "/api/validate/tool", toolCall, login.Token, &result))
+		assert.False(t, result.Valid)
+		assert.Equal(t, "signature or checksum mismatch", result.Error)
+	})
+
+	t.Run("Without Token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, call(t, ts, http.MethodPost, "/api/validate/tool", registered, "", nil))
+	})
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// ScanIntegrity re-verifies the checksum and schema fingerprints of every registered
+// tool each interval, quarantining any that fail, until ctx is cancelled. It catches
+// tampering with tools nobody is currently requesting, which lookups only notice lazily.
+func (h *Handlers) ScanIntegrity(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if failed := h.scanIntegrity(); failed > 0 {
+				h.log.Warn("integrity scan quarantined %d tool(s)", failed)
+			}
+		}
+	}
+}
+
+// scanIntegrity verifies every registered tool once and returns how many failed
+func (h *Handlers) scanIntegrity() int {
+	integrityScans.Inc()
+	failed := 0
+	for _, tool := range h.toolManager.GetTools() {
+		err := h.checkIntegrity(tool.Name)
+		if err != nil && !errors.Is(err, mcp.ErrToolQuarantined) && !errors.Is(err, mcp.ErrToolUnregistered) {
+			failed++
+		}
+	}
+	return failed
+}
+
+// checkIntegrity verifies a registered tool, quarantining it if the check fails
+func (h *Handlers) checkIntegrity(name string) error {
+	err := h.toolManager.VerifyTool(name)
+	if err == nil || errors.Is(err, mcp.ErrToolQuarantined) || errors.Is(err, mcp.ErrToolUnregistered) {
+		// tools quarantined or removed since they were listed have nothing left to check
+		return err
+	}
+	h.log.Error("tool '%s' failed integrity check: %v", name, err)
+	integrityFailures.Inc()
+	// keep the tampered definition as evidence rather than serving or discarding it
+	reason := "integrity check failed: " + err.Error()
+	if qErr := h.toolManager.Quarantine(name, reason); qErr != nil {
+		h.log.Error("failed to quarantine tool '%s': %v", name, qErr)
+		h.recordAudit(nil, auditQuarantine, name, auditFailed, qErr.Error())
+	} else {
+		h.recordAudit(nil, auditQuarantine, name, auditSucceeded, reason)
+	}
+	return err
+}
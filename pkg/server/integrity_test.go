@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanIntegrity_QuarantinesTamperedTool(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+		Name:        "good-tool",
+		Description: "An untouched tool",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+	}))
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+		Name:             "tampered-tool",
+		Description:      "A tool that was modified after signing",
+		InputSchema:      json.RawMessage(`{"type":"object"}`),
+		SecurityMetadata: mcp.SecurityMetadata{Checksum: "0000"},
+	}))
+
+	const interval = 50 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.ScanIntegrity(ctx, interval)
+		close(done)
+	}()
+
+	// registrations racing the scanner must neither block nor be flagged
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+				Name:        fmt.Sprintf("live-tool-%d", i),
+				Description: "A tool registered while the scanner runs",
+				InputSchema: json.RawMessage(`{"type":"object"}`),
+			}))
+		}(i)
+	}
+	wg.Wait()
+
+	require.Eventually(t, func() bool {
+		_, quarantined := h.toolManager.GetQuarantined("tampered-tool")
+		return quarantined
+	}, 2*interval, interval/5, "tampered tool was not quarantined within one scan interval")
+
+	entry, _ := h.toolManager.GetQuarantined("tampered-tool")
+	assert.Contains(t, entry.Reason, "integrity check failed")
+
+	// let another scan run over the remaining tools before checking them
+	time.Sleep(2 * interval)
+	_, err := h.toolManager.GetTool("good-tool")
+	assert.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		_, err := h.toolManager.GetTool(fmt.Sprintf("live-tool-%d", i))
+		assert.NoError(t, err)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scanner did not stop after its context was cancelled")
+	}
+}
+
+func TestNewRouter_StopsIntegrityScanWithContext(t *testing.T) {
+	t.Setenv("MCPTLS_INTEGRITY_SCAN_INTERVAL", "10ms")
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	NewRouter(ctx)
+	require.Greater(t, runtime.NumGoroutine(), baseline, "the integrity scan should be running")
+
+	cancel()
+	assertNoLeakedGoroutines(t, baseline)
+}
@@ -0,0 +1,62 @@
+package server
+
+import "github.com/null-create/mcp-tls/pkg/metrics"
+
+// Directions of proxied traffic, for the direction label
+const (
+	directionClientToServer = "client_to_server"
+	directionServerToClient = "server_to_client"
+)
+
+// Outcomes of a proxied message, for the result label
+const (
+	resultForwarded = "forwarded"
+	resultBlocked   = "blocked"
+)
+
+// Reasons a tool call was blocked, for the reason label
+const (
+	blockMalformed   = "malformed"
+	blockDenylist    = "denylist"
+	blockUnknownTool = "unknown_tool"
+	blockRateLimit   = "rate_limit"
+	blockSchema      = "schema"
+	blockDescription = "description"
+)
+
+var (
+	proxyMessages = metrics.Default.NewCounterVec(
+		"mcptls_proxy_messages_total",
+		"Messages handled by the proxy, by direction and whether they were forwarded or blocked.",
+		"direction", "result",
+	)
+	proxyBlocked = metrics.Default.NewCounterVec(
+		"mcptls_proxy_blocked_total",
+		"Tool calls blocked by the proxy, by reason.",
+		"reason",
+	)
+	proxyFailOpen = metrics.Default.NewCounterVec(
+		"mcptls_proxy_fail_open_total",
+		"Tool calls forwarded unvalidated because validation errored under the fail-open policy.",
+	).With()
+	proxyValidationSeconds = metrics.Default.NewHistogram(
+		"mcptls_proxy_validation_seconds",
+		"Time spent checking each tool call before forwarding or blocking it.",
+		metrics.DefBuckets,
+	)
+
+	integrityScans = metrics.Default.NewCounterVec(
+		"mcptls_integrity_scans_total",
+		"Background sweeps re-verifying every registered tool.",
+	).With()
+	integrityFailures = metrics.Default.NewCounterVec(
+		"mcptls_integrity_failures_total",
+		"Registered tools quarantined after failing an integrity check.",
+	).With()
+)
+
+// countBlocked records a client message the proxy refused to forward
+func countBlocked(reason string) {
+	proxyMessages.With(directionClientToServer, resultBlocked).Inc()
+	proxyBlocked.With(reason).Inc()
+}
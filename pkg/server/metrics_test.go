@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyMetrics_Forwarded(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	tool := mcp.Tool{
+		Name:        "weather-tool",
+		Description: "Gets the weather",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"location":{"type":"string"}}}`),
+	}
+	req := toolCallRequest(t, h, tool, `{"location":"Paris"}`, 1)
+
+	forwarded := proxyMessages.With(directionClientToServer, resultForwarded)
+	replies := proxyMessages.With(directionServerToClient, resultForwarded)
+	before, repliesBefore, observed := forwarded.Value(), replies.Value(), proxyValidationSeconds.Count()
+
+	_, _, err := h.validateAndForward(req)
+	require.NoError(t, err)
+	_, _, err = h.validateAndForward([]byte(`{"jsonrpc":"2.0","method":"tools/list","id":2}`))
+	require.NoError(t, err)
+	_, _, err = h.passthrough([]byte(`{"jsonrpc":"2.0","result":{},"id":1}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, before+2, forwarded.Value())
+	assert.Equal(t, repliesBefore+1, replies.Value())
+	assert.Equal(t, observed+1, proxyValidationSeconds.Count(), "only tool calls should be timed")
+}
+
+func TestProxyMetrics_Blocked(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	h.toolAccess = NewToolAccessList(nil, []string{"delete-file"})
+
+	schema := json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"}},"required":["path"]}`)
+	tests := []struct {
+		reason string
+		tool   mcp.Tool
+		args   string
+	}{
+		{reason: blockDenylist, tool: mcp.Tool{Name: "delete-file", Description: "Deletes a file", InputSchema: schema}, args: `{"path":"/tmp/x"}`},
+		{reason: blockSchema, tool: mcp.Tool{Name: "read-file", Description: "Reads a file", InputSchema: schema}, args: `{}`},
+		{reason: blockDescription, tool: mcp.Tool{Name: "sneaky-tool", Description: "Reads\u200b a file", InputSchema: schema}, args: `{"path":"/tmp/x"}`},
+	}
+
+	blocked := proxyMessages.With(directionClientToServer, resultBlocked)
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			byReason := proxyBlocked.With(tt.reason)
+			before, reasonBefore := blocked.Value(), byReason.Value()
+
+			forward, out, err := h.validateAndForward(toolCallRequest(t, h, tt.tool, tt.args, 1))
+			require.NoError(t, err)
+			assert.Empty(t, forward, "rejected calls should not reach the server")
+			proxyErrorResponse(t, out)
+
+			assert.Equal(t, before+1, blocked.Value())
+			assert.Equal(t, reasonBefore+1, byReason.Value())
+		})
+	}
+}
+
+func TestRouter_Metrics(t *testing.T) {
+	proxyBlocked.With(blockDenylist).Inc()
+
+	rec := serve(t, NewRouter(t.Context()), http.MethodGet, "/metrics", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `mcptls_proxy_blocked_total{reason="denylist"}`)
+	assert.Contains(t, rec.Body.String(), "# TYPE mcptls_proxy_validation_seconds histogram")
+}
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/util"
+)
+
+// RequireJSON rejects requests to mutating endpoints whose body is not declared as
+// application/json with 415 Unsupported Media Type. Parameters such as charset are allowed.
+// Requests without a body, such as action endpoints, are not checked.
+func RequireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				util.WriteError(w, r, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdmin allows only the named users through, rejecting everyone else with
+// 403 Forbidden. It must run after auth.Middleware, which identifies the caller.
+// With no admins configured, every caller is rejected.
+func RequireAdmin(admins []string) func(http.Handler) http.Handler {
+	allowed := make(map[string]struct{}, len(admins))
+	for _, name := range admins {
+		allowed[name] = struct{}{}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.FromContext(r.Context())
+			if !ok {
+				util.WriteError(w, r, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			if _, ok := allowed[claims.Username]; !ok {
+				util.WriteError(w, r, http.StatusForbidden, "admin access required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitedBody is a request body capped by MaxBodySize. It keeps the original
+// body so a route-level override can replace the global limit rather than nest inside it.
+type limitedBody struct {
+	io.ReadCloser
+	orig io.ReadCloser
+}
+
+// MaxBodySize caps request bodies at limit bytes. Reading past the limit fails with an
+// *http.MaxBytesError, which handlers report as 413 Request Entity Too Large. Applied
+// again further down the route tree, the innermost limit replaces the outer one, so
+// routes that legitimately accept larger bodies can raise it.
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil && r.Body != http.NoBody {
+				orig := r.Body
+				if lb, ok := orig.(*limitedBody); ok {
+					orig = lb.orig
+				}
+				r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, orig, limit), orig: orig}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bodyStatus returns the status to report for an error reading or decoding a
+// request body: 413 if the body exceeded MaxBodySize, 400 otherwise.
+func bodyStatus(err error) int {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// Timeout cancels the request's context after d and answers 503 Service Unavailable
+// if the handler hasn't finished by then, so a slow handler can't hold the connection
+// indefinitely. Handlers should give up once the context is done; whatever they write
+// after the deadline is discarded. Responses are buffered until the handler returns,
+// so Timeout doesn't suit streaming endpoints. A d of 0 disables it.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				// re-raised here for the Recoverer middleware
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				maps.Copy(w.Header(), tw.header)
+				if tw.code == 0 {
+					tw.code = http.StatusOK
+				}
+				w.WriteHeader(tw.code)
+				_, _ = w.Write(tw.body.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				// a client that went away gets no answer
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					util.WriteError(w, r, http.StatusServiceUnavailable, "request timed out")
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a response for Timeout, refusing writes once it has timed out
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.header }
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.body.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+// GzipMinSize is the smallest response body, in bytes, that Gzip will compress.
+// Anything smaller is cheaper to send as is.
+const GzipMinSize = 1024
+
+// gzipETagSuffix marks the ETag of a compressed response, whose bytes differ from the
+// uncompressed representation the handler tagged
+const gzipETagSuffix = "-gzip"
+
+// gzipETag returns the tag a strong ETag becomes when its response is compressed.
+// Weak and malformed tags are returned unchanged.
+func gzipETag(etag string) string {
+	if len(etag) < 2 || !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return etag[:len(etag)-1] + gzipETagSuffix + `"`
+}
+
+// Gzip compresses response bodies of at least GzipMinSize bytes for clients that
+// accept gzip. Smaller responses, and responses the handler already encoded, pass through untouched.
+// A compressed response's strong ETag gets gzipETagSuffix, so it never matches the
+// uncompressed representation's; a 304 answering the suffixed tag keeps the suffix.
+func Gzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, ifNoneMatch: r.Header.Get("If-None-Match")}
+		defer gw.finish()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows a gzip response
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q, found := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !found {
+			return true
+		}
+		weight, err := strconv.ParseFloat(q, 64)
+		return err == nil && weight > 0
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of a response until it is known to be
+// large enough to compress, then switches to streaming through a gzip.Writer.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	buf         []byte
+	gz          *gzip.Writer
+	passthrough bool
+	ifNoneMatch string // the request's If-None-Match, to tag a 304 as the response it confirms
+}
+
+func (g *gzipResponseWriter) WriteHeader(code int) {
+	if g.status == 0 {
+		g.status = code
+	}
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	switch {
+	case g.gz != nil:
+		return g.gz.Write(p)
+	case g.passthrough:
+		return g.ResponseWriter.Write(p)
+	}
+
+	g.buf = append(g.buf, p...)
+	if len(g.buf) < GzipMinSize {
+		return len(p), nil
+	}
+
+	header := g.Header()
+	if header.Get("Content-Encoding") != "" {
+		// the handler encoded the body itself
+		g.passthrough = true
+		if err := g.flushBuffer(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	if etag := header.Get("ETag"); etag != "" {
+		header.Set("ETag", gzipETag(etag))
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+	g.gz = gzip.NewWriter(g.ResponseWriter)
+	if _, err := g.gz.Write(g.buf); err != nil {
+		return 0, err
+	}
+	g.buf = nil
+	return len(p), nil
+}
+
+// flushBuffer writes the status and any buffered body uncompressed
+func (g *gzipResponseWriter) flushBuffer() error {
+	if g.status == http.StatusNotModified {
+		header := g.Header()
+		if etag := gzipETag(header.Get("ETag")); etag != header.Get("ETag") && strings.Contains(g.ifNoneMatch, etag) {
+			header.Set("ETag", etag)
+		}
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+	_, err := g.ResponseWriter.Write(g.buf)
+	g.buf = nil
+	return err
+}
+
+// finish completes the response once the handler returns
+func (g *gzipResponseWriter) finish() {
+	switch {
+	case g.gz != nil:
+		g.gz.Close()
+	case g.passthrough:
+	case g.status != 0:
+		g.flushBuffer()
+	}
+}
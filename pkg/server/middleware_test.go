@@ -0,0 +1,345 @@
+package server
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireJSON(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := RequireJSON(next)
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		noBody      bool
+		expected    int
+	}{
+		{name: "json", method: http.MethodPost, contentType: "application/json", expected: http.StatusOK},
+		{name: "json with charset", method: http.MethodPost, contentType: "application/json; charset=utf-8", expected: http.StatusOK},
+		{name: "mixed case", method: http.MethodPost, contentType: "Application/JSON", expected: http.StatusOK},
+		{name: "form data", method: http.MethodPost, contentType: "application/x-www-form-urlencoded", expected: http.StatusUnsupportedMediaType},
+		{name: "plain text", method: http.MethodPut, contentType: "text/plain", expected: http.StatusUnsupportedMediaType},
+		{name: "missing", method: http.MethodPost, contentType: "", expected: http.StatusUnsupportedMediaType},
+		{name: "malformed", method: http.MethodPatch, contentType: "application/json; charset", expected: http.StatusUnsupportedMediaType},
+		{name: "get is not checked", method: http.MethodGet, contentType: "", expected: http.StatusOK},
+		{name: "empty body is not checked", method: http.MethodPost, contentType: "", noBody: true, expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := "{}"
+			if tt.noBody {
+				body = ""
+			}
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
+
+func TestRouter_RejectsNonJSONPost(t *testing.T) {
+	router := NewRouter(t.Context())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/new", strings.NewReader("userName=bob&password=pw"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/users/new", strings.NewReader(`{"userName": "bob", "password": "pw"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestGzip_ListTools(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	for i := range 20 {
+		require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+			Name:        fmt.Sprintf("tool-%d", i),
+			Description: "A tool that makes the list response large enough to compress",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"a":{"type":"string"}}}`),
+		}))
+	}
+	handler := Gzip(http.HandlerFunc(h.ListToolsHandler))
+
+	t.Run("Compressed When Accepted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/tools/list", nil)
+		req.Header.Set("Accept-Encoding", "br, gzip")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+
+		gz, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		require.NoError(t, err)
+
+		var tools []mcp.Tool
+		require.NoError(t, json.Unmarshal(body, &tools))
+		assert.Len(t, tools, 20)
+	})
+
+	t.Run("Uncompressed When Not Accepted", func(t *testing.T) {
+		for _, acceptEncoding := range []string{"", "br", "gzip;q=0"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/tools/list", nil)
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Header().Get("Content-Encoding"), acceptEncoding)
+			assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+
+			var tools []mcp.Tool
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tools), acceptEncoding)
+			assert.Len(t, tools, 20)
+		}
+	})
+
+	t.Run("ETag Differs When Compressed", func(t *testing.T) {
+		list := func(acceptEncoding, ifNoneMatch string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/api/tools/list", nil)
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+			if ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+		plain := list("", "").Header().Get("ETag")
+		compressed := list("gzip", "")
+		require.Equal(t, "gzip", compressed.Header().Get("Content-Encoding"))
+		etag := compressed.Header().Get("ETag")
+		assert.Equal(t, strings.TrimSuffix(plain, `"`)+`-gzip"`, etag)
+
+		// either tag is recognized, and a 304 repeats the one sent
+		for _, tag := range []string{etag, "W/" + etag, plain} {
+			rec := list("gzip", tag)
+			assert.Equal(t, http.StatusNotModified, rec.Code, tag)
+			assert.Equal(t, strings.TrimPrefix(tag, "W/"), rec.Header().Get("ETag"), tag)
+		}
+		assert.Equal(t, http.StatusNotModified, list("", etag).Code)
+	})
+}
+
+func TestGzip_ETag(t *testing.T) {
+	body := strings.Repeat("a", GzipMinSize)
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("")
+	require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, `"v1-gzip"`, rec.Header().Get("ETag"))
+
+	// a 304 repeats the tag the client sent
+	assert.Equal(t, `"v1-gzip"`, get(`"v1-gzip"`).Header().Get("ETag"))
+	assert.Equal(t, `"v1"`, get(`"v1"`).Header().Get("ETag"))
+}
+
+func TestGzip_SmallResponseNotCompressed(t *testing.T) {
+	handler := Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"status":"ok"}`, rec.Body.String())
+}
+
+// oversizedJSON returns a syntactically valid JSON object of at least size bytes
+func oversizedJSON(size int) string {
+	return `{"pad": "` + strings.Repeat("a", size) + `"}`
+}
+
+func TestMaxBodySize_OverrideReplacesLimit(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(bodyStatus(err))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		size     int
+		expected int
+	}{
+		{name: "within limit", handler: MaxBodySize(64)(next), size: 64, expected: http.StatusOK},
+		{name: "over limit", handler: MaxBodySize(64)(next), size: 65, expected: http.StatusRequestEntityTooLarge},
+		{name: "override raises limit", handler: MaxBodySize(64)(MaxBodySize(256)(next)), size: 200, expected: http.StatusOK},
+		{name: "override lowers limit", handler: MaxBodySize(256)(MaxBodySize(64)(next)), size: 200, expected: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("a", tt.size)))
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
+
+func TestRouter_OversizedBodyReturns413(t *testing.T) {
+	t.Setenv("MCPTLS_MAX_BODY_SIZE", "1024")
+	t.Setenv("MCPTLS_MAX_IMPORT_BODY_SIZE", "4096")
+	router := NewRouter(t.Context())
+
+	creds := auth.Credentials{UserName: "hal", Password: "daisy daisy"}
+	rec := serve(t, router, http.MethodPost, "/api/users/new", creds, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = serve(t, router, http.MethodPost, "/api/users/login", creds, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+resp.Token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	routes := []string{
+		"/api/rpc",
+		"/api/users/new",
+		"/api/users/login",
+		"/api/validate/tool",
+		"/api/validate/tools",
+		"/api/validate/toolcall",
+		"/api/tools/register",
+		"/api/tools/import",
+	}
+	for _, path := range routes {
+		t.Run(path, func(t *testing.T) {
+			rec := post(path, oversizedJSON(8192))
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, rec.Body.String())
+		})
+	}
+
+	t.Run("import override", func(t *testing.T) {
+		rec := post("/api/tools/import", oversizedJSON(2048))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "bundles under the import limit should reach signature verification")
+	})
+}
+
+func TestTimeout_SlowHandlerReturns503(t *testing.T) {
+	gaveUp := make(chan error, 1)
+	handler := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			gaveUp <- r.Context().Err()
+		case <-time.After(5 * time.Second):
+			gaveUp <- nil
+		}
+		// too late, so this is discarded
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte("late"))
+		assert.ErrorIs(t, err, http.ErrHandlerTimeout)
+	}))
+
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/validate/tool", nil))
+	assert.Less(t, time.Since(start), time.Second, "the timeout should answer without waiting for the handler")
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var body struct {
+		Error string `json:"error"`
+		Code  int    `json:"code"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+	assert.Equal(t, http.StatusServiceUnavailable, body.Code)
+	assert.Equal(t, "request timed out", body.Error)
+	assert.ErrorIs(t, <-gaveUp, context.DeadlineExceeded, "the handler's context should be cancelled")
+}
+
+func TestTimeout_FastHandlerPassesThrough(t *testing.T) {
+	handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline := r.Context().Deadline()
+		assert.True(t, hasDeadline)
+		w.Header().Set("X-Test", "kept")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("done"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "kept", rec.Header().Get("X-Test"))
+	assert.Equal(t, "done", rec.Body.String())
+}
+
+func TestTimeout_ZeroDisables(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline := r.Context().Deadline()
+		assert.False(t, hasDeadline)
+	})
+	Timeout(0)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestTimeout_PanicReachesRecoverer(t *testing.T) {
+	handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+	assert.PanicsWithValue(t, "handler failed", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
@@ -0,0 +1,314 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/codec"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+	"github.com/null-create/mcp-tls/pkg/util"
+	"github.com/null-create/mcp-tls/pkg/validate"
+)
+
+// OpenAPIVersion is the OpenAPI specification version the served document follows
+const OpenAPIVersion = "3.0.3"
+
+// apiVersion is reported in the info section of the OpenAPI document
+const apiVersion = "1.0.0"
+
+// Response bodies the handlers build from local types, mirrored here so
+// their schemas can be derived like any other
+type (
+	messageResponse struct {
+		Message string `json:"message"`
+	}
+	tokenResponse struct {
+		Token string `json:"token"`
+	}
+)
+
+// apiOperation describes one route for the OpenAPI document. Request and
+// Response are zero values of the Go types sent and returned as JSON, or nil.
+type apiOperation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Tag         string
+	Auth        bool
+	Admin       bool // restricted to MCPTLS_ADMIN_USERS
+	Cert        bool // subject to the client certificate policy, MCPTLS_CERT_POLICY
+	Request     any
+	Response    any
+	RawResponse bool // the response is an opaque signed bundle rather than Response's schema
+}
+
+// apiOperations lists the routes registered by NewRouter
+var apiOperations = []apiOperation{
+	{Method: http.MethodGet, Path: "/health", Summary: "Report server and tool repository health", Tag: "server",
+		Response: struct {
+			Status   string `json:"status"`
+			ToolRepo string `json:"toolRepo"`
+		}{}},
+	{Method: http.MethodGet, Path: "/api/openapi.json", Summary: "This document", Tag: "server"},
+	{Method: http.MethodPost, Path: "/api/rpc", Summary: "Dispatch a JSON-RPC 2.0 request", Tag: "rpc", Auth: true,
+		Request: codec.JSONRPCRequest{}, Response: codec.JSONRPCResponse{}},
+	{Method: http.MethodGet, Path: "/api/users/auth", Summary: "Issue a token for HTTP basic auth credentials", Tag: "users",
+		Response: tokenResponse{}},
+	{Method: http.MethodPost, Path: "/api/users/new", Summary: "Register a user", Tag: "users",
+		Request: auth.Credentials{}, Response: messageResponse{}},
+	{Method: http.MethodPost, Path: "/api/users/login", Summary: "Issue a token for a user's credentials", Tag: "users",
+		Request: auth.Credentials{}, Response: tokenResponse{}},
+	{Method: http.MethodPost, Path: "/api/users/logout", Summary: "Revoke the caller's token", Tag: "users", Auth: true,
+		Response: messageResponse{}},
+	{Method: http.MethodPost, Path: "/api/validate/tool", Summary: "Validate a tool against its registered definition", Tag: "validate", Auth: true, Cert: true,
+		Request: mcp.Tool{}, Response: mcp.ToolValidationResult{}},
+	{Method: http.MethodPost, Path: "/api/validate/tools", Summary: "Validate several tools against their registered definitions", Tag: "validate", Auth: true, Cert: true,
+		Request: []mcp.Tool{}, Response: []mcp.ToolValidationResult{}},
+	{Method: http.MethodPost, Path: "/api/validate/toolcall", Summary: "Check whether a tool call would pass validation, without forwarding it", Tag: "validate", Auth: true, Cert: true,
+		Request: toolCallPreviewRequest{}, Response: toolCallPreview{}},
+	{Method: http.MethodPost, Path: "/api/tools/register", Summary: "Register a tool", Tag: "tools", Auth: true, Cert: true,
+		Request: mcp.Tool{}, Response: messageResponse{}},
+	{Method: http.MethodGet, Path: "/api/tools/list", Summary: "List registered tools", Tag: "tools", Auth: true,
+		Response: []mcp.Tool{}},
+	{Method: http.MethodPost, Path: "/api/tools/verify", Summary: "Re-check the integrity of every registered tool", Tag: "tools", Auth: true,
+		Response: []mcp.ToolValidationResult{}},
+	{Method: http.MethodGet, Path: "/api/tools/coverage", Summary: "Report which registered tools lack effective schemas", Tag: "tools", Auth: true,
+		Response: mcp.SchemaCoverageReport{}},
+	{Method: http.MethodPost, Path: "/api/tools/schema-diff", Summary: "Compare two versions of a tool schema", Tag: "tools", Auth: true,
+		Request: schemaDiffRequest{}, Response: mcp.SchemaDiff{}},
+	{Method: http.MethodGet, Path: "/api/tools/export", Summary: "Export registered tools as a signed bundle", Tag: "tools", Auth: true,
+		RawResponse: true},
+	{Method: http.MethodPost, Path: "/api/tools/import", Summary: "Import tools from a signed bundle", Tag: "tools", Auth: true,
+		Response: messageResponse{}},
+	{Method: http.MethodGet, Path: "/api/cache/stats", Summary: "Report hits, misses, and size for each cache", Tag: "cache", Auth: true,
+		Response: []validate.CacheStats{}},
+	{Method: http.MethodPost, Path: "/api/cache/flush", Summary: "Empty every cache", Tag: "cache", Auth: true, Admin: true,
+		Response: messageResponse{}},
+	{Method: http.MethodGet, Path: "/api/admin/quarantine", Summary: "List quarantined tools", Tag: "admin", Auth: true, Admin: true,
+		Response: []mcp.QuarantinedTool{}},
+	{Method: http.MethodGet, Path: "/api/admin/quarantine/{name}", Summary: "Inspect a quarantined tool", Tag: "admin", Auth: true, Admin: true,
+		Response: mcp.QuarantinedTool{}},
+	{Method: http.MethodPost, Path: "/api/admin/quarantine/{name}/release", Summary: "Return a quarantined tool to the registry", Tag: "admin", Auth: true, Admin: true,
+		Response: messageResponse{}},
+	{Method: http.MethodGet, Path: "/api/admin/audit", Summary: "Fetch the audit chain", Tag: "admin", Auth: true, Admin: true,
+		Response: AuditLog{}},
+}
+
+// OpenAPISpec builds an OpenAPI 3 document describing the HTTP API.
+// Schemas are derived from the Go types the handlers decode and encode.
+func OpenAPISpec() map[string]any {
+	schemas := map[string]any{}
+	paths := map[string]any{}
+	errorSchema := schemaFor(reflect.TypeOf(util.ErrorResponse{}), schemas)
+
+	for _, op := range apiOperations {
+		operation := map[string]any{
+			"summary":     op.Summary,
+			"tags":        []string{op.Tag},
+			"operationId": operationID(op.Method, op.Path),
+		}
+
+		if params := pathParameters(op.Path); len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(schemaFor(reflect.TypeOf(op.Request), schemas)),
+			}
+		}
+
+		ok := map[string]any{"description": "OK"}
+		switch {
+		case op.RawResponse:
+			ok["content"] = jsonContent(map[string]any{"type": "object", "description": "signed tool bundle"})
+		case op.Response != nil:
+			ok["content"] = jsonContent(schemaFor(reflect.TypeOf(op.Response), schemas))
+		}
+		responses := map[string]any{"200": ok}
+		if op.Request != nil {
+			responses["400"] = map[string]any{"description": "Malformed request body"}
+			responses["413"] = map[string]any{"description": "Request body too large"}
+			responses["415"] = map[string]any{"description": "Content-Type is not application/json"}
+		}
+		if op.Auth {
+			operation["security"] = []map[string][]string{{"bearerAuth": {}}}
+			responses["401"] = map[string]any{"description": "Missing, invalid, or revoked token"}
+		}
+		if op.Admin {
+			responses["403"] = map[string]any{"description": "Caller is not an admin"}
+		}
+		if op.Cert {
+			responses["403"] = map[string]any{"description": "Client certificate missing or not authorized for the tool"}
+		}
+		for status, response := range responses {
+			if status != "200" {
+				response.(map[string]any)["content"] = jsonContent(errorSchema)
+			}
+		}
+		operation["responses"] = responses
+
+		item, _ := paths[op.Path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": OpenAPIVersion,
+		"info": map[string]any{
+			"title":   "MCP-TLS Tool Validation Server",
+			"version": apiVersion,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+		},
+	}
+}
+
+// OpenAPIHandler serves the OpenAPI document for the HTTP API
+func (h *Handlers) OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	util.WriteJSON(w, OpenAPISpec())
+}
+
+// operationID derives an identifier such as "postApiToolsRegister" from a route
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '.' }) {
+		part = strings.Trim(part, "{}")
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// pathParameters describes each {name} segment of a route as a required string parameter
+func pathParameters(path string) []map[string]any {
+	var params []map[string]any
+	for _, segment := range strings.Split(path, "/") {
+		if name, ok := strings.CutPrefix(segment, "{"); ok {
+			params = append(params, map[string]any{
+				"name":     strings.TrimSuffix(name, "}"),
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+	}
+	return params
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+)
+
+// schemaFor derives a JSON schema for t following encoding/json's rules for field
+// names. Named struct types are added to schemas and referenced by name.
+func schemaFor(t reflect.Type, schemas map[string]any) map[string]any {
+	switch t {
+	case rawMessageType:
+		return map[string]any{} // any JSON value
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "integer", "description": "nanoseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := schemaFor(t.Elem(), schemas)
+		if _, isRef := schema["$ref"]; !isRef {
+			schema["nullable"] = true
+		}
+		return schema
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		name := schemaName(t)
+		if _, seen := schemas[name]; !seen {
+			schemas[name] = map[string]any{} // placeholder for recursive types
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]any{} // interfaces and anything else accept any value
+}
+
+// structSchema builds the object schema for a struct's exported JSON fields
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := structSchema(field.Type, schemas)
+			for k, v := range embedded["properties"].(map[string]any) {
+				properties[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaFor(field.Type, schemas)
+	}
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+// schemaName qualifies a type's name with its package, e.g. "mcp.Tool".
+// Types local to this package are named without a qualifier.
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg == "server" {
+		return strings.ToUpper(name[:1]) + name[1:]
+	}
+	return pkg + "." + name
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIHandler_ServesSpec(t *testing.T) {
+	router := NewRouter(t.Context())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var spec struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+	assert.Equal(t, OpenAPIVersion, spec.OpenAPI)
+
+	for _, route := range []struct{ method, path string }{
+		{"post", "/api/validate/tool"},
+		{"post", "/api/validate/tools"},
+		{"post", "/api/validate/toolcall"},
+		{"post", "/api/tools/register"},
+		{"get", "/api/tools/list"},
+		{"post", "/api/tools/import"},
+		{"get", "/api/tools/export"},
+	} {
+		assert.Contains(t, spec.Paths[route.path], route.method, "missing %s %s", route.method, route.path)
+	}
+
+	tool, ok := spec.Components.Schemas["mcp.Tool"].(map[string]any)
+	require.True(t, ok, "mcp.Tool schema missing")
+	props := tool["properties"].(map[string]any)
+	for _, field := range []string{"name", "description", "inputSchema", "secMetaData"} {
+		assert.Contains(t, props, field)
+	}
+	assert.Equal(t, "#/components/schemas/mcp.SecurityMetadata",
+		props["secMetaData"].(map[string]any)["$ref"])
+}
+
+func TestOpenAPISpec_CoversRouter(t *testing.T) {
+	routes, ok := NewRouter(t.Context()).(chi.Routes)
+	require.True(t, ok)
+
+	paths := OpenAPISpec()["paths"].(map[string]any)
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if route == "/metrics" {
+			return nil // Prometheus text format, not part of the JSON API
+		}
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		item, ok := paths[route].(map[string]any)
+		if assert.True(t, ok, "route %s missing from spec", route) {
+			assert.Contains(t, item, strings.ToLower(method), "%s %s missing from spec", method, route)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -2,13 +2,20 @@ package server
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"net"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/null-create/mcp-tls/pkg/codec"
 	"github.com/null-create/mcp-tls/pkg/mcp"
+	"github.com/null-create/mcp-tls/pkg/metrics"
+	"github.com/null-create/mcp-tls/pkg/tls"
 	"github.com/null-create/mcp-tls/pkg/validate"
 )
 
@@ -17,39 +24,163 @@ import (
 const (
 	proxyListenAddr  = ":9000"
 	targetServerAddr = "localhost:9001"
+	proxyMetricsAddr = ":9002"
 )
 
-// Intercepts client-to-server and validates tool call requests
-func (h *Handlers) validateAndForward(data []byte) ([]byte, error) {
-	var req codec.JSONRPCRequest
-	if err := json.Unmarshal(data, &req); err != nil {
-		log.Println("Invalid JSON-RPC:", err)
-		return nil, err
+// transformFunc processes one message read from a proxied stream. It returns what to
+// forward to the destination and what to answer the source with instead, such as a
+// rejection; a message with neither is dropped. An error tears the connection down.
+type transformFunc func(data []byte) (forward, reply []byte, err error)
+
+// Intercepts client-to-server and validates tool call requests. Other messages are
+// forwarded unchanged; rejected calls are answered to the client with an error
+// response carrying their ID, and never reach the server.
+func (h *Handlers) validateAndForward(data []byte) ([]byte, []byte, error) {
+	req, rpcErr := h.decodeLimits.ParseRequest(data)
+	if rpcErr != nil && (rpcErr.Code == codec.PARSE_ERROR || rpcErr.Code == codec.INVALID_REQUEST) {
+		log.Println("Invalid JSON-RPC:", rpcErr.Message)
+		countBlocked(blockMalformed)
+		// the id isn't read from a message that doesn't parse or is too large to, so the reply has none
+		resp := codec.NewJSONRPCResponse()
+		resp.Error = rpcErr
+		reply, err := json.Marshal(resp)
+		return nil, reply, err
+	}
+	if rpcErr != nil {
+		// over-nested params are refused whatever the method
+		log.Printf("Rejected JSON-RPC message: %s", rpcErr.Message)
+		countBlocked(blockMalformed)
+		return reject(req.ID, rpcErr.Code, rpcErr.Message, nil)
 	}
 
-	if req.Method == "tool.call" {
-		var tool mcp.Tool
-		if err := json.Unmarshal(req.Params, &tool); err != nil {
-			log.Printf("Failed to unmarshal request params to tool description object: %v", err)
-			return nil, err
-		}
+	switch req.Method {
+	case "tool.call", "tools/call":
+		return h.checkToolCall(req, data)
+	}
+	proxyMessages.With(directionClientToServer, resultForwarded).Inc()
+	return data, nil, nil
+}
+
+// checkToolCall validates a tool.call or MCP tools/call request, returning it to
+// forward or the rejection to reply with. Both carry the tool's name and arguments;
+// anything else a tool.call sends about the tool is ignored.
+func (h *Handlers) checkToolCall(req codec.JSONRPCRequest, data []byte) ([]byte, []byte, error) {
+	start := time.Now()
+	defer func() { proxyValidationSeconds.Observe(time.Since(start).Seconds()) }()
+
+	var tool mcp.CallToolParams
+	if rpcErr := h.decodeLimits.DecodeParams(req.Params, &tool); rpcErr != nil {
+		log.Printf("Failed to unmarshal request params to tool description object: %s", rpcErr.Message)
+		countBlocked(blockMalformed)
+		return reject(req.ID, rpcErr.Code, rpcErr.Message, nil)
+	}
+
+	if !h.toolAccess.Allowed(tool.Name) {
+		log.Printf("Blocked call to disabled tool '%s'", tool.Name)
+		countBlocked(blockDenylist)
+		return reject(req.ID, codec.TOOL_DENIED,
+			"tool '"+tool.Name+"' is disabled", toolErrorData{Tool: tool.Name})
+	}
+
+	// the call is checked against the registered tool, never a schema or
+	// description the client sent along with it
+	registered, err := h.toolManager.GetTool(tool.Name)
+	if err != nil {
+		log.Printf("Blocked call to unregistered tool '%s': %v", tool.Name, err)
+		countBlocked(blockUnknownTool)
+		return reject(req.ID, codec.INVALID_PARAMS, err.Error(), toolErrorData{Tool: tool.Name})
+	}
+	if !h.rateLimiter.Allow(&registered) {
+		log.Printf("Rate limit exceeded for tool '%s'", tool.Name)
+		countBlocked(blockRateLimit)
+		return reject(req.ID, codec.RATE_LIMITED,
+			"rate limit exceeded for tool '"+tool.Name+"'", toolErrorData{Tool: tool.Name})
+	}
 
-		status, err := validate.ValidateToolInputSchema(&tool, tool.Arguments)
+	status, err := h.validators.For(&registered).ValidateInput(&registered, tool.Arguments)
+	if h.errorPolicy.Blocks(status, err) {
+		log.Printf("Failed to validate tool schema: %v", err)
+		countBlocked(blockSchema)
+		rpcErr := toolArgumentsError(tool.Name, status, err)
+		return reject(req.ID, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+	}
+	failedOpen := status == validate.StatusError
+	if failedOpen {
+		log.Printf("WARNING forwarding call to tool '%s' unvalidated under the fail-open policy: %v", tool.Name, err)
+		proxyFailOpen.Inc()
+	}
+	// valid schema. validate description before passing onward
+	if err := validate.ValidateToolDescription(registered.Description); err != nil {
+		log.Printf("Rejected tool '%s': %v", tool.Name, err)
+		countBlocked(blockDescription)
+		return reject(req.ID, codec.INVALID_REQUEST, err.Error(), toolErrorData{Tool: tool.Name})
+	}
+	// only calls that actually passed validation are attested, so a server
+	// requiring attestations refuses the ones forwarded under fail-open
+	if h.attestationKey != nil && !failedOpen {
+		attested, err := tls.AttestCall(req, h.attestationKey)
 		if err != nil {
-			log.Printf("Failed to validate tool schema: %v", err)
-			return nil, err
+			log.Printf("Failed to attest call to tool '%s': %v", tool.Name, err)
+			return reject(req.ID, codec.INTERNAL_ERROR,
+				"internal error attesting call to tool '"+tool.Name+"'", toolErrorData{Tool: tool.Name})
 		}
-		// valid schema. validate description before passing onward
-		if status == validate.StatusSucceeded {
-			if err := validate.ValidateToolDescription(tool.Description); err != nil {
-				return nil, err
-			}
-			return json.Marshal(req)
+		data = attested
+	}
+	proxyMessages.With(directionClientToServer, resultForwarded).Inc()
+	return data, nil, nil
+}
+
+// toolErrorData is the data member of the errors returned for rejected tool calls
+type toolErrorData struct {
+	Tool   string                `json:"tool"`
+	Errors []validate.FieldError `json:"errors,omitempty"`
+}
+
+// toolArgumentsError builds the JSON-RPC error for arguments that failed validation
+func toolArgumentsError(toolName string, status validate.ValidationStatus, err error) *codec.JSONRPCError {
+	if status == validate.StatusError {
+		// internal failures aren't the client's to fix, so don't leak their details
+		return &codec.JSONRPCError{
+			Code:    codec.INTERNAL_ERROR,
+			Message: "internal error validating arguments for tool '" + toolName + "'",
+			Data:    toolErrorData{Tool: toolName},
 		}
 	}
-	return json.Marshal(codec.JSONRPCError{
-		Code: codec.INVALID_REQUEST,
-	})
+
+	data := toolErrorData{Tool: toolName, Errors: fieldErrors(err)}
+	return &codec.JSONRPCError{Code: codec.INVALID_PARAMS, Message: "invalid arguments for tool '" + toolName + "'", Data: data}
+}
+
+// fieldErrors lists the schema violations behind a failed validation, or the
+// error itself against the root when it isn't a schema error
+func fieldErrors(err error) []validate.FieldError {
+	var schemaErr *validate.SchemaError
+	switch {
+	case errors.As(err, &schemaErr):
+		return schemaErr.Errors
+	case err != nil:
+		return []validate.FieldError{{Field: "(root)", Reason: err.Error()}}
+	}
+	return nil
+}
+
+// errorResponse marshals a JSON-RPC error response for the request with the given ID.
+// Notifications (a nil ID) expect no reply, so for them it returns nothing and the
+// rejected message is simply dropped.
+func errorResponse(id *int64, code int, message string, data any) ([]byte, error) {
+	if id == nil {
+		return nil, nil
+	}
+	resp := codec.NewJSONRPCResponse()
+	resp.ID = id
+	resp.Error = &codec.JSONRPCError{Code: code, Message: message, Data: data}
+	return json.Marshal(resp)
+}
+
+// reject answers a rejected request with an error response, forwarding nothing
+func reject(id *int64, code int, message string, data any) ([]byte, []byte, error) {
+	reply, err := errorResponse(id, code, message, data)
+	return nil, reply, err
 }
 
 func (h *Handlers) handleConnection(clientConn net.Conn) {
@@ -62,13 +193,41 @@ func (h *Handlers) handleConnection(clientConn net.Conn) {
 	}
 	defer serverConn.Close()
 
-	go h.proxyStream(clientConn, serverConn, h.validateAndForward)
-	h.proxyStream(serverConn, clientConn, h.passthrough)
+	if err := h.relay(clientConn, serverConn); err != nil {
+		log.Printf("Proxy connection from %s closed: %v", clientConn.RemoteAddr(), err)
+	}
+}
+
+// relay proxies both directions between the client and server until each reaches EOF
+// or either fails, validating the client's messages
+func (h *Handlers) relay(clientConn, serverConn net.Conn) error {
+	return h.relayWith(clientConn, serverConn, h.validateAndForward, h.passthrough)
+}
+
+// relayWith proxies both directions, each through its transform. The first failure
+// closes both connections so the other direction unblocks and exits too; that
+// failure is returned.
+func (h *Handlers) relayWith(clientConn, serverConn net.Conn, toServer, toClient transformFunc) error {
+	client, server := newEndpoint(clientConn), newEndpoint(serverConn)
+	errc := make(chan error, 2)
+	go func() { errc <- h.proxyStream(client, server, toServer) }()
+	go func() { errc <- h.proxyStream(server, client, toClient) }()
+
+	var first error
+	for range 2 {
+		if err := <-errc; err != nil && first == nil {
+			first = err
+			clientConn.Close()
+			serverConn.Close()
+		}
+	}
+	return first
 }
 
 // Simple passthrough for server-to-client direction
-func (h *Handlers) passthrough(data []byte) ([]byte, error) {
-	return data, nil
+func (h *Handlers) passthrough(data []byte) ([]byte, []byte, error) {
+	proxyMessages.With(directionServerToClient, resultForwarded).Inc()
+	return data, nil, nil
 }
 
 type toolError string
@@ -77,29 +236,121 @@ func (e toolError) Error() string { return string(e) }
 
 func ErrInvalidTool(msg string) error { return toolError("Invalid tool call: " + msg) }
 
-// Handles framed JSON messages over TCP (e.g., newline-delimited)
-func (h *Handlers) proxyStream(src, dst net.Conn, transform func([]byte) ([]byte, error)) {
-	reader := bufio.NewReader(src)
-	writer := bufio.NewWriter(dst)
+// proxyWriteTimeout bounds how long a single write may wait on a slow destination
+// before the connection is torn down.
+var proxyWriteTimeout = 30 * time.Second
+
+// Stages of a proxied stream, reported by streamError
+const (
+	opRead      = "read"
+	opTransform = "transform"
+	opWrite     = "write"
+)
+
+// streamError reports which stage of a proxied stream failed
+type streamError struct {
+	Op  string
+	Err error
+}
+
+func (e *streamError) Error() string { return "proxy " + e.Op + ": " + e.Err.Error() }
+func (e *streamError) Unwrap() error { return e.Err }
+
+// endpoint is one side of a proxied connection. Messages reach it both from the
+// stream relaying to it and as replies to its own messages, so every write goes
+// through one buffered writer under a lock, keeping each message whole.
+type endpoint struct {
+	conn net.Conn
+	mu   sync.Mutex
+	w    *bufio.Writer
+}
+
+func newEndpoint(conn net.Conn) *endpoint {
+	return &endpoint{conn: conn, w: bufio.NewWriter(conn)}
+}
+
+// send writes a framed message, flushing it unless more are about to follow.
+// A write stalled longer than proxyWriteTimeout fails.
+func (e *endpoint) send(msg []byte, flush bool) error {
+	if !bytes.HasSuffix(msg, []byte("\n")) {
+		msg = append(msg, '\n')
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.conn.SetWriteDeadline(time.Now().Add(proxyWriteTimeout)); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(msg); err != nil {
+		return err
+	}
+	if flush {
+		return e.w.Flush()
+	}
+	return nil
+}
+
+// Handles framed JSON messages over TCP (newline-delimited). Messages split across
+// several reads are reassembled before transform sees them. What transform forwards
+// is written to dst, and its replies back to src. Writes block until the peer accepts
+// them, so a slow consumer stops further reads from src rather than buffering without
+// bound, but a write stalled longer than proxyWriteTimeout fails.
+// It returns nil once src reaches EOF, half-closing dst so the peer sees the end of input.
+func (h *Handlers) proxyStream(src, dst *endpoint, transform transformFunc) error {
+	reader := bufio.NewReader(src.conn)
 
 	for {
-		line, err := reader.ReadBytes('\n') // framing logic (newline-delimited)
-		if err != nil {
-			if err != io.EOF {
-				log.Printf("Stream read error: %v", err)
+		line, readErr := reader.ReadBytes('\n') // framing logic (newline-delimited)
+		if readErr != nil && readErr != io.EOF {
+			return &streamError{Op: opRead, Err: readErr}
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			forward, reply, err := transform(line)
+			if err != nil {
+				return &streamError{Op: opTransform, Err: err}
+			}
+			// batch messages that already arrived together into a single flush
+			flush := reader.Buffered() == 0 || readErr == io.EOF
+			if len(reply) > 0 {
+				if err := src.send(reply, true); err != nil {
+					return &streamError{Op: opWrite, Err: err}
+				}
+			}
+			if len(forward) > 0 {
+				if err := dst.send(forward, flush); err != nil {
+					return &streamError{Op: opWrite, Err: err}
+				}
 			}
-			return
 		}
 
-		processed, err := transform(line)
-		if err != nil {
-			log.Printf("Processing error: %v", err)
-			return
+		if readErr == io.EOF {
+			if err := dst.flush(); err != nil {
+				return &streamError{Op: opWrite, Err: err}
+			}
+			closeWrite(dst.conn)
+			return nil
 		}
+	}
+}
 
-		writer.Write(processed)
-		writer.Flush()
+// flush sends any batched messages
+func (e *endpoint) flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.conn.SetWriteDeadline(time.Now().Add(proxyWriteTimeout)); err != nil {
+		return err
 	}
+	return e.w.Flush()
+}
+
+// closeWrite signals end of input to the peer, keeping the read side open where the
+// connection supports a half-close so replies to earlier messages still arrive.
+func closeWrite(conn net.Conn) {
+	if hc, ok := conn.(interface{ CloseWrite() error }); ok {
+		hc.CloseWrite()
+		return
+	}
+	conn.Close()
 }
 
 func Proxy() {
@@ -109,6 +360,17 @@ func Proxy() {
 	}
 	log.Printf("MCP proxy listening on %s → %s", proxyListenAddr, targetServerAddr)
 
+	// the proxy has no HTTP router of its own, so serve its metrics separately
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Default.Handler())
+		svrCfgs := ServerConfigs()
+		svrCfgs.Addr = proxyMetricsAddr
+		if err := newHTTPServer(mux, svrCfgs).ListenAndServe(); err != nil {
+			log.Printf("Proxy metrics listener failed: %v", err)
+		}
+	}()
+
 	h := NewHandler()
 
 	for {
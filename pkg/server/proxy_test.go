@@ -0,0 +1,621 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/codec"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+	"github.com/null-create/mcp-tls/pkg/tls"
+	"github.com/null-create/mcp-tls/pkg/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolCallRequest registers tool and returns a tool.call request for it with the given arguments
+func toolCallRequest(t *testing.T, h *Handlers, tool mcp.Tool, args string, id int64) []byte {
+	t.Helper()
+	require.NoError(t, h.toolManager.RegisterTool(tool))
+	tool.Arguments = json.RawMessage(args)
+	params, err := json.Marshal(tool)
+	require.NoError(t, err)
+	req, err := json.Marshal(codec.JSONRPCRequest{JSONRPC: codec.JsonRPCVersion, Method: "tool.call", Params: params, ID: codec.NewID(id)})
+	require.NoError(t, err)
+	return req
+}
+
+// proxyErrorResponse decodes an error response produced by validateAndForward
+func proxyErrorResponse(t *testing.T, out []byte) (int64, codec.JSONRPCError, toolErrorData) {
+	t.Helper()
+	var resp struct {
+		ID    int64 `json:"id"`
+		Error *struct {
+			codec.JSONRPCError
+			Data toolErrorData `json:"data"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.NotNil(t, resp.Error, "expected an error response, got %s", out)
+	return resp.ID, resp.Error.JSONRPCError, resp.Error.Data
+}
+
+func TestValidateAndForward_ValidationErrorDetails(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	tool := mcp.Tool{
+		Name:        "weather-tool",
+		Description: "Gets the weather",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"location":{"type":"string"},"days":{"type":"integer"}},"required":["location"]}`),
+	}
+
+	forward, out, err := h.validateAndForward(toolCallRequest(t, h, tool, `{"days":"three"}`, 42))
+	require.NoError(t, err)
+	assert.Empty(t, forward, "rejected calls should not reach the server")
+
+	id, rpcErr, data := proxyErrorResponse(t, out)
+	assert.Equal(t, int64(42), id)
+	assert.Equal(t, codec.INVALID_PARAMS, rpcErr.Code)
+	assert.Contains(t, rpcErr.Message, "weather-tool")
+	assert.Equal(t, "weather-tool", data.Tool)
+
+	fields := make(map[string]string)
+	for _, fe := range data.Errors {
+		fields[fe.Field] = fe.Reason
+	}
+	assert.Contains(t, fields, "(root)", "missing required property should be reported at the root")
+	assert.Contains(t, fields, "days", "type mismatch should be reported against its field")
+}
+
+func TestValidateAndForward_DuplicateKeyDetails(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	tool := mcp.Tool{
+		Name:        "weather-tool",
+		Description: "Gets the weather",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"location":{"type":"string"}}}`),
+	}
+
+	forward, out, err := h.validateAndForward(toolCallRequest(t, h, tool, `{"location":"A","location":"B"}`, 7))
+	require.NoError(t, err)
+	assert.Empty(t, forward, "rejected calls should not reach the server")
+
+	id, rpcErr, data := proxyErrorResponse(t, out)
+	assert.Equal(t, int64(7), id)
+	assert.Equal(t, codec.INVALID_PARAMS, rpcErr.Code)
+	require.Len(t, data.Errors, 1)
+	assert.Contains(t, data.Errors[0].Reason, "duplicate")
+}
+
+func TestProxyStream_RejectionsAnsweredToClient(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	h.toolAccess = NewToolAccessList(nil, []string{"delete-file"})
+	denied := toolCallRequest(t, h, mcp.Tool{
+		Name:        "delete-file",
+		Description: "Deletes a file",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"}}}`),
+	}, `{"path":"/tmp/x"}`, 4)
+
+	client, proxyClient := net.Pipe()
+	proxyServer, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		h.proxyStream(newEndpoint(proxyClient), newEndpoint(proxyServer), h.validateAndForward)
+		proxyServer.Close()
+	}()
+
+	forwarded := make(chan string, 1)
+	go func() {
+		out, _ := io.ReadAll(server)
+		forwarded <- string(out)
+	}()
+	go func() {
+		client.Write(append(denied, '\n'))
+		client.Write([]byte("not json\n"))
+	}()
+
+	reader := bufio.NewReader(client)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	id, rpcErr, data := proxyErrorResponse(t, []byte(line))
+	assert.Equal(t, int64(4), id)
+	assert.Equal(t, codec.TOOL_DENIED, rpcErr.Code)
+	assert.Equal(t, "delete-file", data.Tool)
+
+	// a message that doesn't parse is answered too, and the stream stays up
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	var parseErr codec.JSONRPCResponse
+	require.NoError(t, json.Unmarshal([]byte(line), &parseErr))
+	require.NotNil(t, parseErr.Error)
+	assert.Equal(t, codec.PARSE_ERROR, parseErr.Error.Code)
+	assert.Nil(t, parseErr.ID)
+
+	client.Close()
+	assert.Empty(t, <-forwarded, "rejected messages should not reach the server")
+}
+
+func TestValidateAndForward_PassesThroughOtherMethods(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+
+	msgs := []string{
+		`{"jsonrpc":"2.0","method":"tools/list","params":{},"id":3}` + "\n",
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n",
+	}
+	for _, msg := range msgs {
+		out, reply, err := h.validateAndForward([]byte(msg))
+		require.NoError(t, err)
+		assert.Empty(t, reply)
+		assert.Equal(t, msg, string(out), "non tool.call messages should be forwarded unchanged")
+	}
+}
+
+func TestValidateAndForward_ValidCallForwardedUnchanged(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	tool := mcp.Tool{
+		Name:        "weather-tool",
+		Description: "Gets the weather",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"location":{"type":"string"}}}`),
+	}
+
+	req := toolCallRequest(t, h, tool, `{"location":"Paris"}`, 5)
+	out, reply, err := h.validateAndForward(req)
+	require.NoError(t, err)
+	assert.Empty(t, reply)
+	assert.Equal(t, req, out)
+}
+
+func TestValidateAndForward_MCPToolsCall(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	h.toolAccess = NewToolAccessList(nil, []string{"delete-file"})
+	schema := json.RawMessage(`{"type":"object","properties":{"location":{"type":"string"}},"required":["location"]}`)
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{Name: "weather-tool", Description: "Gets the weather", InputSchema: schema}))
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{Name: "delete-file", Description: "Deletes a file", InputSchema: schema}))
+
+	call := func(name, args string, id int64) []byte {
+		params, err := json.Marshal(mcp.CallToolParams{Name: name, Arguments: json.RawMessage(args)})
+		require.NoError(t, err)
+		req, err := json.Marshal(codec.JSONRPCRequest{JSONRPC: codec.JsonRPCVersion, Method: "tools/call", Params: params, ID: codec.NewID(id)})
+		require.NoError(t, err)
+		return req
+	}
+
+	t.Run("valid call is forwarded", func(t *testing.T) {
+		req := call("weather-tool", `{"location":"Paris"}`, 1)
+		out, reply, err := h.validateAndForward(req)
+		require.NoError(t, err)
+		assert.Empty(t, reply)
+		assert.Equal(t, req, out)
+	})
+
+	tests := []struct {
+		name string
+		tool string
+		args string
+		code int
+	}{
+		{name: "invalid arguments", tool: "weather-tool", args: `{"location":42}`, code: codec.INVALID_PARAMS},
+		{name: "denied tool", tool: "delete-file", args: `{"location":"Paris"}`, code: codec.TOOL_DENIED},
+		{name: "unregistered tool", tool: "unknown-tool", args: `{"location":"Paris"}`, code: codec.INVALID_PARAMS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forward, out, err := h.validateAndForward(call(tt.tool, tt.args, 2))
+			require.NoError(t, err)
+			assert.Empty(t, forward, "rejected calls should not reach the server")
+			id, rpcErr, data := proxyErrorResponse(t, out)
+			assert.Equal(t, int64(2), id)
+			assert.Equal(t, tt.code, rpcErr.Code)
+			assert.Equal(t, tt.tool, data.Tool)
+		})
+	}
+}
+
+func TestValidateAndForward_MalformedParams(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+
+	forward, out, err := h.validateAndForward([]byte(`{"jsonrpc":"2.0","method":"tool.call","params":"oops","id":9}`))
+	require.NoError(t, err)
+	assert.Empty(t, forward, "rejected calls should not reach the server")
+
+	id, rpcErr, _ := proxyErrorResponse(t, out)
+	assert.Equal(t, int64(9), id)
+	assert.Equal(t, codec.INVALID_PARAMS, rpcErr.Code)
+}
+
+// tcpPair returns the two ends of a loopback TCP connection
+func tcpPair(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := ln.Accept()
+		accepted <- conn
+	}()
+	dialed, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	conn := <-accepted
+	require.NotNil(t, conn)
+	t.Cleanup(func() {
+		dialed.Close()
+		conn.Close()
+	})
+	return dialed, conn
+}
+
+// assertNoLeakedGoroutines fails if the goroutine count doesn't settle back to baseline.
+// It polls by hand since assert.Eventually runs its condition on goroutines of its own.
+func assertNoLeakedGoroutines(t *testing.T, baseline int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+		if runtime.NumGoroutine() <= baseline {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("goroutines leaked: %d running, want at most %d", runtime.NumGoroutine(), baseline)
+}
+
+func TestProxyStream_ReassemblesPartialReads(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	client, proxySrc := net.Pipe()
+	proxyDst, server := net.Pipe()
+
+	done := make(chan error, 1)
+	go func() { done <- h.proxyStream(newEndpoint(proxySrc), newEndpoint(proxyDst), h.passthrough) }()
+
+	go func() {
+		client.Write([]byte(`{"jsonrpc":"2.0",`))
+		client.Write([]byte(`"method":"ping","id":1}` + "\n" + `{"jsonrpc":"2.0","method":"ping","id":2}`))
+		client.Close()
+	}()
+
+	reader := bufio.NewReader(server)
+	first, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","method":"ping","id":1}`+"\n", first)
+
+	// the final message had no newline but is still forwarded, framed, at EOF
+	second, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","method":"ping","id":2}`+"\n", second)
+
+	require.NoError(t, <-done)
+	_, err = reader.ReadByte()
+	assert.ErrorIs(t, err, io.EOF, "destination should be closed once the source ends")
+}
+
+func TestProxyStream_SlowDestination(t *testing.T) {
+	defer func(d time.Duration) { proxyWriteTimeout = d }(proxyWriteTimeout)
+	proxyWriteTimeout = 50 * time.Millisecond
+
+	h := newTestHandler(t, mustGenerateSeed(t))
+	client, proxySrc := net.Pipe()
+	proxyDst, server := net.Pipe() // nothing ever reads from server
+	defer client.Close()
+	defer server.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- h.proxyStream(newEndpoint(proxySrc), newEndpoint(proxyDst), h.passthrough) }()
+	go client.Write([]byte(`{"jsonrpc":"2.0","method":"ping","id":1}` + "\n"))
+
+	select {
+	case err := <-done:
+		var streamErr *streamError
+		require.ErrorAs(t, err, &streamErr)
+		assert.Equal(t, opWrite, streamErr.Op)
+		assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	case <-time.After(2 * time.Second):
+		t.Fatal("proxyStream did not give up on a stalled destination")
+	}
+}
+
+func TestRelay_TransformErrorTearsDownBothDirections(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	baseline := runtime.NumGoroutine()
+
+	client, proxyClient := tcpPair(t)
+	proxyServer, server := tcpPair(t)
+
+	failing := func([]byte) ([]byte, []byte, error) { return nil, nil, errors.New("transform failed") }
+	done := make(chan error, 1)
+	go func() { done <- h.relayWith(proxyClient, proxyServer, failing, h.passthrough) }()
+
+	_, err := client.Write([]byte(`{"jsonrpc":"2.0","method":"ping","id":1}` + "\n"))
+	require.NoError(t, err)
+
+	select {
+	case err := <-done:
+		var streamErr *streamError
+		require.ErrorAs(t, err, &streamErr)
+		assert.Equal(t, opTransform, streamErr.Op)
+	case <-time.After(2 * time.Second):
+		t.Fatal("relay did not tear down after a transform error")
+	}
+
+	// both peers see their connection end
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = client.Read(make([]byte, 1))
+	assert.Error(t, err)
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = server.Read(make([]byte, 1))
+	assert.Error(t, err)
+
+	client.Close()
+	server.Close()
+	assertNoLeakedGoroutines(t, baseline)
+}
+
+func TestRelay_ClosingDestination(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	baseline := runtime.NumGoroutine()
+
+	client, proxyClient := tcpPair(t)
+	proxyServer, server := tcpPair(t)
+
+	done := make(chan error, 1)
+	go func() { done <- h.relay(proxyClient, proxyServer) }()
+
+	// the server answers one message then hangs up
+	_, err := client.Write([]byte(`{"jsonrpc":"2.0","method":"ping","id":1}` + "\n"))
+	require.NoError(t, err)
+	line, err := bufio.NewReader(server).ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, `"ping"`)
+	_, err = server.Write([]byte(`{"jsonrpc":"2.0","result":{},"id":1}` + "\n"))
+	require.NoError(t, err)
+	server.Close()
+
+	// the client gets the reply followed by EOF, then hangs up too
+	reply, err := io.ReadAll(client)
+	require.NoError(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":{},"id":1}`+"\n", string(reply))
+	client.Close()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("relay did not finish after both peers closed")
+	}
+	assertNoLeakedGoroutines(t, baseline)
+}
+
+func TestValidateAndForward_OverNestedParams(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	h.decodeLimits.MaxDepth = 8
+
+	params := strings.Repeat(`{"a":`, 9) + `1` + strings.Repeat(`}`, 9)
+	forward, out, err := h.validateAndForward([]byte(`{"jsonrpc":"2.0","method":"tool.call","params":` + params + `,"id":11}`))
+	require.NoError(t, err)
+	assert.Empty(t, forward)
+
+	id, rpcErr, _ := proxyErrorResponse(t, out)
+	assert.Equal(t, int64(11), id)
+	assert.Equal(t, codec.INVALID_PARAMS, rpcErr.Code)
+}
+
+func TestValidateAndForward_OversizedMessage(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	h.decodeLimits.MaxSize = 64
+
+	msg := `{"jsonrpc":"2.0","method":"tool.call","params":{"name":"` + strings.Repeat("x", 64) + `"},"id":12}`
+	forward, out, err := h.validateAndForward([]byte(msg))
+	require.NoError(t, err)
+	assert.Empty(t, forward, "oversized messages should not reach the server")
+
+	// the message isn't decoded, so the client gets an answer without its id
+	require.NotEmpty(t, out, "oversized messages should be answered")
+	var resp codec.JSONRPCResponse
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codec.INVALID_REQUEST, resp.Error.Code)
+	assert.Contains(t, resp.Error.Message, "maximum size")
+	assert.Nil(t, resp.ID)
+}
+
+func TestValidateAndForward_Notifications(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	h.toolAccess = NewToolAccessList(nil, []string{"delete-file"})
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+		Name:        "read-file",
+		Description: "Reads a file",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"}}}`),
+	}))
+
+	allowed := []byte(`{"jsonrpc":"2.0","method":"tool.call","params":{"name":"read-file","description":"Reads a file","inputSchema":{"type":"object","properties":{"path":{"type":"string"}}},"arguments":{"path":"/tmp/x"}}}` + "\n")
+	out, reply, err := h.validateAndForward(allowed)
+	require.NoError(t, err)
+	assert.Empty(t, reply)
+	assert.Equal(t, allowed, out, "valid notifications should be forwarded")
+
+	denied := []byte(`{"jsonrpc":"2.0","method":"tool.call","params":{"name":"delete-file","arguments":{}}}` + "\n")
+	forward, out, err := h.validateAndForward(denied)
+	require.NoError(t, err)
+	assert.Empty(t, forward, "rejected calls should not reach the server")
+	assert.Empty(t, out, "rejected notifications should be dropped without a response")
+}
+
+func TestProxyStream_DropsEmptyOutput(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	client, proxySrc := net.Pipe()
+	proxyDst, server := net.Pipe()
+
+	drop := func(data []byte) ([]byte, []byte, error) {
+		if bytes.Contains(data, []byte("drop")) {
+			return nil, nil, nil
+		}
+		return data, nil, nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- h.proxyStream(newEndpoint(proxySrc), newEndpoint(proxyDst), drop) }()
+	go func() {
+		client.Write([]byte("{\"drop\":true}\n{\"keep\":true}\n"))
+		client.Close()
+	}()
+
+	out, err := io.ReadAll(server)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"keep\":true}\n", string(out))
+	require.NoError(t, <-done)
+}
+
+func TestValidateAndForward_ValidationErrorPolicy(t *testing.T) {
+	// a schema gojsonschema can't compile, so validation errors rather than fails
+	broken := mcp.Tool{
+		Name:        "broken-tool",
+		Description: "A tool whose input schema does not compile",
+		InputSchema: json.RawMessage(`{"type":"invalid-type"}`),
+	}
+	strict := mcp.Tool{
+		Name:        "strict-tool",
+		Description: "A tool whose input schema compiles",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"days":{"type":"integer"}}}`),
+	}
+
+	t.Run("fail-closed blocks", func(t *testing.T) {
+		h := newTestHandler(t, mustGenerateSeed(t))
+		require.Equal(t, validate.FailClosed, h.errorPolicy, "fail-closed should be the default")
+
+		forward, out, err := h.validateAndForward(toolCallRequest(t, h, broken, `{}`, 1))
+		require.NoError(t, err)
+		assert.Empty(t, forward, "rejected calls should not reach the server")
+		_, rpcErr, data := proxyErrorResponse(t, out)
+		assert.Equal(t, codec.INTERNAL_ERROR, rpcErr.Code)
+		assert.Equal(t, "broken-tool", data.Tool)
+	})
+
+	t.Run("fail-open forwards", func(t *testing.T) {
+		h := newTestHandler(t, mustGenerateSeed(t))
+		h.errorPolicy = validate.FailOpen
+		before := proxyFailOpen.Value()
+
+		req := toolCallRequest(t, h, broken, `{}`, 2)
+		out, reply, err := h.validateAndForward(req)
+		require.NoError(t, err)
+		assert.Empty(t, reply)
+		assert.Equal(t, req, out)
+		assert.Equal(t, before+1, proxyFailOpen.Value())
+	})
+
+	t.Run("fail-open still blocks validation failures", func(t *testing.T) {
+		h := newTestHandler(t, mustGenerateSeed(t))
+		h.errorPolicy = validate.FailOpen
+
+		forward, out, err := h.validateAndForward(toolCallRequest(t, h, strict, `{"days":"three"}`, 3))
+		require.NoError(t, err)
+		assert.Empty(t, forward, "rejected calls should not reach the server")
+		_, rpcErr, _ := proxyErrorResponse(t, out)
+		assert.Equal(t, codec.INVALID_PARAMS, rpcErr.Code)
+	})
+}
+
+func TestValidateAndForward_AttestsValidatedCalls(t *testing.T) {
+	key := make([]byte, tls.HmacKeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	t.Setenv("MCPTLS_ATTESTATION_KEY", base64.StdEncoding.EncodeToString(key))
+	h := newTestHandler(t, mustGenerateSeed(t))
+	tool := mcp.Tool{
+		Name:        "weather-tool",
+		Description: "Gets the weather",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"location":{"type":"string"}}}`),
+	}
+	req := toolCallRequest(t, h, tool, `{"location":"Paris"}`, 5)
+
+	t.Run("validated call verifies downstream", func(t *testing.T) {
+		out, reply, err := h.validateAndForward(req)
+		require.NoError(t, err)
+		assert.Empty(t, reply)
+
+		verified, err := tls.VerifyCall(out, key)
+		require.NoError(t, err)
+		assert.Equal(t, "tool.call", verified.Method)
+		assert.Equal(t, int64(5), *verified.ID)
+		var forwarded mcp.Tool
+		require.NoError(t, json.Unmarshal(verified.Params, &forwarded))
+		assert.JSONEq(t, `{"location":"Paris"}`, string(forwarded.Arguments))
+	})
+
+	t.Run("call bypassing the proxy is rejected downstream", func(t *testing.T) {
+		_, err := tls.VerifyCall(req, key)
+		assert.ErrorIs(t, err, tls.ErrUnattested)
+	})
+
+	t.Run("client supplied attestation is replaced", func(t *testing.T) {
+		var forged map[string]any
+		require.NoError(t, json.Unmarshal(req, &forged))
+		forged["attestation"] = base64.StdEncoding.EncodeToString([]byte("forged"))
+		data, err := json.Marshal(forged)
+		require.NoError(t, err)
+
+		out, reply, err := h.validateAndForward(data)
+		require.NoError(t, err)
+		assert.Empty(t, reply)
+		_, err = tls.VerifyCall(out, key)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejected call is not attested", func(t *testing.T) {
+		invalid := toolCallRequest(t, h, tool, `{"location":42}`, 6)
+		forward, reply, err := h.validateAndForward(invalid)
+		require.NoError(t, err)
+		assert.Empty(t, forward, "rejected calls should not reach the server")
+		proxyErrorResponse(t, reply)
+	})
+
+	t.Run("client supplied schema is ignored", func(t *testing.T) {
+		// the client swaps in a schema its arguments pass, but the registered one applies
+		permissive := tool
+		permissive.InputSchema = json.RawMessage(`{"type":"object"}`)
+		permissive.Arguments = json.RawMessage(`{"location":42,"evil":true}`)
+		params, err := json.Marshal(permissive)
+		require.NoError(t, err)
+		data, err := json.Marshal(codec.JSONRPCRequest{JSONRPC: codec.JsonRPCVersion, Method: "tool.call", Params: params, ID: codec.NewID(8)})
+		require.NoError(t, err)
+
+		forward, reply, err := h.validateAndForward(data)
+		require.NoError(t, err)
+		assert.Empty(t, forward, "calls failing the registered schema should not be attested")
+		id, rpcErr, _ := proxyErrorResponse(t, reply)
+		assert.Equal(t, int64(8), id)
+		assert.Equal(t, codec.INVALID_PARAMS, rpcErr.Code)
+	})
+
+	t.Run("unregistered tool is rejected", func(t *testing.T) {
+		unknown := tool
+		unknown.Name = "unknown-tool"
+		unknown.Arguments = json.RawMessage(`{"location":"Paris"}`)
+		params, err := json.Marshal(unknown)
+		require.NoError(t, err)
+		data, err := json.Marshal(codec.JSONRPCRequest{JSONRPC: codec.JsonRPCVersion, Method: "tool.call", Params: params, ID: codec.NewID(9)})
+		require.NoError(t, err)
+
+		forward, reply, err := h.validateAndForward(data)
+		require.NoError(t, err)
+		assert.Empty(t, forward, "calls to unregistered tools should not reach the server")
+		_, rpcErr, errData := proxyErrorResponse(t, reply)
+		assert.Equal(t, codec.INVALID_PARAMS, rpcErr.Code)
+		assert.Equal(t, "unknown-tool", errData.Tool)
+	})
+
+	t.Run("other methods are forwarded unchanged", func(t *testing.T) {
+		msg := []byte(`{"jsonrpc":"2.0","method":"tools/list","params":{},"id":3}`)
+		out, reply, err := h.validateAndForward(msg)
+		require.NoError(t, err)
+		assert.Empty(t, reply)
+		assert.Equal(t, msg, out)
+	})
+}
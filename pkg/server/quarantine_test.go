@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/audit"
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loginToken registers a user through the router and returns their token
+func loginToken(t *testing.T, router http.Handler, creds auth.Credentials) string {
+	t.Helper()
+	rec := serve(t, router, http.MethodPost, "/api/users/new", creds, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return login(t, router, creds)
+}
+
+// login logs an existing user in through the router and returns their token
+func login(t *testing.T, router http.Handler, creds auth.Credentials) string {
+	t.Helper()
+	rec := serve(t, router, http.MethodPost, "/api/users/login", creds, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.Token
+}
+
+func TestRouter_QuarantineTamperedTool(t *testing.T) {
+	t.Setenv("MCPTLS_ADMIN_USERS", "root")
+	t.Setenv("MCPTLS_ADMIN_CREDENTIALS", "root:correct horse")
+	auditKey := []byte("0123456789abcdef0123456789abcdef")
+	t.Setenv("MCPTLS_AUDIT_KEY", base64.StdEncoding.EncodeToString(auditKey))
+	router := NewRouter(t.Context())
+	admin := login(t, router, auth.Credentials{UserName: "root", Password: "correct horse"})
+	user := loginToken(t, router, auth.Credentials{UserName: "guest", Password: "battery staple"})
+
+	good := mcp.Tool{Name: "good-tool", Description: "An untouched tool", InputSchema: json.RawMessage(`{"type":"object"}`)}
+	require.NoError(t, mcp.SecureTool(&good))
+	// a checksum that doesn't match the definition, as if the tool was modified after signing
+	tampered := mcp.Tool{Name: "tampered-tool", Description: "A tampered tool", InputSchema: json.RawMessage(`{"type":"object"}`)}
+	require.NoError(t, mcp.SecureTool(&tampered))
+	tampered.SecurityMetadata.Checksum = "0000"
+	for _, tool := range []mcp.Tool{good, tampered} {
+		rec := serve(t, router, http.MethodPost, "/api/tools/register", tool, user)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	rec := serve(t, router, http.MethodPost, "/api/tools/verify", nil, user)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serve(t, router, http.MethodGet, "/api/tools/list", nil, user)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed []mcp.Tool
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed, 1, "quarantined tools should not be listed")
+	assert.Equal(t, "good-tool", listed[0].Name)
+
+	t.Run("hidden from non-admins", func(t *testing.T) {
+		rec := serve(t, router, http.MethodGet, "/api/admin/quarantine", nil, user)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("visible to admins", func(t *testing.T) {
+		rec := serve(t, router, http.MethodGet, "/api/admin/quarantine", nil, admin)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var entries []mcp.QuarantinedTool
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+		require.Len(t, entries, 1)
+		assert.Equal(t, "tampered-tool", entries[0].Tool.Name)
+		assert.Contains(t, entries[0].Reason, "checksum")
+		assert.False(t, entries[0].QuarantinedAt.IsZero())
+
+		rec = serve(t, router, http.MethodGet, "/api/admin/quarantine/tampered-tool", nil, admin)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var entry mcp.QuarantinedTool
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entry))
+		assert.Equal(t, "0000", entry.Tool.SecurityMetadata.Checksum)
+
+		rec = serve(t, router, http.MethodGet, "/api/admin/quarantine/good-tool", nil, admin)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		rec := serve(t, router, http.MethodPost, "/api/admin/quarantine/tampered-tool/release", nil, admin)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec = serve(t, router, http.MethodGet, "/api/tools/list", nil, user)
+		var listed []mcp.Tool
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+		assert.Len(t, listed, 2)
+
+		rec = serve(t, router, http.MethodPost, "/api/admin/quarantine/tampered-tool/release", nil, admin)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("audited", func(t *testing.T) {
+		rec := serve(t, router, http.MethodGet, "/api/admin/audit", nil, user)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = serve(t, router, http.MethodGet, "/api/admin/audit", nil, admin)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var auditLog AuditLog
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &auditLog))
+		require.NoError(t, audit.VerifyAuditChain(auditLog.Records, auditKey), "the chain should verify with the configured key")
+		assert.Error(t, audit.VerifyAuditChain(auditLog.Records, []byte("another key")))
+		require.NotEmpty(t, auditLog.Records)
+		assert.Equal(t, auditLog.Records[len(auditLog.Records)-1].Hash, auditLog.Head)
+
+		var events []string
+		for _, record := range auditLog.Records {
+			events = append(events, record.Event+" "+record.Tool+" "+record.Actor+" "+record.Outcome)
+		}
+		assert.Equal(t, []string{
+			"tool.register good-tool guest succeeded",
+			"tool.register tampered-tool guest succeeded",
+			"tool.quarantine tampered-tool  succeeded",
+			"tool.release tampered-tool root succeeded",
+		}, events)
+	})
+}
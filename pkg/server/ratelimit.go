@@ -0,0 +1,124 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimit is a token bucket limit: calls per second sustained, with bursts of up to Burst calls
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+// ToolRateLimits sets the call limits applied to each tool, by annotation category.
+// A tool's category is the most restrictive hint it carries: destructive, then
+// open-world, then read-only; tools with none of these hints get Default.
+// Limits in PerTool override the category limit for the named tool.
+type ToolRateLimits struct {
+	ReadOnly    RateLimit
+	Default     RateLimit
+	OpenWorld   RateLimit
+	Destructive RateLimit
+	PerTool     map[string]RateLimit
+}
+
+// DefaultToolRateLimits returns the limits used by the proxy unless configured otherwise
+func DefaultToolRateLimits() ToolRateLimits {
+	return ToolRateLimits{
+		ReadOnly:    RateLimit{PerSecond: 20, Burst: 40},
+		Default:     RateLimit{PerSecond: 10, Burst: 20},
+		OpenWorld:   RateLimit{PerSecond: 5, Burst: 10},
+		Destructive: RateLimit{PerSecond: 1, Burst: 3},
+		PerTool:     make(map[string]RateLimit),
+	}
+}
+
+// limitFor picks the limit that applies to a tool
+func (l ToolRateLimits) limitFor(tool *mcp.Tool) RateLimit {
+	if limit, ok := l.PerTool[tool.Name]; ok {
+		return limit
+	}
+	switch {
+	case tool.Annotations.DestructiveHint:
+		return l.Destructive
+	case tool.Annotations.OpenWorldHint:
+		return l.OpenWorld
+	case tool.Annotations.ReadOnlyHint:
+		return l.ReadOnly
+	default:
+		return l.Default
+	}
+}
+
+// DefaultMaxTrackedTools caps how many tools a ToolRateLimiter keeps buckets for
+const DefaultMaxTrackedTools = 10000
+
+// ToolRateLimiter keeps a token bucket per tool name. It is safe for concurrent use.
+// At most maxTracked buckets are kept: once full, buckets that have refilled are
+// dropped, which loses nothing, and if every bucket is still in use, calls to tools
+// without one share an overflow bucket at the Default limit.
+type ToolRateLimiter struct {
+	mu         sync.Mutex
+	limits     ToolRateLimits
+	limiters   map[string]*rate.Limiter
+	overflow   *rate.Limiter
+	maxTracked int
+	now        func() time.Time
+}
+
+func NewToolRateLimiter(limits ToolRateLimits) *ToolRateLimiter {
+	if limits.PerTool == nil {
+		limits.PerTool = make(map[string]RateLimit)
+	}
+	return &ToolRateLimiter{
+		limits:     limits,
+		limiters:   make(map[string]*rate.Limiter),
+		overflow:   rate.NewLimiter(rate.Limit(limits.Default.PerSecond), limits.Default.Burst),
+		maxTracked: DefaultMaxTrackedTools,
+		now:        time.Now,
+	}
+}
+
+// SetToolLimit overrides the limit of a single tool, replacing its current bucket
+func (l *ToolRateLimiter) SetToolLimit(toolName string, limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits.PerTool[toolName] = limit
+	delete(l.limiters, toolName)
+}
+
+// Allow reports whether a call to the tool is within its rate limit, using up one call if so.
+// Callers pass the registered tool, so its annotations pick the limit.
+func (l *ToolRateLimiter) Allow(tool *mcp.Tool) bool {
+	now := l.now()
+	l.mu.Lock()
+	limiter, ok := l.limiters[tool.Name]
+	if !ok {
+		if len(l.limiters) >= l.maxTracked {
+			l.dropRefilled(now)
+		}
+		if len(l.limiters) < l.maxTracked {
+			limit := l.limits.limitFor(tool)
+			limiter = rate.NewLimiter(rate.Limit(limit.PerSecond), limit.Burst)
+			l.limiters[tool.Name] = limiter
+		} else {
+			limiter = l.overflow
+		}
+	}
+	l.mu.Unlock()
+	return limiter.AllowN(now, 1)
+}
+
+// dropRefilled forgets the buckets that are full again, since a new bucket behaves the same
+func (l *ToolRateLimiter) dropRefilled(now time.Time) {
+	for name, limiter := range l.limiters {
+		if limiter.TokensAt(now) >= float64(limiter.Burst()) {
+			delete(l.limiters, name)
+		}
+	}
+}
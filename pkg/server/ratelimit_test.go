@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/codec"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFrozenRateLimiter returns a limiter whose clock never moves, so no tokens refill
+func newFrozenRateLimiter(limits ToolRateLimits) *ToolRateLimiter {
+	l := NewToolRateLimiter(limits)
+	frozen := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return frozen }
+	return l
+}
+
+// allowedCalls counts how many back to back calls to tool are allowed, up to max
+func allowedCalls(l *ToolRateLimiter, tool *mcp.Tool, max int) int {
+	for i := range max {
+		if !l.Allow(tool) {
+			return i
+		}
+	}
+	return max
+}
+
+func TestToolRateLimiter_DestructiveThrottledSooner(t *testing.T) {
+	l := newFrozenRateLimiter(DefaultToolRateLimits())
+
+	readOnly := &mcp.Tool{Name: "read-file", Annotations: mcp.ToolAnnotation{ReadOnlyHint: true}}
+	destructive := &mcp.Tool{Name: "delete-file", Annotations: mcp.ToolAnnotation{DestructiveHint: true}}
+	plain := &mcp.Tool{Name: "echo"}
+
+	readOnlyCalls := allowedCalls(l, readOnly, 100)
+	destructiveCalls := allowedCalls(l, destructive, 100)
+	plainCalls := allowedCalls(l, plain, 100)
+
+	defaults := DefaultToolRateLimits()
+	assert.Equal(t, defaults.ReadOnly.Burst, readOnlyCalls)
+	assert.Equal(t, defaults.Destructive.Burst, destructiveCalls)
+	assert.Equal(t, defaults.Default.Burst, plainCalls)
+	assert.Less(t, destructiveCalls, plainCalls)
+	assert.Less(t, plainCalls, readOnlyCalls)
+}
+
+func TestToolRateLimiter_DestructiveHintWins(t *testing.T) {
+	l := newFrozenRateLimiter(DefaultToolRateLimits())
+	tool := &mcp.Tool{Name: "mixed", Annotations: mcp.ToolAnnotation{ReadOnlyHint: true, DestructiveHint: true}}
+	assert.Equal(t, DefaultToolRateLimits().Destructive.Burst, allowedCalls(l, tool, 100))
+}
+
+func TestToolRateLimiter_PerToolOverride(t *testing.T) {
+	limits := DefaultToolRateLimits()
+	limits.PerTool["trusted-delete"] = RateLimit{PerSecond: 100, Burst: 50}
+	l := newFrozenRateLimiter(limits)
+
+	overridden := &mcp.Tool{Name: "trusted-delete", Annotations: mcp.ToolAnnotation{DestructiveHint: true}}
+	assert.Equal(t, 50, allowedCalls(l, overridden, 100))
+
+	readOnly := &mcp.Tool{Name: "read-file", Annotations: mcp.ToolAnnotation{ReadOnlyHint: true}}
+	l.SetToolLimit("read-file", RateLimit{PerSecond: 1, Burst: 1})
+	assert.Equal(t, 1, allowedCalls(l, readOnly, 100))
+}
+
+func TestToolRateLimiter_Refills(t *testing.T) {
+	l := NewToolRateLimiter(DefaultToolRateLimits())
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	tool := &mcp.Tool{Name: "delete-file", Annotations: mcp.ToolAnnotation{DestructiveHint: true}}
+	allowedCalls(l, tool, 100)
+	require.False(t, l.Allow(tool))
+
+	now = now.Add(time.Second)
+	assert.True(t, l.Allow(tool), "expected a token to refill after a second")
+}
+
+func TestToolRateLimiter_BoundedBuckets(t *testing.T) {
+	l := NewToolRateLimiter(DefaultToolRateLimits())
+	l.maxTracked = 2
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	destructive := func(name string) *mcp.Tool {
+		return &mcp.Tool{Name: name, Annotations: mcp.ToolAnnotation{DestructiveHint: true}}
+	}
+	allowedCalls(l, destructive("a"), 100)
+	allowedCalls(l, destructive("b"), 100)
+
+	// both buckets are in use, so further tools share the overflow bucket
+	assert.Equal(t, DefaultToolRateLimits().Default.Burst, allowedCalls(l, destructive("c"), 100))
+	assert.False(t, l.Allow(destructive("d")), "the overflow bucket should be shared")
+	assert.Len(t, l.limiters, 2)
+
+	// once the buckets refill they're dropped to make room
+	now = now.Add(time.Minute)
+	assert.Equal(t, DefaultToolRateLimits().Destructive.Burst, allowedCalls(l, destructive("e"), 100))
+	assert.Contains(t, l.limiters, "e")
+	assert.LessOrEqual(t, len(l.limiters), 2)
+}
+
+func TestValidateAndForward_RateLimited(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	h.rateLimiter = newFrozenRateLimiter(DefaultToolRateLimits())
+
+	tool := mcp.Tool{
+		Name:        "delete-file",
+		Description: "Deletes a file",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"}}}`),
+		Annotations: mcp.ToolAnnotation{DestructiveHint: true},
+	}
+	require.NoError(t, h.toolManager.RegisterTool(tool))
+
+	// the client claims the tool is read-only, but the registered annotations apply
+	call := tool
+	call.Annotations = mcp.ToolAnnotation{ReadOnlyHint: true}
+	call.Arguments = json.RawMessage(`{"path":"/tmp/x"}`)
+	params, err := json.Marshal(call)
+	require.NoError(t, err)
+	req, err := json.Marshal(codec.JSONRPCRequest{JSONRPC: codec.JsonRPCVersion, Method: "tool.call", Params: params, ID: codec.NewID(1)})
+	require.NoError(t, err)
+
+	for range DefaultToolRateLimits().Destructive.Burst {
+		_, reply, err := h.validateAndForward(req)
+		require.NoError(t, err)
+		assert.Empty(t, reply)
+	}
+
+	forward, out, err := h.validateAndForward(req)
+	require.NoError(t, err)
+	assert.Empty(t, forward, "rejected calls should not reach the server")
+	id, rpcErr, data := proxyErrorResponse(t, out)
+	assert.Equal(t, int64(1), id)
+	assert.Equal(t, codec.RATE_LIMITED, rpcErr.Code)
+	assert.Equal(t, "delete-file", data.Tool)
+}
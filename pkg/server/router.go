@@ -1,15 +1,32 @@
 package server
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/config"
+	"github.com/null-create/mcp-tls/pkg/metrics"
+	"github.com/null-create/mcp-tls/pkg/util"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/redis/go-redis/v9"
 )
 
-func NewRouter() http.Handler {
+// NewRouter builds the API router. Background work it starts, such as the
+// integrity scan, runs until ctx is cancelled.
+func NewRouter(ctx context.Context) http.Handler {
+	h := NewHandler()
+	cfgs := config.LoadConfigs()
+	if cfgs.IntegrityScanInterval > 0 {
+		go h.ScanIntegrity(ctx, cfgs.IntegrityScanInterval)
+	}
+	return newRouter(&h, cfgs)
+}
+
+// newRouter registers the API routes and middleware on a new router, served by h
+func newRouter(h *Handlers, cfgs *config.Config) http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware stack
@@ -17,15 +34,31 @@ func NewRouter() http.Handler {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(Gzip)
+	r.Use(MaxBodySize(int64(cfgs.MaxBodySize)))
 
-	// Load handlers
-	h := NewHandler()
+	// chi's defaults answer in plain text; keep every error in the JSON envelope
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		util.WriteError(w, r, http.StatusNotFound, "not found")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		util.WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	})
 
 	// Health check
 	r.Get("/health", h.HealthCheckHandler)
 
+	// Prometheus metrics
+	r.Handle("/metrics", metrics.Default.Handler())
+
 	// API routes
 	r.Route("/api", func(r chi.Router) {
+		r.Use(RequireJSON)
+		r.Get("/openapi.json", h.OpenAPIHandler)
+		r.Route("/rpc", func(r chi.Router) {
+			r.Use(auth.Middleware)
+			r.Post("/", h.RPCHandler)
+		})
 		r.Route("/users", func(r chi.Router) {
 			r.Route("/auth", func(r chi.Router) {
 				r.Get("/", h.TokenRequestHandler)
@@ -33,22 +66,73 @@ func NewRouter() http.Handler {
 			r.Route("/new", func(r chi.Router) {
 				r.Post("/", h.RegisterUserHandler)
 			})
+			r.Route("/login", func(r chi.Router) {
+				r.Post("/", h.LoginHandler)
+			})
+			r.Route("/logout", func(r chi.Router) {
+				r.Use(auth.Middleware)
+				r.Post("/", h.LogoutHandler)
+			})
 		})
 		r.Route("/validate", func(r chi.Router) {
 			r.Use(auth.Middleware)
+			// validating large payloads can run long; don't hold the connection indefinitely
+			r.Use(Timeout(cfgs.ValidateTimeout))
 			r.Post("/tool", h.ValidateToolHandler)
 			r.Post("/tools", h.ValidateToolsHandler)
+			r.Post("/toolcall", h.ValidateToolCallHandler)
+		})
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(auth.Middleware)
+			r.Use(RequireAdmin(cfgs.AdminUsers))
+			r.Route("/quarantine", func(r chi.Router) {
+				r.Get("/", h.ListQuarantinedHandler)
+				r.Get("/{name}", h.GetQuarantinedHandler)
+				r.Post("/{name}/release", h.ReleaseQuarantinedHandler)
+			})
+			r.Get("/audit", h.AuditLogHandler)
+		})
+		r.Route("/cache", func(r chi.Router) {
+			r.Use(auth.Middleware)
+			r.Get("/stats", h.CacheStatsHandler)
+			r.With(RequireAdmin(cfgs.AdminUsers)).Post("/flush", h.CacheFlushHandler)
 		})
 		r.Route("/tools", func(r chi.Router) {
 			r.Use(auth.Middleware)
 			r.Route("/register", func(r chi.Router) {
+				r.Use(Idempotent(idempotencyStore(cfgs), cfgs.IdempotencyTTL))
 				r.Post("/", h.ToolRegistrationHandler)
 			})
 			r.Route("/list", func(r chi.Router) {
 				r.Get("/", h.ListToolsHandler)
 			})
+			r.Route("/verify", func(r chi.Router) {
+				r.Post("/", h.VerifyToolsHandler)
+			})
+			r.Route("/coverage", func(r chi.Router) {
+				r.Get("/", h.SchemaCoverageHandler)
+			})
+			r.Route("/schema-diff", func(r chi.Router) {
+				r.Post("/", h.SchemaDiffHandler)
+			})
+			r.Route("/export", func(r chi.Router) {
+				r.Get("/", h.ExportToolsHandler)
+			})
+			r.Route("/import", func(r chi.Router) {
+				// signed bundles carry the whole tool set
+				r.Use(MaxBodySize(int64(cfgs.MaxImportBodySize)))
+				r.Post("/", h.ImportToolsHandler)
+			})
 		})
 	})
 
 	return r
 }
+
+// idempotencyStore returns the store for idempotent responses: Redis if configured, memory otherwise
+func idempotencyStore(cfgs *config.Config) IdempotencyStore {
+	if cfgs.RedisAddr == "" {
+		return NewMemoryIdempotencyStore()
+	}
+	return NewRedisIdempotencyStore(redis.NewClient(&redis.Options{Addr: cfgs.RedisAddr}))
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/config"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+	"github.com/null-create/mcp-tls/pkg/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve sends a request with an optional JSON body and bearer token through the router
+func serve(t *testing.T, router http.Handler, method, path string, body any, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRouter_LogoutRevokesToken(t *testing.T) {
+	router := NewRouter(t.Context())
+	creds := auth.Credentials{UserName: "dave", Password: "open the pod bay doors"}
+
+	rec := serve(t, router, http.MethodPost, "/api/users/new", creds, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serve(t, router, http.MethodPost, "/api/users/login", creds, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	rec = serve(t, router, http.MethodGet, "/api/tools/list", nil, resp.Token)
+	assert.Equal(t, http.StatusOK, rec.Code, "token should work before logout")
+
+	rec = serve(t, router, http.MethodPost, "/api/users/logout", nil, resp.Token)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serve(t, router, http.MethodGet, "/api/tools/list", nil, resp.Token)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "token should be rejected after logout")
+
+	rec = serve(t, router, http.MethodPost, "/api/users/logout", nil, resp.Token)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "logout should not accept a revoked token")
+}
+
+func TestRouter_CacheStatsAndFlush(t *testing.T) {
+	t.Setenv("MCPTLS_ADMIN_USERS", "root")
+	t.Setenv("MCPTLS_ADMIN_CREDENTIALS", "root:correct horse")
+	h := newTestHandler(t, mustGenerateSeed(t))
+	router := newRouter(h, config.LoadConfigs())
+	admin := login(t, router, auth.Credentials{UserName: "root", Password: "correct horse"})
+	user := loginToken(t, router, auth.Credentials{UserName: "guest", Password: "battery staple"})
+
+	tool := &mcp.Tool{Name: "cached-tool", InputSchema: json.RawMessage(`{"type":"object","properties":{"q":{"type":"string"}}}`)}
+	for _, input := range []string{`{"q":"a"}`, `{"q":"a"}`, `{"q":"b"}`} {
+		validate.ValidateToolInput(tool, []byte(input), validate.WithCache(h.validationCache))
+	}
+
+	stats := func() validate.CacheStats {
+		t.Helper()
+		rec := serve(t, router, http.MethodGet, "/api/cache/stats", nil, user)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var stats []validate.CacheStats
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+		require.Len(t, stats, 1)
+		return stats[0]
+	}
+	got := stats()
+	assert.Equal(t, "validation_results", got.Name)
+	assert.Equal(t, uint64(1), got.Hits)
+	assert.Equal(t, uint64(2), got.Misses)
+	assert.Equal(t, 2, got.Size)
+
+	rec := serve(t, router, http.MethodPost, "/api/cache/flush", nil, user)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "only admins may flush")
+	assert.Equal(t, 2, stats().Size)
+
+	rec = serve(t, router, http.MethodPost, "/api/cache/flush", nil, admin)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 0, stats().Size)
+	assert.Equal(t, 0, h.validationCache.Len())
+}
+
+func TestRouter_SchemaDiff(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	router := newRouter(h, config.LoadConfigs())
+	token := loginToken(t, router, auth.Credentials{UserName: "reviewer", Password: "correct horse"})
+
+	body := map[string]json.RawMessage{
+		"from": json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"},"days":{"type":"string"}},"required":["city"]}`),
+		"to":   json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"},"days":{"type":"integer"},"units":{"type":"string"}},"required":["city","days"]}`),
+	}
+	rec := serve(t, router, http.MethodPost, "/api/tools/schema-diff", body, token)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var diff mcp.SchemaDiff
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &diff))
+	assert.Equal(t, mcp.SchemaDiff{
+		AddedProperties: []string{"units"},
+		ChangedTypes:    []mcp.TypeChange{{Property: "days", From: "string", To: "integer"}},
+		AddedRequired:   []string{"days"},
+	}, diff)
+
+	rec = serve(t, router, http.MethodPost, "/api/tools/schema-diff", map[string]any{"from": []int{1}, "to": body["to"]}, token)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "schemas must be objects")
+
+	rec = serve(t, router, http.MethodPost, "/api/tools/schema-diff", body, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRouter_ValidateToolCall(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	require.NoError(t, h.toolManager.RegisterTool(mcp.Tool{
+		Name:        "weather",
+		Description: "Looks up the forecast",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`),
+	}))
+	router := newRouter(h, config.LoadConfigs())
+	token := loginToken(t, router, auth.Credentials{UserName: "previewer", Password: "correct horse"})
+
+	preview := func(t *testing.T, body any) toolCallPreview {
+		t.Helper()
+		rec := serve(t, router, http.MethodPost, "/api/validate/toolcall", body, token)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var result toolCallPreview
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		return result
+	}
+
+	t.Run("passing call", func(t *testing.T) {
+		result := preview(t, map[string]any{"toolName": "weather", "arguments": map[string]any{"city": "Oslo"}})
+		assert.Equal(t, toolCallPreview{ToolName: "weather", Status: validate.StatusSucceeded, Valid: true}, result)
+	})
+
+	t.Run("failing call", func(t *testing.T) {
+		result := preview(t, map[string]any{"toolName": "weather", "arguments": map[string]any{"city": 42}})
+		assert.Equal(t, validate.StatusFailed, result.Status)
+		assert.False(t, result.Valid)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "city", result.Errors[0].Field)
+	})
+
+	t.Run("unknown tool", func(t *testing.T) {
+		rec := serve(t, router, http.MethodPost, "/api/validate/toolcall", map[string]any{"toolName": "nope", "arguments": map[string]any{}}, token)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("missing tool name", func(t *testing.T) {
+		rec := serve(t, router, http.MethodPost, "/api/validate/toolcall", map[string]any{"arguments": map[string]any{}}, token)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		rec := serve(t, router, http.MethodPost, "/api/validate/toolcall", map[string]any{"toolName": "weather"}, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
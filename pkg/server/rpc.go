@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/null-create/mcp-tls/pkg/auth"
+	"github.com/null-create/mcp-tls/pkg/codec"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+	"github.com/null-create/mcp-tls/pkg/validate"
+)
+
+// ---- JSON-RPC dispatcher
+
+// rpcMethod handles the params of a single JSON-RPC method and returns
+// either a result to be marshalled or a JSON-RPC error. ctx ends with the request.
+type rpcMethod func(ctx context.Context, params json.RawMessage) (any, *codec.JSONRPCError)
+
+// rpcRequestKey is the context key of the HTTP request a JSON-RPC method is serving
+type rpcRequestKey struct{}
+
+// rpcRequest returns the HTTP request carrying a JSON-RPC method's call, or nil outside RPCHandler
+func rpcRequest(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(rpcRequestKey{}).(*http.Request)
+	return r
+}
+
+// rpcMethods returns the JSON-RPC methods served by the dispatcher
+func (h *Handlers) rpcMethods() map[string]rpcMethod {
+	return map[string]rpcMethod{
+		"initialize": h.rpcInitialize,
+		"tools/list": h.rpcListTools,
+		"tools/call": h.rpcCallTool,
+	}
+}
+
+// ToolExecutor runs a tools/call request whose arguments passed validation.
+// The tool is the registered definition, not one supplied by the client.
+// ctx is cancelled when the call's timeout, from the tool's x-timeout-ms or
+// MCPTLS_TOOL_CALL_TIMEOUT, runs out.
+type ToolExecutor interface {
+	Execute(ctx context.Context, tool mcp.Tool, arguments json.RawMessage) (mcp.CallToolResult, error)
+}
+
+// SetToolExecutor sets the executor tools/call hands validated calls to.
+// Without one, tools/call only validates and then reports an internal error.
+func (h *Handlers) SetToolExecutor(executor ToolExecutor) {
+	h.executor = executor
+}
+
+// Dispatches JSON-RPC 2.0 requests to the registered method handlers
+func (h *Handlers) RPCHandler(w http.ResponseWriter, r *http.Request) {
+	// read one byte past the limit so oversized bodies are rejected rather than truncated
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(h.decodeLimits.MaxSize)+1))
+	if err != nil {
+		if status := bodyStatus(err); status == http.StatusRequestEntityTooLarge {
+			h.log.Error("json-rpc request body too large: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			resp := codec.NewJSONRPCResponse()
+			resp.Error = &codec.JSONRPCError{Code: codec.INVALID_REQUEST, Message: "request too large"}
+			_ = json.NewEncoder(w).Encode(resp)
+			return
+		}
+		h.writeRPCError(w, nil, &codec.JSONRPCError{
+			Code:    codec.PARSE_ERROR,
+			Message: "parse error: " + err.Error(),
+		})
+		return
+	}
+	// methods that authorize the caller's client certificate need the request
+	ctx := context.WithValue(r.Context(), rpcRequestKey{}, r)
+	req, rpcErr := h.decodeLimits.ParseRequest(body)
+	if req.IsNotification() && (rpcErr == nil || rpcErr.Code == codec.INVALID_PARAMS) {
+		h.handleNotification(ctx, w, req, rpcErr)
+		return
+	}
+	if rpcErr != nil {
+		h.writeRPCError(w, req.ID, rpcErr)
+		return
+	}
+	if req.JSONRPC != codec.JsonRPCVersion {
+		h.writeRPCError(w, req.ID, &codec.JSONRPCError{
+			Code:    codec.INVALID_REQUEST,
+			Message: "unsupported jsonrpc version",
+		})
+		return
+	}
+
+	method, ok := h.rpcMethods()[req.Method]
+	if !ok {
+		h.writeRPCError(w, req.ID, &codec.JSONRPCError{
+			Code:    codec.METHOD_NOT_FOUND,
+			Message: "method not found: " + req.Method,
+		})
+		return
+	}
+
+	result, rpcErr := method(ctx, req.Params)
+	if rpcErr != nil {
+		h.writeRPCError(w, req.ID, rpcErr)
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		h.writeRPCError(w, req.ID, &codec.JSONRPCError{
+			Code:    codec.INTERNAL_ERROR,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp := codec.NewJSONRPCResponse()
+	resp.ID = req.ID
+	resp.Result = data
+	codec.WriteJSONRPCResponse(w, resp, h.rpcErrorStatus)
+}
+
+// handleNotification runs a JSON-RPC notification. The sender expects no response,
+// so failures are only logged and the request is acknowledged with an empty 202.
+func (h *Handlers) handleNotification(ctx context.Context, w http.ResponseWriter, req codec.JSONRPCRequest, rpcErr *codec.JSONRPCError) {
+	defer w.WriteHeader(http.StatusAccepted)
+
+	switch method, ok := h.rpcMethods()[req.Method]; {
+	case rpcErr != nil:
+	case req.JSONRPC != codec.JsonRPCVersion:
+		rpcErr = &codec.JSONRPCError{Code: codec.INVALID_REQUEST, Message: "unsupported jsonrpc version"}
+	case !ok:
+		rpcErr = &codec.JSONRPCError{Code: codec.METHOD_NOT_FOUND, Message: "method not found: " + req.Method}
+	default:
+		_, rpcErr = method(ctx, req.Params)
+	}
+	if rpcErr != nil {
+		h.log.Warn("json-rpc notification %q dropped: %s", req.Method, rpcErr.Message)
+	}
+}
+
+func (h *Handlers) writeRPCError(w http.ResponseWriter, id *int64, rpcErr *codec.JSONRPCError) {
+	h.log.Error("json-rpc error %d: %s", rpcErr.Code, rpcErr.Message)
+	codec.WriteJSONRPCError(w, id, rpcErr, h.rpcErrorStatus)
+}
+
+// rpcInitialize performs the MCP handshake, rejecting clients
+// requesting a protocol version the server does not support.
+func (h *Handlers) rpcInitialize(_ context.Context, params json.RawMessage) (any, *codec.JSONRPCError) {
+	var initParams mcp.InitializeParams
+	if rpcErr := h.decodeLimits.DecodeParams(params, &initParams); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	result, err := h.toolManager.HandleInitialize(initParams)
+	if err != nil {
+		return nil, &codec.JSONRPCError{
+			Code:    codec.INVALID_PARAMS,
+			Message: err.Error(),
+			Data: map[string]any{
+				"supported": mcp.SupportedVersions,
+				"requested": initParams.ProtocolVersion,
+			},
+		}
+	}
+	return result, nil
+}
+
+// rpcListTools returns a page of the registered tools, starting after the params' cursor
+func (h *Handlers) rpcListTools(_ context.Context, params json.RawMessage) (any, *codec.JSONRPCError) {
+	var listParams mcp.ListToolsParams
+	if len(params) > 0 {
+		if rpcErr := h.decodeLimits.DecodeParams(params, &listParams); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+
+	result, err := h.toolManager.ListToolsPage(listParams.Cursor, mcp.DefaultPageSize)
+	if err != nil {
+		return nil, &codec.JSONRPCError{Code: codec.INVALID_PARAMS, Message: err.Error()}
+	}
+	return result, nil
+}
+
+// rpcCallTool checks a call against the registered tool, its access and rate limits,
+// and validates the arguments against its schema before handing it to the executor.
+// Failures of the tool itself are returned in the result with isError set.
+func (h *Handlers) rpcCallTool(ctx context.Context, params json.RawMessage) (any, *codec.JSONRPCError) {
+	var callParams mcp.CallToolParams
+	if rpcErr := h.decodeLimits.DecodeParams(params, &callParams); rpcErr != nil {
+		return nil, rpcErr
+	}
+	if callParams.Name == "" {
+		return nil, &codec.JSONRPCError{Code: codec.INVALID_PARAMS, Message: "invalid params: missing tool name"}
+	}
+
+	tool, err := h.toolManager.GetTool(callParams.Name)
+	if err != nil {
+		return nil, &codec.JSONRPCError{Code: codec.INVALID_PARAMS, Message: err.Error(), Data: toolErrorData{Tool: callParams.Name}}
+	}
+	if !h.toolAccess.Allowed(tool.Name) {
+		h.recordAudit(rpcRequest(ctx), auditCall, tool.Name, auditDenied, "tool is disabled")
+		return nil, &codec.JSONRPCError{
+			Code:    codec.TOOL_DENIED,
+			Message: "tool '" + tool.Name + "' is disabled",
+			Data:    toolErrorData{Tool: tool.Name},
+		}
+	}
+	if err := h.authorizeCert(rpcRequest(ctx), auth.ActionCall, tool.Name); err != nil {
+		h.recordAudit(rpcRequest(ctx), auditCall, tool.Name, auditDenied, err.Error())
+		return nil, &codec.JSONRPCError{Code: codec.TOOL_DENIED, Message: err.Error(), Data: toolErrorData{Tool: tool.Name}}
+	}
+	if !h.rateLimiter.Allow(&tool) {
+		return nil, &codec.JSONRPCError{
+			Code:    codec.RATE_LIMITED,
+			Message: "rate limit exceeded for tool '" + tool.Name + "'",
+			Data:    toolErrorData{Tool: tool.Name},
+		}
+	}
+
+	arguments := callParams.Arguments
+	if len(arguments) == 0 {
+		arguments = json.RawMessage(`{}`)
+	}
+	status, err := h.validators.For(&tool).ValidateInput(&tool, arguments)
+	if h.errorPolicy.Blocks(status, err) {
+		return nil, toolArgumentsError(tool.Name, status, err)
+	}
+	if status == validate.StatusError {
+		h.log.Warn("executing call to tool '%s' unvalidated under the fail-open policy: %v", tool.Name, err)
+	}
+
+	if h.executor == nil {
+		return nil, &codec.JSONRPCError{
+			Code:    codec.INTERNAL_ERROR,
+			Message: "no executor configured for tool '" + tool.Name + "'",
+			Data:    toolErrorData{Tool: tool.Name},
+		}
+	}
+	timeout := tool.CallTimeout(h.toolCallTimeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := h.executor.Execute(ctx, tool, arguments)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("tool '%s' timed out after %v", tool.Name, timeout)
+	}
+	if err != nil {
+		return mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(err.Error())}, IsError: true}, nil
+	}
+	if result.Content == nil {
+		result.Content = []mcp.Content{} // the spec requires an array, even when empty
+	}
+	return result, nil
+}
@@ -0,0 +1,309 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/codec"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// doRPC sends a JSON-RPC request through the dispatcher and decodes the response.
+func doRPC(t *testing.T, h *Handlers, method string, id int64, params any) codec.JSONRPCResponse {
+	t.Helper()
+	rawParams, err := json.Marshal(params)
+	require.NoError(t, err)
+	body, err := json.Marshal(codec.JSONRPCRequest{
+		JSONRPC: codec.JsonRPCVersion,
+		Method:  method,
+		Params:  rawParams,
+		ID:      codec.NewID(id),
+	})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h.RPCHandler(rec, httptest.NewRequest(http.MethodPost, "/api/rpc", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp codec.JSONRPCResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestRPCInitialize(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+
+	t.Run("Successful Handshake", func(t *testing.T) {
+		resp := doRPC(t, h, "initialize", 1, mcp.InitializeParams{
+			ProtocolVersion: mcp.Version,
+			ClientInfo:      mcp.Implementation{Name: "test-client", Version: "1.0.0"},
+		})
+		require.Nil(t, resp.Error)
+		assert.Equal(t, codec.JsonRPCVersion, resp.JSONRPC)
+		assert.Equal(t, codec.NewID(1), resp.ID)
+
+		var result mcp.InitializeResult
+		require.NoError(t, json.Unmarshal(resp.Result, &result))
+		assert.Equal(t, mcp.Version, result.ProtocolVersion)
+		assert.Equal(t, "mcp-tls-tool-manager", result.ServerInfo.Name)
+	})
+
+	t.Run("Version Mismatch Rejected", func(t *testing.T) {
+		resp := doRPC(t, h, "initialize", 2, mcp.InitializeParams{
+			ProtocolVersion: "1999-01-01",
+			ClientInfo:      mcp.Implementation{Name: "test-client", Version: "1.0.0"},
+		})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, codec.INVALID_PARAMS, resp.Error.Code)
+		assert.Equal(t, codec.NewID(2), resp.ID)
+		assert.Empty(t, resp.Result)
+	})
+
+	t.Run("Unknown Method", func(t *testing.T) {
+		resp := doRPC(t, h, "does/not/exist", 3, nil)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, codec.METHOD_NOT_FOUND, resp.Error.Code)
+	})
+}
+
+func TestRPCHandler_OverNestedParams(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+
+	params := strings.Repeat(`{"a":`, h.decodeLimits.MaxDepth+1) + `1` + strings.Repeat(`}`, h.decodeLimits.MaxDepth+1)
+	body := `{"jsonrpc":"2.0","method":"initialize","params":` + params + `,"id":4}`
+
+	rec := httptest.NewRecorder()
+	h.RPCHandler(rec, httptest.NewRequest(http.MethodPost, "/api/rpc", strings.NewReader(body)))
+
+	var resp codec.JSONRPCResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codec.INVALID_PARAMS, resp.Error.Code)
+	assert.Equal(t, codec.NewID(4), resp.ID)
+}
+
+func TestRPCHandler_Notifications(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+
+	notifications := map[string]string{
+		"known method":   `{"jsonrpc":"2.0","method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+		"unknown method": `{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+	}
+	for name, body := range notifications {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.RPCHandler(rec, httptest.NewRequest(http.MethodPost, "/api/rpc", strings.NewReader(body)))
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			assert.Empty(t, rec.Body.String(), "notifications must not get a response")
+		})
+	}
+
+	t.Run("request with id 0 gets a response", func(t *testing.T) {
+		body := `{"jsonrpc":"2.0","method":"notifications/initialized","id":0}`
+		rec := httptest.NewRecorder()
+		h.RPCHandler(rec, httptest.NewRequest(http.MethodPost, "/api/rpc", strings.NewReader(body)))
+
+		var resp codec.JSONRPCResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, codec.METHOD_NOT_FOUND, resp.Error.Code)
+		assert.Equal(t, codec.NewID(0), resp.ID)
+	})
+
+	t.Run("parse error gets a null id", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.RPCHandler(rec, httptest.NewRequest(http.MethodPost, "/api/rpc", strings.NewReader(`{"jsonrpc":`)))
+		assert.Contains(t, rec.Body.String(), `"id":null`)
+	})
+}
+
+func TestRPCHandler_ErrorStatus(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	body := `{"jsonrpc":"2.0","method":"does/not/exist","id":5}`
+
+	for _, tc := range []struct {
+		name       string
+		mapStatus  bool
+		wantStatus int
+	}{
+		{name: "Default", wantStatus: http.StatusOK},
+		{name: "Mapped", mapStatus: true, wantStatus: http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h.rpcErrorStatus = tc.mapStatus
+			rec := httptest.NewRecorder()
+			h.RPCHandler(rec, httptest.NewRequest(http.MethodPost, "/api/rpc", strings.NewReader(body)))
+			assert.Equal(t, tc.wantStatus, rec.Code)
+
+			var resp codec.JSONRPCResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.NotNil(t, resp.Error)
+			assert.Equal(t, codec.METHOD_NOT_FOUND, resp.Error.Code)
+			assert.Equal(t, codec.NewID(5), resp.ID)
+		})
+	}
+}
+
+// echoExecutor answers tool calls with their arguments, failing for the tool named "broken"
+type echoExecutor struct{}
+
+func (echoExecutor) Execute(_ context.Context, tool mcp.Tool, arguments json.RawMessage) (mcp.CallToolResult, error) {
+	if tool.Name == "broken" {
+		return mcp.CallToolResult{}, errors.New("upstream unavailable")
+	}
+	return mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(string(arguments))}}, nil
+}
+
+func registerRPCTool(t *testing.T, h *Handlers, name string) {
+	t.Helper()
+	tool := mcp.Tool{
+		Name:        name,
+		Description: "Echoes its input",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`),
+	}
+	require.NoError(t, mcp.SecureTool(&tool))
+	require.NoError(t, h.toolManager.RegisterTool(tool))
+}
+
+func TestRPCListTools(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	for i := range mcp.DefaultPageSize + 5 {
+		registerRPCTool(t, h, fmt.Sprintf("tool-%03d", i))
+	}
+
+	resp := doRPC(t, h, "tools/list", 1, nil)
+	require.Nil(t, resp.Error)
+	var first map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(resp.Result, &first))
+	assert.Contains(t, first, "tools")
+	assert.Contains(t, first, "nextCursor")
+
+	var page mcp.ListToolsResult
+	require.NoError(t, json.Unmarshal(resp.Result, &page))
+	require.Len(t, page.Tools, mcp.DefaultPageSize)
+	assert.Equal(t, "tool-000", page.Tools[0].Name)
+
+	resp = doRPC(t, h, "tools/list", 2, mcp.ListToolsParams{Cursor: page.NextCursor})
+	require.Nil(t, resp.Error)
+	page = mcp.ListToolsResult{}
+	require.NoError(t, json.Unmarshal(resp.Result, &page))
+	require.Len(t, page.Tools, 5)
+	assert.Equal(t, fmt.Sprintf("tool-%03d", mcp.DefaultPageSize), page.Tools[0].Name)
+	assert.Empty(t, page.NextCursor)
+
+	resp = doRPC(t, h, "tools/list", 3, mcp.ListToolsParams{Cursor: "!not-a-cursor"})
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codec.INVALID_PARAMS, resp.Error.Code)
+}
+
+func TestRPCCallTool(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	registerRPCTool(t, h, "echo")
+	registerRPCTool(t, h, "broken")
+
+	t.Run("No Executor", func(t *testing.T) {
+		resp := doRPC(t, h, "tools/call", 1, mcp.CallToolParams{Name: "echo", Arguments: json.RawMessage(`{"text":"hi"}`)})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, codec.INTERNAL_ERROR, resp.Error.Code)
+	})
+
+	h.SetToolExecutor(echoExecutor{})
+
+	t.Run("Valid Call", func(t *testing.T) {
+		resp := doRPC(t, h, "tools/call", 2, mcp.CallToolParams{Name: "echo", Arguments: json.RawMessage(`{"text":"hi"}`)})
+		require.Nil(t, resp.Error)
+		assert.JSONEq(t, `{"content":[{"type":"text","text":"{\"text\":\"hi\"}"}]}`, string(resp.Result))
+	})
+
+	t.Run("Tool Failure", func(t *testing.T) {
+		resp := doRPC(t, h, "tools/call", 3, mcp.CallToolParams{Name: "broken", Arguments: json.RawMessage(`{"text":"hi"}`)})
+		require.Nil(t, resp.Error)
+		assert.JSONEq(t, `{"content":[{"type":"text","text":"upstream unavailable"}],"isError":true}`, string(resp.Result))
+	})
+
+	t.Run("Invalid Arguments", func(t *testing.T) {
+		resp := doRPC(t, h, "tools/call", 4, mcp.CallToolParams{Name: "echo", Arguments: json.RawMessage(`{"text":42}`)})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, codec.INVALID_PARAMS, resp.Error.Code)
+		assert.Equal(t, "invalid arguments for tool 'echo'", resp.Error.Message)
+	})
+
+	t.Run("Unknown Tool", func(t *testing.T) {
+		resp := doRPC(t, h, "tools/call", 5, mcp.CallToolParams{Name: "missing"})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, codec.INVALID_PARAMS, resp.Error.Code)
+	})
+
+	t.Run("Denied Tool", func(t *testing.T) {
+		h.toolAccess = NewToolAccessList(nil, []string{"echo"})
+		defer func() { h.toolAccess = NewToolAccessList(nil, nil) }()
+		resp := doRPC(t, h, "tools/call", 6, mcp.CallToolParams{Name: "echo", Arguments: json.RawMessage(`{"text":"hi"}`)})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, codec.TOOL_DENIED, resp.Error.Code)
+	})
+}
+
+// deadlineExecutor records the deadline each call runs under, waiting it out for the tool named "slow"
+type deadlineExecutor struct {
+	remaining chan time.Duration
+}
+
+func (e deadlineExecutor) Execute(ctx context.Context, tool mcp.Tool, arguments json.RawMessage) (mcp.CallToolResult, error) {
+	deadline, _ := ctx.Deadline()
+	e.remaining <- time.Until(deadline)
+	if tool.Name == "slow" {
+		<-ctx.Done()
+		return mcp.CallToolResult{}, ctx.Err()
+	}
+	return mcp.CallToolResult{}, nil
+}
+
+func TestRPCCallTool_Timeout(t *testing.T) {
+	h := newTestHandler(t, mustGenerateSeed(t))
+	h.toolCallTimeout = time.Minute
+	executor := deadlineExecutor{remaining: make(chan time.Duration, 1)}
+	h.SetToolExecutor(executor)
+
+	registerRPCTool(t, h, "default")
+	for name, ms := range map[string]int{"quick": 2000, "slow": 20} {
+		tool := mcp.Tool{
+			Name:        name,
+			Description: "Declares its own timeout",
+			InputSchema: json.RawMessage(fmt.Sprintf(`{"type":"object","x-timeout-ms":%d}`, ms)),
+		}
+		require.NoError(t, mcp.SecureTool(&tool))
+		require.NoError(t, h.toolManager.RegisterTool(tool))
+	}
+
+	t.Run("Global Default", func(t *testing.T) {
+		resp := doRPC(t, h, "tools/call", 1, mcp.CallToolParams{Name: "default", Arguments: json.RawMessage(`{"text":"hi"}`)})
+		require.Nil(t, resp.Error)
+		remaining := <-executor.remaining
+		assert.InDelta(t, time.Minute, remaining, float64(time.Second))
+	})
+
+	t.Run("Declared Timeout", func(t *testing.T) {
+		resp := doRPC(t, h, "tools/call", 2, mcp.CallToolParams{Name: "quick"})
+		require.Nil(t, resp.Error)
+		remaining := <-executor.remaining
+		assert.InDelta(t, 2*time.Second, remaining, float64(time.Second))
+	})
+
+	t.Run("Timed Out", func(t *testing.T) {
+		resp := doRPC(t, h, "tools/call", 3, mcp.CallToolParams{Name: "slow"})
+		require.Nil(t, resp.Error)
+		<-executor.remaining
+		assert.JSONEq(t, `{"content":[{"type":"text","text":"tool 'slow' timed out after 20ms"}],"isError":true}`, string(resp.Result))
+	})
+}
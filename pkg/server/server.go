@@ -2,6 +2,9 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,14 +15,20 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/null-create/logger"
+	"github.com/null-create/mcp-tls/pkg/config"
 )
 
 type Conf struct {
-	Addr         string
-	Proxy        bool // Whether this is a proxy server
-	TimeoutRead  time.Duration
-	TimeoutWrite time.Duration
-	TimeoutIdle  time.Duration
+	Addr              string
+	Proxy             bool // Whether this is a proxy server
+	TimeoutRead       time.Duration
+	TimeoutReadHeader time.Duration // Bounds how long a client may take to send its headers
+	TimeoutWrite      time.Duration
+	TimeoutIdle       time.Duration // How long an idle keep-alive connection is held open
+	ShutdownGrace     time.Duration // How long in-flight requests get to drain on shutdown
+	KeepAlive         bool          // Whether connections are reused across requests
+	HTTP2             bool          // Whether HTTP/2 is offered to TLS clients
+	HTTP2MaxStreams   int           // Concurrent requests allowed on one HTTP/2 connection
 }
 
 func ServerConfigs() *Conf {
@@ -27,11 +36,17 @@ func ServerConfigs() *Conf {
 	if addr == "" {
 		addr = "localhost:8080"
 	}
+	cfgs := config.LoadConfigs()
 	return &Conf{
-		Addr:         addr,
-		TimeoutRead:  time.Second * 30,
-		TimeoutWrite: time.Second * 30,
-		TimeoutIdle:  time.Second * 30,
+		Addr:              addr,
+		TimeoutRead:       time.Second * 30,
+		TimeoutReadHeader: cfgs.ReadHeaderTimeout,
+		TimeoutWrite:      time.Second * 30,
+		TimeoutIdle:       cfgs.IdleTimeout,
+		ShutdownGrace:     cfgs.ShutdownGrace,
+		KeepAlive:         cfgs.KeepAlive,
+		HTTP2:             cfgs.HTTP2,
+		HTTP2MaxStreams:   cfgs.HTTP2MaxStreams,
 	}
 }
 
@@ -39,26 +54,43 @@ func ServerConfigs() *Conf {
 // the MCP Server object within its handlers using a call to
 // setupRoutes().
 type Server struct {
-	StartTime time.Time
-	Svr       *http.Server
-	log       *logger.Logger
+	StartTime     time.Time
+	ShutdownGrace time.Duration
+	Svr           *http.Server
+	log           *logger.Logger
 }
 
 func NewServer(handlers http.Handler) *Server {
 	svrCfgs := ServerConfigs()
 	return &Server{
-		StartTime: time.Now().UTC(),
-		log:       logger.NewLogger("SERVER", uuid.NewString()),
-		Svr: &http.Server{
-			Handler:      handlers,
-			Addr:         svrCfgs.Addr,
-			ReadTimeout:  svrCfgs.TimeoutRead,
-			WriteTimeout: svrCfgs.TimeoutWrite,
-			IdleTimeout:  svrCfgs.TimeoutIdle,
-		},
+		StartTime:     time.Now().UTC(),
+		ShutdownGrace: svrCfgs.ShutdownGrace,
+		log:           logger.NewLogger("SERVER", uuid.NewString()),
+		Svr:           newHTTPServer(handlers, svrCfgs),
 	}
 }
 
+// newHTTPServer applies the timeouts and protocol settings in svrCfgs to a new http.Server.
+// Every HTTP listener is built here so none is left without a ReadHeaderTimeout, which
+// stops clients trickling headers from holding connections open indefinitely.
+func newHTTPServer(handlers http.Handler, svrCfgs *Conf) *http.Server {
+	svr := &http.Server{
+		Handler:           handlers,
+		Addr:              svrCfgs.Addr,
+		ReadTimeout:       svrCfgs.TimeoutRead,
+		ReadHeaderTimeout: svrCfgs.TimeoutReadHeader,
+		WriteTimeout:      svrCfgs.TimeoutWrite,
+		IdleTimeout:       svrCfgs.TimeoutIdle,
+		Protocols:         new(http.Protocols),
+		HTTP2:             &http.HTTP2Config{MaxConcurrentStreams: svrCfgs.HTTP2MaxStreams},
+	}
+	svr.Protocols.SetHTTP1(true)
+	// HTTP/2 is negotiated over TLS only, so it has no effect on Run
+	svr.Protocols.SetHTTP2(svrCfgs.HTTP2)
+	svr.SetKeepAlivesEnabled(svrCfgs.KeepAlive)
+	return svr
+}
+
 func secondsToTimeStr(seconds float64) string {
 	duration := time.Duration(int64(seconds)) * time.Second
 	timeValue := time.Time{}.Add(duration)
@@ -79,33 +111,75 @@ func (s *Server) Shutdown() (string, error) {
 	return s.RunTime(), nil
 }
 
+// gracefully shuts down the server, waiting up to ShutdownGrace for in-flight
+// requests to finish before forcibly closing it. reports whether the forced path was taken.
+func (s *Server) gracefulShutdown(ctx context.Context) (bool, error) {
+	shutdownCtx, cancel := context.WithTimeout(ctx, s.ShutdownGrace)
+	defer cancel()
+
+	err := s.Svr.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Printf("shutdown timed out after %v. forcing exit.", s.ShutdownGrace)
+		if _, err := s.Shutdown(); err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+	return false, err
+}
+
 // starts a server that can be shut down via ctrl-c
 func (s *Server) Run() {
+	s.run(s.Svr.ListenAndServe)
+}
+
+// starts a server serving HTTPS with the given PEM certificate and key files
+// that can be shut down via ctrl-c. When MCPTLS_TLS_CLIENT_CA is set, clients
+// may present a certificate, which must then verify against that CA bundle.
+func (s *Server) RunTLS(certFile, keyFile string) {
+	if caFile := config.LoadConfigs().TLSClientCA; caFile != "" {
+		tlsConfig, err := clientCertConfig(caFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.Svr.TLSConfig = tlsConfig
+	}
+	s.run(func() error {
+		return s.Svr.ListenAndServeTLS(certFile, keyFile)
+	})
+}
+
+// clientCertConfig requests client certificates, verifying any that are
+// presented against the CA certificates in caFile
+func clientCertConfig(caFile string) (*tls.Config, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in client CA bundle '%s'", caFile)
+	}
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.VerifyClientCertIfGiven, // tokens remain an alternative to certificates
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+func (s *Server) run(listen func() error) {
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
+	defer serverStopCtx()
 
 	// listen for syscall signals for process to interrupt/quit
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer signal.Stop(sig)
 	go func() {
 		<-sig
 
-		// shutdown signal with grace period of 10 seconds
-		// nolint:golint
-		shutdownCtx, _ := context.WithTimeout(serverCtx, 10*time.Second)
-
-		go func() {
-			<-shutdownCtx.Done()
-			if shutdownCtx.Err() == context.DeadlineExceeded {
-				log.Println("shutdown timed out. forcing exit.")
-				if _, err := s.Shutdown(); err != nil {
-					log.Fatal(err)
-				}
-				log.Printf("server run time: %s", s.RunTime())
-			}
-		}()
-
 		log.Println("shutting down server...")
-		if err := s.Svr.Shutdown(shutdownCtx); err != nil {
+		if _, err := s.gracefulShutdown(serverCtx); err != nil {
 			log.Fatal(err)
 		}
 		log.Printf("server run time: %v", s.RunTime())
@@ -113,7 +187,7 @@ func (s *Server) Run() {
 	}()
 
 	log.Printf("🛠️ MCP-TLS server is running on at %s...", s.Svr.Addr)
-	if err := s.Svr.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := listen(); err != nil && err != http.ErrServerClosed {
 		log.Fatal(err)
 	}
 
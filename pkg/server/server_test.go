@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestServer serves s on a random local port and returns its base URL
+func startTestServer(t *testing.T, s *Server) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.Svr.Serve(ln)
+	return "http://" + ln.Addr().String()
+}
+
+func TestNewServer_ShutdownGrace(t *testing.T) {
+	s := NewServer(http.NotFoundHandler())
+	assert.Equal(t, config.DefaultShutdownGrace, s.ShutdownGrace)
+
+	t.Setenv("MCPTLS_SHUTDOWN_GRACE", "250ms")
+	s = NewServer(http.NotFoundHandler())
+	assert.Equal(t, 250*time.Millisecond, s.ShutdownGrace)
+}
+
+func TestGracefulShutdown_ForcedAfterGrace(t *testing.T) {
+	t.Setenv("MCPTLS_SHUTDOWN_GRACE", "50ms")
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	s := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	}))
+	url := startTestServer(t, s)
+
+	go http.Get(url)
+	select {
+	case <-entered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler was never reached")
+	}
+
+	start := time.Now()
+	forced, err := s.gracefulShutdown(context.Background())
+	require.NoError(t, err)
+	assert.True(t, forced, "expected blocked handler to trigger forced shutdown")
+	assert.Less(t, time.Since(start), config.DefaultShutdownGrace)
+}
+
+func TestGracefulShutdown_CleanDoesNotWaitForGrace(t *testing.T) {
+	t.Setenv("MCPTLS_SHUTDOWN_GRACE", "5s")
+
+	s := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	url := startTestServer(t, s)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	time.Sleep(5 * time.Millisecond) // let the request get in flight
+
+	start := time.Now()
+	forced, err := s.gracefulShutdown(context.Background())
+	require.NoError(t, err)
+	assert.False(t, forced)
+	assert.Less(t, time.Since(start), time.Second, "clean shutdown should not wait out the grace period")
+	<-done
+}
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1 and its key as PEM files
+func writeTestCert(t testing.TB) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+// startTLSTestServer serves s over TLS on a random local port, as RunTLS does, and
+// returns its base URL and a client that offers HTTP/2 and trusts any certificate
+func startTLSTestServer(t testing.TB, s *Server) (string, *http.Client) {
+	t.Helper()
+	certFile, keyFile := writeTestCert(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.Svr.ServeTLS(ln, certFile, keyFile)
+	t.Cleanup(func() { s.Svr.Close() })
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+	t.Cleanup(client.CloseIdleConnections)
+	return "https://" + ln.Addr().String(), client
+}
+
+func TestNewServer_TLSNegotiatesHTTP2(t *testing.T) {
+	tests := []struct {
+		name  string
+		http2 string
+		proto string
+	}{
+		{name: "enabled by default", http2: "", proto: "HTTP/2.0"},
+		{name: "disabled", http2: "false", proto: "HTTP/1.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MCPTLS_HTTP2", tt.http2)
+			s := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.Proto))
+			}))
+			url, client := startTLSTestServer(t, s)
+
+			resp, err := client.Get(url)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.proto, resp.Proto)
+		})
+	}
+}
+
+func TestNewServer_ConnectionSettings(t *testing.T) {
+	s := NewServer(http.NotFoundHandler())
+	assert.Equal(t, config.DefaultReadHeaderTimeout, s.Svr.ReadHeaderTimeout, "a header timeout guards against slowloris clients")
+	assert.Equal(t, config.DefaultIdleTimeout, s.Svr.IdleTimeout)
+	assert.Equal(t, config.DefaultHTTP2MaxStreams, s.Svr.HTTP2.MaxConcurrentStreams)
+
+	t.Setenv("MCPTLS_READ_HEADER_TIMEOUT", "2s")
+	t.Setenv("MCPTLS_IDLE_TIMEOUT", "90s")
+	t.Setenv("MCPTLS_HTTP2_MAX_STREAMS", "32")
+	s = NewServer(http.NotFoundHandler())
+	assert.Equal(t, 2*time.Second, s.Svr.ReadHeaderTimeout)
+	assert.Equal(t, 90*time.Second, s.Svr.IdleTimeout)
+	assert.Equal(t, 32, s.Svr.HTTP2.MaxConcurrentStreams)
+}
+
+func TestNewServer_KeepAliveDisabled(t *testing.T) {
+	t.Setenv("MCPTLS_KEEP_ALIVE", "false")
+	s := NewServer(http.NotFoundHandler())
+	url := startTestServer(t, s)
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.True(t, resp.Close, "expected the server to close the connection after the response")
+}
+
+// BenchmarkTLSServer measures concurrent requests over HTTP/2, multiplexed on one
+// connection, against HTTP/1.1 over a pool of them. On loopback the two are close;
+// HTTP/2's gain is in fewer connections and handshakes across many clients.
+func BenchmarkTLSServer(b *testing.B) {
+	for _, http2 := range []string{"true", "false"} {
+		b.Run("http2="+http2, func(b *testing.B) {
+			b.Setenv("MCPTLS_HTTP2", http2)
+			s := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			}))
+			url, client := startTLSTestServer(b, s)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					resp, err := client.Get(url)
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					resp.Body.Close()
+				}
+			})
+		})
+	}
+}
+
+func TestNewServer_ClosesSlowHeaderConnections(t *testing.T) {
+	t.Setenv("MCPTLS_READ_HEADER_TIMEOUT", "100ms")
+	s := NewServer(http.NotFoundHandler())
+	addr := strings.TrimPrefix(startTestServer(t, s), "http://")
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// trickle a header line at a time, never finishing the request
+	start := time.Now()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n"))
+	require.NoError(t, err)
+	closed := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(conn)
+		closed <- err
+	}()
+	for i := 0; ; i++ {
+		select {
+		case <-closed:
+			// a clean close and a reset both mean the server dropped the connection
+			assert.Less(t, time.Since(start), 2*time.Second)
+			return
+		case <-time.After(50 * time.Millisecond):
+			if i > 100 {
+				t.Fatal("connection still open long after the header timeout")
+			}
+			conn.Write([]byte(fmt.Sprintf("X-Trickle-%d: x\r\n", i)))
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"fmt"
+	"net/http/httptest"
+
+	"github.com/null-create/mcp-tls/pkg/config"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// NewTestServer serves the full API router, configured from the environment,
+// over a loopback listener so integration tests can drive it with a real HTTP
+// client. tools are registered up front; users, tokens and tools live in memory
+// and are discarded with the server. Callers must Close it when done.
+func NewTestServer(tools ...mcp.Tool) (*httptest.Server, error) {
+	h := NewHandler()
+	for _, tool := range tools {
+		if err := h.toolManager.RegisterTool(tool); err != nil {
+			return nil, fmt.Errorf("failed to register tool '%s': %w", tool.Name, err)
+		}
+	}
+	return httptest.NewServer(newRouter(&h, config.LoadConfigs())), nil
+}
@@ -0,0 +1,77 @@
+package tls
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/null-create/mcp-tls/pkg/codec"
+)
+
+// ErrUnattested is returned by VerifyCall for a request without an attestation
+var ErrUnattested = errors.New("call carries no attestation")
+
+// AttestedRequest is a JSON-RPC request forwarded by the proxy with an HMAC-SHA256
+// over the rest of the request, proving it passed validation on the way through.
+type AttestedRequest struct {
+	codec.JSONRPCRequest
+	Attestation []byte `json:"attestation,omitempty"`
+}
+
+// RetrieveAttestationKey loads the key the proxy and the MCP server share for
+// attesting tool calls from the base64 encoded MCPTLS_ATTESTATION_KEY. It returns
+// no key if the variable is not set, leaving calls unattested.
+func RetrieveAttestationKey() ([]byte, error) {
+	encoded := os.Getenv("MCPTLS_ATTESTATION_KEY")
+	if encoded == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode MCPTLS_ATTESTATION_KEY: %w", err)
+	}
+	if len(key) < HmacKeySize {
+		return nil, fmt.Errorf("%w: expected at least %d bytes for attestation key", ErrInvalidKey, HmacKeySize)
+	}
+	return key, nil
+}
+
+// AttestCall signs req with key and returns the marshalled AttestedRequest to forward
+func AttestCall(req codec.JSONRPCRequest, key []byte) ([]byte, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal call: %w", err)
+	}
+	signature, err := signHMAC(data, key)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(AttestedRequest{JSONRPCRequest: req, Attestation: signature})
+}
+
+// VerifyCall checks the attestation of a marshalled AttestedRequest against key
+// and returns the request it covers. Requests that bypassed the proxy, or were
+// changed after it signed them, are rejected with ErrUnattested or ErrAuthenticationFailed.
+// An attestation proves a call was validated, not that it's fresh, so it doesn't
+// stop a validated call from being replayed.
+func VerifyCall(data []byte, key []byte) (codec.JSONRPCRequest, error) {
+	var attested AttestedRequest
+	if err := json.Unmarshal(data, &attested); err != nil {
+		return codec.JSONRPCRequest{}, fmt.Errorf("%w: failed to unmarshal call: %w", ErrInvalidInput, err)
+	}
+	if len(attested.Attestation) == 0 {
+		return codec.JSONRPCRequest{}, ErrUnattested
+	}
+	// the attestation covers the request as the proxy marshalled it, which
+	// re-marshalling the decoded fields reproduces
+	signed, err := json.Marshal(attested.JSONRPCRequest)
+	if err != nil {
+		return codec.JSONRPCRequest{}, fmt.Errorf("%w: failed to marshal call: %w", ErrInvalidInput, err)
+	}
+	if err := verifyHMAC(signed, attested.Attestation, key); err != nil {
+		return codec.JSONRPCRequest{}, err
+	}
+	return attested.JSONRPCRequest, nil
+}
@@ -0,0 +1,102 @@
+package tls
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/codec"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttestAndVerifyCall(t *testing.T) {
+	key := mustGenerateKey(t, HmacKeySize)
+	req := codec.JSONRPCRequest{
+		JSONRPC: codec.JsonRPCVersion,
+		Method:  "tool.call",
+		Params:  json.RawMessage(`{"name":"weather","arguments":{"city":"<Paris>"}}`),
+		ID:      codec.NewID(7),
+	}
+
+	t.Run("Success Round Trip", func(t *testing.T) {
+		attested, err := AttestCall(req, key)
+		require.NoError(t, err)
+
+		verified, err := VerifyCall(attested, key)
+		require.NoError(t, err)
+		assert.Equal(t, req.Method, verified.Method)
+		assert.Equal(t, int64(7), *verified.ID)
+		assert.JSONEq(t, string(req.Params), string(verified.Params))
+	})
+
+	t.Run("Fail Unattested", func(t *testing.T) {
+		unsigned, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		_, err = VerifyCall(unsigned, key)
+		assert.ErrorIs(t, err, ErrUnattested)
+	})
+
+	t.Run("Fail Tampered Params", func(t *testing.T) {
+		attested, err := AttestCall(req, key)
+		require.NoError(t, err)
+
+		var tampered AttestedRequest
+		require.NoError(t, json.Unmarshal(attested, &tampered))
+		tampered.Params = json.RawMessage(`{"name":"weather","arguments":{"city":"; rm -rf /"}}`)
+		data, err := json.Marshal(tampered)
+		require.NoError(t, err)
+
+		_, err = VerifyCall(data, key)
+		assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	})
+
+	t.Run("Fail Wrong Key", func(t *testing.T) {
+		attested, err := AttestCall(req, key)
+		require.NoError(t, err)
+
+		_, err = VerifyCall(attested, mustGenerateKey(t, HmacKeySize))
+		assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	})
+
+	t.Run("Fail Malformed", func(t *testing.T) {
+		_, err := VerifyCall([]byte(`{"method":`), key)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	})
+
+	t.Run("Fail Empty Key", func(t *testing.T) {
+		_, err := AttestCall(req, nil)
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+}
+
+func TestRetrieveAttestationKey(t *testing.T) {
+	t.Run("Key From Environment", func(t *testing.T) {
+		key := mustGenerateKey(t, HmacKeySize)
+		t.Setenv("MCPTLS_ATTESTATION_KEY", base64.StdEncoding.EncodeToString(key))
+		got, err := RetrieveAttestationKey()
+		require.NoError(t, err)
+		assert.Equal(t, key, got)
+	})
+
+	t.Run("No Key When Unset", func(t *testing.T) {
+		t.Setenv("MCPTLS_ATTESTATION_KEY", "")
+		got, err := RetrieveAttestationKey()
+		require.NoError(t, err)
+		assert.Nil(t, got, "calls should go unattested without a key")
+	})
+
+	t.Run("Fail Short Key", func(t *testing.T) {
+		t.Setenv("MCPTLS_ATTESTATION_KEY", base64.StdEncoding.EncodeToString([]byte{1, 2, 3}))
+		_, err := RetrieveAttestationKey()
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+
+	t.Run("Fail Bad Base64", func(t *testing.T) {
+		t.Setenv("MCPTLS_ATTESTATION_KEY", "not base64!")
+		_, err := RetrieveAttestationKey()
+		assert.Error(t, err)
+	})
+}
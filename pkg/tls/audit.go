@@ -0,0 +1,32 @@
+package tls
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+)
+
+// RetrieveAuditKey loads the key audit records are HMAC'd with from the base64
+// encoded MCPTLS_AUDIT_KEY. If the variable is not set an ephemeral key is
+// generated, so records from earlier runs can't be verified after a restart.
+func RetrieveAuditKey() ([]byte, error) {
+	encoded := os.Getenv("MCPTLS_AUDIT_KEY")
+	if encoded == "" {
+		log.Printf("WARNING MCPTLS_AUDIT_KEY not set, generating ephemeral audit key")
+		key := make([]byte, HmacKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate audit key: %w", err)
+		}
+		return key, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode MCPTLS_AUDIT_KEY: %w", err)
+	}
+	if len(key) < HmacKeySize {
+		return nil, fmt.Errorf("%w: expected at least %d bytes for audit key", ErrInvalidKey, HmacKeySize)
+	}
+	return key, nil
+}
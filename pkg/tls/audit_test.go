@@ -0,0 +1,38 @@
+package tls
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetrieveAuditKey(t *testing.T) {
+	t.Run("Key From Environment", func(t *testing.T) {
+		key := mustGenerateKey(t, HmacKeySize)
+		t.Setenv("MCPTLS_AUDIT_KEY", base64.StdEncoding.EncodeToString(key))
+		got, err := RetrieveAuditKey()
+		require.NoError(t, err)
+		assert.Equal(t, key, got)
+	})
+
+	t.Run("Ephemeral Key When Unset", func(t *testing.T) {
+		t.Setenv("MCPTLS_AUDIT_KEY", "")
+		got, err := RetrieveAuditKey()
+		require.NoError(t, err)
+		assert.Len(t, got, HmacKeySize)
+	})
+
+	t.Run("Fail Short Key", func(t *testing.T) {
+		t.Setenv("MCPTLS_AUDIT_KEY", base64.StdEncoding.EncodeToString([]byte{1, 2, 3}))
+		_, err := RetrieveAuditKey()
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+
+	t.Run("Fail Bad Base64", func(t *testing.T) {
+		t.Setenv("MCPTLS_AUDIT_KEY", "not base64!")
+		_, err := RetrieveAuditKey()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,107 @@
+package tls
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// BundleAlgo identifies the signature scheme used for signed bundles.
+const BundleAlgo = "Ed25519"
+
+// SignedBundle wraps an arbitrary JSON payload together with a detached
+// signature over the exact payload bytes.
+type SignedBundle struct {
+	Algo      string          `json:"algo"`
+	Payload   json.RawMessage `json:"payload"`
+	Signature []byte          `json:"signature"`
+}
+
+// RetrieveBundleKey loads the Ed25519 bundle signing key from the base64 encoded
+// seed in MCPTLS_BUNDLE_KEY. If the variable is not set an ephemeral key is generated,
+// meaning bundles exported by this process can only be imported by this process.
+func RetrieveBundleKey() (ed25519.PrivateKey, error) {
+	encoded := os.Getenv("MCPTLS_BUNDLE_KEY")
+	if encoded == "" {
+		log.Printf("WARNING MCPTLS_BUNDLE_KEY not set, generating ephemeral bundle key")
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate bundle key: %w", err)
+		}
+		return key, nil
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode MCPTLS_BUNDLE_KEY: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%w: expected %d bytes for bundle key seed", ErrInvalidKey, ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// SignBundle marshals data and signs the resulting bytes with the given
+// Ed25519 private key, returning the marshalled SignedBundle.
+func SignBundle(data any, key ed25519.PrivateKey) ([]byte, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%w: expected %d bytes for Ed25519 private key", ErrInvalidKey, ed25519.PrivateKeySize)
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle payload: %w", err)
+	}
+
+	bundle := SignedBundle{
+		Algo:      BundleAlgo,
+		Payload:   payload,
+		Signature: ed25519.Sign(key, payload),
+	}
+
+	bundleBytes, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signed bundle: %w", err)
+	}
+	return bundleBytes, nil
+}
+
+// VerifyBundle checks the signature of a marshalled SignedBundle against the
+// given public key and, if valid, unmarshals the payload into target.
+// 'target' must be a pointer to the expected struct type (e.g., *mcp.ToolSet).
+func VerifyBundle(bundleData []byte, key ed25519.PublicKey, target any) error {
+	if len(bundleData) == 0 {
+		return fmt.Errorf("%w: bundle data cannot be empty", ErrInvalidInput)
+	}
+	if target == nil {
+		return errors.New("target interface cannot be nil")
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: expected %d bytes for Ed25519 public key", ErrInvalidKey, ed25519.PublicKeySize)
+	}
+
+	var bundle SignedBundle
+	if err := json.Unmarshal(bundleData, &bundle); err != nil {
+		return fmt.Errorf("%w: failed to unmarshal signed bundle: %w", ErrInvalidInput, err)
+	}
+	if bundle.Algo != BundleAlgo {
+		return fmt.Errorf("%w: unsupported bundle algorithm '%s'", ErrInvalidInput, bundle.Algo)
+	}
+	if len(bundle.Payload) == 0 || len(bundle.Signature) == 0 {
+		return fmt.Errorf("%w: incomplete signed bundle structure", ErrInvalidInput)
+	}
+
+	if !ed25519.Verify(key, bundle.Payload, bundle.Signature) {
+		return ErrAuthenticationFailed
+	}
+
+	if err := json.Unmarshal(bundle.Payload, target); err != nil {
+		return fmt.Errorf("failed to unmarshal bundle payload into target: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package tls
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mustGenerateBundleKey generates an Ed25519 key pair or fails the test.
+func mustGenerateBundleKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, "Failed to generate bundle key")
+	return pub, priv
+}
+
+func TestSignAndVerifyBundle(t *testing.T) {
+	pub, priv := mustGenerateBundleKey(t)
+	originalData := testPayload{Name: "Alice", Age: 30}
+
+	t.Run("Success Round Trip", func(t *testing.T) {
+		bundle, err := SignBundle(&originalData, priv)
+		require.NoError(t, err)
+
+		var recoveredData testPayload
+		require.NoError(t, VerifyBundle(bundle, pub, &recoveredData))
+		assert.Equal(t, originalData, recoveredData)
+	})
+
+	t.Run("Fail Tampered Payload", func(t *testing.T) {
+		bundleBytes, err := SignBundle(&originalData, priv)
+		require.NoError(t, err)
+
+		var bundle SignedBundle
+		require.NoError(t, json.Unmarshal(bundleBytes, &bundle))
+		bundle.Payload = json.RawMessage(`{"name":"Mallory","age":30}`)
+		tampered, err := json.Marshal(bundle)
+		require.NoError(t, err)
+
+		var recoveredData testPayload
+		err = VerifyBundle(tampered, pub, &recoveredData)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	})
+
+	t.Run("Fail Wrong Public Key", func(t *testing.T) {
+		bundle, err := SignBundle(&originalData, priv)
+		require.NoError(t, err)
+
+		wrongPub, _ := mustGenerateBundleKey(t)
+		var recoveredData testPayload
+		err = VerifyBundle(bundle, wrongPub, &recoveredData)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	})
+
+	t.Run("Fail Unsupported Algorithm", func(t *testing.T) {
+		bundleBytes, err := SignBundle(&originalData, priv)
+		require.NoError(t, err)
+
+		var bundle SignedBundle
+		require.NoError(t, json.Unmarshal(bundleBytes, &bundle))
+		bundle.Algo = "none"
+		modified, err := json.Marshal(bundle)
+		require.NoError(t, err)
+
+		var recoveredData testPayload
+		err = VerifyBundle(modified, pub, &recoveredData)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	})
+
+	t.Run("Fail Empty Input", func(t *testing.T) {
+		var recoveredData testPayload
+		err := VerifyBundle(nil, pub, &recoveredData)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	})
+
+	t.Run("Fail Bad Key Size", func(t *testing.T) {
+		_, err := SignBundle(&originalData, ed25519.PrivateKey{1, 2, 3})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+}
+
+func TestRetrieveBundleKey(t *testing.T) {
+	t.Run("Seed From Environment", func(t *testing.T) {
+		seed := mustGenerateKey(t, ed25519.SeedSize)
+		t.Setenv("MCPTLS_BUNDLE_KEY", base64.StdEncoding.EncodeToString(seed))
+
+		key, err := RetrieveBundleKey()
+		require.NoError(t, err)
+		assert.Equal(t, ed25519.NewKeyFromSeed(seed), key)
+	})
+
+	t.Run("Ephemeral Key When Unset", func(t *testing.T) {
+		t.Setenv("MCPTLS_BUNDLE_KEY", "")
+
+		key, err := RetrieveBundleKey()
+		require.NoError(t, err)
+		assert.Len(t, key, ed25519.PrivateKeySize)
+	})
+
+	t.Run("Fail Bad Seed Size", func(t *testing.T) {
+		t.Setenv("MCPTLS_BUNDLE_KEY", base64.StdEncoding.EncodeToString([]byte{1, 2, 3}))
+
+		_, err := RetrieveBundleKey()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+}
@@ -0,0 +1,121 @@
+package util
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreaker.Do while the breaker is failing fast.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the current mode of a CircuitBreaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // calls go through
+	BreakerOpen     BreakerState = "open"      // calls fail fast until the cooldown ends
+	BreakerHalfOpen BreakerState = "half-open" // a single trial call decides whether to close again
+)
+
+// CircuitBreaker stops calling a failing remote after a run of consecutive failures.
+// Once open it fails fast for the cooldown period, then lets one trial call through:
+// success closes the breaker, failure opens it for another cooldown.
+// Errors marked with Permanent don't count as failures, since they say
+// the request was bad rather than that the remote is down.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     BreakerState
+	openedAt  time.Time
+	trial     bool // a half-open trial call is in flight
+	now       func() time.Time
+}
+
+// NewCircuitBreaker returns a closed breaker that opens after threshold
+// consecutive failures and stays open for cooldown.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+		now:       time.Now,
+	}
+}
+
+// State returns the breaker's current state.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState()
+}
+
+// currentState moves an open breaker to half-open once its cooldown has passed
+func (b *CircuitBreaker) currentState() BreakerState {
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		b.state = BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Do calls fn if the breaker allows it, recording the outcome.
+// Returns ErrCircuitOpen without calling fn while the breaker is open.
+// A panic in fn counts as a failure before it carries on up the stack,
+// so a panicking trial call doesn't leave the breaker stuck half-open.
+func (b *CircuitBreaker) Do(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	returned := false
+	defer func() {
+		if !returned {
+			b.record(errPanicked)
+		}
+	}()
+	err := fn()
+	returned = true
+	b.record(err)
+	return err
+}
+
+// errPanicked is recorded for a call that panicked instead of returning
+var errPanicked = errors.New("call panicked")
+
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.currentState() {
+	case BreakerOpen:
+		return ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.trial {
+			return ErrCircuitOpen
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+
+	var permanent *permanentError
+	if err == nil || errors.As(err, &permanent) {
+		b.failures = 0
+		b.state = BreakerClosed
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
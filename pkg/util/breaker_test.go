@@ -0,0 +1,147 @@
+package util
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker whose clock only moves when advance is called
+func newTestBreaker(threshold int, cooldown time.Duration) (*CircuitBreaker, func(time.Duration)) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(threshold, cooldown)
+	b.now = func() time.Time { return now }
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+var errRemoteDown = errors.New("remote down")
+
+func failing() error    { return errRemoteDown }
+func succeeding() error { return nil }
+
+func TestCircuitBreaker_OpensAfterFailures(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Minute)
+
+	for i := range 3 {
+		if state := b.State(); state != BreakerClosed {
+			t.Fatalf("Expected closed breaker before failure %d, got %s", i+1, state)
+		}
+		if err := b.Do(failing); !errors.Is(err, errRemoteDown) {
+			t.Fatalf("Expected remote error, got: %v", err)
+		}
+	}
+	if state := b.State(); state != BreakerOpen {
+		t.Fatalf("Expected open breaker, got %s", state)
+	}
+
+	called := false
+	err := b.Do(func() error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got: %v", err)
+	}
+	if called {
+		t.Error("Expected open breaker to fail fast without calling fn")
+	}
+}
+
+func TestCircuitBreaker_SuccessResetsFailures(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Minute)
+
+	b.Do(failing)
+	b.Do(failing)
+	b.Do(succeeding)
+	b.Do(failing)
+	b.Do(failing)
+	if state := b.State(); state != BreakerClosed {
+		t.Errorf("Expected failures to be counted consecutively, breaker is %s", state)
+	}
+}
+
+func TestCircuitBreaker_PermanentErrorsDoNotCount(t *testing.T) {
+	b, _ := newTestBreaker(1, time.Minute)
+
+	b.Do(func() error { return Permanent(errors.New("bad request")) })
+	if state := b.State(); state != BreakerClosed {
+		t.Errorf("Expected permanent errors to leave the breaker closed, got %s", state)
+	}
+}
+
+func TestCircuitBreaker_RecoversAfterCooldown(t *testing.T) {
+	b, advance := newTestBreaker(2, time.Minute)
+	b.Do(failing)
+	b.Do(failing)
+
+	advance(30 * time.Second)
+	if state := b.State(); state != BreakerOpen {
+		t.Fatalf("Expected breaker to stay open during cooldown, got %s", state)
+	}
+
+	advance(30 * time.Second)
+	if state := b.State(); state != BreakerHalfOpen {
+		t.Fatalf("Expected half-open breaker after cooldown, got %s", state)
+	}
+
+	// a failed trial opens the breaker for another cooldown
+	if err := b.Do(failing); !errors.Is(err, errRemoteDown) {
+		t.Fatalf("Expected trial call to run, got: %v", err)
+	}
+	if state := b.State(); state != BreakerOpen {
+		t.Fatalf("Expected failed trial to reopen breaker, got %s", state)
+	}
+
+	// a successful trial closes it
+	advance(time.Minute)
+	if err := b.Do(succeeding); err != nil {
+		t.Fatalf("Expected trial call to succeed, got: %v", err)
+	}
+	if state := b.State(); state != BreakerClosed {
+		t.Errorf("Expected successful trial to close breaker, got %s", state)
+	}
+}
+
+func TestCircuitBreaker_SingleHalfOpenTrial(t *testing.T) {
+	b, advance := newTestBreaker(1, time.Minute)
+	b.Do(failing)
+	advance(time.Minute)
+
+	err := b.Do(func() error {
+		// while the trial is in flight other calls fail fast
+		if err := b.Do(succeeding); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected concurrent call to fail fast during trial, got: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected trial call to succeed, got: %v", err)
+	}
+}
+
+func TestCircuitBreaker_PanickingTrial(t *testing.T) {
+	b, advance := newTestBreaker(1, time.Minute)
+	b.Do(failing)
+	advance(time.Minute)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected the panic to reach the caller")
+			}
+		}()
+		b.Do(func() error { panic("remote client bug") })
+	}()
+	if state := b.State(); state != BreakerOpen {
+		t.Fatalf("Expected a panicking trial to reopen the breaker, got %s", state)
+	}
+
+	// the next trial is let through after another cooldown
+	advance(time.Minute)
+	if err := b.Do(succeeding); err != nil {
+		t.Fatalf("Expected trial call to run, got: %v", err)
+	}
+	if state := b.State(); state != BreakerClosed {
+		t.Errorf("Expected successful trial to close breaker, got %s", state)
+	}
+}
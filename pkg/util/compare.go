@@ -0,0 +1,11 @@
+package util
+
+import "crypto/subtle"
+
+// SecureEqual reports whether two digests, signatures, or other secrets are
+// equal in time independent of their contents, so a caller can't recover an
+// expected value byte by byte from how quickly mismatches are rejected.
+// Only the lengths of a and b can leak.
+func SecureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
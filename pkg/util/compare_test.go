@@ -0,0 +1,21 @@
+package util
+
+import "testing"
+
+func TestSecureEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"sha256:deadbeef", "sha256:deadbeef", true},
+		{"", "", true},
+		{"sha256:deadbeef", "sha256:deadbeee", false},
+		{"sha256:deadbeef", "sha256:deadbee", false},
+		{"sha256:deadbeef", "", false},
+	}
+	for _, tt := range tests {
+		if got := SecureEqual(tt.a, tt.b); got != tt.want {
+			t.Errorf("SecureEqual(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
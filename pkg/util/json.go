@@ -2,13 +2,40 @@ package util
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
 )
 
-func WriteError(w http.ResponseWriter, code int, message string) {
+// DecodeJSON decodes a JSON body into v. In strict mode fields v doesn't
+// define are rejected, with an error naming the first one, instead of
+// being silently dropped.
+func DecodeJSON(r io.Reader, v any, strict bool) error {
+	dec := json.NewDecoder(r)
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(v)
+}
+
+// ErrorResponse is the JSON envelope every API error is returned in
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	Code      int    `json:"code"`                 // the HTTP status code
+	RequestID string `json:"request_id,omitempty"` // set by the RequestID middleware, for correlating with server logs
+}
+
+// WriteError writes an ErrorResponse with the given status code, carrying the
+// request's ID when the RequestID middleware assigned one.
+func WriteError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.WriteHeader(code)
-	_ = json.NewEncoder(w).Encode(map[string]string{
-		"error": message,
+	_ = json.NewEncoder(w).Encode(ErrorResponse{
+		Error:     message,
+		Code:      code,
+		RequestID: middleware.GetReqID(r.Context()),
 	})
 }
 
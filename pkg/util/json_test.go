@@ -0,0 +1,44 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestWriteError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "host/abc-000001"))
+	rec := httptest.NewRecorder()
+	WriteError(rec, req, http.StatusTeapot, "short and stout")
+
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var got ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	want := ErrorResponse{Error: "short and stout", Code: http.StatusTeapot, RequestID: "host/abc-000001"}
+	if got != want {
+		t.Errorf("WriteError() body = %+v, want %+v", got, want)
+	}
+
+	// without the RequestID middleware the field is left out
+	rec = httptest.NewRecorder()
+	WriteError(rec, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusBadRequest, "bad")
+	var raw map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	if _, ok := raw["request_id"]; ok {
+		t.Errorf("expected no request_id without the middleware, got %v", raw)
+	}
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffPolicy controls the delay between retry attempts. The delay starts at
+// Initial and is multiplied by Multiplier after each failure, up to Max. Each delay
+// is then randomized by up to ±Jitter (a fraction, e.g. 0.2 for ±20%) so clients
+// retrying against the same server don't stay in lockstep.
+type BackoffPolicy struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// DefaultBackoff is a reasonable policy for remote calls.
+var DefaultBackoff = BackoffPolicy{
+	Initial:    100 * time.Millisecond,
+	Max:        5 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Delay returns the wait before the retry following the given zero-based attempt.
+func (b BackoffPolicy) Delay(attempt int) time.Duration {
+	delay := float64(b.Initial) * math.Pow(b.Multiplier, float64(attempt))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// permanentError marks an error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Retry returns it immediately instead of trying again.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Retry calls fn up to attempts times, waiting between failures as set by backoff,
+// until it succeeds. It stops early when fn returns an error wrapped with Permanent,
+// which is returned unwrapped, or when ctx is done. Otherwise the last error is returned.
+func Retry(ctx context.Context, attempts int, backoff BackoffPolicy, fn func() error) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var err error
+	for attempt := range attempts {
+		if err = fn(); err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt == attempts-1 {
+			break
+		}
+
+		timer := time.NewTimer(backoff.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: last error: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", attempts, err)
+}
@@ -0,0 +1,116 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fastBackoff keeps the tests quick while still exercising the waits
+var fastBackoff = BackoffPolicy{
+	Initial:    time.Millisecond,
+	Max:        5 * time.Millisecond,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+func TestRetry_SucceedsOnThirdTry(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), 5, fastBackoff, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("transient failure")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestRetry_ExhaustsAttempts(t *testing.T) {
+	failure := errors.New("still down")
+	calls := 0
+	err := Retry(context.Background(), 4, fastBackoff, func() error {
+		calls++
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected last error to be returned, got: %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("Expected 4 calls, got %d", calls)
+	}
+}
+
+func TestRetry_PermanentErrorStopsEarly(t *testing.T) {
+	failure := errors.New("bad request")
+	calls := 0
+	err := Retry(context.Background(), 5, fastBackoff, func() error {
+		calls++
+		return Permanent(failure)
+	})
+	if err != failure {
+		t.Errorf("Expected unwrapped permanent error, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call, got %d", calls)
+	}
+}
+
+func TestRetry_ContextCancellationAbortsEarly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	slow := BackoffPolicy{Initial: time.Hour, Max: time.Hour, Multiplier: 1}
+
+	calls := 0
+	done := make(chan error)
+	go func() {
+		done <- Retry(ctx, 5, slow, func() error {
+			calls++
+			return errors.New("transient failure")
+		})
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got: %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("Expected 1 call before cancelling, got %d", calls)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Retry did not return after the context was cancelled")
+	}
+
+	if err := Retry(ctx, 5, slow, func() error {
+		t.Error("fn should not be called with a cancelled context")
+		return nil
+	}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+}
+
+func TestBackoffPolicy_Delay(t *testing.T) {
+	policy := BackoffPolicy{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for attempt, want := range expected {
+		if got := policy.Delay(attempt); got != want {
+			t.Errorf("Delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for range 100 {
+		if got := policy.Delay(0); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("Delay(0) with jitter = %v, want within ±50%% of 100ms", got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package util
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// StatusRecorder wraps an http.ResponseWriter to capture the status code and the
+// number of body bytes written, which the ResponseWriter doesn't expose, for
+// logging and metrics middleware. Flush and Hijack are passed through when the
+// wrapped writer supports them, so streaming and WebSocket handlers keep working.
+type StatusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// NewStatusRecorder wraps w
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w}
+}
+
+// Status returns the status code sent: the first final status passed to WriteHeader,
+// 200 if the handler wrote or flushed a body without calling it, 101 after a Hijack,
+// or 0 if nothing has been sent yet.
+func (r *StatusRecorder) Status() int {
+	return r.status
+}
+
+// BytesWritten returns the number of body bytes written so far
+func (r *StatusRecorder) BytesWritten() int64 {
+	return r.bytes
+}
+
+func (r *StatusRecorder) WriteHeader(code int) {
+	// informational responses, e.g. 103 Early Hints, precede the real status
+	if r.status == 0 && (code >= http.StatusOK || code == http.StatusSwitchingProtocols) {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *StatusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends any buffered data to the client, if the wrapped writer supports it
+func (r *StatusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		if r.status == 0 {
+			r.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+// Hijack takes over the connection, e.g. to upgrade it to a WebSocket, if the
+// wrapped writer supports it. The recorder counts that as 101 Switching Protocols.
+func (r *StatusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (r *StatusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,156 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStatusRecorder_CapturesStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+		bytes   int64
+	}{
+		{
+			name: "explicit status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("created"))
+			},
+			status: http.StatusCreated,
+			bytes:  7,
+		},
+		{
+			name: "implicit 200 on write",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "hello")
+				_, _ = io.WriteString(w, ", world")
+			},
+			status: http.StatusOK,
+			bytes:  12,
+		},
+		{
+			name: "first status wins",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.WriteHeader(http.StatusOK)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "informational status is skipped",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusEarlyHints)
+				w.WriteHeader(http.StatusAccepted)
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			name: "implicit 200 on flush",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.(http.Flusher).Flush()
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:    "nothing written",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			status:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			sr := NewStatusRecorder(rec)
+			tt.handler(sr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if sr.Status() != tt.status {
+				t.Errorf("Status() = %d, want %d", sr.Status(), tt.status)
+			}
+			if sr.BytesWritten() != tt.bytes {
+				t.Errorf("BytesWritten() = %d, want %d", sr.BytesWritten(), tt.bytes)
+			}
+			if int64(rec.Body.Len()) != tt.bytes {
+				t.Errorf("wrapped writer got %d bytes, want %d", rec.Body.Len(), tt.bytes)
+			}
+		})
+	}
+}
+
+func TestStatusRecorder_Flush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sr := NewStatusRecorder(rec)
+	_, _ = sr.Write([]byte("event: ping\n\n"))
+	sr.Flush()
+	if !rec.Flushed {
+		t.Error("Expected Flush to reach the wrapped writer")
+	}
+
+	// through http.ResponseController too
+	rec = httptest.NewRecorder()
+	if err := http.NewResponseController(NewStatusRecorder(rec)).Flush(); err != nil || !rec.Flushed {
+		t.Errorf("ResponseController.Flush() = %v, flushed %v", err, rec.Flushed)
+	}
+}
+
+// hijackableRecorder is an httptest.ResponseRecorder whose connection can be taken over
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.conn, bufio.NewReadWriter(bufio.NewReader(h.conn), bufio.NewWriter(h.conn)), nil
+}
+
+func TestStatusRecorder_Hijack(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	sr := NewStatusRecorder(&hijackableRecorder{ResponseRecorder: httptest.NewRecorder(), conn: server})
+	conn, _, err := sr.Hijack()
+	if err != nil {
+		t.Fatalf("Hijack() error = %v", err)
+	}
+	if conn != server {
+		t.Error("Expected the wrapped writer's connection")
+	}
+	if sr.Status() != http.StatusSwitchingProtocols {
+		t.Errorf("Status() = %d, want %d after a hijack", sr.Status(), http.StatusSwitchingProtocols)
+	}
+
+	// a writer that can't be hijacked says so
+	if _, _, err := NewStatusRecorder(httptest.NewRecorder()).Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Hijack() error = %v, want http.ErrNotSupported", err)
+	}
+}
+
+func TestStatusRecorder_RealServer(t *testing.T) {
+	var recorded *StatusRecorder
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded = NewStatusRecorder(w)
+		http.Error(recorded, "teapot", http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot || recorded.Status() != http.StatusTeapot {
+		t.Errorf("status = %d, recorded %d, want %d", resp.StatusCode, recorded.Status(), http.StatusTeapot)
+	}
+	if recorded.BytesWritten() != int64(len(body)) || !strings.Contains(string(body), "teapot") {
+		t.Errorf("BytesWritten() = %d for body %q", recorded.BytesWritten(), body)
+	}
+}
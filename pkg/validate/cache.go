@@ -0,0 +1,173 @@
+package validate
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// DefaultCacheSize is the number of validation results kept when no size is given
+const DefaultCacheSize = 1024
+
+// ValidationCache is a bounded, concurrency-safe LRU cache of input validation results
+// keyed by the tool's schema fingerprint and a hash of the arguments, so repeated
+// identical tool calls skip schema compilation and validation entirely.
+type ValidationCache struct {
+	mu           sync.Mutex
+	capacity     int
+	order        *list.List               // most recently used entries at the front
+	entries      map[string]*list.Element // cache key -> element holding a *cacheEntry
+	fingerprints map[string]string        // tool name -> schema fingerprint of its cached entries
+	hits         uint64
+	misses       uint64
+}
+
+type cacheEntry struct {
+	key         string
+	toolName    string
+	fingerprint string
+	args        []byte
+	status      ValidationStatus
+	err         error
+}
+
+// NewValidationCache creates a cache holding at most capacity results.
+// A non-positive capacity uses DefaultCacheSize.
+func NewValidationCache(capacity int) *ValidationCache {
+	if capacity <= 0 {
+		capacity = DefaultCacheSize
+	}
+	return &ValidationCache{
+		capacity:     capacity,
+		order:        list.New(),
+		entries:      make(map[string]*list.Element),
+		fingerprints: make(map[string]string),
+	}
+}
+
+// cacheKey derives the cache key for a validation of the given arguments
+// against a schema fingerprint. The options are part of the key since they
+// can change both the outcome and the returned arguments.
+func cacheKey(fingerprint string, inputArguments []byte, options inputOptions) string {
+	h := sha256.New()
+	h.Write([]byte(fingerprint))
+	h.Write([]byte{0})
+	h.Write(inputArguments)
+	var flags [2]byte
+	if options.coerceTypes {
+		flags[0] = 1
+	}
+	if options.applyDefaults {
+		flags[1] = 1
+	}
+	h.Write(flags[:])
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns the cached result for key, if any.
+func (c *ValidationCache) get(toolName, fingerprint, key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidateStale(toolName, fingerprint)
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry), true
+}
+
+// put stores a result, evicting the least recently used entry when full.
+func (c *ValidationCache) put(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidateStale(entry.toolName, entry.fingerprint)
+	c.fingerprints[entry.toolName] = entry.fingerprint
+
+	if elem, ok := c.entries[entry.key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// invalidateStale drops every entry cached for the tool under a different
+// schema fingerprint. The caller must hold the lock.
+func (c *ValidationCache) invalidateStale(toolName, fingerprint string) {
+	previous, ok := c.fingerprints[toolName]
+	if !ok || previous == fingerprint {
+		return
+	}
+
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if entry := elem.Value.(*cacheEntry); entry.toolName == toolName && entry.fingerprint != fingerprint {
+			c.removeElement(elem)
+		}
+		elem = next
+	}
+	delete(c.fingerprints, toolName)
+}
+
+// removeElement removes an entry from the cache. The caller must hold the lock.
+func (c *ValidationCache) removeElement(elem *list.Element) {
+	entry := c.order.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+}
+
+// Len returns the number of cached results.
+func (c *ValidationCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// CacheStats reports a cache's effectiveness and occupancy
+type CacheStats struct {
+	Name     string `json:"name"`
+	Hits     uint64 `json:"hits"`
+	Misses   uint64 `json:"misses"`
+	Size     int    `json:"size"`
+	Capacity int    `json:"capacity"`
+}
+
+// StatsCache is a cache operators can inspect and clear
+type StatsCache interface {
+	Stats() CacheStats
+	Flush()
+}
+
+var _ StatsCache = (*ValidationCache)(nil)
+
+// Stats returns the cache's hit and miss counts since creation, and its current size
+func (c *ValidationCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Name:     "validation_results",
+		Hits:     c.hits,
+		Misses:   c.misses,
+		Size:     c.order.Len(),
+		Capacity: c.capacity,
+	}
+}
+
+// Flush drops every cached result. Hit and miss counts are kept.
+func (c *ValidationCache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+	clear(c.fingerprints)
+}
@@ -0,0 +1,154 @@
+package validate
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+func newCacheTestTool(propertyType string) *mcp.Tool {
+	return &mcp.Tool{
+		Name: "cached-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"value": map[string]interface{}{"type": propertyType},
+			},
+			"required": []string{"value"},
+		}),
+	}
+}
+
+func TestValidationCache_Hit(t *testing.T) {
+	cache := NewValidationCache(10)
+	tool := newCacheTestTool("string")
+	input := []byte(`{"value": "hello"}`)
+
+	_, status, err := ValidateToolInput(tool, input, WithCache(cache))
+	if status != StatusSucceeded || err != nil {
+		t.Fatalf("first validation = %v, %v", status, err)
+	}
+
+	_, status, err = ValidateToolInput(tool, input, WithCache(cache))
+	if status != StatusSucceeded || err != nil {
+		t.Fatalf("cached validation = %v, %v", status, err)
+	}
+
+	if cache.hits != 1 || cache.misses != 1 {
+		t.Errorf("hits = %d, misses = %d, want 1 and 1", cache.hits, cache.misses)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", cache.Len())
+	}
+}
+
+func TestValidationCache_FailedResultCached(t *testing.T) {
+	cache := NewValidationCache(10)
+	tool := newCacheTestTool("string")
+	input := []byte(`{"value": 42}`)
+
+	_, first, firstErr := ValidateToolInput(tool, input, WithCache(cache))
+	_, second, secondErr := ValidateToolInput(tool, input, WithCache(cache))
+
+	if first != StatusFailed || second != StatusFailed {
+		t.Fatalf("statuses = %v, %v, want %v", first, second, StatusFailed)
+	}
+	if firstErr == nil || secondErr == nil || firstErr.Error() != secondErr.Error() {
+		t.Errorf("cached error = %v, want %v", secondErr, firstErr)
+	}
+	if cache.hits != 1 {
+		t.Errorf("hits = %d, want 1", cache.hits)
+	}
+}
+
+func TestValidationCache_SchemaChangeInvalidates(t *testing.T) {
+	cache := NewValidationCache(10)
+	input := []byte(`{"value": "hello"}`)
+
+	_, status, _ := ValidateToolInput(newCacheTestTool("string"), input, WithCache(cache))
+	if status != StatusSucceeded {
+		t.Fatalf("status = %v, want %v", status, StatusSucceeded)
+	}
+
+	// same tool name and input but a changed schema must not reuse the prior result
+	_, status, _ = ValidateToolInput(newCacheTestTool("integer"), input, WithCache(cache))
+	if status != StatusFailed {
+		t.Errorf("status after schema change = %v, want %v", status, StatusFailed)
+	}
+	if cache.hits != 0 {
+		t.Errorf("hits = %d, want 0", cache.hits)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d, want stale entry evicted leaving 1", cache.Len())
+	}
+}
+
+func TestValidationCache_Bounded(t *testing.T) {
+	cache := NewValidationCache(3)
+	tool := newCacheTestTool("string")
+
+	for i := 0; i < 5; i++ {
+		ValidateToolInput(tool, []byte(fmt.Sprintf(`{"value": "v%d"}`, i)), WithCache(cache))
+	}
+	if cache.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", cache.Len())
+	}
+
+	// the oldest entry was evicted, the newest is still cached
+	ValidateToolInput(tool, []byte(`{"value": "v0"}`), WithCache(cache))
+	ValidateToolInput(tool, []byte(`{"value": "v4"}`), WithCache(cache))
+	if cache.hits != 1 {
+		t.Errorf("hits = %d, want 1", cache.hits)
+	}
+}
+
+func TestValidationCache_Concurrent(t *testing.T) {
+	cache := NewValidationCache(8)
+	tool := newCacheTestTool("string")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			input := []byte(fmt.Sprintf(`{"value": "v%d"}`, i%10))
+			if _, status, err := ValidateToolInput(tool, input, WithCache(cache)); status != StatusSucceeded {
+				t.Errorf("status = %v, err = %v", status, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.Len() > 8 {
+		t.Errorf("Len() = %d, want at most 8", cache.Len())
+	}
+}
+
+func TestValidationCache_StatsAndFlush(t *testing.T) {
+	cache := NewValidationCache(10)
+	tool := newCacheTestTool("string")
+	input := []byte(`{"value": "hello"}`)
+
+	ValidateToolInput(tool, input, WithCache(cache))
+	ValidateToolInput(tool, input, WithCache(cache))
+	ValidateToolInput(tool, []byte(`{"value": "other"}`), WithCache(cache))
+
+	want := CacheStats{Name: "validation_results", Hits: 1, Misses: 2, Size: 2, Capacity: 10}
+	if stats := cache.Stats(); stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+
+	cache.Flush()
+	if cache.Len() != 0 {
+		t.Errorf("Len() after Flush = %d, want 0", cache.Len())
+	}
+
+	// a flushed entry is validated again rather than served from the cache
+	ValidateToolInput(tool, input, WithCache(cache))
+	want = CacheStats{Name: "validation_results", Hits: 1, Misses: 3, Size: 1, Capacity: 10}
+	if stats := cache.Stats(); stats != want {
+		t.Errorf("Stats() after Flush = %+v, want %+v", stats, want)
+	}
+}
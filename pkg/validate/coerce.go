@@ -0,0 +1,100 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+)
+
+var (
+	// integers and numbers must match the JSON number grammar exactly,
+	// so strings like "030", " 30", or "1e3" for an integer are never coerced
+	integerLiteral = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	numberLiteral  = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+)
+
+// coerceTypes converts string values in the arguments to the numeric or boolean
+// type declared for them in the schema. The arguments are returned unchanged if
+// either document cannot be decoded or nothing needed converting.
+func coerceTypes(schema json.RawMessage, inputArguments []byte) []byte {
+	var schemaDoc map[string]any
+	if err := json.Unmarshal(schema, &schemaDoc); err != nil {
+		return inputArguments
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(inputArguments))
+	decoder.UseNumber() // keep numbers exactly as sent
+	var args any
+	if err := decoder.Decode(&args); err != nil {
+		return inputArguments
+	}
+
+	coerced, changed := coerceValue(schemaDoc, args)
+	if !changed {
+		return inputArguments
+	}
+
+	data, err := json.Marshal(coerced)
+	if err != nil {
+		return inputArguments
+	}
+	return data
+}
+
+// coerceValue walks a decoded value alongside its schema, returning the
+// possibly converted value and whether anything was changed.
+func coerceValue(schema map[string]any, value any) (any, bool) {
+	schemaType, _ := schema["type"].(string)
+
+	switch v := value.(type) {
+	case string:
+		switch schemaType {
+		case "integer":
+			if integerLiteral.MatchString(v) {
+				return json.Number(v), true
+			}
+		case "number":
+			if numberLiteral.MatchString(v) {
+				return json.Number(v), true
+			}
+		case "boolean":
+			switch v {
+			case "true":
+				return true, true
+			case "false":
+				return false, true
+			}
+		}
+
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		changed := false
+		for key, propValue := range v {
+			propSchema, ok := properties[key].(map[string]any)
+			if !ok {
+				continue
+			}
+			if coerced, ok := coerceValue(propSchema, propValue); ok {
+				v[key] = coerced
+				changed = true
+			}
+		}
+		return v, changed
+
+	case []any:
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return v, false
+		}
+		changed := false
+		for i, item := range v {
+			if coerced, ok := coerceValue(items, item); ok {
+				v[i] = coerced
+				changed = true
+			}
+		}
+		return v, changed
+	}
+
+	return value, false
+}
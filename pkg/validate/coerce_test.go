@@ -0,0 +1,133 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+func TestValidateToolInput_CoerceTypes(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "coerce-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name":    map[string]interface{}{"type": "string"},
+				"age":     map[string]interface{}{"type": "integer"},
+				"score":   map[string]interface{}{"type": "number"},
+				"active":  map[string]interface{}{"type": "boolean"},
+				"zipCode": map[string]interface{}{"type": "string"},
+				"address": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"floor": map[string]interface{}{"type": "integer"},
+					},
+				},
+				"ids": map[string]interface{}{
+					"type":  "array",
+					"items": map[string]interface{}{"type": "integer"},
+				},
+			},
+			"required": []string{"name", "age"},
+		}),
+	}
+
+	tests := []struct {
+		name           string
+		input          string
+		expectedStatus ValidationStatus
+		expectedArgs   map[string]any
+	}{
+		{
+			name:           "numeric and boolean strings coerced",
+			input:          `{"name": "John", "age": "30", "score": "9.5", "active": "true"}`,
+			expectedStatus: StatusSucceeded,
+			expectedArgs:   map[string]any{"name": "John", "age": float64(30), "score": 9.5, "active": true},
+		},
+		{
+			name:           "nested object and array items coerced",
+			input:          `{"name": "John", "age": 30, "address": {"floor": "3"}, "ids": ["1", 2]}`,
+			expectedStatus: StatusSucceeded,
+			expectedArgs: map[string]any{
+				"name": "John", "age": float64(30),
+				"address": map[string]any{"floor": float64(3)},
+				"ids":     []any{float64(1), float64(2)},
+			},
+		},
+		{
+			name:           "string fields keep numeric-looking values",
+			input:          `{"name": "John", "age": 30, "zipCode": "01234"}`,
+			expectedStatus: StatusSucceeded,
+			expectedArgs:   map[string]any{"name": "John", "age": float64(30), "zipCode": "01234"},
+		},
+		{
+			name:           "non-numeric string still fails",
+			input:          `{"name": "John", "age": "thirty"}`,
+			expectedStatus: StatusFailed,
+		},
+		{
+			name:           "ambiguous leading zero not coerced",
+			input:          `{"name": "John", "age": "030"}`,
+			expectedStatus: StatusFailed,
+		},
+		{
+			name:           "fractional value not coerced to integer",
+			input:          `{"name": "John", "age": "30.5"}`,
+			expectedStatus: StatusFailed,
+		},
+		{
+			name:           "boolean-like words not coerced",
+			input:          `{"name": "John", "age": 30, "active": "yes"}`,
+			expectedStatus: StatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, status, err := ValidateToolInput(tool, []byte(tt.input), WithCoerceTypes())
+			if status != tt.expectedStatus {
+				t.Fatalf("ValidateToolInput() status = %v, want %v (err: %v)", status, tt.expectedStatus, err)
+			}
+			if tt.expectedArgs == nil {
+				return
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(args, &got); err != nil {
+				t.Fatalf("coerced arguments are not valid JSON: %v", err)
+			}
+			if !jsonEqual(got, tt.expectedArgs) {
+				t.Errorf("ValidateToolInput() args = %s, want %v", args, tt.expectedArgs)
+			}
+		})
+	}
+}
+
+func TestValidateToolInput_NoCoercionByDefault(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "strict-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"age": map[string]interface{}{"type": "integer"},
+			},
+		}),
+	}
+
+	input := []byte(`{"age": "30"}`)
+	args, status, _ := ValidateToolInput(tool, input)
+	if status != StatusFailed {
+		t.Errorf("ValidateToolInput() status = %v, want %v", status, StatusFailed)
+	}
+	if string(args) != string(input) {
+		t.Errorf("ValidateToolInput() args = %s, want unchanged %s", args, input)
+	}
+}
+
+// jsonEqual compares two decoded JSON values by their canonical encoding.
+func jsonEqual(a, b any) bool {
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aj) == string(bj)
+}
@@ -0,0 +1,43 @@
+package validate
+
+import (
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// oneOfAmbiguous replaces gojsonschema's description of a oneOf failure when the value
+// matched more than one subschema, which it otherwise reports exactly like matching none.
+const oneOfAmbiguous = "Must validate one and only one schema (oneOf), but matches more than one"
+
+// contextSeparator joins error paths for comparison. Unlike the default "." it doesn't
+// turn up in real property names, so "a.b" isn't mistaken for a child of "a".
+const contextSeparator = "\x00"
+
+// clarifyOneOf rewrites the description of each oneOf failure caused by matching
+// several subschemas. gojsonschema follows a oneOf that matched none with the errors
+// of the closest subschema, all at or under the oneOf's own path; a oneOf failure with
+// no other errors there can only have matched more than one. When sibling keywords
+// failed at the same path the cause is ambiguous and the original description is kept.
+func clarifyOneOf(results []gojsonschema.ResultError) {
+	for i, result := range results {
+		if result.Type() != "number_one_of" {
+			continue
+		}
+		path := result.Context().String(contextSeparator)
+		explained := false
+		for j, other := range results {
+			if j == i {
+				continue
+			}
+			otherPath := other.Context().String(contextSeparator)
+			if otherPath == path || strings.HasPrefix(otherPath, path+contextSeparator) {
+				explained = true
+				break
+			}
+		}
+		if !explained {
+			result.SetDescription(oneOfAmbiguous)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+func TestValidateToolInputSchema_Combinators(t *testing.T) {
+	tests := []struct {
+		name           string
+		schema         string
+		input          string
+		expectedStatus ValidationStatus
+		errorContains  string
+	}{
+		// allOf: every subschema must match
+		{
+			name: "allOf all match",
+			schema: `{"type":"object","allOf":[
+				{"properties":{"name":{"type":"string"}},"required":["name"]},
+				{"properties":{"age":{"type":"integer","minimum":0}},"required":["age"]}
+			]}`,
+			input:          `{"name":"ada","age":36}`,
+			expectedStatus: StatusSucceeded,
+		},
+		{
+			name: "allOf one fails",
+			schema: `{"type":"object","allOf":[
+				{"properties":{"name":{"type":"string"}},"required":["name"]},
+				{"properties":{"age":{"type":"integer","minimum":0}},"required":["age"]}
+			]}`,
+			input:          `{"name":"ada","age":-1}`,
+			expectedStatus: StatusFailed,
+			errorContains:  "Must validate all the schemas (allOf)",
+		},
+		// anyOf: at least one subschema must match
+		{
+			name:           "anyOf one matches",
+			schema:         `{"type":"object","properties":{"id":{"anyOf":[{"type":"string"},{"type":"integer"}]}}}`,
+			input:          `{"id":42}`,
+			expectedStatus: StatusSucceeded,
+		},
+		{
+			name:           "anyOf none match",
+			schema:         `{"type":"object","properties":{"id":{"anyOf":[{"type":"string"},{"type":"integer"}]}}}`,
+			input:          `{"id":true}`,
+			expectedStatus: StatusFailed,
+			errorContains:  "Must validate at least one schema (anyOf)",
+		},
+		// oneOf: exactly one subschema must match
+		{
+			name: "oneOf exactly one matches",
+			schema: `{"type":"object","properties":{"payment":{"oneOf":[
+				{"type":"object","properties":{"card":{"type":"string"}},"required":["card"]},
+				{"type":"object","properties":{"iban":{"type":"string"}},"required":["iban"]}
+			]}}}`,
+			input:          `{"payment":{"card":"4111"}}`,
+			expectedStatus: StatusSucceeded,
+		},
+		{
+			name: "oneOf none match",
+			schema: `{"type":"object","properties":{"payment":{"oneOf":[
+				{"type":"object","properties":{"card":{"type":"string"}},"required":["card"]},
+				{"type":"object","properties":{"iban":{"type":"string"}},"required":["iban"]}
+			]}}}`,
+			input:          `{"payment":{"cash":true}}`,
+			expectedStatus: StatusFailed,
+			errorContains:  "Must validate one and only one schema (oneOf)",
+		},
+		{
+			name: "oneOf several match",
+			schema: `{"type":"object","properties":{"payment":{"oneOf":[
+				{"type":"object","properties":{"card":{"type":"string"}},"required":["card"]},
+				{"type":"object","properties":{"iban":{"type":"string"}},"required":["iban"]}
+			]}}}`,
+			input:          `{"payment":{"card":"4111","iban":"DE89"}}`,
+			expectedStatus: StatusFailed,
+			errorContains:  "payment: " + oneOfAmbiguous,
+		},
+		// if/then/else: the branch taken depends on the condition
+		{
+			name: "if then branch passes",
+			schema: `{"type":"object","properties":{"unit":{"type":"string"},"value":{"type":"number"}},
+				"if":{"properties":{"unit":{"const":"percent"}}},
+				"then":{"properties":{"value":{"maximum":100}}},
+				"else":{"properties":{"value":{"minimum":0}}}}`,
+			input:          `{"unit":"percent","value":50}`,
+			expectedStatus: StatusSucceeded,
+		},
+		{
+			name: "if then branch fails",
+			schema: `{"type":"object","properties":{"unit":{"type":"string"},"value":{"type":"number"}},
+				"if":{"properties":{"unit":{"const":"percent"}}},
+				"then":{"properties":{"value":{"maximum":100}}},
+				"else":{"properties":{"value":{"minimum":0}}}}`,
+			input:          `{"unit":"percent","value":150}`,
+			expectedStatus: StatusFailed,
+			errorContains:  `Must validate "then" as "if" was valid`,
+		},
+		{
+			name: "if else branch passes",
+			schema: `{"type":"object","properties":{"unit":{"type":"string"},"value":{"type":"number"}},
+				"if":{"properties":{"unit":{"const":"percent"}}},
+				"then":{"properties":{"value":{"maximum":100}}},
+				"else":{"properties":{"value":{"minimum":0}}}}`,
+			input:          `{"unit":"kelvin","value":150}`,
+			expectedStatus: StatusSucceeded,
+		},
+		{
+			name: "if else branch fails",
+			schema: `{"type":"object","properties":{"unit":{"type":"string"},"value":{"type":"number"}},
+				"if":{"properties":{"unit":{"const":"percent"}}},
+				"then":{"properties":{"value":{"maximum":100}}},
+				"else":{"properties":{"value":{"minimum":0}}}}`,
+			input:          `{"unit":"kelvin","value":-5}`,
+			expectedStatus: StatusFailed,
+			errorContains:  `Must validate "else" as "if" was not valid`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &mcp.Tool{Name: "combinator-tool", InputSchema: json.RawMessage(tt.schema)}
+			status, err := ValidateToolInputSchema(tool, []byte(tt.input))
+			if status != tt.expectedStatus {
+				t.Errorf("ValidateToolInputSchema() status = %v, want %v (err: %v)", status, tt.expectedStatus, err)
+			}
+			if tt.errorContains == "" {
+				if err != nil {
+					t.Errorf("ValidateToolInputSchema() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("ValidateToolInputSchema() error = %v, want error containing %q", err, tt.errorContains)
+			}
+		})
+	}
+}
+
+func TestClarifyOneOf_NoMatchKeepsSubschemaErrors(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "combinator-tool",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"id":{"oneOf":[
+			{"type":"string","minLength":3},
+			{"type":"integer"}
+		]}}}`),
+	}
+	_, err := ValidateToolInputSchema(tool, []byte(`{"id":"ab"}`))
+
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected a *SchemaError, got %T: %v", err, err)
+	}
+	if strings.Contains(err.Error(), oneOfAmbiguous) {
+		t.Errorf("A oneOf matching no subschema was reported as matching several: %v", err)
+	}
+	// the closest subschema's violation explains why nothing matched
+	found := false
+	for _, fieldErr := range schemaErr.Errors {
+		if fieldErr.Field == "id" && strings.Contains(fieldErr.Reason, "length must be greater than or equal to 3") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the closest subschema's violation among %+v", schemaErr.Errors)
+	}
+}
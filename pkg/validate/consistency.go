@@ -0,0 +1,145 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// ConsistencyWarning describes a mismatch between a tool's description and its input schema.
+// A description that talks about parameters the schema doesn't define can steer a model
+// into sending, or believing it sent, input the tool never validates.
+type ConsistencyWarning struct {
+	Parameter string `json:"parameter"`
+	Reason    string `json:"reason"`
+}
+
+func (w ConsistencyWarning) String() string { return fmt.Sprintf("%s: %s", w.Parameter, w.Reason) }
+
+var (
+	// `name` quoted as code
+	backtickRef = regexp.MustCompile("`([A-Za-z_][A-Za-z0-9_.-]*)`")
+	// parameter 'name', argument "name"
+	namedRef = regexp.MustCompile(`(?i)\b(?:parameter|argument|param|field)s?\s+['"]([A-Za-z_][A-Za-z0-9_.-]*)['"]`)
+	// identifiers written as snake_case or camelCase, which prose rarely uses otherwise
+	identifierRef = regexp.MustCompile(`\b([a-z][a-z0-9]*(?:_[a-z0-9]+)+|[a-z]+[A-Z][A-Za-z0-9]*)\b`)
+)
+
+// CheckDescriptionSchemaConsistency compares a tool's description with its input schema,
+// warning about parameters the description refers to that the schema doesn't define,
+// and required parameters neither the description nor the schema describes. Parameters
+// are recognised in the description when quoted as `code` or after "parameter" and the
+// like, or when written as snake_case or camelCase identifiers.
+//
+// The check is advisory: it is heuristic, so its warnings are meant for review rather
+// than for rejecting tools.
+func CheckDescriptionSchemaConsistency(tool *mcp.Tool) []ConsistencyWarning {
+	if tool == nil {
+		return nil
+	}
+	var schema map[string]any
+	if len(tool.InputSchema) > 0 {
+		if err := json.Unmarshal(tool.InputSchema, &schema); err != nil {
+			return nil // malformed schemas are reported by schema validation
+		}
+	}
+	known := map[string]struct{}{}
+	collectPropertyNames(schema, known)
+
+	var warnings []ConsistencyWarning
+	seen := map[string]struct{}{}
+	for _, ref := range descriptionReferences(tool.Description) {
+		key := strings.ToLower(ref)
+		if _, dup := seen[key]; dup || strings.EqualFold(ref, tool.Name) {
+			continue
+		}
+		seen[key] = struct{}{}
+		if !definesPath(known, key) {
+			warnings = append(warnings, ConsistencyWarning{
+				Parameter: ref,
+				Reason:    "referenced in the description but not defined in the input schema",
+			})
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	required, _ := schema["required"].([]any)
+	for _, r := range required {
+		name, ok := r.(string)
+		if !ok || mentions(tool.Description, name) {
+			continue
+		}
+		if prop, _ := properties[name].(map[string]any); prop != nil {
+			if desc, _ := prop["description"].(string); strings.TrimSpace(desc) != "" {
+				continue
+			}
+		}
+		warnings = append(warnings, ConsistencyWarning{
+			Parameter: name,
+			Reason:    "required by the input schema but not described",
+		})
+	}
+	return warnings
+}
+
+// descriptionReferences returns the parameter names a description appears to refer to, in order
+func descriptionReferences(description string) []string {
+	type match struct {
+		at   int
+		name string
+	}
+	var matches []match
+	for _, re := range []*regexp.Regexp{backtickRef, namedRef, identifierRef} {
+		for _, loc := range re.FindAllStringSubmatchIndex(description, -1) {
+			matches = append(matches, match{at: loc[2], name: description[loc[2]:loc[3]]})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int { return a.at - b.at })
+
+	refs := make([]string, 0, len(matches))
+	for _, m := range matches {
+		refs = append(refs, m.name)
+	}
+	return refs
+}
+
+// collectPropertyNames adds the lowercased name of every property defined anywhere in
+// the schema, so descriptions may refer to nested fields as well as top-level ones
+func collectPropertyNames(schema any, names map[string]struct{}) {
+	switch s := schema.(type) {
+	case map[string]any:
+		if properties, ok := s["properties"].(map[string]any); ok {
+			for name := range properties {
+				names[strings.ToLower(name)] = struct{}{}
+			}
+		}
+		for _, v := range s {
+			collectPropertyNames(v, names)
+		}
+	case []any:
+		for _, v := range s {
+			collectPropertyNames(v, names)
+		}
+	}
+}
+
+// definesPath reports whether every segment of a possibly dotted name, such as
+// "options.depth", is a known property
+func definesPath(known map[string]struct{}, name string) bool {
+	for _, segment := range strings.Split(name, ".") {
+		if _, ok := known[segment]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// mentions reports whether text contains name as a whole word, ignoring case
+func mentions(text, name string) bool {
+	re, err := regexp.Compile(`(?i)(^|[^A-Za-z0-9_])` + regexp.QuoteMeta(name) + `($|[^A-Za-z0-9_])`)
+	return err == nil && re.MatchString(text)
+}
@@ -0,0 +1,55 @@
+package validate
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// ErrInvalidContent is returned when tool result content is malformed or unsafe
+var ErrInvalidContent = errors.New("invalid content")
+
+// ValidateContent checks a piece of tool result content before it is passed on:
+// text must be free of hidden unicode characters, and images must be valid
+// base64 with an image MIME type.
+func ValidateContent(c mcp.Content) error {
+	switch content := c.(type) {
+	case mcp.TextContent:
+		return validateTextContent(content)
+	case *mcp.TextContent:
+		return validateTextContent(*content)
+	case mcp.ImageContent:
+		return validateImageContent(content)
+	case *mcp.ImageContent:
+		return validateImageContent(*content)
+	case nil:
+		return fmt.Errorf("%w: missing content", ErrInvalidContent)
+	default:
+		return fmt.Errorf("%w: unsupported content type '%s'", ErrInvalidContent, c.ContentType())
+	}
+}
+
+func validateTextContent(content mcp.TextContent) error {
+	if detections := DetectHiddenUnicode(content.Text); len(detections) > 0 {
+		return fmt.Errorf("%w: %d hidden characters detected in text", ErrInvalidContent, len(detections))
+	}
+	return nil
+}
+
+func validateImageContent(content mcp.ImageContent) error {
+	mediaType, _, err := mime.ParseMediaType(content.MIMEType)
+	if err != nil || !strings.HasPrefix(mediaType, "image/") {
+		return fmt.Errorf("%w: '%s' is not an image MIME type", ErrInvalidContent, content.MIMEType)
+	}
+	if content.Data == "" {
+		return fmt.Errorf("%w: empty image data", ErrInvalidContent)
+	}
+	if _, err := base64.StdEncoding.Strict().DecodeString(content.Data); err != nil {
+		return fmt.Errorf("%w: image data is not valid base64: %v", ErrInvalidContent, err)
+	}
+	return nil
+}
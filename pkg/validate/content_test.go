@@ -0,0 +1,50 @@
+package validate
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// unknownContent is a Content implementation ValidateContent doesn't know about
+type unknownContent struct{}
+
+func (unknownContent) ContentType() string { return "audio" }
+
+func TestValidateContent(t *testing.T) {
+	png := base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\nfake image bytes"))
+	text := mcp.NewTextContent("The weather is sunny")
+
+	tests := []struct {
+		name      string
+		content   mcp.Content
+		expectErr bool
+	}{
+		{name: "valid text", content: text},
+		{name: "valid text pointer", content: &text},
+		{name: "valid image", content: mcp.NewImageContent(png, "image/png")},
+		{name: "image mime type with parameters", content: mcp.NewImageContent(png, "image/svg+xml; charset=utf-8")},
+		{name: "hidden unicode in text", content: mcp.NewTextContent("sunny\u200b\U000E0041"), expectErr: true},
+		{name: "bad base64", content: mcp.NewImageContent("not*base64!", "image/png"), expectErr: true},
+		{name: "unpadded base64", content: mcp.NewImageContent("YWJj ZA", "image/png"), expectErr: true},
+		{name: "empty image data", content: mcp.NewImageContent("", "image/png"), expectErr: true},
+		{name: "non-image mime type", content: mcp.NewImageContent(png, "application/pdf"), expectErr: true},
+		{name: "malformed mime type", content: mcp.NewImageContent(png, "image/png;;="), expectErr: true},
+		{name: "unsupported content", content: unknownContent{}, expectErr: true},
+		{name: "nil content", content: nil, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateContent(tt.content)
+			if tt.expectErr && !errors.Is(err, ErrInvalidContent) {
+				t.Errorf("ValidateContent() = %v, want ErrInvalidContent", err)
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("ValidateContent() unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// applyDefaults fills properties missing from the arguments with the defaults
+// declared in the schema. The arguments are returned unchanged if either
+// document cannot be decoded or no default needed applying.
+func applyDefaults(schema json.RawMessage, inputArguments []byte) []byte {
+	var schemaDoc map[string]any
+	if err := json.Unmarshal(schema, &schemaDoc); err != nil {
+		return inputArguments
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(inputArguments))
+	decoder.UseNumber() // keep numbers exactly as sent
+	var args map[string]any
+	if err := decoder.Decode(&args); err != nil || args == nil {
+		return inputArguments
+	}
+
+	if !fillDefaults(schemaDoc, args) {
+		return inputArguments
+	}
+
+	data, err := json.Marshal(args)
+	if err != nil {
+		return inputArguments
+	}
+	return data
+}
+
+// fillDefaults sets missing properties of obj from the schema's property defaults and
+// recurses into nested objects that are present, reporting whether obj was changed.
+func fillDefaults(schema map[string]any, obj map[string]any) bool {
+	properties, _ := schema["properties"].(map[string]any)
+	changed := false
+
+	for key, prop := range properties {
+		propSchema, ok := prop.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		value, present := obj[key]
+		if !present {
+			if def, hasDefault := propSchema["default"]; hasDefault {
+				obj[key] = copyJSONValue(def)
+				changed = true
+			}
+			continue
+		}
+
+		if nested, ok := value.(map[string]any); ok {
+			if fillDefaults(propSchema, nested) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// copyJSONValue deep copies a decoded JSON value so that defaults
+// are never shared between argument documents.
+func copyJSONValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = copyJSONValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = copyJSONValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,111 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+func TestValidateToolInput_ApplyDefaults(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "defaults-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{"type": "string"},
+				"limit": map[string]interface{}{"type": "integer", "default": 10},
+				"sort":  map[string]interface{}{"type": "string", "default": "asc"},
+				"options": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"caseSensitive": map[string]interface{}{"type": "boolean", "default": false},
+						"tags": map[string]interface{}{
+							"type":    "array",
+							"default": []string{"all"},
+						},
+					},
+				},
+			},
+			"required": []string{"query"},
+		}),
+	}
+
+	tests := []struct {
+		name           string
+		input          string
+		expectedStatus ValidationStatus
+		expectedArgs   map[string]any
+	}{
+		{
+			name:           "missing fields populated",
+			input:          `{"query": "go"}`,
+			expectedStatus: StatusSucceeded,
+			expectedArgs:   map[string]any{"query": "go", "limit": float64(10), "sort": "asc"},
+		},
+		{
+			name:           "explicit values not overwritten",
+			input:          `{"query": "go", "limit": 50, "sort": "desc"}`,
+			expectedStatus: StatusSucceeded,
+			expectedArgs:   map[string]any{"query": "go", "limit": float64(50), "sort": "desc"},
+		},
+		{
+			name:           "nested object defaults populated",
+			input:          `{"query": "go", "options": {}}`,
+			expectedStatus: StatusSucceeded,
+			expectedArgs: map[string]any{
+				"query": "go", "limit": float64(10), "sort": "asc",
+				"options": map[string]any{"caseSensitive": false, "tags": []any{"all"}},
+			},
+		},
+		{
+			name:           "nested explicit values kept",
+			input:          `{"query": "go", "options": {"caseSensitive": true}}`,
+			expectedStatus: StatusSucceeded,
+			expectedArgs: map[string]any{
+				"query": "go", "limit": float64(10), "sort": "asc",
+				"options": map[string]any{"caseSensitive": true, "tags": []any{"all"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, status, err := ValidateToolInput(tool, []byte(tt.input), WithApplyDefaults())
+			if status != tt.expectedStatus {
+				t.Fatalf("ValidateToolInput() status = %v, want %v (err: %v)", status, tt.expectedStatus, err)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(args, &got); err != nil {
+				t.Fatalf("arguments are not valid JSON: %v", err)
+			}
+			if !jsonEqual(got, tt.expectedArgs) {
+				t.Errorf("ValidateToolInput() args = %s, want %v", args, tt.expectedArgs)
+			}
+		})
+	}
+}
+
+func TestValidateToolInput_DefaultsNotAppliedOnFailure(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "defaults-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{"type": "string"},
+				"limit": map[string]interface{}{"type": "integer", "default": 10},
+			},
+			"required": []string{"query"},
+		}),
+	}
+
+	input := []byte(`{"limit": 5}`)
+	args, status, _ := ValidateToolInput(tool, input, WithApplyDefaults())
+	if status != StatusFailed {
+		t.Errorf("ValidateToolInput() status = %v, want %v", status, StatusFailed)
+	}
+	if string(args) != string(input) {
+		t.Errorf("ValidateToolInput() args = %s, want unchanged %s", args, input)
+	}
+}
@@ -0,0 +1,68 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrDeniedPhrase is returned for a tool description containing a denylisted phrase
+var ErrDeniedPhrase = errors.New("denied phrase in tool description")
+
+var (
+	denylistMu        sync.RWMutex
+	descriptionDenied []string // normalized, lower case
+)
+
+var normalizeDescriptions atomic.Bool
+
+// SetDescriptionDenylist replaces the phrases ValidateToolDescription rejects, such as
+// known prompt injections. Phrases are matched case-insensitively anywhere in the text.
+func SetDescriptionDenylist(phrases []string) {
+	denied := make([]string, 0, len(phrases))
+	for _, phrase := range phrases {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			denied = append(denied, foldPhrase(phrase))
+		}
+	}
+	denylistMu.Lock()
+	defer denylistMu.Unlock()
+	descriptionDenied = denied
+}
+
+// SetDescriptionNormalization sets whether descriptions are NFKC normalized before
+// they are matched against the denylist, so look-alike forms such as fullwidth letters
+// or decomposed accents can't slip past it. Hidden characters are always detected in
+// the original text, and a description that normalization changes raises an alert.
+func SetDescriptionNormalization(enabled bool) {
+	normalizeDescriptions.Store(enabled)
+}
+
+// foldPhrase puts a denylisted phrase in the form descriptions are compared in
+func foldPhrase(phrase string) string {
+	return strings.ToLower(norm.NFKC.String(phrase))
+}
+
+// checkDeniedPhrases matches the description, normalized if enabled, against the denylist
+func checkDeniedPhrases(description string) error {
+	if normalizeDescriptions.Load() {
+		if normalized := norm.NFKC.String(description); normalized != description {
+			securityAlert("tool description changed under NFKC normalization: %q became %q", truncateForLog(description), truncateForLog(normalized))
+			description = normalized
+		}
+	}
+	text := strings.ToLower(description)
+
+	denylistMu.RLock()
+	defer denylistMu.RUnlock()
+	for _, phrase := range descriptionDenied {
+		if strings.Contains(text, phrase) {
+			return fmt.Errorf("%w: %q", ErrDeniedPhrase, phrase)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package validate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// Executor runs a tool with already validated arguments and returns its raw output
+type Executor func(tool *mcp.Tool, inputArguments []byte) (string, error)
+
+// ExecuteTool validates the arguments, runs the tool with exec, and validates its output,
+// returning the tool result message with metadata describing how it was produced.
+// Arguments that fail validation are returned as an error without running the tool.
+// If execution or output validation fails, the message still carries the metadata but
+// not the output, and the failure is returned alongside it.
+func ExecuteTool(tool *mcp.Tool, inputArguments []byte, exec Executor, opts ...InputOption) (mcp.Message, error) {
+	if tool == nil {
+		return mcp.Message{}, ErrNilTool
+	}
+	args, _, err := ValidateToolInput(tool, inputArguments, opts...)
+	if err != nil {
+		return mcp.Message{}, fmt.Errorf("tool '%s' not executed: %w", tool.Name, err)
+	}
+
+	start := time.Now()
+	output, execErr := exec(tool, args)
+	checksum := sha256.Sum256([]byte(output))
+
+	meta := &mcp.ToolResultMetadata{
+		Duration:       time.Since(start),
+		OutputChecksum: hex.EncodeToString(checksum[:]),
+	}
+	msg := mcp.Message{Role: mcp.RoleTool, ToolName: tool.Name, Metadata: meta}
+
+	if execErr != nil {
+		meta.ExecutionStatus = mcp.StatusError
+		return msg, fmt.Errorf("tool '%s' failed to execute: %w", tool.Name, execErr)
+	}
+
+	status, err := ValidateToolOutput(output, tool)
+	meta.ValidationStatus = string(status)
+	if err != nil {
+		meta.ExecutionStatus = mcp.StatusFailed
+		return msg, err
+	}
+
+	meta.ExecutionStatus = mcp.StatusSucceeded
+	msg.Content = output
+	return msg, nil
+}
@@ -0,0 +1,119 @@
+package validate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+func newExecuteTestTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "weather-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"location": map[string]interface{}{"type": "string"}},
+			"required":   []string{"location"},
+		}),
+		OutputSchema: mustMarshalJSON(map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"temp": map[string]interface{}{"type": "number"}},
+			"required":   []string{"temp"},
+		}),
+	}
+}
+
+func TestExecuteTool_Succeeded(t *testing.T) {
+	const output = `{"temp": 21.5}`
+	delay := 20 * time.Millisecond
+	exec := func(tool *mcp.Tool, args []byte) (string, error) {
+		time.Sleep(delay)
+		return output, nil
+	}
+
+	msg, err := ExecuteTool(newExecuteTestTool(), []byte(`{"location": "Paris"}`), exec)
+	if err != nil {
+		t.Fatalf("ExecuteTool() unexpected error: %v", err)
+	}
+	if msg.Role != mcp.RoleTool || msg.ToolName != "weather-tool" || msg.Content != output {
+		t.Errorf("ExecuteTool() message = %+v, want tool result from weather-tool", msg)
+	}
+
+	meta := msg.Metadata
+	if meta == nil {
+		t.Fatal("ExecuteTool() message has no metadata")
+	}
+	if meta.ExecutionStatus != mcp.StatusSucceeded {
+		t.Errorf("ExecutionStatus = %q, want %q", meta.ExecutionStatus, mcp.StatusSucceeded)
+	}
+	if meta.ValidationStatus != string(StatusSucceeded) {
+		t.Errorf("ValidationStatus = %q, want %q", meta.ValidationStatus, StatusSucceeded)
+	}
+	if meta.Duration < delay || meta.Duration > delay+time.Second {
+		t.Errorf("Duration = %v, want about %v", meta.Duration, delay)
+	}
+	sum := sha256.Sum256([]byte(output))
+	if meta.OutputChecksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("OutputChecksum = %q, want SHA-256 of the output", meta.OutputChecksum)
+	}
+}
+
+func TestExecuteTool_InvalidOutput(t *testing.T) {
+	exec := func(tool *mcp.Tool, args []byte) (string, error) {
+		return `{"temp": "hot"}`, nil
+	}
+
+	msg, err := ExecuteTool(newExecuteTestTool(), []byte(`{"location": "Paris"}`), exec)
+	if err == nil {
+		t.Fatal("ExecuteTool() expected an output validation error")
+	}
+	if msg.Metadata == nil || msg.Metadata.ExecutionStatus != mcp.StatusFailed {
+		t.Fatalf("ExecuteTool() metadata = %+v, want status %q", msg.Metadata, mcp.StatusFailed)
+	}
+	if msg.Metadata.ValidationStatus != string(StatusFailed) {
+		t.Errorf("ValidationStatus = %q, want %q", msg.Metadata.ValidationStatus, StatusFailed)
+	}
+	if msg.Content != "" {
+		t.Errorf("output that failed validation was passed on: %q", msg.Content)
+	}
+}
+
+func TestExecuteTool_ExecutionError(t *testing.T) {
+	boom := errors.New("boom")
+	exec := func(tool *mcp.Tool, args []byte) (string, error) {
+		return "", boom
+	}
+
+	msg, err := ExecuteTool(newExecuteTestTool(), []byte(`{"location": "Paris"}`), exec)
+	if !errors.Is(err, boom) {
+		t.Fatalf("ExecuteTool() error = %v, want %v", err, boom)
+	}
+	if msg.Metadata == nil || msg.Metadata.ExecutionStatus != mcp.StatusError {
+		t.Fatalf("ExecuteTool() metadata = %+v, want status %q", msg.Metadata, mcp.StatusError)
+	}
+	if msg.Metadata.ValidationStatus != "" {
+		t.Errorf("ValidationStatus = %q, want unset when the tool didn't run", msg.Metadata.ValidationStatus)
+	}
+}
+
+func TestExecuteTool_InvalidInputNotExecuted(t *testing.T) {
+	executed := false
+	exec := func(tool *mcp.Tool, args []byte) (string, error) {
+		executed = true
+		return `{"temp": 1}`, nil
+	}
+
+	msg, err := ExecuteTool(newExecuteTestTool(), []byte(`{}`), exec)
+	if err == nil {
+		t.Fatal("ExecuteTool() expected an input validation error")
+	}
+	if executed {
+		t.Error("tool was executed with invalid arguments")
+	}
+	if msg.Metadata != nil {
+		t.Errorf("ExecuteTool() metadata = %+v, want none for an unexecuted tool", msg.Metadata)
+	}
+}
@@ -0,0 +1,121 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/null-create/mcp-tls/pkg/codec"
+)
+
+const (
+	// MaxJSONDepth is the deepest nesting of objects and arrays accepted in tool arguments or output.
+	MaxJSONDepth = 64
+	// MaxJSONSize is the largest tool argument or output document accepted, in bytes.
+	MaxJSONSize = 1 << 20
+)
+
+var (
+	ErrJSONTooDeep  = codec.ErrJSONTooDeep
+	ErrJSONTooLarge = codec.ErrJSONTooLarge
+)
+
+// ErrDuplicateKey is returned when a JSON object repeats a key. encoding/json silently keeps
+// the last value, so a duplicate can smuggle a different value past anyone reviewing the first.
+var ErrDuplicateKey = errors.New("duplicate key detected")
+
+// ErrDoubleEncoded is returned when tool arguments arrive as a JSON string that looks
+// like an encoded object or array but does not hold valid JSON once unwrapped.
+var ErrDoubleEncoded = errors.New("double-encoded arguments are not valid JSON")
+
+// unwrapDoubleEncoded undoes one level of encoding for clients that send tool arguments
+// as a JSON string, e.g. "{\"city\":\"Paris\"}", rather than as the object itself.
+// Only strings holding an object or array are unwrapped, so plain string arguments
+// are left alone. Anything else, including malformed JSON, is returned unchanged.
+func unwrapDoubleEncoded(data []byte) ([]byte, bool, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '"' {
+		return data, false, nil
+	}
+	var inner string
+	if err := json.Unmarshal(trimmed, &inner); err != nil {
+		return data, false, nil
+	}
+	inner = strings.TrimSpace(inner)
+	if inner == "" || (inner[0] != '{' && inner[0] != '[') {
+		return data, false, nil
+	}
+	if !json.Valid([]byte(inner)) {
+		return data, false, ErrDoubleEncoded
+	}
+	return []byte(inner), true, nil
+}
+
+// checkJSONLimits rejects tool arguments or output that exceed MaxJSONDepth or MaxJSONSize
+func checkJSONLimits(data []byte) error {
+	return codec.CheckJSONLimits(data, MaxJSONDepth, MaxJSONSize)
+}
+
+// jsonFrame tracks the state of an object or array while scanning tokens
+type jsonFrame struct {
+	isObject  bool
+	expectKey bool
+	keys      map[string]struct{}
+}
+
+// checkDuplicateKeys scans a JSON document token by token and returns an ErrDuplicateKey
+// error naming the first key repeated within a single object. Malformed JSON is not
+// reported here and is left to the schema validator.
+func checkDuplicateKeys(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var stack []*jsonFrame
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return nil // end of input, or malformed JSON
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		if top != nil && top.isObject && top.expectKey {
+			if key, ok := tok.(string); ok {
+				if _, seen := top.keys[key]; seen {
+					return fmt.Errorf("%w: '%s'", ErrDuplicateKey, key)
+				}
+				top.keys[key] = struct{}{}
+				top.expectKey = false
+				continue
+			}
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, &jsonFrame{isObject: true, expectKey: true, keys: make(map[string]struct{})})
+		case json.Delim('['):
+			stack = append(stack, &jsonFrame{})
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			valueDone(stack)
+		default:
+			valueDone(stack)
+		}
+	}
+}
+
+// valueDone marks a complete value in the innermost frame, so an enclosing
+// object expects its next key.
+func valueDone(stack []*jsonFrame) {
+	if len(stack) == 0 {
+		return
+	}
+	if top := stack[len(stack)-1]; top.isObject {
+		top.expectKey = true
+	}
+}
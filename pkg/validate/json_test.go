@@ -0,0 +1,209 @@
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+func TestCheckDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expectDup bool
+	}{
+		{name: "no duplicates", input: `{"a": 1, "b": {"a": 2}, "c": [{"a": 3}, {"a": 4}]}`},
+		{name: "duplicate top-level key", input: `{"location": "A", "location": "B"}`, expectDup: true},
+		{name: "duplicate nested key", input: `{"outer": {"x": 1, "x": 2}}`, expectDup: true},
+		{name: "duplicate key in array element", input: `{"items": [{"id": 1}, {"id": 2, "id": 3}]}`, expectDup: true},
+		{name: "duplicate after nested value", input: `{"a": {"b": [1, 2]}, "a": 3}`, expectDup: true},
+		{name: "same key in sibling objects", input: `[{"id": 1}, {"id": 2}]`},
+		{name: "key equal to a string value", input: `{"a": "b", "b": "a"}`},
+		{name: "scalar document", input: `"just a string"`},
+		{name: "malformed json", input: `{"a": 1,`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDuplicateKeys([]byte(tt.input))
+			if tt.expectDup && !errors.Is(err, ErrDuplicateKey) {
+				t.Errorf("checkDuplicateKeys() = %v, want ErrDuplicateKey", err)
+			}
+			if !tt.expectDup && err != nil {
+				t.Errorf("checkDuplicateKeys() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateToolInputSchema_DuplicateKeys(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "weather-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"location": map[string]interface{}{"type": "string"},
+				"options": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"units": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		}),
+	}
+
+	inputs := map[string]string{
+		"top-level": `{"location": "A", "location": "B"}`,
+		"nested":    `{"location": "A", "options": {"units": "C", "units": "F"}}`,
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			status, err := ValidateToolInputSchema(tool, []byte(input))
+			if status != StatusFailed {
+				t.Errorf("ValidateToolInputSchema() status = %v, want %v", status, StatusFailed)
+			}
+			if !errors.Is(err, ErrDuplicateKey) {
+				t.Errorf("ValidateToolInputSchema() error = %v, want ErrDuplicateKey", err)
+			}
+		})
+	}
+}
+
+func TestValidateToolOutput_DuplicateKeys(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "weather-tool",
+		OutputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+		}),
+	}
+
+	status, err := ValidateToolOutput(`{"temp": 20, "temp": 99}`, tool)
+	if status != StatusFailed {
+		t.Errorf("ValidateToolOutput() status = %v, want %v", status, StatusFailed)
+	}
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("ValidateToolOutput() error = %v, want ErrDuplicateKey", err)
+	}
+}
+
+func TestCheckJSONLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expectErr error
+	}{
+		{name: "normal document", input: `{"a": [1, {"b": [2, 3]}], "c": "x"}`},
+		{name: "at max depth", input: strings.Repeat("[", MaxJSONDepth) + strings.Repeat("]", MaxJSONDepth)},
+		{
+			name:      "over max depth",
+			input:     strings.Repeat("[", MaxJSONDepth+1) + strings.Repeat("]", MaxJSONDepth+1),
+			expectErr: ErrJSONTooDeep,
+		},
+		{name: "brackets inside strings", input: `{"a": "` + strings.Repeat("[{", MaxJSONDepth) + `"}`},
+		{name: "escaped quote inside string", input: `{"a": "\"` + strings.Repeat("[", MaxJSONDepth) + `"}`},
+		{name: "over max size", input: `"` + strings.Repeat("a", MaxJSONSize) + `"`, expectErr: ErrJSONTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkJSONLimits([]byte(tt.input))
+			if !errors.Is(err, tt.expectErr) {
+				t.Errorf("checkJSONLimits() = %v, want %v", err, tt.expectErr)
+			}
+		})
+	}
+}
+
+func TestValidateToolInputSchema_NestingLimit(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "nested-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+		}),
+	}
+
+	nested := `{"a": ` + strings.Repeat(`{"a": `, MaxJSONDepth) + `1` + strings.Repeat("}", MaxJSONDepth) + `}`
+	status, err := ValidateToolInputSchema(tool, []byte(nested))
+	if status != StatusError {
+		t.Errorf("ValidateToolInputSchema() status = %v, want %v", status, StatusError)
+	}
+	if !errors.Is(err, ErrJSONTooDeep) {
+		t.Errorf("ValidateToolInputSchema() error = %v, want ErrJSONTooDeep", err)
+	}
+
+	status, err = ValidateToolInputSchema(tool, []byte(`{"a": {"a": {"a": 1}}}`))
+	if status != StatusSucceeded || err != nil {
+		t.Errorf("ValidateToolInputSchema() = %v, %v, want %v, nil", status, err, StatusSucceeded)
+	}
+}
+
+func TestValidateToolOutput_NestingLimit(t *testing.T) {
+	tool := &mcp.Tool{
+		Name:         "nested-tool",
+		OutputSchema: mustMarshalJSON(map[string]interface{}{"type": "array"}),
+	}
+
+	nested := strings.Repeat("[", MaxJSONDepth+1) + strings.Repeat("]", MaxJSONDepth+1)
+	status, err := ValidateToolOutput(nested, tool)
+	if status != StatusError {
+		t.Errorf("ValidateToolOutput() status = %v, want %v", status, StatusError)
+	}
+	if !errors.Is(err, ErrJSONTooDeep) {
+		t.Errorf("ValidateToolOutput() error = %v, want ErrJSONTooDeep", err)
+	}
+}
+
+func TestValidateToolInput_DoubleEncodedArguments(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "weather-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"location": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"location"},
+		}),
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		wantStatus ValidationStatus
+		wantArgs   string
+		wantErr    error
+	}{
+		{name: "object", input: `{"location": "Paris"}`, wantStatus: StatusSucceeded, wantArgs: `{"location": "Paris"}`},
+		{name: "double-encoded object", input: `"{\"location\": \"Paris\"}"`, wantStatus: StatusSucceeded, wantArgs: `{"location": "Paris"}`},
+		{name: "double-encoded and invalid", input: `"{\"location\": 42}"`, wantStatus: StatusFailed},
+		{name: "unterminated string", input: `"{\"location\": \"Paris\"}`, wantStatus: StatusError},
+		{name: "double-encoded truncated", input: `"{\"location\": \"Paris\""`, wantStatus: StatusFailed, wantErr: ErrDoubleEncoded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, status, err := ValidateToolInput(tool, []byte(tt.input))
+			if status != tt.wantStatus {
+				t.Errorf("ValidateToolInput() status = %v, want %v (err %v)", status, tt.wantStatus, err)
+			}
+			if tt.wantArgs != "" && string(args) != tt.wantArgs {
+				t.Errorf("ValidateToolInput() args = %s, want %s", args, tt.wantArgs)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateToolInput() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("plain string argument is not unwrapped", func(t *testing.T) {
+		stringTool := &mcp.Tool{Name: "echo", InputSchema: []byte(`{"type": "string"}`)}
+		args, status, err := ValidateToolInput(stringTool, []byte(`"hello"`))
+		if status != StatusSucceeded || err != nil {
+			t.Fatalf("ValidateToolInput() = %v, %v, want success", status, err)
+		}
+		if string(args) != `"hello"` {
+			t.Errorf("ValidateToolInput() args = %s, want unchanged", args)
+		}
+	})
+}
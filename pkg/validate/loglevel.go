@@ -0,0 +1,48 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// LogLevel controls whether the security alerts and errors for rejected tool
+// input and output carry the payload itself or only its metadata.
+type LogLevel string
+
+const (
+	// LogLevelInfo reports only the tool name, the violated field paths and the
+	// status. This is the default.
+	LogLevelInfo LogLevel = "info"
+	// LogLevelWarn reports the same as LogLevelInfo; the validate layer only logs alerts.
+	LogLevelWarn LogLevel = "warn"
+	// LogLevelDebug also includes the rejected payload, redacted and truncated.
+	LogLevelDebug LogLevel = "debug"
+)
+
+// ParseLogLevel parses "debug", "info" or "warn". An empty string means LogLevelInfo.
+func ParseLogLevel(s string) (LogLevel, error) {
+	switch LogLevel(s) {
+	case "", LogLevelInfo:
+		return LogLevelInfo, nil
+	case LogLevelWarn, LogLevelDebug:
+		return LogLevel(s), nil
+	}
+	return LogLevelInfo, fmt.Errorf("unknown log level '%s'", s)
+}
+
+var debugLogging atomic.Bool
+
+// SetLogLevel sets whether rejected payloads are logged
+func SetLogLevel(l LogLevel) {
+	debugLogging.Store(l == LogLevelDebug)
+}
+
+// loggedPayload returns doc, redacted against schema and truncated, as a labelled
+// line to append to an alert, or nothing unless debug logging is enabled
+func loggedPayload(label string, schema json.RawMessage, doc []byte) string {
+	if !debugLogging.Load() {
+		return ""
+	}
+	return "\n" + label + ": " + truncateForLog(Redact(schema, doc))
+}
@@ -0,0 +1,93 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// useLogLevel sets the validate layer's log level for the rest of the test
+func useLogLevel(t *testing.T, l LogLevel) {
+	t.Helper()
+	SetLogLevel(l)
+	t.Cleanup(func() { SetLogLevel(LogLevelInfo) })
+}
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    LogLevel
+		wantErr bool
+	}{
+		{input: "", want: LogLevelInfo},
+		{input: "info", want: LogLevelInfo},
+		{input: "debug", want: LogLevelDebug},
+		{input: "warn", want: LogLevelWarn},
+		{input: "trace", want: LogLevelInfo, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLogLevel(tt.input)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseLogLevel(%q) = %q, %v; want %q, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLogLevel_GatesPayloads(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "profile-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"age": map[string]interface{}{"type": "integer"}},
+		}),
+		OutputSchema: mustMarshalJSON(map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"score": map[string]interface{}{"type": "number"}},
+		}),
+	}
+	input := []byte(`{"age":"private-input"}`)
+	output := `{"score":"private-output"}`
+
+	tests := []struct {
+		level    LogLevel
+		payloads bool
+	}{
+		{level: LogLevelInfo, payloads: false},
+		{level: LogLevelWarn, payloads: false},
+		{level: LogLevelDebug, payloads: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.level), func(t *testing.T) {
+			useLogLevel(t, tt.level)
+			alerts := captureAlerts(t)
+
+			if status, _ := ValidateToolInputSchema(tool, input); status != StatusFailed {
+				t.Fatalf("ValidateToolInputSchema() status = %s, want %s", status, StatusFailed)
+			}
+			status, err := ValidateToolOutput(output, tool)
+			if status != StatusFailed {
+				t.Fatalf("ValidateToolOutput() status = %s, want %s", status, StatusFailed)
+			}
+
+			logged := alerts.String()
+			for _, payload := range []string{"private-input", "private-output"} {
+				if strings.Contains(logged, payload) != tt.payloads {
+					t.Errorf("at %s level, payload %q logged = %v, want %v:\n%s", tt.level, payload, !tt.payloads, tt.payloads, logged)
+				}
+			}
+			if strings.Contains(err.Error(), "private-output") != tt.payloads {
+				t.Errorf("at %s level, unexpected payload presence in the error:\n%s", tt.level, err)
+			}
+
+			// the metadata is logged either way
+			for _, meta := range []string{"profile-tool", "age:", "score:"} {
+				if !strings.Contains(logged, meta) {
+					t.Errorf("at %s level, alerts should mention %q:\n%s", tt.level, meta, logged)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package validate
+
+import "context"
+
+// InputOption configures optional behaviour of ValidateToolInput.
+type InputOption func(*inputOptions)
+
+type inputOptions struct {
+	coerceTypes   bool // convert string-encoded numbers and booleans to the schema's type
+	applyDefaults bool // fill missing properties from their schema defaults after validation
+	cache         *ValidationCache
+	ctx           context.Context // stops validation early once done
+}
+
+func newInputOptions(opts []InputOption) inputOptions {
+	options := inputOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithCoerceTypes enables lenient type validation. String values are converted to
+// numbers or booleans before validation when the schema expects exactly that type and
+// the string is an unambiguous literal of it (e.g. "30" for an integer, "true" for a boolean).
+func WithCoerceTypes() InputOption {
+	return func(o *inputOptions) {
+		o.coerceTypes = true
+	}
+}
+
+// WithApplyDefaults fills in properties missing from the arguments with the
+// "default" declared for them in the schema, recursing into nested objects.
+// Defaults are applied only after validation succeeds and never overwrite
+// values that were explicitly provided.
+func WithApplyDefaults() InputOption {
+	return func(o *inputOptions) {
+		o.applyDefaults = true
+	}
+}
+
+// WithCache reuses prior results from the given cache for identical arguments
+// validated against an identical schema, and records new results in it.
+func WithCache(cache *ValidationCache) InputOption {
+	return func(o *inputOptions) {
+		o.cache = cache
+	}
+}
+
+// WithContext stops validation early, with StatusError, once ctx is done, e.g.
+// when the request it serves has timed out. A schema check already underway
+// runs to completion, so ctx is checked before each step rather than during one.
+func WithContext(ctx context.Context) InputOption {
+	return func(o *inputOptions) {
+		o.ctx = ctx
+	}
+}
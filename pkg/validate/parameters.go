@@ -0,0 +1,54 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ValidateParameters checks that a tool's Parameters conform to its own InputSchema,
+// catching tools that ship default parameters their schema would reject. Parameters
+// are a sample or default set rather than a complete call, so the schema's top-level
+// required list isn't enforced; every parameter given must still be valid.
+func ValidateParameters(tool *mcp.Tool) (ValidationStatus, error) {
+	if tool == nil {
+		return StatusError, ErrNilTool
+	}
+	if len(tool.Parameters) == 0 {
+		return StatusSucceeded, nil
+	}
+	if len(tool.InputSchema) == 0 {
+		return StatusFailed, fmt.Errorf("no InputSchema defined for tool '%s' to check its parameters against", tool.Name)
+	}
+
+	var schema map[string]json.RawMessage
+	if err := json.Unmarshal(tool.InputSchema, &schema); err != nil {
+		return StatusError, fmt.Errorf("internal schema error for tool '%s'", tool.Name)
+	}
+	delete(schema, "required")
+	partial, err := json.Marshal(schema)
+	if err != nil {
+		return StatusError, fmt.Errorf("internal schema error for tool '%s'", tool.Name)
+	}
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(partial))
+	if err != nil {
+		return StatusError, fmt.Errorf("internal schema error for tool '%s'", tool.Name)
+	}
+
+	result, err := compiled.Validate(gojsonschema.NewGoLoader(tool.Parameters))
+	if err != nil {
+		return StatusError, fmt.Errorf("internal validation error for tool '%s'", tool.Name)
+	}
+	if !result.Valid() {
+		schemaErr := newSchemaError(
+			tool.Name,
+			fmt.Sprintf("Parameters of tool '%s' violate its input schema:", tool.Name),
+			result.Errors(),
+		)
+		return StatusFailed, schemaErr.withVerbosity()
+	}
+	return StatusSucceeded, nil
+}
@@ -0,0 +1,74 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+const parametersTestSchema = `{
+	"type": "object",
+	"properties": {
+		"city": {"type": "string"},
+		"days": {"type": "integer", "minimum": 1, "maximum": 14},
+		"units": {"type": "string", "enum": ["metric", "imperial"]}
+	},
+	"required": ["city"],
+	"additionalProperties": false
+}`
+
+func TestValidateParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters map[string]any
+		schema     string
+		status     ValidationStatus
+		field      string // the violation expected, if any
+	}{
+		{name: "consistent", parameters: map[string]any{"city": "Oslo", "days": 3, "units": "metric"}, schema: parametersTestSchema, status: StatusSucceeded},
+		{name: "partial defaults skip required", parameters: map[string]any{"days": 7}, schema: parametersTestSchema, status: StatusSucceeded},
+		{name: "no parameters", schema: parametersTestSchema, status: StatusSucceeded},
+		{name: "wrong type", parameters: map[string]any{"days": "three"}, schema: parametersTestSchema, status: StatusFailed, field: "days"},
+		{name: "out of range", parameters: map[string]any{"days": 30}, schema: parametersTestSchema, status: StatusFailed, field: "days"},
+		{name: "not in enum", parameters: map[string]any{"units": "kelvin"}, schema: parametersTestSchema, status: StatusFailed, field: "units"},
+		{name: "unknown parameter", parameters: map[string]any{"country": "NO"}, schema: parametersTestSchema, status: StatusFailed, field: "(root)"},
+		{name: "no schema", parameters: map[string]any{"city": "Oslo"}, status: StatusFailed},
+		{name: "invalid schema", parameters: map[string]any{"city": "Oslo"}, schema: `{"type": 12}`, status: StatusError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &mcp.Tool{Name: "weather", Parameters: tt.parameters, InputSchema: json.RawMessage(tt.schema)}
+			status, err := ValidateParameters(tool)
+			if status != tt.status {
+				t.Fatalf("ValidateParameters() status = %v (err: %v), want %v", status, err, tt.status)
+			}
+			if tt.status == StatusSucceeded {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if tt.field == "" {
+				return
+			}
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("Expected a *SchemaError, got %T: %v", err, err)
+			}
+			if len(schemaErr.Errors) != 1 || schemaErr.Errors[0].Field != tt.field {
+				t.Errorf("Expected one violation on %q, got %+v", tt.field, schemaErr.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateParameters_NilTool(t *testing.T) {
+	if status, err := ValidateParameters(nil); status != StatusError || !errors.Is(err, ErrNilTool) {
+		t.Errorf("ValidateParameters(nil) = %v, %v, want ErrNilTool", status, err)
+	}
+}
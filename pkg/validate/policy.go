@@ -0,0 +1,45 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorPolicy decides what happens to a tool call when validation could not run to
+// completion (StatusError), e.g. because the tool's schema fails to compile. Calls that
+// were validated and rejected (StatusFailed) are always blocked, whatever the policy.
+type ErrorPolicy string
+
+const (
+	// FailClosed blocks calls whose validation errored. This is the default.
+	FailClosed ErrorPolicy = "fail-closed"
+	// FailOpen lets calls whose validation errored through, leaving it to the caller to warn.
+	FailOpen ErrorPolicy = "fail-open"
+)
+
+// ParseErrorPolicy parses "fail-closed" or "fail-open". An empty string means FailClosed.
+func ParseErrorPolicy(s string) (ErrorPolicy, error) {
+	switch ErrorPolicy(s) {
+	case "", FailClosed:
+		return FailClosed, nil
+	case FailOpen:
+		return FailOpen, nil
+	}
+	return FailClosed, fmt.Errorf("unknown validation error policy '%s'", s)
+}
+
+// Blocks reports whether a call that validated with the given status and error should
+// be blocked. Arguments over the JSON size or nesting limits are refused under either
+// policy, as those limits protect the validator itself rather than reporting on it.
+func (p ErrorPolicy) Blocks(status ValidationStatus, err error) bool {
+	switch status {
+	case StatusSucceeded:
+		return err != nil
+	case StatusError:
+		if errors.Is(err, ErrJSONTooDeep) || errors.Is(err, ErrJSONTooLarge) {
+			return true
+		}
+		return p != FailOpen
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseErrorPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ErrorPolicy
+		wantErr bool
+	}{
+		{in: "", want: FailClosed},
+		{in: "fail-closed", want: FailClosed},
+		{in: "fail-open", want: FailOpen},
+		{in: "open", want: FailClosed, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseErrorPolicy(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseErrorPolicy(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestErrorPolicy_Blocks(t *testing.T) {
+	internal := errors.New("internal schema error for tool 'x'")
+	tests := []struct {
+		name       string
+		status     ValidationStatus
+		err        error
+		closedWant bool
+		openWant   bool
+	}{
+		{name: "succeeded", status: StatusSucceeded, closedWant: false, openWant: false},
+		{name: "failed", status: StatusFailed, err: errors.New("bad input"), closedWant: true, openWant: true},
+		{name: "internal error", status: StatusError, err: internal, closedWant: true, openWant: false},
+		{name: "too deep", status: StatusError, err: ErrJSONTooDeep, closedWant: true, openWant: true},
+		{name: "too large", status: StatusError, err: ErrJSONTooLarge, closedWant: true, openWant: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FailClosed.Blocks(tt.status, tt.err); got != tt.closedWant {
+				t.Errorf("FailClosed.Blocks() = %v, want %v", got, tt.closedWant)
+			}
+			if got := FailOpen.Blocks(tt.status, tt.err); got != tt.openWant {
+				t.Errorf("FailOpen.Blocks() = %v, want %v", got, tt.openWant)
+			}
+		})
+	}
+}
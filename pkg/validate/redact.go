@@ -0,0 +1,131 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/null-create/mcp-tls/pkg/config"
+)
+
+// SensitiveKeyword is the schema extension marking a property whose value must never be logged
+const SensitiveKeyword = "x-sensitive"
+
+// redactedValue replaces sensitive values in logged tool inputs and outputs
+const redactedValue = "[REDACTED]"
+
+var (
+	sensitiveMu     sync.RWMutex
+	sensitiveFields = map[string]struct{}{}
+)
+
+// SetSensitiveFields replaces the property names whose values are redacted from logs
+// wherever they appear, in addition to properties marked "x-sensitive": true in a schema.
+// Names are matched case-insensitively.
+func SetSensitiveFields(names []string) {
+	fields := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		fields[strings.ToLower(name)] = struct{}{}
+	}
+	sensitiveMu.Lock()
+	defer sensitiveMu.Unlock()
+	sensitiveFields = fields
+}
+
+func isSensitiveField(name string, schema map[string]any) bool {
+	if marked, _ := schema[SensitiveKeyword].(bool); marked {
+		return true
+	}
+	sensitiveMu.RLock()
+	defer sensitiveMu.RUnlock()
+	_, ok := sensitiveFields[strings.ToLower(name)]
+	return ok
+}
+
+// Redact returns a copy of a JSON document that is safe to log, with the values of
+// sensitive properties masked. Validation must always use the original document.
+// Anything that isn't valid JSON is withheld entirely, since it can't be inspected.
+func Redact(schema json.RawMessage, data []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return redactedValue
+	}
+
+	var schemaDoc map[string]any
+	_ = json.Unmarshal(schema, &schemaDoc) // without a schema only named fields are redacted
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redactValue(schemaDoc, doc)); err != nil {
+		return redactedValue
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// redactValue masks sensitive properties in v, following schema down through
+// object properties and array items.
+func redactValue(schema map[string]any, v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		for key, child := range value {
+			propertySchema, _ := properties[key].(map[string]any)
+			if isSensitiveField(key, propertySchema) {
+				value[key] = redactedValue
+				continue
+			}
+			value[key] = redactValue(propertySchema, child)
+		}
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		for i, child := range value {
+			value[i] = redactValue(items, child)
+		}
+	}
+	return v
+}
+
+var maxLoggedOutput atomic.Int64
+
+func init() { maxLoggedOutput.Store(config.DefaultMaxLoggedOutput) }
+
+// SetMaxLoggedOutput sets how many bytes of a rejected tool output are embedded in
+// errors and logs before it is truncated. Values below one restore the default.
+func SetMaxLoggedOutput(n int) {
+	if n < 1 {
+		n = config.DefaultMaxLoggedOutput
+	}
+	maxLoggedOutput.Store(int64(n))
+}
+
+// truncateForLog shortens s to the configured limit, noting the full length
+func truncateForLog(s string) string {
+	limit := int(maxLoggedOutput.Load())
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	// don't split a multi-byte character
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s... (truncated, %d bytes total)", s[:cut], len(s))
+}
+
+// alertOutput receives security alerts from the validate layer
+var alertOutput io.Writer = os.Stdout
+
+// securityAlert reports rejected tool input or output. Callers must
+// pass documents through Redact before including them.
+func securityAlert(format string, args ...any) {
+	fmt.Fprintf(alertOutput, "SECURITY ALERT: "+format+"\n", args...)
+}
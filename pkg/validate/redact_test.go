@@ -0,0 +1,181 @@
+package validate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/config"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// captureAlerts redirects security alerts into a buffer for the rest of the test
+func captureAlerts(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	prev := alertOutput
+	alertOutput = buf
+	t.Cleanup(func() { alertOutput = prev })
+	return buf
+}
+
+// useSensitiveFields sets the sensitive field denylist for the rest of the test
+func useSensitiveFields(t *testing.T, names ...string) {
+	t.Helper()
+	SetSensitiveFields(names)
+	t.Cleanup(func() { SetSensitiveFields(nil) })
+}
+
+func TestRedact(t *testing.T) {
+	useSensitiveFields(t, "Password")
+	schema := mustMarshalJSON(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"apiKey": map[string]interface{}{"type": "string", SensitiveKeyword: true},
+			"user": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"ssn": map[string]interface{}{"type": "string", SensitiveKeyword: true},
+				},
+			},
+			"cards": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"number": map[string]interface{}{SensitiveKeyword: true}},
+				},
+			},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "marked property", input: `{"apiKey":"sk-123","city":"Paris"}`, expected: `{"apiKey":"[REDACTED]","city":"Paris"}`},
+		{name: "nested property", input: `{"user":{"ssn":"123-45-6789","name":"Ann"}}`, expected: `{"user":{"name":"Ann","ssn":"[REDACTED]"}}`},
+		{name: "array items", input: `{"cards":[{"number":4111111111111111}]}`, expected: `{"cards":[{"number":"[REDACTED]"}]}`},
+		{name: "denylisted name at any depth", input: `{"extra":{"password":"hunter2"}}`, expected: `{"extra":{"password":"[REDACTED]"}}`},
+		{name: "sensitive object value", input: `{"apiKey":{"v":"sk-123"}}`, expected: `{"apiKey":"[REDACTED]"}`},
+		{name: "nothing sensitive", input: `{"city":"Paris","count":12345678901234567890}`, expected: `{"city":"Paris","count":12345678901234567890}`},
+		{name: "not json", input: `apiKey=sk-123`, expected: `[REDACTED]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(schema, []byte(tt.input)); got != tt.expected {
+				t.Errorf("Redact() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateToolInput_RedactsSensitiveFieldsInLog(t *testing.T) {
+	alerts := captureAlerts(t)
+	useLogLevel(t, LogLevelDebug)
+	tool := &mcp.Tool{
+		Name: "login-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"user":     map[string]interface{}{"type": "string"},
+				"password": map[string]interface{}{"type": "string", "minLength": 12, SensitiveKeyword: true},
+			},
+		}),
+	}
+
+	// validation sees the real value: it's too short, so the call fails
+	status, err := ValidateToolInputSchema(tool, []byte(`{"user":"ann","password":"hunter2"}`))
+	if status != StatusFailed || err == nil {
+		t.Fatalf("ValidateToolInputSchema() = %v, %v, want %v", status, err, StatusFailed)
+	}
+
+	logged := alerts.String()
+	if strings.Contains(logged, "hunter2") {
+		t.Errorf("sensitive value leaked into the log:\n%s", logged)
+	}
+	if !strings.Contains(logged, `"password":"[REDACTED]"`) || !strings.Contains(logged, `"user":"ann"`) {
+		t.Errorf("log should show the input with only the sensitive value masked:\n%s", logged)
+	}
+}
+
+func TestValidateToolOutput_RedactsSensitiveFields(t *testing.T) {
+	alerts := captureAlerts(t)
+	useLogLevel(t, LogLevelDebug)
+	useSensitiveFields(t, "token")
+	tool := &mcp.Tool{
+		Name: "auth-tool",
+		OutputSchema: mustMarshalJSON(map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"expires": map[string]interface{}{"type": "integer"}},
+			"required":   []string{"expires"},
+		}),
+	}
+
+	status, err := ValidateToolOutput(`{"token":"secret-token","expires":"soon"}`, tool)
+	if status != StatusFailed || err == nil {
+		t.Fatalf("ValidateToolOutput() = %v, %v, want %v", status, err, StatusFailed)
+	}
+
+	for name, text := range map[string]string{"log": alerts.String(), "error": err.Error()} {
+		if strings.Contains(text, "secret-token") {
+			t.Errorf("sensitive value leaked into the %s:\n%s", name, text)
+		}
+		if !strings.Contains(text, `"token":"[REDACTED]"`) {
+			t.Errorf("%s should show the redacted output:\n%s", name, text)
+		}
+	}
+}
+
+func TestTruncateForLog(t *testing.T) {
+	SetMaxLoggedOutput(8)
+	t.Cleanup(func() { SetMaxLoggedOutput(0) })
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "under limit", input: "short", expected: "short"},
+		{name: "at limit", input: "12345678", expected: "12345678"},
+		{name: "over limit", input: "1234567890", expected: "12345678... (truncated, 10 bytes total)"},
+		{name: "multi-byte boundary", input: "1234567€€", expected: "1234567... (truncated, 13 bytes total)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateForLog(tt.input); got != tt.expected {
+				t.Errorf("truncateForLog() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateToolOutput_TruncatesLargeOutput(t *testing.T) {
+	alerts := captureAlerts(t)
+	useLogLevel(t, LogLevelDebug)
+	tool := &mcp.Tool{
+		Name: "bulk-tool",
+		OutputSchema: mustMarshalJSON(map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"data": map[string]interface{}{"type": "number"}},
+		}),
+	}
+	huge := `{"data":"` + strings.Repeat("x", 1<<19) + `"}`
+
+	status, err := ValidateToolOutput(huge, tool)
+	if status != StatusFailed || err == nil {
+		t.Fatalf("ValidateToolOutput() = %v, %v, want %v", status, err, StatusFailed)
+	}
+
+	limit := config.DefaultMaxLoggedOutput + 512 // room for the summary and the truncation note
+	if len(err.Error()) > limit {
+		t.Errorf("error message is %d bytes, want at most %d", len(err.Error()), limit)
+	}
+	if alerts.Len() > limit {
+		t.Errorf("logged alert is %d bytes, want at most %d", alerts.Len(), limit)
+	}
+	if !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("error message should note the truncation:\n%.200s", err.Error())
+	}
+}
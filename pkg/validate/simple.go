@@ -0,0 +1,189 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync"
+)
+
+// simpleSchema is an object schema whose properties are each a single primitive
+// type, as most tool input schemas are. Arguments can be checked against it
+// directly, which is much cheaper than compiling and running gojsonschema.
+type simpleSchema struct {
+	properties map[string]string // property name -> its JSON type
+	required   []string
+	closed     bool // additionalProperties is false
+}
+
+// maxSimpleSchemas bounds the memo of schemas already examined
+const maxSimpleSchemas = 1024
+
+var (
+	simpleSchemasMu sync.Mutex
+	simpleSchemas   = map[string]*simpleSchema{} // schema -> its simple form, or nil if it has none
+)
+
+// simpleSchemaFor returns the schema's simple form, or nil if it uses anything the
+// fast path doesn't handle, e.g. nesting, combinators, formats or constraints. Each
+// schema is only examined once, the first time input is validated against it.
+func simpleSchemaFor(schema json.RawMessage) *simpleSchema {
+	simpleSchemasMu.Lock()
+	defer simpleSchemasMu.Unlock()
+	simple, ok := simpleSchemas[string(schema)]
+	if !ok {
+		if len(simpleSchemas) >= maxSimpleSchemas {
+			clear(simpleSchemas)
+		}
+		simple = parseSimpleSchema(schema)
+		simpleSchemas[string(schema)] = simple
+	}
+	return simple
+}
+
+// simplePrimitiveTypes are the property types the fast path checks
+var simplePrimitiveTypes = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true, "null": true,
+}
+
+// simpleAnnotations are keywords that don't affect validation
+var simpleAnnotations = map[string]bool{"title": true, "description": true}
+
+// isJSONString reports whether a keyword's value is a string. gojsonschema refuses
+// to compile a schema whose annotations aren't, so such schemas aren't simple.
+func isJSONString(value json.RawMessage) bool {
+	var s string
+	return len(value) > 0 && value[0] == '"' && json.Unmarshal(value, &s) == nil
+}
+
+func parseSimpleSchema(schema json.RawMessage) *simpleSchema {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil
+	}
+	simple := &simpleSchema{properties: map[string]string{}}
+	for keyword, value := range root {
+		switch {
+		case keyword == "type":
+			if string(value) != `"object"` {
+				return nil
+			}
+		case keyword == "properties":
+			if !simple.parseProperties(value) {
+				return nil
+			}
+		case keyword == "required":
+			// gojsonschema rejects empty or repeated names under draft 4, so leave those to it
+			if err := json.Unmarshal(value, &simple.required); err != nil || len(simple.required) == 0 {
+				return nil
+			}
+			seen := map[string]bool{}
+			for _, name := range simple.required {
+				if seen[name] {
+					return nil
+				}
+				seen[name] = true
+			}
+		case keyword == "additionalProperties":
+			switch string(value) {
+			case "false":
+				simple.closed = true
+			case "true":
+			default:
+				return nil
+			}
+		case simpleAnnotations[keyword]:
+			if !isJSONString(value) {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+	if _, typed := root["type"]; !typed {
+		return nil
+	}
+	return simple
+}
+
+// parseProperties records each property's type, reporting whether all of them are simple
+func (s *simpleSchema) parseProperties(data json.RawMessage) bool {
+	var properties map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &properties); err != nil {
+		return false
+	}
+	for name, property := range properties {
+		var propertyType string
+		for keyword, value := range property {
+			switch {
+			case keyword == "type":
+				if err := json.Unmarshal(value, &propertyType); err != nil || !simplePrimitiveTypes[propertyType] {
+					return false
+				}
+			case simpleAnnotations[keyword]:
+				if !isJSONString(value) {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+		if propertyType == "" {
+			return false
+		}
+		s.properties[name] = propertyType
+	}
+	return true
+}
+
+// valid reports whether the arguments satisfy the schema, exactly as gojsonschema
+// would decide. Anything that can't be decoded is left to gojsonschema to report.
+func (s *simpleSchema) valid(arguments []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(arguments))
+	dec.UseNumber()
+	var document map[string]any
+	if err := dec.Decode(&document); err != nil || document == nil || dec.More() {
+		return false
+	}
+	for _, name := range s.required {
+		if _, ok := document[name]; !ok {
+			return false
+		}
+	}
+	for name, value := range document {
+		propertyType, declared := s.properties[name]
+		if !declared {
+			if s.closed {
+				return false
+			}
+			continue
+		}
+		if !hasJSONType(value, propertyType) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasJSONType reports whether a decoded value is of a JSON schema primitive type.
+// As in gojsonschema, any number with an integral value counts as an integer.
+func hasJSONType(value any, jsonType string) bool {
+	switch v := value.(type) {
+	case string:
+		return jsonType == "string"
+	case bool:
+		return jsonType == "boolean"
+	case nil:
+		return jsonType == "null"
+	case json.Number:
+		if jsonType == "number" {
+			return true
+		}
+		if jsonType != "integer" {
+			return false
+		}
+		n, ok := new(big.Rat).SetString(string(v))
+		return ok && n.IsInt()
+	}
+	return false
+}
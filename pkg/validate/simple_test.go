@@ -0,0 +1,154 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+var simpleTestSchemas = []string{
+	`{"type":"object","properties":{"city":{"type":"string"},"days":{"type":"integer"}},"required":["city"]}`,
+	`{"type":"object","properties":{"lat":{"type":"number","description":"latitude"},"metric":{"type":"boolean"}},"required":["lat","metric"],"additionalProperties":false}`,
+	`{"type":"object","title":"note","properties":{"text":{"type":"string"},"tag":{"type":"null"}},"additionalProperties":true}`,
+	`{"type":"object"}`,
+}
+
+var simpleTestDocuments = []string{
+	`{"city":"Oslo"}`,
+	`{"city":"Oslo","days":3}`,
+	`{"city":"Oslo","days":3.0}`,
+	`{"city":"Oslo","days":1e2}`,
+	`{"city":"Oslo","days":3.5}`,
+	`{"city":"Oslo","days":"3"}`,
+	`{"city":null}`,
+	`{"city":7}`,
+	`{"days":3}`,
+	`{"lat":59.9,"metric":true}`,
+	`{"lat":59,"metric":false}`,
+	`{"lat":"59.9","metric":true}`,
+	`{"lat":59.9,"metric":true,"extra":1}`,
+	`{"lat":59.9}`,
+	`{"text":"hi","tag":null}`,
+	`{"text":"hi","tag":"x"}`,
+	`{"other":[1,2,{"nested":true}]}`,
+	`{}`,
+	`[]`,
+	`null`,
+	`"city"`,
+	`42`,
+	`{"city":`,
+}
+
+// TestSimpleSchema_MatchesGojsonschema checks the fast path against gojsonschema for
+// every pairing: it must never accept what gojsonschema rejects, nor the reverse
+func TestSimpleSchema_MatchesGojsonschema(t *testing.T) {
+	for _, schemaText := range simpleTestSchemas {
+		simple := parseSimpleSchema(json.RawMessage(schemaText))
+		if simple == nil {
+			t.Fatalf("Expected %s to be simple", schemaText)
+		}
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schemaText))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, document := range simpleTestDocuments {
+			want := false
+			if result, err := schema.Validate(gojsonschema.NewStringLoader(document)); err == nil {
+				want = result.Valid()
+			}
+			if got := simple.valid([]byte(document)); got != want {
+				t.Errorf("schema %s, document %s: fast path valid = %v, gojsonschema = %v", schemaText, document, got, want)
+			}
+		}
+
+		// declining only hands the input to gojsonschema, so odd input is declined rather than judged
+		if simple.valid([]byte(`{"city":"Oslo"} {"city":"Bergen"}`)) {
+			t.Errorf("schema %s: expected trailing data to be left to gojsonschema", schemaText)
+		}
+	}
+}
+
+func TestParseSimpleSchema_NotSimple(t *testing.T) {
+	for _, schema := range []string{
+		`{"type":"object","properties":{"address":{"type":"object","properties":{"street":{"type":"string"}}}}}`,
+		`{"type":"object","properties":{"tags":{"type":"array"}}}`,
+		`{"type":"object","properties":{"email":{"type":"string","format":"email"}}}`,
+		`{"type":"object","properties":{"name":{"type":"string","minLength":1}}}`,
+		`{"type":"object","properties":{"id":{"type":["string","integer"]}}}`,
+		`{"type":"object","properties":{"id":{}}}`,
+		`{"type":"object","oneOf":[{"required":["a"]},{"required":["b"]}]}`,
+		`{"type":"object","properties":{"a":{"type":"string"}},"additionalProperties":{"type":"string"}}`,
+		`{"type":"object","required":[]}`,
+		`{"type":"object","required":["a","a"]}`,
+		`{"type":"object","$schema":"http://json-schema.org/draft-07/schema#"}`,
+		`{"properties":{"a":{"type":"string"}}}`,
+		`{"type":"string"}`,
+		`not json`,
+	} {
+		if parseSimpleSchema(json.RawMessage(schema)) != nil {
+			t.Errorf("Expected %s to need full validation", schema)
+		}
+	}
+}
+
+// TestParseSimpleSchema_InvalidAnnotations checks that schemas gojsonschema refuses to
+// compile are never given a simple form that would accept input for them
+func TestParseSimpleSchema_InvalidAnnotations(t *testing.T) {
+	for _, schemaText := range []string{
+		`{"type":"object","title":5,"properties":{"a":{"type":"string"}}}`,
+		`{"type":"object","description":null,"properties":{"a":{"type":"string"}}}`,
+		`{"type":"object","properties":{"a":{"type":"string","description":["x"]}}}`,
+		`{"type":"object","properties":{"a":{"type":"string","title":{"text":"a"}}}}`,
+	} {
+		if _, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schemaText)); err == nil {
+			t.Fatalf("Expected gojsonschema to reject %s", schemaText)
+		}
+		if parseSimpleSchema(json.RawMessage(schemaText)) != nil {
+			t.Errorf("Expected %s to be left to gojsonschema", schemaText)
+		}
+	}
+}
+
+func TestValidateToolInput_SimpleSchemaErrorsUnchanged(t *testing.T) {
+	captureAlerts(t)
+	tool := newCacheTestTool("string")
+	if simpleSchemaFor(tool.InputSchema) == nil {
+		t.Fatal("Expected the test tool's schema to be simple")
+	}
+	_, status, err := ValidateToolInput(tool, []byte(`{"value": 42}`))
+	if status != StatusFailed {
+		t.Fatalf("status = %v, want %v", status, StatusFailed)
+	}
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || len(schemaErr.Errors) != 1 || schemaErr.Errors[0].Field != "value" {
+		t.Errorf("Expected gojsonschema's violation on 'value', got: %v", err)
+	}
+}
+
+const benchmarkSchema = `{"type":"object","properties":{"city":{"type":"string"},"days":{"type":"integer"},"metric":{"type":"boolean"}},"required":["city","days"]}`
+
+var benchmarkInput = []byte(`{"city":"Oslo","days":5,"metric":true}`)
+
+func BenchmarkSimpleSchema(b *testing.B) {
+	simple := parseSimpleSchema(json.RawMessage(benchmarkSchema))
+	for i := 0; i < b.N; i++ {
+		if !simple.valid(benchmarkInput) {
+			b.Fatal("Expected valid input")
+		}
+	}
+}
+
+func BenchmarkGojsonschema(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(benchmarkSchema))
+		if err != nil {
+			b.Fatal(err)
+		}
+		result, err := schema.Validate(gojsonschema.NewBytesLoader(benchmarkInput))
+		if err != nil || !result.Valid() {
+			b.Fatal("Expected valid input")
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/null-create/mcp-tls/pkg/codec"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// StreamingOutputValidator validates a tool's output as it arrives in chunks, e.g.
+// over SSE. When the output schema is an array with a single items schema, each
+// element is validated as soon as it is complete, so a bad element fails the stream
+// without waiting for the rest. Other schemas can't be checked piecemeal and are
+// validated once the output is complete. Either way Close validates the assembled
+// output against the full schema, catching array-level constraints such as maxItems.
+// Output larger than MaxJSONSize fails the stream as soon as it is written.
+type StreamingOutputValidator struct {
+	tool       *mcp.Tool
+	itemSchema json.RawMessage      // the array's items schema, with the root's definitions; nil if not streamable
+	items      *gojsonschema.Schema // itemSchema compiled
+
+	buf     []byte
+	pos     int  // offset in buf of the next element to read
+	started bool // the array's opening bracket has been read
+	ended   bool // the array's closing bracket has been read
+	index   int  // index of the next element
+	scan    elementScan
+	err     error
+}
+
+// elementScan finds where an array element ends, resuming where it left off as
+// chunks arrive, so each byte is scanned once however the element is split
+type elementScan struct {
+	off      int // bytes of the element scanned so far
+	depth    int // nesting of objects and arrays
+	inString bool
+	escaped  bool // the previous byte in the string was a backslash
+}
+
+// NewStreamingOutputValidator prepares to validate a stream of the tool's output
+func NewStreamingOutputValidator(tool *mcp.Tool) (*StreamingOutputValidator, error) {
+	if tool == nil {
+		return nil, ErrNilTool
+	}
+	v := &StreamingOutputValidator{tool: tool}
+	itemSchema := streamableItemSchema(tool.OutputSchema)
+	if itemSchema == nil {
+		return v, nil
+	}
+	items, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(itemSchema))
+	if err != nil {
+		return nil, fmt.Errorf("internal output schema error for tool '%s'", tool.Name)
+	}
+	v.itemSchema, v.items = itemSchema, items
+	return v, nil
+}
+
+// Streaming reports whether elements are validated as they arrive rather than at Close
+func (v *StreamingOutputValidator) Streaming() bool {
+	return v.items != nil
+}
+
+// Write accepts the next chunk of output, validating any array elements it completes.
+// Once an element fails, Write and Close keep returning that failure.
+func (v *StreamingOutputValidator) Write(chunk []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+	if len(v.buf)+len(chunk) > MaxJSONSize {
+		v.err = v.fail(fmt.Errorf("%w of %d bytes", codec.ErrJSONTooLarge, MaxJSONSize))
+		return 0, v.err
+	}
+	v.buf = append(v.buf, chunk...)
+	if v.items != nil {
+		v.err = v.consume()
+	}
+	if v.err != nil {
+		return 0, v.err
+	}
+	return len(chunk), nil
+}
+
+// Close validates the complete output against the tool's full output schema
+func (v *StreamingOutputValidator) Close() (ValidationStatus, error) {
+	if v.err != nil {
+		return StatusFailed, v.err
+	}
+	return ValidateToolOutput(string(v.buf), v.tool)
+}
+
+// consume validates every element completed since the last call
+func (v *StreamingOutputValidator) consume() error {
+	for !v.ended {
+		v.skipSpace()
+		if v.pos >= len(v.buf) {
+			return nil
+		}
+		if !v.started {
+			if v.buf[v.pos] != '[' {
+				return v.fail(errors.New("expected the output to be an array"))
+			}
+			v.started = true
+			v.pos++
+			continue
+		}
+
+		switch v.buf[v.pos] {
+		case ']':
+			v.ended = true
+			v.pos++
+			return nil
+		case ',':
+			if v.index == 0 {
+				return v.fail(errors.New("unexpected ',' before the first element"))
+			}
+			v.pos++
+			continue
+		}
+
+		n := v.scan.end(v.buf[v.pos:])
+		if n == 0 {
+			return nil
+		}
+		element := v.buf[v.pos : v.pos+n]
+		var raw json.RawMessage
+		if err := json.Unmarshal(element, &raw); err != nil {
+			return v.fail(err)
+		}
+		if err := v.validateElement(element); err != nil {
+			return err
+		}
+		v.pos += n
+		v.index++
+		v.scan = elementScan{}
+	}
+	return nil
+}
+
+// validateElement checks one array element against the items schema
+func (v *StreamingOutputValidator) validateElement(element []byte) error {
+	result, err := v.items.Validate(gojsonschema.NewBytesLoader(element))
+	if err != nil {
+		return v.fail(err)
+	}
+	if result.Valid() {
+		return nil
+	}
+	schemaErr := newSchemaError(
+		v.tool.Name,
+		fmt.Sprintf("Tool '%s' output failed validation at element %d:", v.tool.Name, v.index),
+		result.Errors(),
+	)
+	schemaErr.msg += loggedPayload("Raw Element", v.itemSchema, element)
+	securityAlert("%v", schemaErr)
+	return schemaErr.withVerbosity()
+}
+
+// fail reports a stream that isn't well-formed JSON as a validation failure
+func (v *StreamingOutputValidator) fail(err error) error {
+	securityAlert("streamed output of tool '%s' rejected at element %d: %v", v.tool.Name, v.index, err)
+	return fmt.Errorf("output of tool '%s' rejected at element %d: %w", v.tool.Name, v.index, err)
+}
+
+func (v *StreamingOutputValidator) skipSpace() {
+	for v.pos < len(v.buf) {
+		switch v.buf[v.pos] {
+		case ' ', '\t', '\n', '\r':
+			v.pos++
+		default:
+			return
+		}
+	}
+}
+
+// end returns the length of the element at the start of data, or 0 when data ends
+// before the element does and more input is needed. Scalars end at the next delimiter,
+// so a number running to the end of data may still continue in the next chunk.
+// Whether the element is well-formed is left to the caller.
+func (s *elementScan) end(data []byte) int {
+	for ; s.off < len(data); s.off++ {
+		c := data[s.off]
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+				if s.depth == 0 {
+					return s.off + 1
+				}
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			s.inString = true
+		case '{', '[':
+			s.depth++
+		case '}', ']':
+			if s.depth == 0 {
+				// ends a scalar, or is a stray bracket the caller will reject
+				return max(s.off, 1)
+			}
+			s.depth--
+			if s.depth == 0 {
+				return s.off + 1
+			}
+		case ',', ' ', '\t', '\n', '\r':
+			if s.depth == 0 {
+				return max(s.off, 1)
+			}
+		}
+	}
+	return 0
+}
+
+// streamableItemSchema returns the schema each element of an array output must match,
+// or nil if the output schema isn't an array with a single items schema. The root's
+// definitions are carried over so references to them from the items still resolve.
+func streamableItemSchema(outputSchema json.RawMessage) json.RawMessage {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(outputSchema, &root); err != nil {
+		return nil
+	}
+	if string(root["type"]) != `"array"` {
+		return nil
+	}
+	var items map[string]json.RawMessage
+	if err := json.Unmarshal(root["items"], &items); err != nil {
+		// absent, or the tuple form whose schema depends on position
+		return nil
+	}
+	for _, keyword := range []string{"definitions", "$defs"} {
+		if defs, ok := root[keyword]; ok {
+			if _, clash := items[keyword]; !clash {
+				items[keyword] = defs
+			}
+		}
+	}
+	itemSchema, err := json.Marshal(items)
+	if err != nil {
+		return nil
+	}
+	return itemSchema
+}
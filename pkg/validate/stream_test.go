@@ -0,0 +1,224 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/codec"
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+var forecastStreamTool = &mcp.Tool{
+	Name: "forecast-stream",
+	OutputSchema: json.RawMessage(`{
+		"type": "array",
+		"maxItems": 3,
+		"items": {"$ref": "#/definitions/day"},
+		"definitions": {
+			"day": {
+				"type": "object",
+				"properties": {"day": {"type": "string"}, "high": {"type": "number"}},
+				"required": ["day", "high"]
+			}
+		}
+	}`),
+}
+
+// streamChunks writes each chunk in turn, returning the index of the first rejected chunk, or -1
+func streamChunks(t *testing.T, v *StreamingOutputValidator, chunks ...string) (int, error) {
+	t.Helper()
+	for i, chunk := range chunks {
+		if _, err := v.Write([]byte(chunk)); err != nil {
+			return i, err
+		}
+	}
+	return -1, nil
+}
+
+func TestStreamingOutputValidator_ValidStream(t *testing.T) {
+	captureAlerts(t)
+	v, err := NewStreamingOutputValidator(forecastStreamTool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Streaming() {
+		t.Fatal("Expected an array output schema to be streamed")
+	}
+
+	// elements split across chunks at awkward points, including mid-number
+	rejected, err := streamChunks(t, v,
+		`[{"day":"mon","hi`, `gh":1`, `2},`, ` {"day":"tue","high":14}`, `,{"day":"wed",`, `"high":-3}]`)
+	if rejected != -1 {
+		t.Fatalf("Chunk %d rejected: %v", rejected, err)
+	}
+	if status, err := v.Close(); status != StatusSucceeded || err != nil {
+		t.Errorf("Close() = %v, %v, want success", status, err)
+	}
+}
+
+func TestStreamingOutputValidator_FailsFastOnBadElement(t *testing.T) {
+	alerts := captureAlerts(t)
+	v, err := NewStreamingOutputValidator(forecastStreamTool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rejected, err := streamChunks(t, v,
+		`[{"day":"mon","high":12},`,
+		`{"day":"tue","high":"warm"},`, // the bad element
+		`{"day":"wed","high":9}]`,
+	)
+	if rejected != 1 {
+		t.Fatalf("Expected the second chunk to be rejected, got chunk %d (err: %v)", rejected, err)
+	}
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected a *SchemaError, got %T: %v", err, err)
+	}
+	if !strings.Contains(err.Error(), "at element 1") {
+		t.Errorf("Expected the error to name element 1, got: %v", err)
+	}
+	if len(schemaErr.Errors) != 1 || schemaErr.Errors[0].Field != "high" {
+		t.Errorf("Expected one violation on 'high', got %+v", schemaErr.Errors)
+	}
+	if !strings.Contains(alerts.String(), "at element 1") {
+		t.Errorf("Expected a security alert for the element, got: %s", alerts)
+	}
+
+	// the stream stays failed
+	if _, err := v.Write([]byte(`{"day":"thu","high":1}]`)); !errors.As(err, &schemaErr) {
+		t.Errorf("Expected later writes to keep failing, got: %v", err)
+	}
+	if status, _ := v.Close(); status != StatusFailed {
+		t.Errorf("Close() status = %v, want %v", status, StatusFailed)
+	}
+}
+
+func TestStreamingOutputValidator_ArrayConstraintsCheckedAtClose(t *testing.T) {
+	captureAlerts(t)
+	v, err := NewStreamingOutputValidator(forecastStreamTool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every element is fine, but there are more than maxItems
+	element := `{"day":"d","high":1}`
+	if rejected, err := streamChunks(t, v, "["+strings.Repeat(element+",", 3)+element+"]"); rejected != -1 {
+		t.Fatalf("Unexpected rejection while streaming: %v", err)
+	}
+	if status, err := v.Close(); status != StatusFailed || err == nil {
+		t.Errorf("Close() = %v, %v, want a maxItems failure", status, err)
+	}
+}
+
+func TestStreamingOutputValidator_MalformedStream(t *testing.T) {
+	captureAlerts(t)
+	tests := []struct {
+		name   string
+		chunks []string
+	}{
+		{name: "not an array", chunks: []string{`{"day":"mon"}`}},
+		{name: "broken element", chunks: []string{`[{"day":}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewStreamingOutputValidator(forecastStreamTool)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rejected, _ := streamChunks(t, v, tt.chunks...); rejected == -1 {
+				t.Error("Expected the malformed stream to be rejected")
+			}
+		})
+	}
+}
+
+func TestStreamingOutputValidator_ByteAtATime(t *testing.T) {
+	captureAlerts(t)
+	v, err := NewStreamingOutputValidator(forecastStreamTool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// brackets, braces and escaped quotes inside strings don't end an element
+	output := `[{"day":"mon \"]},{\\","high":12}, {"day":"` + strings.Repeat("x", 64<<10) + `","high":-3.5}]`
+	chunks := make([]string, len(output))
+	for i := range output {
+		chunks[i] = output[i : i+1]
+	}
+	if rejected, err := streamChunks(t, v, chunks...); rejected != -1 {
+		t.Fatalf("Chunk %d rejected: %v", rejected, err)
+	}
+	if v.index != 2 {
+		t.Errorf("Expected both elements to be validated while streaming, got %d", v.index)
+	}
+	if status, err := v.Close(); status != StatusSucceeded || err != nil {
+		t.Errorf("Close() = %v, %v, want success", status, err)
+	}
+}
+
+func TestStreamingOutputValidator_SizeLimit(t *testing.T) {
+	captureAlerts(t)
+	for _, tool := range []*mcp.Tool{
+		forecastStreamTool,
+		{Name: "forecast", OutputSchema: json.RawMessage(`{"type":"object"}`)},
+	} {
+		v, err := NewStreamingOutputValidator(tool)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// an element that never ends would otherwise be buffered without limit
+		chunk := strings.Repeat("x", 64<<10)
+		rejected, err := streamChunks(t, v, append([]string{`[{"day":"`}, slices.Repeat([]string{chunk}, MaxJSONSize/len(chunk)+1)...)...)
+		if rejected == -1 || !errors.Is(err, codec.ErrJSONTooLarge) {
+			t.Errorf("%s: expected the stream to fail once it exceeds %d bytes, got chunk %d: %v", tool.Name, MaxJSONSize, rejected, err)
+		}
+		if len(v.buf) > MaxJSONSize {
+			t.Errorf("%s: buffered %d bytes, want at most %d", tool.Name, len(v.buf), MaxJSONSize)
+		}
+		if status, _ := v.Close(); status != StatusFailed {
+			t.Errorf("%s: Close() status = %v, want %v", tool.Name, status, StatusFailed)
+		}
+	}
+}
+
+func TestStreamingOutputValidator_BufferedFallback(t *testing.T) {
+	captureAlerts(t)
+	tool := &mcp.Tool{
+		Name:         "forecast",
+		OutputSchema: json.RawMessage(`{"type":"object","properties":{"high":{"type":"number"}},"required":["high"]}`),
+	}
+	v, err := NewStreamingOutputValidator(tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Streaming() {
+		t.Fatal("Expected an object output schema to be buffered")
+	}
+	// nothing can be checked until the output is complete
+	if rejected, err := streamChunks(t, v, `{"high":`, `"warm"}`); rejected != -1 {
+		t.Fatalf("Unexpected rejection of a buffered chunk: %v", err)
+	}
+	if status, err := v.Close(); status != StatusFailed || err == nil {
+		t.Errorf("Close() = %v, %v, want a validation failure", status, err)
+	}
+}
+
+func TestStreamableItemSchema(t *testing.T) {
+	tests := []struct {
+		schema     string
+		streamable bool
+	}{
+		{`{"type":"array","items":{"type":"string"}}`, true},
+		{`{"type":"array","items":[{"type":"string"},{"type":"number"}]}`, false},
+		{`{"type":"array"}`, false},
+		{`{"type":"object"}`, false},
+		{`not json`, false},
+	}
+	for _, tt := range tests {
+		if got := streamableItemSchema(json.RawMessage(tt.schema)) != nil; got != tt.streamable {
+			t.Errorf("streamableItemSchema(%s) streamable = %v, want %v", tt.schema, got, tt.streamable)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package validate
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
 
 // Unicode prompt-injection info:
 // https://www.robustintelligence.com/blog-posts/understanding-and-mitigating-unicode-tag-prompt-injection
@@ -18,8 +22,10 @@ const (
 // DetectedCharInfo holds information about a detected problematic character.
 type DetectedCharInfo struct {
 	Rune       rune              `json:"rune"`
-	Hex        string            `json:"hex"`   // Hex representation (e.g., "U+E0020")
-	Index      int               `json:"index"` // Rune index (start byte) in the original string
+	Hex        string            `json:"hex"`       // Hex representation (e.g., "U+E0020")
+	Index      int               `json:"index"`     // Byte offset in the original string, same as ByteIndex
+	ByteIndex  int               `json:"byteIndex"` // Byte offset in the original string
+	RuneIndex  int               `json:"runeIndex"` // Offset in runes (code points) in the original string
 	Category   DetectionCategory `json:"category"`
 	Translated string            `json:"translated,omitempty"` // Plaintext equivalent, if applicable
 }
@@ -68,93 +74,145 @@ func isDeprecated(r rune) bool {
 	return false
 }
 
-// detectHiddenUnicode scans the input string for runes falling into
-// predefined problematic categories like Unicode Tags, Bidi controls, etc.
+// translateTag maps Tag characters back to the ASCII they shadow
+func translateTag(r rune) string {
+	switch {
+	case r >= 0xE0020 && r <= 0xE007E:
+		// Corresponds to ASCII printable characters U+0020 to U+007E
+		return string(rune(r - 0xE0000))
+	case r == 0xE007F:
+		return "[Cancel Tag]" // Special tag
+	case r == 0xE0001:
+		return "[Start Tag]" // Special tag
+	}
+	return ""
+}
+
+// translateBidi gives the standard abbreviations for Bidi chars
+func translateBidi(r rune) string {
+	switch r {
+	case 0x202A:
+		return "[LRE]" // Left-to-Right Embedding
+	case 0x202B:
+		return "[RLE]" // Right-to-Left Embedding
+	case 0x202C:
+		return "[PDF]" // Pop Directional Formatting
+	case 0x202D:
+		return "[LRO]" // Left-to-Right Override
+	case 0x202E:
+		return "[RLO]" // Right-to-Left Override
+	case 0x061C:
+		return "[ALM]" // Arabic Letter Mark
+	case 0x2066:
+		return "[LRI]" // Left-to-Right Isolate
+	case 0x2067:
+		return "[RLI]" // Right-to-Left Isolate
+	case 0x2068:
+		return "[FSI]" // First Strong Isolate
+	case 0x2069:
+		return "[PDI]" // Pop Directional Isolate
+	}
+	return "[Bidi]"
+}
+
+// translateInvisible names invisible chars
+func translateInvisible(r rune) string {
+	switch r {
+	case 0x200B:
+		return "[ZWSP]" // Zero Width Space
+	case 0x200C:
+		return "[ZWNJ]" // Zero Width Non-Joiner
+	case 0x200D:
+		return "[ZWJ]" // Zero Width Joiner
+	case 0x2060:
+		return "[WJ]" // Word Joiner
+	case 0xFEFF:
+		return "[ZWNBSP/BOM]" // Zero Width No-Break Space / Byte Order Mark
+	}
+	return "[Invisible]"
+}
+
+// HiddenUnicodeRule flags the runes Match accepts as belonging to Category.
+// Translate, if set, gives a plaintext equivalent of a matched rune.
+type HiddenUnicodeRule struct {
+	Category  DetectionCategory
+	Match     func(r rune) bool
+	Translate func(r rune) string
+}
+
+// RuneRange matches the runes from lo to hi inclusive, for use as a HiddenUnicodeRule's Match
+func RuneRange(lo, hi rune) func(rune) bool {
+	return func(r rune) bool { return r >= lo && r <= hi }
+}
+
+var defaultHiddenUnicodeRules = []HiddenUnicodeRule{
+	{Category: TagChar, Match: isTag, Translate: translateTag},
+	{Category: BidiControl, Match: isBidiControl, Translate: translateBidi},
+	{Category: InvisibleFmt, Match: isInvisibleFormatting, Translate: translateInvisible},
+	{Category: DeprecatedChar, Match: isDeprecated, Translate: func(rune) string { return "[Deprecated/NonChar]" }},
+}
+
+var (
+	hiddenUnicodeMu    sync.RWMutex
+	hiddenUnicodeRules = defaultHiddenUnicodeRules
+)
+
+// DefaultHiddenUnicodeRules returns the built-in rules: Unicode Tags, Bidi controls,
+// invisible formatting and non-characters
+func DefaultHiddenUnicodeRules() []HiddenUnicodeRule {
+	return slices.Clone(defaultHiddenUnicodeRules)
+}
+
+// SetHiddenUnicodeRules replaces the rules DetectHiddenUnicode applies. Rules are tried
+// in order and the first match decides a rune's category. nil restores the defaults.
+func SetHiddenUnicodeRules(rules []HiddenUnicodeRule) {
+	if rules == nil {
+		rules = defaultHiddenUnicodeRules
+	}
+	hiddenUnicodeMu.Lock()
+	defer hiddenUnicodeMu.Unlock()
+	hiddenUnicodeRules = slices.Clone(rules)
+}
+
+// AddHiddenUnicodeRules extends the rules DetectHiddenUnicode applies, e.g. with
+// variation selectors or private use areas. They are tried after the existing rules.
+func AddHiddenUnicodeRules(rules ...HiddenUnicodeRule) {
+	hiddenUnicodeMu.Lock()
+	defer hiddenUnicodeMu.Unlock()
+	hiddenUnicodeRules = append(slices.Clone(hiddenUnicodeRules), rules...)
+}
+
+// DetectHiddenUnicode scans the input string for runes matching the hidden Unicode
+// rules, by default problematic categories like Unicode Tags, Bidi controls, etc.
 // It returns a slice of DetectedCharInfo for each problematic rune found,
 // including a translated representation where applicable.
-func detectHiddenUnicode(text string) []DetectedCharInfo {
+func DetectHiddenUnicode(text string) []DetectedCharInfo {
+	hiddenUnicodeMu.RLock()
+	rules := hiddenUnicodeRules
+	hiddenUnicodeMu.RUnlock()
+
 	var detected = make([]DetectedCharInfo, 0)
+	runeIndex := -1
 	for index, r := range text {
-		var category DetectionCategory
-		var translated string // Variable to hold the translation
-		isProblematic := false
-
-		switch {
-		case isTag(r):
-			category = TagChar
-			isProblematic = true
-			// Attempt to translate Tag characters back to ASCII equivalents
-			if r >= 0xE0020 && r <= 0xE007E {
-				// Corresponds to ASCII printable characters U+0020 to U+007E
-				translated = string(rune(r - 0xE0000))
-			} else if r == 0xE007F {
-				translated = "[Cancel Tag]" // Special tag
-			} else if r == 0xE0001 {
-				translated = "[Start Tag]" // Special tag
-			}
-
-		case isBidiControl(r):
-			category = BidiControl
-			isProblematic = true
-			// Provide standard abbreviations for Bidi chars
-			switch r {
-			case 0x202A:
-				translated = "[LRE]" // Left-to-Right Embedding
-			case 0x202B:
-				translated = "[RLE]" // Right-to-Left Embedding
-			case 0x202C:
-				translated = "[PDF]" // Pop Directional Formatting
-			case 0x202D:
-				translated = "[LRO]" // Left-to-Right Override
-			case 0x202E:
-				translated = "[RLO]" // Right-to-Left Override
-			case 0x061C:
-				translated = "[ALM]" // Arabic Letter Mark
-			case 0x2066:
-				translated = "[LRI]" // Left-to-Right Isolate
-			case 0x2067:
-				translated = "[RLI]" // Right-to-Left Isolate
-			case 0x2068:
-				translated = "[FSI]" // First Strong Isolate
-			case 0x2069:
-				translated = "[PDI]" // Pop Directional Isolate
-			default:
-				translated = "[Bidi]"
+		runeIndex++
+		for _, rule := range rules {
+			if !rule.Match(r) {
+				continue
 			}
-
-		case isInvisibleFormatting(r):
-			category = InvisibleFmt
-			isProblematic = true
-			// Provide names for invisible chars
-			switch r {
-			case 0x200B:
-				translated = "[ZWSP]" // Zero Width Space
-			case 0x200C:
-				translated = "[ZWNJ]" // Zero Width Non-Joiner
-			case 0x200D:
-				translated = "[ZWJ]" // Zero Width Joiner
-			case 0x2060:
-				translated = "[WJ]" // Word Joiner
-			case 0xFEFF:
-				translated = "[ZWNBSP/BOM]" // Zero Width No-Break Space / Byte Order Mark
-			default:
-				translated = "[Invisible]"
+			var translated string
+			if rule.Translate != nil {
+				translated = rule.Translate(r)
 			}
-
-		case isDeprecated(r):
-			category = DeprecatedChar
-			isProblematic = true
-			translated = "[Deprecated/NonChar]" // No direct translation
-		}
-
-		if isProblematic {
 			detected = append(detected, DetectedCharInfo{
 				Rune:       r,
 				Hex:        fmt.Sprintf("U+%04X", r), // Format as Unicode hex
-				Index:      index,                    // Note: This is byte index
-				Category:   category,
-				Translated: translated, // Assign the determined translation
+				Index:      index,
+				ByteIndex:  index,
+				RuneIndex:  runeIndex,
+				Category:   rule.Category,
+				Translated: translated,
 			})
+			break
 		}
 	}
 	return detected
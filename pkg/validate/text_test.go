@@ -38,14 +38,14 @@ func TestDetectHiddenUnicode(t *testing.T) {
 			name:  "Single Tag Character (Printable)",
 			input: "A\U000E0042C", // Embeds Tag 'B' (U+E0042)
 			expected: []DetectedCharInfo{
-				{Rune: '\U000E0042', Hex: "U+E0042", Index: 1, Category: TagChar, Translated: "B"},
+				{Rune: '\U000E0042', Hex: "U+E0042", Index: 1, ByteIndex: 1, RuneIndex: 1, Category: TagChar, Translated: "B"},
 			},
 		},
 		{
 			name:  "Single Tag Character (Cancel Tag)",
 			input: "End\U000E007F",
 			expected: []DetectedCharInfo{
-				{Rune: '\U000E007F', Hex: "U+E007F", Index: 3, Category: TagChar, Translated: "[Cancel Tag]"},
+				{Rune: '\U000E007F', Hex: "U+E007F", Index: 3, ByteIndex: 3, RuneIndex: 3, Category: TagChar, Translated: "[Cancel Tag]"},
 			},
 		},
 		{
@@ -53,7 +53,7 @@ func TestDetectHiddenUnicode(t *testing.T) {
 			input: "Nul\U000E0000Char", // U+E0000
 			expected: []DetectedCharInfo{
 				// Note: Translated is empty/omitted based on current logic
-				{Rune: '\U000E0000', Hex: "U+E0000", Index: 3, Category: TagChar, Translated: ""},
+				{Rune: '\U000E0000', Hex: "U+E0000", Index: 3, ByteIndex: 3, RuneIndex: 3, Category: TagChar, Translated: ""},
 			},
 		},
 		{
@@ -61,74 +61,74 @@ func TestDetectHiddenUnicode(t *testing.T) {
 			// "SST This is another test payload CT" using tags
 			input: "\U000E0001\U000E0054\U000E0068\U000E0069\U000E0073\U000E0020\U000E0069\U000E0073\U000E0020\U000E0061\U000E006E\U000E006F\U000E0074\U000E0068\U000E0065\U000E0072\U000E0020\U000E0074\U000E0065\U000E0073\U000E0074\U000E0020\U000E0070\U000E0061\U000E0079\U000E006C\U000E006F\U000E0061\U000E0064\U000E007F",
 			expected: []DetectedCharInfo{
-				{Rune: '\U000E0001', Hex: "U+E0001", Index: 0, Category: TagChar, Translated: "[Start Tag]"}, // Assuming [Start Tag] translation was added
-				{Rune: '\U000E0054', Hex: "U+E0054", Index: 4, Category: TagChar, Translated: "T"},
-				{Rune: '\U000E0068', Hex: "U+E0068", Index: 8, Category: TagChar, Translated: "h"},
-				{Rune: '\U000E0069', Hex: "U+E0069", Index: 12, Category: TagChar, Translated: "i"},
-				{Rune: '\U000E0073', Hex: "U+E0073", Index: 16, Category: TagChar, Translated: "s"},
-				{Rune: '\U000E0020', Hex: "U+E0020", Index: 20, Category: TagChar, Translated: " "},
-				{Rune: '\U000E0069', Hex: "U+E0069", Index: 24, Category: TagChar, Translated: "i"},
-				{Rune: '\U000E0073', Hex: "U+E0073", Index: 28, Category: TagChar, Translated: "s"},
-				{Rune: '\U000E0020', Hex: "U+E0020", Index: 32, Category: TagChar, Translated: " "},
-				{Rune: '\U000E0061', Hex: "U+E0061", Index: 36, Category: TagChar, Translated: "a"},
-				{Rune: '\U000E006E', Hex: "U+E006E", Index: 40, Category: TagChar, Translated: "n"},
-				{Rune: '\U000E006F', Hex: "U+E006F", Index: 44, Category: TagChar, Translated: "o"},
-				{Rune: '\U000E0074', Hex: "U+E0074", Index: 48, Category: TagChar, Translated: "t"},
-				{Rune: '\U000E0068', Hex: "U+E0068", Index: 52, Category: TagChar, Translated: "h"},
-				{Rune: '\U000E0065', Hex: "U+E0065", Index: 56, Category: TagChar, Translated: "e"},
-				{Rune: '\U000E0072', Hex: "U+E0072", Index: 60, Category: TagChar, Translated: "r"},
-				{Rune: '\U000E0020', Hex: "U+E0020", Index: 64, Category: TagChar, Translated: " "},
-				{Rune: '\U000E0074', Hex: "U+E0074", Index: 68, Category: TagChar, Translated: "t"},
-				{Rune: '\U000E0065', Hex: "U+E0065", Index: 72, Category: TagChar, Translated: "e"},
-				{Rune: '\U000E0073', Hex: "U+E0073", Index: 76, Category: TagChar, Translated: "s"},
-				{Rune: '\U000E0074', Hex: "U+E0074", Index: 80, Category: TagChar, Translated: "t"},
-				{Rune: '\U000E0020', Hex: "U+E0020", Index: 84, Category: TagChar, Translated: " "},
-				{Rune: '\U000E0070', Hex: "U+E0070", Index: 88, Category: TagChar, Translated: "p"},
-				{Rune: '\U000E0061', Hex: "U+E0061", Index: 92, Category: TagChar, Translated: "a"},
-				{Rune: '\U000E0079', Hex: "U+E0079", Index: 96, Category: TagChar, Translated: "y"},
-				{Rune: '\U000E006C', Hex: "U+E006C", Index: 100, Category: TagChar, Translated: "l"},
-				{Rune: '\U000E006F', Hex: "U+E006F", Index: 104, Category: TagChar, Translated: "o"},
-				{Rune: '\U000E0061', Hex: "U+E0061", Index: 108, Category: TagChar, Translated: "a"},
-				{Rune: '\U000E0064', Hex: "U+E0064", Index: 112, Category: TagChar, Translated: "d"},
-				{Rune: '\U000E007F', Hex: "U+E007F", Index: 116, Category: TagChar, Translated: "[Cancel Tag]"},
+				{Rune: '\U000E0001', Hex: "U+E0001", Index: 0, ByteIndex: 0, RuneIndex: 0, Category: TagChar, Translated: "[Start Tag]"}, // Assuming [Start Tag] translation was added
+				{Rune: '\U000E0054', Hex: "U+E0054", Index: 4, ByteIndex: 4, RuneIndex: 1, Category: TagChar, Translated: "T"},
+				{Rune: '\U000E0068', Hex: "U+E0068", Index: 8, ByteIndex: 8, RuneIndex: 2, Category: TagChar, Translated: "h"},
+				{Rune: '\U000E0069', Hex: "U+E0069", Index: 12, ByteIndex: 12, RuneIndex: 3, Category: TagChar, Translated: "i"},
+				{Rune: '\U000E0073', Hex: "U+E0073", Index: 16, ByteIndex: 16, RuneIndex: 4, Category: TagChar, Translated: "s"},
+				{Rune: '\U000E0020', Hex: "U+E0020", Index: 20, ByteIndex: 20, RuneIndex: 5, Category: TagChar, Translated: " "},
+				{Rune: '\U000E0069', Hex: "U+E0069", Index: 24, ByteIndex: 24, RuneIndex: 6, Category: TagChar, Translated: "i"},
+				{Rune: '\U000E0073', Hex: "U+E0073", Index: 28, ByteIndex: 28, RuneIndex: 7, Category: TagChar, Translated: "s"},
+				{Rune: '\U000E0020', Hex: "U+E0020", Index: 32, ByteIndex: 32, RuneIndex: 8, Category: TagChar, Translated: " "},
+				{Rune: '\U000E0061', Hex: "U+E0061", Index: 36, ByteIndex: 36, RuneIndex: 9, Category: TagChar, Translated: "a"},
+				{Rune: '\U000E006E', Hex: "U+E006E", Index: 40, ByteIndex: 40, RuneIndex: 10, Category: TagChar, Translated: "n"},
+				{Rune: '\U000E006F', Hex: "U+E006F", Index: 44, ByteIndex: 44, RuneIndex: 11, Category: TagChar, Translated: "o"},
+				{Rune: '\U000E0074', Hex: "U+E0074", Index: 48, ByteIndex: 48, RuneIndex: 12, Category: TagChar, Translated: "t"},
+				{Rune: '\U000E0068', Hex: "U+E0068", Index: 52, ByteIndex: 52, RuneIndex: 13, Category: TagChar, Translated: "h"},
+				{Rune: '\U000E0065', Hex: "U+E0065", Index: 56, ByteIndex: 56, RuneIndex: 14, Category: TagChar, Translated: "e"},
+				{Rune: '\U000E0072', Hex: "U+E0072", Index: 60, ByteIndex: 60, RuneIndex: 15, Category: TagChar, Translated: "r"},
+				{Rune: '\U000E0020', Hex: "U+E0020", Index: 64, ByteIndex: 64, RuneIndex: 16, Category: TagChar, Translated: " "},
+				{Rune: '\U000E0074', Hex: "U+E0074", Index: 68, ByteIndex: 68, RuneIndex: 17, Category: TagChar, Translated: "t"},
+				{Rune: '\U000E0065', Hex: "U+E0065", Index: 72, ByteIndex: 72, RuneIndex: 18, Category: TagChar, Translated: "e"},
+				{Rune: '\U000E0073', Hex: "U+E0073", Index: 76, ByteIndex: 76, RuneIndex: 19, Category: TagChar, Translated: "s"},
+				{Rune: '\U000E0074', Hex: "U+E0074", Index: 80, ByteIndex: 80, RuneIndex: 20, Category: TagChar, Translated: "t"},
+				{Rune: '\U000E0020', Hex: "U+E0020", Index: 84, ByteIndex: 84, RuneIndex: 21, Category: TagChar, Translated: " "},
+				{Rune: '\U000E0070', Hex: "U+E0070", Index: 88, ByteIndex: 88, RuneIndex: 22, Category: TagChar, Translated: "p"},
+				{Rune: '\U000E0061', Hex: "U+E0061", Index: 92, ByteIndex: 92, RuneIndex: 23, Category: TagChar, Translated: "a"},
+				{Rune: '\U000E0079', Hex: "U+E0079", Index: 96, ByteIndex: 96, RuneIndex: 24, Category: TagChar, Translated: "y"},
+				{Rune: '\U000E006C', Hex: "U+E006C", Index: 100, ByteIndex: 100, RuneIndex: 25, Category: TagChar, Translated: "l"},
+				{Rune: '\U000E006F', Hex: "U+E006F", Index: 104, ByteIndex: 104, RuneIndex: 26, Category: TagChar, Translated: "o"},
+				{Rune: '\U000E0061', Hex: "U+E0061", Index: 108, ByteIndex: 108, RuneIndex: 27, Category: TagChar, Translated: "a"},
+				{Rune: '\U000E0064', Hex: "U+E0064", Index: 112, ByteIndex: 112, RuneIndex: 28, Category: TagChar, Translated: "d"},
+				{Rune: '\U000E007F', Hex: "U+E007F", Index: 116, ByteIndex: 116, RuneIndex: 29, Category: TagChar, Translated: "[Cancel Tag]"},
 			},
 		},
 		{
 			name:  "Bidi Control Characters",
 			input: "Hello\u202EWRLD", // U+202E is RLO
 			expected: []DetectedCharInfo{
-				{Rune: '\u202E', Hex: "U+202E", Index: 5, Category: BidiControl, Translated: "[RLO]"},
+				{Rune: '\u202E', Hex: "U+202E", Index: 5, ByteIndex: 5, RuneIndex: 5, Category: BidiControl, Translated: "[RLO]"},
 			},
 		},
 		{
 			name:  "Invisible Formatting Characters",
 			input: "Click\u200BHere", // U+200B is ZWSP
 			expected: []DetectedCharInfo{
-				{Rune: '\u200B', Hex: "U+200B", Index: 5, Category: InvisibleFmt, Translated: "[ZWSP]"},
+				{Rune: '\u200B', Hex: "U+200B", Index: 5, ByteIndex: 5, RuneIndex: 5, Category: InvisibleFmt, Translated: "[ZWSP]"},
 			},
 		},
 		{
 			name:  "Deprecated/Non-Character",
 			input: "Invalid\uFDD0Char", // U+FDD0 is a non-character
 			expected: []DetectedCharInfo{
-				{Rune: '\uFDD0', Hex: "U+FDD0", Index: 7, Category: DeprecatedChar, Translated: "[Deprecated/NonChar]"},
+				{Rune: '\uFDD0', Hex: "U+FDD0", Index: 7, ByteIndex: 7, RuneIndex: 7, Category: DeprecatedChar, Translated: "[Deprecated/NonChar]"},
 			},
 		},
 		{
 			name:  "Mixed Problematic Characters",
 			input: "Command: \U000E0072\U000E006D\u202Etxt.evil\U000E007F", // rm<RLO>txt.evil<CancelTag>
 			expected: []DetectedCharInfo{
-				{Rune: '\U000E0072', Hex: "U+E0072", Index: 9, Category: TagChar, Translated: "r"},
-				{Rune: '\U000E006D', Hex: "U+E006D", Index: 13, Category: TagChar, Translated: "m"},
-				{Rune: '\u202E', Hex: "U+202E", Index: 17, Category: BidiControl, Translated: "[RLO]"},         // Note index carefully
-				{Rune: '\U000E007F', Hex: "U+E007F", Index: 28, Category: TagChar, Translated: "[Cancel Tag]"}, // Index after RLO and txt.evil
+				{Rune: '\U000E0072', Hex: "U+E0072", Index: 9, ByteIndex: 9, RuneIndex: 9, Category: TagChar, Translated: "r"},
+				{Rune: '\U000E006D', Hex: "U+E006D", Index: 13, ByteIndex: 13, RuneIndex: 10, Category: TagChar, Translated: "m"},
+				{Rune: '\u202E', Hex: "U+202E", Index: 17, ByteIndex: 17, RuneIndex: 11, Category: BidiControl, Translated: "[RLO]"},         // Note index carefully
+				{Rune: '\U000E007F', Hex: "U+E007F", Index: 28, ByteIndex: 28, RuneIndex: 20, Category: TagChar, Translated: "[Cancel Tag]"}, // Index after RLO and txt.evil
 			},
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			actualDetections := detectHiddenUnicode(tc.input)
+			actualDetections := DetectHiddenUnicode(tc.input)
 
 			// Use require for length check, as mismatch makes element checks pointless
 			require.Len(t, actualDetections, len(tc.expected), "Number of detections mismatch")
@@ -143,9 +143,66 @@ func TestDetectHiddenUnicode(t *testing.T) {
 				assert.Equal(t, tc.expected[i].Rune, actualDetections[i].Rune, "Rune mismatch at index %d", i)
 				assert.Equal(t, tc.expected[i].Hex, actualDetections[i].Hex, "Hex mismatch at index %d", i)
 				assert.Equal(t, tc.expected[i].Index, actualDetections[i].Index, "Index mismatch at index %d", i)
+				assert.Equal(t, tc.expected[i].ByteIndex, actualDetections[i].ByteIndex, "ByteIndex mismatch at index %d", i)
+				assert.Equal(t, tc.expected[i].RuneIndex, actualDetections[i].RuneIndex, "RuneIndex mismatch at index %d", i)
 				assert.Equal(t, tc.expected[i].Category, actualDetections[i].Category, "Category mismatch at index %d", i)
 				assert.Equal(t, tc.expected[i].Translated, actualDetections[i].Translated, "Translated mismatch at index %d", i)
 			}
 		})
 	}
 }
+
+func TestDetectHiddenUnicode_MultiByteIndices(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		byteIndex int
+		runeIndex int
+	}{
+		{name: "after Japanese", input: "\u65E5\u672C\u202Ex", byteIndex: 6, runeIndex: 2},
+		{name: "after emoji", input: "a\U0001F60A\u200Bb", byteIndex: 5, runeIndex: 2},
+		{name: "after accented Latin", input: "caf\u00E9 \U000E0041", byteIndex: 6, runeIndex: 5},
+		{name: "after another hidden rune", input: "\U000E0041\u00E9\u200B", byteIndex: 6, runeIndex: 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			detections := DetectHiddenUnicode(tc.input)
+			require.NotEmpty(t, detections)
+			last := detections[len(detections)-1]
+			assert.Equal(t, tc.byteIndex, last.ByteIndex, "ByteIndex")
+			assert.Equal(t, tc.byteIndex, last.Index, "Index stays the byte offset")
+			assert.Equal(t, tc.runeIndex, last.RuneIndex, "RuneIndex")
+
+			// each index locates the rune in its own unit
+			assert.Equal(t, last.Rune, []rune(tc.input[last.ByteIndex:])[0])
+			assert.Equal(t, last.Rune, []rune(tc.input)[last.RuneIndex])
+		})
+	}
+}
+
+func TestDetectHiddenUnicode_AddedRules(t *testing.T) {
+	t.Cleanup(func() { SetHiddenUnicodeRules(nil) })
+	const variationSelector DetectionCategory = "Variation Selector"
+	// "a" followed by VS16, which is invisible after a letter
+	text := "a\uFE0Fb"
+	require.Empty(t, DetectHiddenUnicode(text), "variation selectors aren't detected by default")
+
+	AddHiddenUnicodeRules(HiddenUnicodeRule{
+		Category:  variationSelector,
+		Match:     RuneRange(0xFE00, 0xFE0F),
+		Translate: func(r rune) string { return "[VS]" },
+	})
+	assert.Equal(t, []DetectedCharInfo{
+		{Rune: 0xFE0F, Hex: "U+FE0F", Index: 1, ByteIndex: 1, RuneIndex: 1, Category: variationSelector, Translated: "[VS]"},
+	}, DetectHiddenUnicode(text))
+	assert.Error(t, ValidateToolDescription("Looks up a\uFE0F forecast"))
+
+	// the built-in rules still apply, and keep precedence
+	detections := DetectHiddenUnicode("x\u202Ey")
+	require.Len(t, detections, 1)
+	assert.Equal(t, BidiControl, detections[0].Category)
+
+	SetHiddenUnicodeRules(nil)
+	assert.Empty(t, DetectHiddenUnicode(text), "nil restores the defaults")
+	assert.Len(t, DefaultHiddenUnicodeRules(), 4)
+}
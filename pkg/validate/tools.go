@@ -3,7 +3,6 @@ package validate
 import (
 	"bytes"
 	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,12 +15,50 @@ import (
 
 type ValidationStatus string
 
+// ErrNilTool is returned, with StatusError where a status is reported, when a nil tool is validated
+var ErrNilTool = errors.New("no tool to validate against")
+
 const (
 	StatusSucceeded ValidationStatus = "succeeded"
 	StatusFailed    ValidationStatus = "failed"
 	StatusError     ValidationStatus = "error"
 )
 
+// FieldError describes a single schema violation within a tool's input or output.
+type FieldError struct {
+	Field  string `json:"field"`  // path to the offending value, "(root)" for the document itself
+	Reason string `json:"reason"` // human readable description of the violation
+}
+
+// SchemaError is returned when a tool's input or output fails schema validation.
+// It carries each violation so callers can report them in a structured form.
+type SchemaError struct {
+	Tool    string
+	Errors  []FieldError
+	msg     string
+	summary string // the one-line message used under VerbositySummary
+}
+
+func (e *SchemaError) Error() string { return e.msg }
+
+// newSchemaError collects the violations from a failed validation result,
+// formatting the message as header followed by one violation per line.
+func newSchemaError(toolName, header string, results []gojsonschema.ResultError) *SchemaError {
+	clarifyOneOf(results)
+	fieldErrors := make([]FieldError, 0, len(results))
+	lines := make([]string, 0, len(results))
+	for _, desc := range results {
+		fieldErrors = append(fieldErrors, FieldError{Field: desc.Field(), Reason: desc.Description()})
+		lines = append(lines, fmt.Sprintf("- %s", desc))
+	}
+	return &SchemaError{
+		Tool:    toolName,
+		Errors:  fieldErrors,
+		msg:     header + "\n" + strings.Join(lines, "\n"),
+		summary: fmt.Sprintf("%s %d violation(s)", header, len(results)),
+	}
+}
+
 // FindTool retrieves the trusted tool by name from the tool registry.
 // In a real system, this might involve looking up in a secure registry
 // and potentially verifying signatures/sources stored in SecurityMetadata.
@@ -55,45 +92,146 @@ func ValidateToolCall(
 	return foundTool, status, nil
 }
 
+// ValidateCall validates a decoded tool call's arguments against the registered tool it names.
+func ValidateCall(call mcp.ToolCall, toolManager *mcp.ToolManager) (*mcp.Tool, ValidationStatus, error) {
+	return ValidateToolCall(call.FunctionName, call.Arguments, toolManager)
+}
+
 // ValidateToolInputSchema validates the input arguments against the tool's input schema.
 func ValidateToolInputSchema(tool *mcp.Tool, inputArguments []byte) (ValidationStatus, error) {
+	_, status, err := ValidateToolInput(tool, inputArguments)
+	return status, err
+}
+
+// ValidateToolInput validates the input arguments against the tool's input schema,
+// applying any given options. It returns the arguments that were actually validated,
+// which differ from inputArguments only when they arrived double-encoded as a JSON string
+// or an option rewrote them (e.g. WithCoerceTypes), so the caller can forward the cleaned version.
+func ValidateToolInput(tool *mcp.Tool, inputArguments []byte, opts ...InputOption) ([]byte, ValidationStatus, error) {
+	if tool == nil {
+		return inputArguments, StatusError, ErrNilTool
+	}
+	options := newInputOptions(opts)
+	if options.cache == nil || len(tool.InputSchema) == 0 {
+		return validateToolInput(tool, inputArguments, options)
+	}
+
+	fingerprint, err := generateSchemaFingerprint(tool.InputSchema)
+	if err != nil {
+		// let the uncached path report the schema problem
+		return validateToolInput(tool, inputArguments, options)
+	}
+
+	key := cacheKey(fingerprint, inputArguments, options)
+	if entry, ok := options.cache.get(tool.Name, fingerprint, key); ok {
+		return entry.args, entry.status, entry.err
+	}
+
+	args, status, err := validateToolInput(tool, inputArguments, options)
+	// internal errors may be transient, so only definitive outcomes are cached
+	if status != StatusError {
+		options.cache.put(&cacheEntry{
+			key:         key,
+			toolName:    tool.Name,
+			fingerprint: fingerprint,
+			args:        args,
+			status:      status,
+			err:         err,
+		})
+	}
+	return args, status, err
+}
+
+func validateToolInput(tool *mcp.Tool, inputArguments []byte, options inputOptions) ([]byte, ValidationStatus, error) {
+	if err := options.ctx.Err(); err != nil {
+		return inputArguments, StatusError, fmt.Errorf("input validation for tool '%s' stopped: %w", tool.Name, err)
+	}
 	// Only validate if schema is provided
 	if len(tool.InputSchema) > 0 {
-		schemaLoader := gojsonschema.NewBytesLoader(tool.InputSchema)
-		documentLoader := gojsonschema.NewBytesLoader(inputArguments)
-		schema, err := gojsonschema.NewSchema(schemaLoader)
-		if err != nil {
-			return StatusError, fmt.Errorf("internal schema error for tool '%s'", tool.Name)
+		if err := checkJSONLimits(inputArguments); err != nil {
+			securityAlert("input for tool '%s' rejected: %v", tool.Name, err)
+			return inputArguments, StatusError, err
 		}
 
-		result, err := schema.Validate(documentLoader)
+		unwrapped, ok, err := unwrapDoubleEncoded(inputArguments)
 		if err != nil {
-			return StatusError, fmt.Errorf("internal validation error for tool '%s'", tool.Name)
+			return inputArguments, StatusFailed, fmt.Errorf("input for tool '%s' rejected: %w", tool.Name, err)
+		}
+		if ok {
+			// the unwrapped document can nest deeper than the string that carried it
+			if err := checkJSONLimits(unwrapped); err != nil {
+				securityAlert("input for tool '%s' rejected: %v", tool.Name, err)
+				return inputArguments, StatusError, err
+			}
+			inputArguments = unwrapped
 		}
 
-		if !result.Valid() {
-			var validationErrors []string
-			for _, desc := range result.Errors() {
-				validationErrors = append(validationErrors, fmt.Sprintf("- %s", desc))
+		if err := checkDuplicateKeys(inputArguments); err != nil {
+			securityAlert("input for tool '%s' rejected: %v", tool.Name, err)
+			return inputArguments, StatusFailed, err
+		}
+
+		if options.coerceTypes {
+			inputArguments = coerceTypes(tool.InputSchema, inputArguments)
+		}
+
+		// simple schemas skip gojsonschema for input they accept; rejections
+		// still go through it so the reported violations are the same
+		if simple := simpleSchemaFor(tool.InputSchema); simple == nil || !simple.valid(inputArguments) {
+			// the full schema check is the costly step, so don't start it for a caller that's gone
+			if err := options.ctx.Err(); err != nil {
+				return inputArguments, StatusError, fmt.Errorf("input validation for tool '%s' stopped: %w", tool.Name, err)
+			}
+			schemaLoader := gojsonschema.NewBytesLoader(tool.InputSchema)
+			documentLoader := gojsonschema.NewBytesLoader(inputArguments)
+			schema, err := gojsonschema.NewSchema(schemaLoader)
+			if err != nil {
+				return inputArguments, StatusError, fmt.Errorf("internal schema error for tool '%s'", tool.Name)
+			}
+
+			result, err := schema.Validate(documentLoader)
+			if err != nil {
+				return inputArguments, StatusError, fmt.Errorf("internal validation error for tool '%s'", tool.Name)
+			}
+
+			if !result.Valid() {
+				schemaErr := newSchemaError(
+					tool.Name,
+					fmt.Sprintf("Input validation failed for tool '%s':", tool.Name),
+					result.Errors(),
+				)
+				securityAlert("%v%s", schemaErr, loggedPayload("Raw Input", tool.InputSchema, inputArguments))
+				return inputArguments, StatusFailed, schemaErr.withVerbosity()
 			}
-			errorMsg := fmt.Sprintf(
-				"Input validation failed for tool '%s':\n%s",
-				tool.Name, strings.Join(validationErrors, "\n"),
-			)
-			fmt.Println("SECURITY ALERT:", errorMsg)
-			return StatusFailed, errors.New(errorMsg)
 		}
 		fmt.Printf("Input arguments for tool '%s' validated successfully", tool.Name)
+
+		if options.applyDefaults {
+			inputArguments = applyDefaults(tool.InputSchema, inputArguments)
+		}
 	} else {
-		return StatusFailed, fmt.Errorf("no InputSchema defined for tool '%s'", tool.Name)
+		return inputArguments, StatusFailed, fmt.Errorf("no InputSchema defined for tool '%s'", tool.Name)
 	}
 
-	return StatusSucceeded, nil
+	return inputArguments, StatusSucceeded, nil
 }
 
 // ValidateToolOutput validates the tool's output against its output schema.
 func ValidateToolOutput(rawResult string, tool *mcp.Tool) (ValidationStatus, error) {
+	if tool == nil {
+		return StatusError, ErrNilTool
+	}
 	if len(tool.OutputSchema) > 0 {
+		if err := checkJSONLimits([]byte(rawResult)); err != nil {
+			securityAlert("output of tool '%s' rejected: %v", tool.Name, err)
+			return StatusError, err
+		}
+
+		if err := checkDuplicateKeys([]byte(rawResult)); err != nil {
+			securityAlert("output of tool '%s' rejected: %v", tool.Name, err)
+			return StatusFailed, err
+		}
+
 		outputSchemaLoader := gojsonschema.NewBytesLoader(tool.OutputSchema)
 		outputDocumentLoader := gojsonschema.NewStringLoader(rawResult)
 		outputSchema, err := gojsonschema.NewSchema(outputSchemaLoader)
@@ -109,14 +247,16 @@ func ValidateToolOutput(rawResult string, tool *mcp.Tool) (ValidationStatus, err
 		}
 
 		if !outputResult.Valid() {
-			var validationErrors []string
-			for _, desc := range outputResult.Errors() {
-				validationErrors = append(validationErrors, fmt.Sprintf("- %s", desc))
-			}
-			errorMsg := fmt.Sprintf("Tool '%s' output failed validation:\n%s\nRaw Output: %s",
-				tool.Name, strings.Join(validationErrors, "\n"), rawResult)
-			fmt.Println("SECURITY ALERT:", errorMsg)
-			return StatusFailed, errors.New(errorMsg)
+			schemaErr := newSchemaError(
+				tool.Name,
+				fmt.Sprintf("Tool '%s' output failed validation:", tool.Name),
+				outputResult.Errors(),
+			)
+			// the message travels on to callers' logs, so it only ever carries the redacted
+			// output, and only at debug level
+			schemaErr.msg += loggedPayload("Raw Output", tool.OutputSchema, []byte(rawResult))
+			securityAlert("%v", schemaErr)
+			return StatusFailed, schemaErr.withVerbosity()
 		}
 		fmt.Printf("Output content for tool '%s' validated successfully.\n", tool.Name)
 	}
@@ -124,18 +264,22 @@ func ValidateToolOutput(rawResult string, tool *mcp.Tool) (ValidationStatus, err
 }
 
 // ValidateToolDescription analyzes the tools descriptive text for hidden characters
-// and potentially injected prompts
+// and potentially injected prompts, matching it against the denylist set with
+// SetDescriptionDenylist
 func ValidateToolDescription(toolDescription string) error {
-	detections := detectHiddenUnicode(toolDescription)
-	if len(detections) == 0 {
-		return nil
+	detections := DetectHiddenUnicode(toolDescription)
+	if len(detections) > 0 {
+		return fmt.Errorf("ALERT: %d hidden characters detected in tool description text", len(detections))
 	}
-	return fmt.Errorf("ALERT: %d hidden characters detected in tool description text", len(detections))
+	return checkDeniedPhrases(toolDescription)
 }
 
 // ValidateToolSecurity performs comprehensive security validation on a tool.
 // This includes checksum validation, schema fingerprint validation, and description validation.
 func ValidateToolSecurity(tool *mcp.Tool, toolManager *mcp.ToolManager) error {
+	if tool == nil {
+		return ErrNilTool
+	}
 	if err := ValidateToolDescription(tool.Description); err != nil {
 		return fmt.Errorf("tool description validation failed: %w", err)
 	}
@@ -151,6 +295,9 @@ func ValidateToolSecurity(tool *mcp.Tool, toolManager *mcp.ToolManager) error {
 
 // ValidateToolIntegrity performs integrity checks on a tool's security metadata.
 func ValidateToolIntegrity(tool *mcp.Tool) error {
+	if tool == nil {
+		return ErrNilTool
+	}
 	// Validate checksum if present
 	if tool.SecurityMetadata.Checksum != "" {
 		expectedChecksum, err := generateToolChecksum(*tool)
@@ -173,57 +320,39 @@ func ValidateToolIntegrity(tool *mcp.Tool) error {
 		}
 	}
 
-	return nil
-}
+	// Validate output schema fingerprint if present
+	if tool.SecurityMetadata.OutputSignature != "" {
+		if len(tool.OutputSchema) == 0 {
+			return errors.New("output schema fingerprint validation failed - output schema is missing")
+		}
 
-// canonicalizeJson converts a JSON object to a canonical form for consistent hashing
-func canonicalizeJson(data json.RawMessage) (json.RawMessage, error) {
-	var obj any
-	if err := json.Unmarshal(data, &obj); err != nil {
-		return nil, err
-	}
+		expectedFingerprint, err := generateSchemaFingerprint(tool.OutputSchema)
+		if err != nil {
+			return fmt.Errorf("failed to generate output schema fingerprint for validation: %w", err)
+		}
 
-	// Sort keys and ensure consistent serialization
-	canonical, err := json.Marshal(obj)
-	if err != nil {
-		return nil, err
+		if expectedFingerprint != tool.SecurityMetadata.OutputSignature {
+			return errors.New("output schema fingerprint validation failed - output schema may have been tampered with")
+		}
 	}
 
-	return canonical, nil
+	return nil
 }
 
-// generateSchemaFingerprint creates a fingerprint of the schema using SHA-256
-func generateSchemaFingerprint(schema json.RawMessage) (string, error) {
-	canonical, err := canonicalizeJson(schema)
-	if err != nil {
-		return "", err
-	}
+// canonicalizeJson converts a JSON object to a canonical form for consistent hashing,
+// the same one the registry computes checksums and fingerprints over
+func canonicalizeJson(data json.RawMessage) (json.RawMessage, error) {
+	return mcp.CanonicalJSON(data)
+}
 
-	hash := sha256.Sum256(canonical)
-	return hex.EncodeToString(hash[:]), nil
+// generateSchemaFingerprint creates a fingerprint of the schema, the same one the registry stores
+func generateSchemaFingerprint(schema json.RawMessage) (string, error) {
+	return mcp.SchemaFingerprint(schema)
 }
 
-// generateToolChecksum creates a checksum of the entire tool definition using SHA-256
+// generateToolChecksum creates a checksum of the tool definition, the same one the registry stores
 func generateToolChecksum(tool mcp.Tool) (string, error) {
-	toolCopy := mcp.Tool{
-		Name:        tool.Name,
-		Description: tool.Description,
-		InputSchema: tool.InputSchema,
-	}
-
-	data, err := json.Marshal(toolCopy)
-	if err != nil {
-		return "", err
-	}
-
-	// Use canonical JSON for consistent checksums
-	canonical, err := canonicalizeJson(data)
-	if err != nil {
-		return "", err
-	}
-
-	hash := sha256.Sum256(canonical)
-	return hex.EncodeToString(hash[:]), nil
+	return mcp.ToolChecksum(tool)
 }
 
 // Use canonical serialization (deterministic field order)
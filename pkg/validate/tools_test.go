@@ -2,6 +2,7 @@ package validate
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/null-create/mcp-tls/pkg/mcp"
@@ -386,14 +387,10 @@ func TestValidateToolInputSchema(t *testing.T) {
 func TestValidateToolInputSchema_NilTool(t *testing.T) {
 	inputArgs := []byte(`{"test": "value"}`)
 
-	// This should panic or handle gracefully - testing the behavior
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("ValidateToolInputSchema() with nil tool should panic or handle gracefully")
-		}
-	}()
-
-	ValidateToolInputSchema(nil, inputArgs)
+	status, err := ValidateToolInputSchema(nil, inputArgs)
+	if status != StatusError || !errors.Is(err, ErrNilTool) {
+		t.Errorf("ValidateToolInputSchema() with nil tool = %v, %v, want %v, ErrNilTool", status, err, StatusError)
+	}
 }
 
 func TestValidateToolInputSchema_NilInputArguments(t *testing.T) {
@@ -878,14 +875,35 @@ func TestValidateToolOutput(t *testing.T) {
 func TestValidateToolOutput_NilTool(t *testing.T) {
 	rawResult := `{"test": "value"}`
 
-	// This should panic or handle gracefully - testing the behavior
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("ValidateToolOutput() with nil tool should panic or handle gracefully")
-		}
-	}()
+	status, err := ValidateToolOutput(rawResult, nil)
+	if status != StatusError || !errors.Is(err, ErrNilTool) {
+		t.Errorf("ValidateToolOutput() with nil tool = %v, %v, want %v, ErrNilTool", status, err, StatusError)
+	}
+}
 
-	ValidateToolOutput(rawResult, nil)
+func TestNilTool_NoPanic(t *testing.T) {
+	args := []byte(`{"test": "value"}`)
+	if _, status, err := ValidateToolInput(nil, args, WithCache(NewValidationCache(1))); status != StatusError || !errors.Is(err, ErrNilTool) {
+		t.Errorf("ValidateToolInput() = %v, %v, want ErrNilTool", status, err)
+	}
+	if _, status, err := ValidateToolInputSchemaVersions(nil, args, nil); status != StatusError || !errors.Is(err, ErrNilTool) {
+		t.Errorf("ValidateToolInputSchemaVersions() = %v, %v, want ErrNilTool", status, err)
+	}
+	if _, err := NewStreamingOutputValidator(nil); !errors.Is(err, ErrNilTool) {
+		t.Errorf("NewStreamingOutputValidator() error = %v, want ErrNilTool", err)
+	}
+	if _, err := ExecuteTool(nil, args, nil); !errors.Is(err, ErrNilTool) {
+		t.Errorf("ExecuteTool() error = %v, want ErrNilTool", err)
+	}
+	if err := ValidateToolIntegrity(nil); !errors.Is(err, ErrNilTool) {
+		t.Errorf("ValidateToolIntegrity() error = %v, want ErrNilTool", err)
+	}
+	if err := ValidateToolSecurity(nil, nil); !errors.Is(err, ErrNilTool) {
+		t.Errorf("ValidateToolSecurity() error = %v, want ErrNilTool", err)
+	}
+	if warnings := CheckDescriptionSchemaConsistency(nil); warnings != nil {
+		t.Errorf("CheckDescriptionSchemaConsistency() = %v, want none", warnings)
+	}
 }
 
 func TestValidateToolOutput_EdgeCases(t *testing.T) {
@@ -987,3 +1005,153 @@ func TestValidateToolOutput_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateToolIntegrity_OutputSchema(t *testing.T) {
+	newSecuredTool := func(t *testing.T) *mcp.Tool {
+		tool := &mcp.Tool{
+			Name:        "integrity-tool",
+			Description: "A tool with an output schema",
+			InputSchema: mustMarshalJSON(map[string]interface{}{
+				"type": "object",
+			}),
+			OutputSchema: mustMarshalJSON(map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"result": map[string]interface{}{
+						"type": "string",
+					},
+				},
+			}),
+		}
+		if err := mcp.SecureTool(tool); err != nil {
+			t.Fatalf("SecureTool() unexpected error: %v", err)
+		}
+		return tool
+	}
+
+	t.Run("untampered output schema", func(t *testing.T) {
+		tool := newSecuredTool(t)
+		if err := ValidateToolIntegrity(tool); err != nil {
+			t.Errorf("ValidateToolIntegrity() unexpected error: %v", err)
+		}
+	})
+
+	t.Run("tampered output schema", func(t *testing.T) {
+		tool := newSecuredTool(t)
+		tool.OutputSchema = mustMarshalJSON(map[string]interface{}{
+			"type": "string",
+		})
+
+		err := ValidateToolIntegrity(tool)
+		if err == nil {
+			t.Fatal("ValidateToolIntegrity() expected error but got none")
+		}
+		if !containsString(err.Error(), "output schema fingerprint validation failed") {
+			t.Errorf("ValidateToolIntegrity() error = %v, want output schema fingerprint failure", err)
+		}
+	})
+
+	t.Run("removed output schema", func(t *testing.T) {
+		tool := newSecuredTool(t)
+		tool.OutputSchema = nil
+
+		if err := ValidateToolIntegrity(tool); err == nil {
+			t.Error("ValidateToolIntegrity() expected error but got none")
+		}
+	})
+}
+
+func TestValidateToolIntegrity_Deprecated(t *testing.T) {
+	newDeprecatedTool := func(t *testing.T) *mcp.Tool {
+		tool := &mcp.Tool{
+			Name:               "legacy-tool",
+			Description:        "A deprecated tool",
+			InputSchema:        mustMarshalJSON(map[string]interface{}{"type": "object"}),
+			Deprecated:         true,
+			DeprecationMessage: "use new-tool instead",
+		}
+		if err := mcp.SecureTool(tool); err != nil {
+			t.Fatalf("SecureTool() unexpected error: %v", err)
+		}
+		return tool
+	}
+
+	t.Run("untampered deprecated tool", func(t *testing.T) {
+		if err := ValidateToolIntegrity(newDeprecatedTool(t)); err != nil {
+			t.Errorf("ValidateToolIntegrity() unexpected error: %v", err)
+		}
+	})
+
+	tamper := map[string]func(*mcp.Tool){
+		"deprecation removed":         func(tool *mcp.Tool) { tool.Deprecated = false },
+		"deprecation reworded":        func(tool *mcp.Tool) { tool.DeprecationMessage = "still supported" },
+		"deprecation message cleared": func(tool *mcp.Tool) { tool.DeprecationMessage = "" },
+	}
+	for name, change := range tamper {
+		t.Run(name, func(t *testing.T) {
+			tool := newDeprecatedTool(t)
+			change(tool)
+			err := ValidateToolIntegrity(tool)
+			if err == nil || !containsString(err.Error(), "tool checksum validation failed") {
+				t.Errorf("ValidateToolIntegrity() error = %v, want checksum failure", err)
+			}
+		})
+	}
+}
+
+func TestValidateToolInputSchema_SchemaError(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "weather-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"location": map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"location"},
+		}),
+	}
+
+	_, err := ValidateToolInputSchema(tool, []byte(`{"days": 3}`))
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("ValidateToolInputSchema() error = %v, want *SchemaError", err)
+	}
+	if schemaErr.Tool != "weather-tool" {
+		t.Errorf("SchemaError.Tool = %q, want %q", schemaErr.Tool, "weather-tool")
+	}
+	if len(schemaErr.Errors) != 1 || schemaErr.Errors[0].Field != "(root)" || !containsString(schemaErr.Errors[0].Reason, "location") {
+		t.Errorf("SchemaError.Errors = %+v, want a single root error naming location", schemaErr.Errors)
+	}
+	if !containsString(err.Error(), "Input validation failed") {
+		t.Errorf("error message %q lost its summary", err.Error())
+	}
+}
+
+func TestValidateCall(t *testing.T) {
+	tm := mcp.NewToolManager("test", "1.0.0", false)
+	if err := tm.RegisterTool(mcp.Tool{
+		Name: "get_weather",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"location": map[string]interface{}{"type": "string"}},
+			"required":   []string{"location"},
+		}),
+	}); err != nil {
+		t.Fatalf("RegisterTool() error: %v", err)
+	}
+
+	var call mcp.ToolCall
+	data := `{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"location\":\"Paris\"}"}}`
+	if err := json.Unmarshal([]byte(data), &call); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+
+	if _, status, err := ValidateCall(call, tm); status != StatusSucceeded || err != nil {
+		t.Errorf("ValidateCall() = %v, %v, want %v", status, err, StatusSucceeded)
+	}
+
+	call.Arguments = json.RawMessage(`{}`)
+	if _, status, _ := ValidateCall(call, tm); status != StatusFailed {
+		t.Errorf("ValidateCall() with missing argument = %v, want %v", status, StatusFailed)
+	}
+}
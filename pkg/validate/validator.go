@@ -0,0 +1,101 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// Validator validates the inputs and outputs of a tool call.
+type Validator interface {
+	ValidateInput(tool *mcp.Tool, inputArguments []byte) (ValidationStatus, error)
+	ValidateOutput(tool *mcp.Tool, rawResult string) (ValidationStatus, error)
+}
+
+// ContextValidator is implemented by Validators that can stop validating input
+// early once a context is done, such as when the request they serve times out.
+type ContextValidator interface {
+	ValidateInputContext(ctx context.Context, tool *mcp.Tool, inputArguments []byte) (ValidationStatus, error)
+}
+
+// ValidateInputContext validates input with v, passing ctx along if v is a
+// ContextValidator. Other validators are only run if ctx isn't done yet.
+func ValidateInputContext(ctx context.Context, v Validator, tool *mcp.Tool, inputArguments []byte) (ValidationStatus, error) {
+	if cv, ok := v.(ContextValidator); ok {
+		return cv.ValidateInputContext(ctx, tool, inputArguments)
+	}
+	if err := ctx.Err(); err != nil {
+		return StatusError, fmt.Errorf("input validation stopped: %w", err)
+	}
+	return v.ValidateInput(tool, inputArguments)
+}
+
+// SchemaValidator is the default Validator, checking inputs and outputs
+// against the tool's JSON Schemas.
+type SchemaValidator struct {
+	Cache *ValidationCache // optional cache of input validation results
+}
+
+// ValidateInput validates the arguments against the tool's input schema.
+func (v SchemaValidator) ValidateInput(tool *mcp.Tool, inputArguments []byte) (ValidationStatus, error) {
+	return v.ValidateInputContext(context.Background(), tool, inputArguments)
+}
+
+// ValidateInputContext validates the arguments against the tool's input schema,
+// stopping early once ctx is done.
+func (v SchemaValidator) ValidateInputContext(ctx context.Context, tool *mcp.Tool, inputArguments []byte) (ValidationStatus, error) {
+	opts := []InputOption{WithContext(ctx)}
+	if v.Cache != nil {
+		opts = append(opts, WithCache(v.Cache))
+	}
+	_, status, err := ValidateToolInput(tool, inputArguments, opts...)
+	return status, err
+}
+
+// ValidateOutput validates the raw result against the tool's output schema.
+func (v SchemaValidator) ValidateOutput(tool *mcp.Tool, rawResult string) (ValidationStatus, error) {
+	return ValidateToolOutput(rawResult, tool)
+}
+
+// ValidatorRegistry selects the Validator used for each tool, falling back
+// to a default for tools without a dedicated validator.
+type ValidatorRegistry struct {
+	mu         sync.RWMutex
+	defaultVal Validator
+	byTool     map[string]Validator
+}
+
+// NewValidatorRegistry creates a registry that uses defaultValidator
+// for any tool without a dedicated validator.
+func NewValidatorRegistry(defaultValidator Validator) *ValidatorRegistry {
+	return &ValidatorRegistry{
+		defaultVal: defaultValidator,
+		byTool:     make(map[string]Validator),
+	}
+}
+
+// Register assigns a dedicated validator to the named tool.
+func (r *ValidatorRegistry) Register(toolName string, v Validator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byTool[toolName] = v
+}
+
+// Unregister reverts the named tool to the default validator.
+func (r *ValidatorRegistry) Unregister(toolName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.byTool, toolName)
+}
+
+// For returns the validator to use for the given tool.
+func (r *ValidatorRegistry) For(tool *mcp.Tool) Validator {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if v, ok := r.byTool[tool.Name]; ok {
+		return v
+	}
+	return r.defaultVal
+}
@@ -0,0 +1,76 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// failingValidator rejects every input and output.
+type failingValidator struct{}
+
+func (failingValidator) ValidateInput(tool *mcp.Tool, inputArguments []byte) (ValidationStatus, error) {
+	return StatusFailed, errors.New("rejected by failing validator")
+}
+
+func (failingValidator) ValidateOutput(tool *mcp.Tool, rawResult string) (ValidationStatus, error) {
+	return StatusFailed, errors.New("rejected by failing validator")
+}
+
+func TestValidatorRegistry_Dispatch(t *testing.T) {
+	registry := NewValidatorRegistry(SchemaValidator{})
+	registry.Register("custom-tool", failingValidator{})
+
+	schema := mustMarshalJSON(map[string]interface{}{"type": "object"})
+	customTool := &mcp.Tool{Name: "custom-tool", InputSchema: schema, OutputSchema: schema}
+	schemaTool := &mcp.Tool{Name: "schema-tool", InputSchema: schema, OutputSchema: schema}
+	input := []byte(`{}`)
+
+	if status, _ := registry.For(customTool).ValidateInput(customTool, input); status != StatusFailed {
+		t.Errorf("custom tool input status = %v, want %v", status, StatusFailed)
+	}
+	if status, _ := registry.For(customTool).ValidateOutput(customTool, `{}`); status != StatusFailed {
+		t.Errorf("custom tool output status = %v, want %v", status, StatusFailed)
+	}
+	if status, err := registry.For(schemaTool).ValidateInput(schemaTool, input); status != StatusSucceeded {
+		t.Errorf("schema tool input status = %v, want %v (err: %v)", status, StatusSucceeded, err)
+	}
+
+	registry.Unregister("custom-tool")
+	if status, err := registry.For(customTool).ValidateInput(customTool, input); status != StatusSucceeded {
+		t.Errorf("unregistered tool input status = %v, want %v (err: %v)", status, StatusSucceeded, err)
+	}
+}
+
+func TestValidateInputContext_Canceled(t *testing.T) {
+	schema := mustMarshalJSON(map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"q": map[string]interface{}{"type": "string", "minLength": 1}},
+	})
+	tool := &mcp.Tool{Name: "slow-tool", InputSchema: schema}
+	input := []byte(`{"q":"a"}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cache := NewValidationCache(10)
+	status, err := ValidateInputContext(ctx, SchemaValidator{Cache: cache}, tool, input)
+	if status != StatusError || !errors.Is(err, context.Canceled) {
+		t.Errorf("ValidateInputContext() = %v, %v, want StatusError wrapping context.Canceled", status, err)
+	}
+	if cache.Len() != 0 {
+		t.Error("Expected a cancelled validation not to be cached")
+	}
+	if status, err := ValidateInputContext(context.Background(), SchemaValidator{Cache: cache}, tool, input); status != StatusSucceeded {
+		t.Errorf("ValidateInputContext() = %v, %v, want success with a live context", status, err)
+	}
+
+	// validators without context support aren't started once it's done
+	if status, err := ValidateInputContext(ctx, failingValidator{}, tool, input); status != StatusError || !errors.Is(err, context.Canceled) {
+		t.Errorf("ValidateInputContext() = %v, %v, want StatusError wrapping context.Canceled", status, err)
+	}
+	if status, _ := ValidateInputContext(context.Background(), failingValidator{}, tool, input); status != StatusFailed {
+		t.Errorf("ValidateInputContext() = %v, want the validator's own result", status)
+	}
+}
@@ -0,0 +1,47 @@
+package validate
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrorVerbosity controls how much of a schema violation report the SchemaError
+// returned to callers carries. The security alert always gets the full report.
+type ErrorVerbosity string
+
+const (
+	// VerbosityFull lists every violation in the error message. This is the default.
+	VerbosityFull ErrorVerbosity = "full"
+	// VerbositySummary reduces the message to a single line naming the tool.
+	// The structured SchemaError.Errors are kept either way.
+	VerbositySummary ErrorVerbosity = "summary"
+)
+
+// ParseErrorVerbosity parses "full" or "summary". An empty string means VerbosityFull.
+func ParseErrorVerbosity(s string) (ErrorVerbosity, error) {
+	switch ErrorVerbosity(s) {
+	case "", VerbosityFull:
+		return VerbosityFull, nil
+	case VerbositySummary:
+		return VerbositySummary, nil
+	}
+	return VerbosityFull, fmt.Errorf("unknown validation error verbosity '%s'", s)
+}
+
+var summaryErrors atomic.Bool
+
+// SetErrorVerbosity sets the detail of the errors ValidateToolInputSchema and
+// ValidateToolOutput return for schema violations.
+func SetErrorVerbosity(v ErrorVerbosity) {
+	summaryErrors.Store(v == VerbositySummary)
+}
+
+// withVerbosity returns e trimmed to the configured verbosity
+func (e *SchemaError) withVerbosity() *SchemaError {
+	if !summaryErrors.Load() {
+		return e
+	}
+	short := *e
+	short.msg = e.summary
+	return &short
+}
@@ -0,0 +1,112 @@
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// useErrorVerbosity sets the schema error verbosity for the rest of the test
+func useErrorVerbosity(t *testing.T, v ErrorVerbosity) {
+	t.Helper()
+	SetErrorVerbosity(v)
+	t.Cleanup(func() { SetErrorVerbosity(VerbosityFull) })
+}
+
+func TestParseErrorVerbosity(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ErrorVerbosity
+		wantErr bool
+	}{
+		{input: "", want: VerbosityFull},
+		{input: "full", want: VerbosityFull},
+		{input: "summary", want: VerbositySummary},
+		{input: "terse", want: VerbosityFull, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseErrorVerbosity(tt.input)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseErrorVerbosity(%q) = %q, %v; want %q, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestErrorVerbosity(t *testing.T) {
+	tool := &mcp.Tool{
+		Name: "profile-tool",
+		InputSchema: mustMarshalJSON(map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{"type": "string"},
+				"age":  map[string]interface{}{"type": "integer", "minimum": 0},
+			},
+			"required": []string{"name"},
+		}),
+		OutputSchema: mustMarshalJSON(map[string]interface{}{
+			"type":     "object",
+			"required": []string{"ok", "id"},
+		}),
+	}
+	input := []byte(`{"age": -1}`)
+	output := `{}`
+
+	tests := []struct {
+		name      string
+		verbosity ErrorVerbosity
+		check     func(t *testing.T, msg string)
+	}{
+		{
+			name:      "full",
+			verbosity: VerbosityFull,
+			check: func(t *testing.T, msg string) {
+				if n := strings.Count(msg, "\n- "); n != 2 {
+					t.Errorf("error %q lists %d violations, want 2", msg, n)
+				}
+			},
+		},
+		{
+			name:      "summary",
+			verbosity: VerbositySummary,
+			check: func(t *testing.T, msg string) {
+				if strings.Contains(msg, "\n") {
+					t.Errorf("error %q spans several lines, want a one-line summary", msg)
+				}
+				if !strings.Contains(msg, "profile-tool") || !strings.Contains(msg, "2 violation(s)") {
+					t.Errorf("error %q should name the tool and count its violations", msg)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useErrorVerbosity(t, tt.verbosity)
+			alerts := captureAlerts(t)
+
+			status, err := ValidateToolInputSchema(tool, input)
+			if status != StatusFailed {
+				t.Fatalf("ValidateToolInputSchema() status = %s, want %s", status, StatusFailed)
+			}
+			tt.check(t, err.Error())
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) || len(schemaErr.Errors) != 2 {
+				t.Errorf("ValidateToolInputSchema() error = %#v, want a *SchemaError with 2 violations", err)
+			}
+
+			status, err = ValidateToolOutput(output, tool)
+			if status != StatusFailed {
+				t.Fatalf("ValidateToolOutput() status = %s, want %s", status, StatusFailed)
+			}
+			tt.check(t, err.Error())
+
+			// the audit log gets every violation whatever the verbosity
+			if n := strings.Count(alerts.String(), "\n- "); n != 4 {
+				t.Errorf("security alerts list %d violations, want 4:\n%s", n, alerts.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// CurrentSchemaVersion names the tool's own input schema in ValidateToolInputSchemaVersions
+const CurrentSchemaVersion = "current"
+
+// SchemaVersion is an input schema a tool still accepts, e.g. the one it had
+// before an upgrade, while clients migrate to its current schema
+type SchemaVersion struct {
+	Version string          `json:"version"`
+	Schema  json.RawMessage `json:"schema"`
+}
+
+// ValidateToolInputSchemaVersions validates the input arguments against the tool's
+// input schema or any of the given earlier versions, passing if one of them accepts
+// the input. It returns the version that matched, CurrentSchemaVersion for the tool's
+// own schema, which is tried first. Input no version accepts is reported with the
+// violations of the current schema, and an empty version.
+func ValidateToolInputSchemaVersions(
+	tool *mcp.Tool,
+	inputArguments []byte,
+	versions []SchemaVersion,
+	opts ...InputOption,
+) (string, ValidationStatus, error) {
+	if tool == nil {
+		return "", StatusError, ErrNilTool
+	}
+	options := newInputOptions(opts)
+	if len(tool.InputSchema) == 0 {
+		_, status, err := validateToolInput(tool, inputArguments, options)
+		return "", status, err
+	}
+
+	candidates := append([]SchemaVersion{{Version: CurrentSchemaVersion, Schema: tool.InputSchema}}, versions...)
+	schemas := make([]*gojsonschema.Schema, len(candidates))
+	for i, candidate := range candidates {
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(candidate.Schema))
+		if err != nil {
+			return "", StatusError, fmt.Errorf("internal schema error for tool '%s' version '%s'", tool.Name, candidate.Version)
+		}
+		schemas[i] = schema
+	}
+
+	// find the accepting version quietly, so input written for an earlier
+	// version doesn't raise alerts for failing the ones after it
+	args := inputArguments
+	if unwrapped, ok, err := unwrapDoubleEncoded(inputArguments); err == nil && ok {
+		args = unwrapped
+	}
+	for i, candidate := range candidates {
+		document := args
+		if options.coerceTypes {
+			document = coerceTypes(candidate.Schema, document)
+		}
+		result, err := schemas[i].Validate(gojsonschema.NewBytesLoader(document))
+		if err != nil || !result.Valid() {
+			continue
+		}
+
+		// the full validation still applies every other check to the input
+		versioned := *tool
+		versioned.InputSchema = candidate.Schema
+		if _, status, err := validateToolInput(&versioned, inputArguments, options); status != StatusSucceeded {
+			return "", status, err
+		}
+		return candidate.Version, StatusSucceeded, nil
+	}
+
+	// report against the current schema, the one clients should move to
+	_, status, err := validateToolInput(tool, inputArguments, options)
+	if status == StatusFailed && len(versions) > 0 {
+		err = fmt.Errorf("input matched none of %d schema versions for tool '%s': %w", len(candidates), tool.Name, err)
+	}
+	return "", status, err
+}
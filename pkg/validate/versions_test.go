@@ -0,0 +1,99 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/null-create/mcp-tls/pkg/mcp"
+)
+
+// the weather tool after renaming "city" to "location"; v1 is the schema it replaced
+var (
+	versionedWeatherTool = &mcp.Tool{
+		Name:        "weather",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"location":{"type":"string"}},"required":["location"],"additionalProperties":false}`),
+	}
+	weatherV1 = SchemaVersion{
+		Version: "v1",
+		Schema:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"],"additionalProperties":false}`),
+	}
+)
+
+func TestValidateToolInputSchemaVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		version string
+		status  ValidationStatus
+	}{
+		{name: "new schema", input: `{"location":"Oslo"}`, version: CurrentSchemaVersion, status: StatusSucceeded},
+		{name: "old schema", input: `{"city":"Oslo"}`, version: "v1", status: StatusSucceeded},
+		{name: "neither", input: `{"town":"Oslo"}`, version: "", status: StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts := captureAlerts(t)
+			version, status, err := ValidateToolInputSchemaVersions(versionedWeatherTool, []byte(tt.input), []SchemaVersion{weatherV1})
+			if version != tt.version || status != tt.status {
+				t.Errorf("got version %q, status %v (err: %v), want %q, %v", version, status, err, tt.version, tt.status)
+			}
+			if tt.status == StatusSucceeded {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				if alerts.Len() != 0 {
+					t.Errorf("Expected no alerts for input an accepted version matched, got: %s", alerts)
+				}
+				return
+			}
+
+			// rejected input is reported against the current schema
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("Expected a *SchemaError, got %T: %v", err, err)
+			}
+			if !strings.Contains(err.Error(), "none of 2 schema versions") {
+				t.Errorf("Expected the error to say no version matched, got: %v", err)
+			}
+			if !strings.Contains(err.Error(), "location") {
+				t.Errorf("Expected the current schema's violations, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateToolInputSchemaVersions_AppliesOptions(t *testing.T) {
+	captureAlerts(t)
+	tool := &mcp.Tool{
+		Name:        "counter",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"count":{"type":"integer"}},"required":["count"]}`),
+	}
+	previous := SchemaVersion{
+		Version: "v1",
+		Schema:  json.RawMessage(`{"type":"object","properties":{"n":{"type":"integer"}},"required":["n"]}`),
+	}
+	version, status, err := ValidateToolInputSchemaVersions(tool, []byte(`{"n":"3"}`), []SchemaVersion{previous}, WithCoerceTypes())
+	if version != "v1" || status != StatusSucceeded {
+		t.Errorf("got version %q, status %v (err: %v), want v1 to accept the coerced input", version, status, err)
+	}
+}
+
+func TestValidateToolInputSchemaVersions_Errors(t *testing.T) {
+	captureAlerts(t)
+	broken := SchemaVersion{Version: "v0", Schema: json.RawMessage(`{"type": 12}`)}
+	if _, status, err := ValidateToolInputSchemaVersions(versionedWeatherTool, []byte(`{"city":"Oslo"}`), []SchemaVersion{broken}); status != StatusError || err == nil {
+		t.Errorf("Expected an error for an invalid schema version, got %v, %v", status, err)
+	}
+
+	// the checks beyond the schema still apply to input an earlier version accepts
+	if version, status, _ := ValidateToolInputSchemaVersions(versionedWeatherTool, []byte(`{"city":"Oslo","city":"Bergen"}`), []SchemaVersion{weatherV1}); status != StatusFailed || version != "" {
+		t.Errorf("Expected duplicate keys to be rejected, got version %q, status %v", version, status)
+	}
+
+	// with no earlier versions it behaves like ValidateToolInputSchema
+	if version, status, _ := ValidateToolInputSchemaVersions(versionedWeatherTool, []byte(`{"city":"Oslo"}`), nil); status != StatusFailed || version != "" {
+		t.Errorf("Expected the current schema alone to reject old input, got version %q, status %v", version, status)
+	}
+}